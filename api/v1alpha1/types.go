@@ -13,13 +13,73 @@ const (
 )
 
 const (
+	OpenDepotStorageMigrationPhasePending   = "Pending"
+	OpenDepotStorageMigrationPhaseRunning   = "Running"
+	OpenDepotStorageMigrationPhaseSucceeded = "Succeeded"
+	OpenDepotStorageMigrationPhaseFailed    = "Failed"
+)
+
+const (
+	OpenDepotStorageAuditProblemChecksumMismatch = "ChecksumMismatch"
+	OpenDepotStorageAuditProblemError            = "Error"
+	OpenDepotStorageAuditProblemMissing          = "Missing"
+)
+
+const (
+	OpenDepotDiscoveryModeReleases        = "Releases"
+	OpenDepotDiscoveryModeReleasesAndTags = "ReleasesAndTags"
+	OpenDepotDiscoveryModeTags            = "Tags"
+)
+
+const (
+	OpenDepotRetagPolicyAccept     = "Accept"
+	OpenDepotRetagPolicyQuarantine = "Quarantine"
+	OpenDepotRetagPolicyRefuse     = "Refuse"
+)
+
+const (
+	OpenDepotStorageLayoutContentAddressed = "ContentAddressed"
+	OpenDepotStorageLayoutUUID             = "UUID"
+)
+
+const (
+	// OpenDepotConditionTagMoved is the Version condition that is true while the upstream tag of a module version
+	// points to a different commit than the archive being served.
+	OpenDepotConditionTagMoved = "TagMoved"
+	// OpenDepotConditionStorageIntegrity is the Version condition that is false while a StorageAudit finds the archive
+	// of the Version missing from storage or not matching its checksum.
+	OpenDepotConditionStorageIntegrity = "StorageIntegrity"
+)
+
+const (
+	OpenDepotAzureSecretDataFieldConnection  = "connectionString"
+	OpenDepotAzureSecretDataFieldSASToken    = "sasToken"
 	OpenDepotFinalizer                       = "opendepot.defdev.io/finalizer"
+	OpenDepotGCSSecretDataFieldAccountJSON   = "serviceAccountJSON"
 	OpenDepotGithubSecretDataFieldAppID      = "githubAppID"
 	OpenDepotGithubSecretDataFieldInstallID  = "githubInstallID"
 	OpenDepotGithubSecretDataFieldPrivateKey = "githubPrivateKey"
+	OpenDepotGithubSecretDataFieldToken      = "githubToken"
 	OpenDepotGithubSecretName                = "opendepot-github-application-secret"
+	OpenDepotDependencyLabel                 = "opendepot.defdev.io/dependency"
 	OpenDepotModule                          = "Module"
+	OpenDepotOCISecretDataFieldCABundle      = "ca.crt"
+	OpenDepotOCISecretDataFieldPassword      = "password"
+	OpenDepotOCISecretDataFieldUsername      = "username"
 	OpenDepotProvider                        = "Provider"
+	OpenDepotPullThroughLabel                = "opendepot.defdev.io/pull-through"
+	OpenDepotRegistrySecretDataFieldToken    = "registryToken"
+	OpenDepotS3SecretDataFieldAccessKeyID    = "accessKeyID"
+	OpenDepotS3SecretDataFieldCABundle       = "ca.crt"
+	OpenDepotS3SecretDataFieldExternalID     = "externalID"
+	OpenDepotS3SecretDataFieldRoleARN        = "roleARN"
+	OpenDepotS3SecretDataFieldSecretKey      = "secretAccessKey"
+	OpenDepotS3SecretDataFieldSessionToken   = "sessionToken"
+	OpenDepotSigningKeysSecretDataField      = "gpgPublicKeys"
+	OpenDepotStoragePrimaryReplica           = "primary"
+	OpenDepotStorageProfileAnnotation        = "opendepot.defdev.io/storage-profile"
+	OpenDepotWebhookSecretDataField          = "webhookSecret"
+	OpenDepotWebhookSyncAnnotation           = "opendepot.defdev.io/webhook-sync"
 )
 
 // DepotSpec defines the desired state of Depot.
@@ -34,23 +94,84 @@ type DepotSpec struct {
 	// The polling interval in minutes for how often the Depot controller should check for new versions of the modules it manages.
 	// If not specified, the default is 0.
 	PollingIntervalMinutes *int `json:"pollingIntervalMinutes,omitempty"`
+	// The configuration for pull-through caching of modules and providers requested from the server
+	// in the Depot's namespace that no Module or Provider has been declared for. When omitted, the
+	// server only serves declared modules and providers.
+	PullThrough *DepotPullThroughConfig `json:"pullThrough,omitempty"`
+	// The configuration for receiving repository webhooks that trigger an immediate sync of a single module.
+	// When omitted, webhook requests for this Depot are rejected.
+	WebhookConfig *DepotWebhookConfig `json:"webhookConfig,omitempty"`
+}
+
+// DepotPullThroughConfig defines the upstream namespaces that the server may pull modules and providers
+// from on first request. Pulled-through resources use the Depot's global config.
+type DepotPullThroughConfig struct {
+	// The GitHub repository owners that modules may be pulled through from, e.g. 'terraform-aws-modules'.
+	// Owners are tried in order until one has a repository with the requested module name.
+	ModuleOwners []string `json:"moduleOwners,omitempty"`
+	// The maximum number of module and provider versions the server pulls through into the Depot's
+	// namespace per hour. Requests for further versions are answered with 429 Too Many Requests until
+	// the hour has passed. Defaults to 30.
+	MaxVersionsPerHour *int `json:"maxVersionsPerHour,omitempty"`
+	// The provider namespaces in the upstream registry that providers may be pulled through from,
+	// e.g. 'hashicorp'. Namespaces are tried in order until one publishes the requested provider.
+	ProviderNamespaces []string `json:"providerNamespaces,omitempty"`
+	// The host of the upstream provider registry. Defaults to 'registry.opentofu.org'.
+	ProviderRegistry *string `json:"providerRegistry,omitempty"`
+	// The name of a Secret holding a 'registryToken' field that is sent as a bearer token to the
+	// upstream provider registry.
+	ProviderRegistrySecretName *string `json:"providerRegistrySecretName,omitempty"`
+	// How long in seconds a request waits for a pulled-through version to sync before the server
+	// responds with 503 Service Unavailable. The sync continues in the background. Defaults to 60.
+	SyncTimeoutSeconds *int `json:"syncTimeoutSeconds,omitempty"`
+}
+
+// DepotWebhookConfig defines how the Depot controller verifies repository webhooks sent for a Depot.
+type DepotWebhookConfig struct {
+	// The name of the Secret in the Depot's namespace that holds the shared webhook secret in a 'webhookSecret' field.
+	// GitHub and generic webhooks use the secret as the HMAC-SHA256 signing key, while GitLab webhooks send it as
+	// the secret token.
+	SecretName string `json:"secretName"`
 }
 
 // Defines the desired config of all OpenDepot modules managed by the Depot controller.
 type GlobalConfig struct {
 	GithubClientConfig *GithubClientConfig `json:"githubClientConfig,omitempty"`
 	ModuleConfig       *ModuleConfig       `json:"moduleConfig,omitempty"`
-	StorageConfig      *StorageConfig      `json:"storageConfig"`
+	StorageConfig      *StorageConfig      `json:"storageConfig,omitempty"`
+	// The name of the StorageProfile used by modules and providers that set neither a storageConfig
+	// nor a storageProfileName. Ignored when storageConfig is set.
+	StorageProfileName *string `json:"storageProfileName,omitempty"`
 }
 
 // DepotStatus defines the observed state of Depot.
 type DepotStatus struct {
+	// The GitHub API rate limits most recently observed by module syncs, one for each set of credentials. Modules
+	// whose credentials have exhausted their rate limit are deferred until it resets.
+	GithubRateLimits []GithubRateLimitStatus `json:"githubRateLimits,omitempty"`
 	// The list of Module resource names created and managed by this Depot.
 	Modules []string `json:"modules,omitempty"`
+	// The generation of the spec the most recent full sync applied to every module and provider. Modules requested
+	// by webhooks are synced on their own only while it matches the Depot's generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// The list of Provider resource names created and managed by this Depot.
 	Providers []string `json:"providers,omitempty"`
 }
 
+// GithubRateLimitStatus is a GitHub API rate limit observed by the Depot controller.
+type GithubRateLimitStatus struct {
+	// The credentials the rate limit applies to: the name of the Secret holding them, followed by the GitHub App
+	// installation ID, or the repository owner it is resolved from, for GitHub App credentials. Unauthenticated
+	// clients are reported as 'anonymous'. Credentials used with a GitHub Enterprise Server are prefixed with its host.
+	Credential string `json:"credential"`
+	// The maximum number of requests permitted in the current rate limit window.
+	Limit int `json:"limit"`
+	// The number of requests remaining in the current rate limit window.
+	Remaining int `json:"remaining"`
+	// The RFC3339 timestamp at which the current rate limit window resets.
+	ResetAt string `json:"resetAt,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="GlobalConfig",type="string",JSONPath=".spec.globalConfig",description="The global configuration applied to all modules managed by this Depot"
@@ -76,14 +197,37 @@ type DepotList struct {
 // ModuleConfig is the configuration settings for the Module and for each
 // Version created by the Module controller.
 type ModuleConfig struct {
+	// The configuration for registering the providers and modules that each version of the module depends on.
+	// Dependencies are always recorded on the Version status. When omitted, no resources are created for them.
+	Dependencies *ModuleDependencyConfig `json:"dependencies,omitempty"`
+	// The source the Depot controller uses to discover module versions.
+	// This must be one of 'Releases', 'Tags' or 'ReleasesAndTags'. 'Releases' lists the GitHub releases
+	// of the repository, 'Tags' lists its git tags, and 'ReleasesAndTags' combines both.
+	// Defaults to 'Releases'. This field is only respected by the Depot controller.
+	DiscoveryMode *string `json:"discoveryMode,omitempty"`
+	// Patterns of files left out of the module archive, in '.gitignore' syntax, e.g. '.github/' or 'tests/'.
+	// They are applied after the patterns in the repository's '.opendepotignore' file, so they can override it.
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
 	// The file format of the module
 	// This must be one of 'zip' or 'tar'.
 	FileFormat *string `json:"fileFormat,omitempty"`
 	// The Github client configuration settings.
 	GithubClientConfig *GithubClientConfig `json:"githubClientConfig,omitempty"`
+	// What the Version controller does when the git tag of a synced module version moves to another commit.
+	// This must be one of 'Refuse', 'Quarantine' or 'Accept'. 'Refuse' keeps serving the stored archive,
+	// 'Quarantine' also stores the new content without serving it, and 'Accept' replaces the stored archive.
+	// Defaults to 'Refuse' when Immutable is true and to 'Accept' otherwise.
+	RetagPolicy *string `json:"retagPolicy,omitempty"`
 	// When true, enforces that the ChecksumSHA256 of the module archive
 	// always matches the value stored in this field and in any destination storage config.
 	Immutable *bool `json:"immutable,omitempty"`
+	// A flag to include draft GitHub releases during version discovery. Defaults to false.
+	// This field is only respected by the Depot controller.
+	IncludeDrafts *bool `json:"includeDrafts,omitempty"`
+	// A flag to include prereleases during version discovery. A version is a prerelease when its GitHub release
+	// is marked as a prerelease or its tag has a semver prerelease suffix such as '1.0.0-rc.1'. Defaults to false.
+	// This field is only respected by the Depot controller.
+	IncludePrereleases *bool `json:"includePrereleases,omitempty"`
 	// The name of the module. If omitted, the name of the Module resource
 	// is used in its place.
 	Name *string `json:"name,omitempty"`
@@ -95,6 +239,10 @@ type ModuleConfig struct {
 	RepoUrl *string `json:"repoUrl,omitempty"`
 	// The external storage configuration settings.
 	StorageConfig *StorageConfig `json:"storageConfig,omitempty"`
+	// The name of the StorageProfile holding the storage configuration settings. The profile is resolved each time
+	// a Version is stored or downloaded. Ignored when storageConfig is set. When both are omitted, the profile named
+	// by the 'opendepot.defdev.io/storage-profile' annotation of the namespace is used.
+	StorageProfileName *string `json:"storageProfileName,omitempty"`
 	// A comma separated list of version constraints such as
 	// '1.2.1' or '>= 1.0.0, < 2.0.0' or '~> 1.0.0, != 1.0.2'. This field is only
 	// respected by the Depot controller.
@@ -103,12 +251,41 @@ type ModuleConfig struct {
 	VersionHistoryLimit *int `json:"versionHistoryLimit,omitempty"`
 }
 
+// ModuleDependencyConfig defines how the Version controller registers the dependencies of a module version.
+type ModuleDependencyConfig struct {
+	// When true, the Version controller creates or extends a Provider for each provider in the module's
+	// 'required_providers' and a Module for each nested registry or GitHub module call, so the dependency
+	// closure is served alongside the module. Only resources created this way are extended; Providers and
+	// Modules declared by a Depot or by hand are left unchanged. Registered Modules inherit this config,
+	// so their own dependencies are registered in turn.
+	Register bool `json:"register,omitempty"`
+	// The OS(s) that registered providers are mirrored for. Defaults to 'linux'.
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+	// The architecture(s) that registered providers are mirrored for. Defaults to 'amd64'.
+	Architectures []string `json:"architectures,omitempty"`
+}
+
 type GithubClientConfig struct {
+	// The base URL of the GitHub REST API. Set this to target a GitHub Enterprise Server instance,
+	// e.g. 'https://github.example.com/api/v3/'. When omitted, requests are sent to api.github.com.
+	// The host of this URL is also used to build repository URLs for modules managed by a Depot.
+	BaseURL *string `json:"baseUrl,omitempty"`
+	// A PEM encoded CA bundle used to verify the TLS certificate presented by a GitHub Enterprise Server
+	// instance that is signed by an internal certificate authority. The bundle is added to the system roots.
+	CABundle *string `json:"caBundle,omitempty"`
+	// The name of the Secret holding the GitHub credentials. The Secret must exist in the same namespace as the
+	// resource. When omitted, the Secret named 'opendepot-github-application-secret' is used.
+	SecretName *string `json:"secretName,omitempty"`
+	// The upload URL of a GitHub Enterprise Server instance, e.g. 'https://github.example.com/api/uploads/'.
+	// When omitted, the value of baseUrl is used in its place.
+	UploadURL *string `json:"uploadUrl,omitempty"`
 	// This flag determines whether the GitHub client used to download modules
-	// will be authenticated with a Github App. It's highly recommended
+	// will be authenticated. It's highly recommended
 	// to enable this flag to avoid GitHub API rate limiting. When enabled, the namespace where the Module resource exists
-	// must contain a Secret named 'opendepot-github-application-secret'. The secret must contain a githubAppID,
-	// githubInstallID, and githubPrivateKey field. The private key must also be base64 encoded before being added
+	// must contain the Secret referenced by secretName. To authenticate with a personal access token the secret must
+	// contain a githubToken field. To authenticate with a Github App the secret must contain a githubAppID and
+	// githubPrivateKey field, and may contain a githubInstallID field. When githubInstallID is omitted the installation
+	// is resolved from the repository owner. The private key must also be base64 encoded before being added
 	// as data to the secret. When accessed, the controller will base64 decode the key to build an in-memory client
 	// to authenticate with the Github API.
 	UseAuthenticatedClient bool `json:"useAuthenticatedClient,omitempty"`
@@ -187,6 +364,19 @@ type ProviderConfig struct {
 	// e.g. 'hashicorp', 'integrations', 'DataDog'. Defaults to 'hashicorp' when omitted,
 	// preserving backwards compatibility for existing Provider resources.
 	Namespace *string `json:"namespace,omitempty"`
+	// The host of the upstream provider registry that versions are discovered and downloaded
+	// from, e.g. 'registry.terraform.io' or another OpenDepot. The registry's provider API is
+	// resolved through its '.well-known/terraform.json' service discovery document. A scheme may
+	// be included, e.g. 'https://registry.example.com'. Defaults to 'registry.opentofu.org'.
+	Registry *string `json:"registry,omitempty"`
+	// The name of a Secret holding a 'registryToken' field that is sent as a bearer token to the
+	// upstream registry. The Secret must exist in the namespace of the resource being reconciled.
+	RegistrySecretName *string `json:"registrySecretName,omitempty"`
+	// The name of a Secret holding a 'gpgPublicKeys' field with one or more ASCII armored public keys.
+	// When set, the upstream SHA256SUMS signature of each provider package is verified against these
+	// keys instead of the signing keys returned by the upstream registry. The Secret must exist in the
+	// namespace of the resource being reconciled.
+	SigningKeysSecretName *string `json:"signingKeysSecretName,omitempty"`
 	// The OS(s) that the provider supports. This is used to set the 'os' constraint in the provider's versions.
 	OperatingSystems []string `json:"operatingSystems,omitempty"`
 	// The architecture(s) that the provider supports. This is used to set the 'arch' constraint in the provider's versions.
@@ -206,6 +396,10 @@ type ProviderConfig struct {
 	SourceRepository *string `json:"sourceRepository,omitempty"`
 	// The external storage configuration settings.
 	StorageConfig *StorageConfig `json:"storageConfig,omitempty"`
+	// The name of the StorageProfile holding the storage configuration settings. The profile is resolved each time
+	// a Version is stored or downloaded. Ignored when storageConfig is set. When both are omitted, the profile named
+	// by the 'opendepot.defdev.io/storage-profile' annotation of the namespace is used.
+	StorageProfileName *string `json:"storageProfileName,omitempty"`
 	// The version history limit for the provider.
 	VersionHistoryLimit *int `json:"versionHistoryLimit,omitempty"`
 	// A comma-separated list of version constraints such as
@@ -371,6 +565,8 @@ type VersionSpec struct {
 type VersionStatus struct {
 	// The SHA256 checksum of the module as a base64 encoded string.
 	Checksum *string `json:"checksum"`
+	// The 'h1:' hash of the files in the archive, the hash scheme recorded in '.terraform.lock.hcl'.
+	PackageHash *string `json:"packageHash,omitempty"`
 	// A flag that determines whether the Version has been successfully reconciled.
 	Synced bool `json:"synced"`
 	// The Version's reconciliation status.
@@ -381,6 +577,119 @@ type VersionStatus struct {
 	// The IaC source scan result for this specific module version archive.
 	// Only populated for module Version resources when scanning is enabled.
 	SourceScan *ModuleSourceScan `json:"sourceScan,omitempty"`
+	// The result of verifying the upstream SHA256SUMS signature of this provider package.
+	// Only populated for provider Version resources.
+	SignatureVerification *ProviderSignatureVerification `json:"signatureVerification,omitempty"`
+	// The git tag and commit the stored module archive was fetched from. Only populated for module Version resources.
+	Source *ModuleVersionSource `json:"source,omitempty"`
+	// The module archives that were replaced after the version's tag moved, oldest first. At most ten are kept.
+	// Only populated for module Version resources.
+	DigestHistory []ModuleVersionDigest `json:"digestHistory,omitempty"`
+	// The content of a moved tag held back by the 'Quarantine' retag policy.
+	Quarantined *ModuleVersionDigest `json:"quarantined,omitempty"`
+	// The conditions of the Version, such as 'TagMoved'.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Whether the stored module archive was repackaged deterministically. Module versions synced before archives
+	// were repackaged are uploaded again on their next reconcile. Only populated for module Version resources.
+	Normalized bool `json:"normalized,omitempty"`
+	// The providers and modules required by the configuration in this module version's archive.
+	// Only populated for module Version resources.
+	Dependencies *ModuleDependencies `json:"dependencies,omitempty"`
+	// The state of the stored archive in each replica of the storage config, including the primary.
+	// Only populated when the storage config has replicas.
+	// +listType=map
+	// +listMapKey=name
+	Replicas []StorageReplicaStatus `json:"replicas,omitempty"`
+}
+
+// StorageReplicaStatus is the state of the archive of a Version in one replica of its storage config.
+type StorageReplicaStatus struct {
+	// The name of the replica.
+	Name string `json:"name"`
+	// The base64 encoded SHA256 checksum of the archive stored in the replica.
+	Checksum *string `json:"checksum,omitempty"`
+	// Whether the replica holds the archive of the Version.
+	Synced bool `json:"synced"`
+	// The replica's reconciliation status.
+	SyncStatus string `json:"syncStatus,omitempty"`
+}
+
+// ModuleVersionSource is the upstream git tag and commit a module archive was fetched from.
+type ModuleVersionSource struct {
+	// The name of the git tag, e.g. 'v1.2.0'.
+	Tag string `json:"tag"`
+	// The SHA of the commit the tag pointed to.
+	CommitSHA string `json:"commitSHA"`
+	// The SHA of the tag object of an annotated tag. Empty for lightweight tags.
+	TagObjectSHA string `json:"tagObjectSHA,omitempty"`
+	// RFC3339 timestamp at which the archive was fetched.
+	FetchedAt string `json:"fetchedAt"`
+}
+
+// ModuleVersionDigest is a module archive fetched from a commit.
+type ModuleVersionDigest struct {
+	// The SHA of the commit the archive was fetched from.
+	CommitSHA string `json:"commitSHA"`
+	// The base64 encoded SHA256 checksum of the archive.
+	Checksum string `json:"checksum,omitempty"`
+	// RFC3339 timestamp at which the archive was fetched.
+	FetchedAt string `json:"fetchedAt,omitempty"`
+	// RFC3339 timestamp at which the archive was replaced or quarantined.
+	RecordedAt string `json:"recordedAt,omitempty"`
+	// The file name the archive is stored under. Only set for quarantined content.
+	FileName *string `json:"fileName,omitempty"`
+}
+
+// ModuleDependencies are the providers and modules required by a module version.
+type ModuleDependencies struct {
+	// The providers declared in 'required_providers' blocks.
+	Providers []ProviderDependency `json:"providers,omitempty"`
+	// The registry and GitHub modules called by 'module' blocks. Local module calls are not included.
+	Modules []ModuleDependency `json:"modules,omitempty"`
+}
+
+// ProviderDependency is a provider required by a module version.
+type ProviderDependency struct {
+	// The provider source address, e.g. 'hashicorp/aws'.
+	Source string `json:"source"`
+	// The version constraints the provider is required at. Constraints declared more than once are combined.
+	VersionConstraints string `json:"versionConstraints,omitempty"`
+	// The name of the Provider resource that serves the dependency. Only set when dependencies are registered.
+	ProviderRef *string `json:"providerRef,omitempty"`
+}
+
+// ModuleDependency is a module called by a module version.
+type ModuleDependency struct {
+	// The module source, e.g. 'terraform-aws-modules/vpc/aws'.
+	Source string `json:"source"`
+	// The version constraints the module is called with, or the version pinned by the 'ref' of a Git source.
+	VersionConstraints string `json:"versionConstraints,omitempty"`
+	// The owner of the GitHub repository the module resolves to. Only set when the module can be mirrored from
+	// GitHub: sources on private registries and Git sources pinned to a branch or commit are recorded without it.
+	RepoOwner string `json:"repoOwner,omitempty"`
+	// The name of the GitHub repository the module resolves to.
+	Name string `json:"name,omitempty"`
+	// The name of the Module resource that serves the dependency. Only set when dependencies are registered.
+	ModuleRef *string `json:"moduleRef,omitempty"`
+}
+
+// ProviderSignatureVerification records the keys a provider package's upstream SHA256SUMS was verified against.
+type ProviderSignatureVerification struct {
+	// Whether SHA256SUMS was signed by a trusted key and lists the package's checksum.
+	Verified bool `json:"verified"`
+	// Where the trusted keys came from: 'Registry' for the keys in the upstream registry response,
+	// or 'Secret' for the keys pinned with signingKeysSecretName.
+	KeySource string `json:"keySource"`
+	// The IDs of the trusted keys.
+	TrustedKeyIDs []string `json:"trustedKeyIDs,omitempty"`
+	// The ID of the key that signed SHA256SUMS.
+	SignedByKeyID *string `json:"signedByKeyID,omitempty"`
+	// The RFC3339 timestamp at which verification was performed.
+	VerifiedAt string `json:"verifiedAt"`
+	// The reason verification failed.
+	Message *string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -399,8 +708,22 @@ type AmazonS3Config struct {
 	// The S3 bucket key, ie: 'my/bucket/prefix'
 	// The file name will be automatically generated by the opendepot-module-controller.
 	Key *string `json:"key,omitempty"`
-	// The AWS region for the bucket.
+	// The AWS region for the bucket. S3 compatible stores that ignore the region still require a value, ie: 'us-east-1'.
 	Region string `json:"region"`
+	// The URL of an S3 compatible endpoint such as MinIO, Ceph or Cloudflare R2, ie: 'https://minio.example.com:9000'.
+	// When omitted, the AWS endpoint for the region is used.
+	Endpoint *string `json:"endpoint,omitempty"`
+	// Whether to address the bucket in the request path instead of the host name. Most S3 compatible stores
+	// require path-style addressing.
+	UsePathStyle bool `json:"usePathStyle,omitempty"`
+	// The name of a Secret in the Version's namespace with a PEM encoded CA bundle in a 'ca.crt' field. The
+	// certificates are trusted in addition to the system roots when connecting to the endpoint.
+	CABundleSecretName *string `json:"caBundleSecretName,omitempty"`
+	// The name of a Secret in the Version's namespace with static credentials in 'accessKeyID' and 'secretAccessKey'
+	// fields and an optional 'sessionToken' field, and/or the ARN of an IAM role to assume in a 'roleARN' field with
+	// an optional 'externalID' field. The role is assumed with the static credentials when present, otherwise with
+	// the default AWS credential chain. When omitted, the default AWS credential chain is used.
+	CredentialsSecretName *string `json:"credentialsSecretName,omitempty"`
 }
 
 type AzureStorageConfig struct {
@@ -412,11 +735,17 @@ type AzureStorageConfig struct {
 	SubscriptionID string `json:"subscriptionID"`
 	// The Azure Resource Group where the Azure Storage Account is located.
 	ResourceGroup string `json:"resourceGroup"`
+	// The name of a Secret in the Version's namespace with a Storage Account connection string in a
+	// 'connectionString' field or a SAS token in a 'sasToken' field. When omitted, DefaultAzureCredential is used.
+	CredentialsSecretName *string `json:"credentialsSecretName,omitempty"`
 }
 
 type GoogleCloudStorageConfig struct {
 	// The GCS bucket name.
 	Bucket string `json:"bucket"`
+	// The name of a Secret in the Version's namespace with a service account key in a 'serviceAccountJSON' field.
+	// When omitted, Application Default Credentials are used.
+	CredentialsSecretName *string `json:"credentialsSecretName,omitempty"`
 }
 
 // StorageConfig holds details about how to store a Version.
@@ -428,6 +757,65 @@ type StorageConfig struct {
 	S3 *AmazonS3Config `json:"s3,omitempty"`
 	// The configuration settings for storing Versions in a Google Cloud Storage bucket.
 	GCS *GoogleCloudStorageConfig `json:"gcs,omitempty"`
+	// The configuration settings for storing Versions as OCI artifacts in an OCI distribution registry.
+	OCI *OCIStorageConfig `json:"oci,omitempty"`
+	// Additional backends every Version is replicated to. The backend configured above is the replica named 'primary'.
+	Replicas []StorageReplicaConfig `json:"replicas,omitempty"`
+	// The names of the replicas in the order the server reads from them, ie: ['eu-west-1', 'primary']. Replicas that
+	// are not listed are read after the listed ones, in their declared order. Defaults to 'primary' followed by the
+	// replicas in their declared order.
+	ReadOrder []string `json:"readOrder,omitempty"`
+	// Encrypts archives on the client before they are stored in the backend and in every replica.
+	Encryption *StorageEncryptionConfig `json:"encryption,omitempty"`
+	// How the file names of newly stored archives are chosen. This must be one of 'UUID' or 'ContentAddressed'.
+	// 'UUID' gives each Version its own file, 'ContentAddressed' names the file after the SHA256 checksum of the
+	// archive so Versions with identical archives share one file. Defaults to 'UUID'.
+	Layout *string `json:"layout,omitempty"`
+}
+
+// StorageEncryptionConfig configures the client-side envelope encryption of stored archives. Each archive is encrypted
+// with its own AES-256-GCM data key, which is stored with the archive wrapped by a key of the keyring.
+type StorageEncryptionConfig struct {
+	// The name of a Secret holding the keyring. Each field of the Secret is a key ID whose value is a 32 byte
+	// AES-256 key, raw or base64 encoded.
+	KeyringSecretName string `json:"keyringSecretName"`
+	// The ID of the key that wraps the data keys of newly stored archives. Archives wrapped by the other keys of the
+	// keyring are still read, and are stored again with the primary key when their Version is reconciled.
+	PrimaryKeyID string `json:"primaryKeyID"`
+}
+
+// StorageReplicaConfig is an additional backend a Version is replicated to. Exactly one backend must be configured.
+type StorageReplicaConfig struct {
+	// The name of the replica, unique within the storage config. 'primary' is reserved for the backend configured on
+	// the storage config itself.
+	Name         string              `json:"name"`
+	AzureStorage *AzureStorageConfig `json:"azureStorage,omitempty"`
+	// The configuration settings for storing Versions on a local filesystem.
+	FileSystem *FileSystemConfig `json:"fileSystem,omitempty"`
+	// The configuration settings for storing Versions in an Amazon S3 bucket.
+	S3 *AmazonS3Config `json:"s3,omitempty"`
+	// The configuration settings for storing Versions in a Google Cloud Storage bucket.
+	GCS *GoogleCloudStorageConfig `json:"gcs,omitempty"`
+	// The configuration settings for storing Versions as OCI artifacts in an OCI distribution registry.
+	OCI *OCIStorageConfig `json:"oci,omitempty"`
+}
+
+// The configuration settings for storing Versions as OCI artifacts in an OCI distribution registry such as Harbor.
+// Each module or provider is stored in its own repository under the repository prefix and each file is a tag.
+type OCIStorageConfig struct {
+	// The registry host and optional port, ie: 'harbor.example.com'.
+	Registry string `json:"registry"`
+	// The repository prefix artifacts are pushed under, ie: 'opendepot/modules'. The module or provider name is
+	// appended to it.
+	Repository string `json:"repository"`
+	// Whether to connect to the registry over HTTP instead of HTTPS.
+	PlainHTTP bool `json:"plainHTTP,omitempty"`
+	// The name of a Secret in the Version's namespace with a PEM encoded CA bundle in a 'ca.crt' field. The
+	// certificates are trusted in addition to the system roots when connecting to the registry.
+	CABundleSecretName *string `json:"caBundleSecretName,omitempty"`
+	// The name of a Secret in the Version's namespace with registry credentials in 'username' and 'password' fields.
+	// When omitted, the registry is accessed anonymously.
+	CredentialsSecretName *string `json:"credentialsSecretName,omitempty"`
 }
 
 // The configuration settings for storing Versions on a local filesystem.
@@ -436,9 +824,203 @@ type FileSystemConfig struct {
 	DirectoryPath *string `json:"directoryPath,omitempty"`
 }
 
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="SecretNamespace",type="string",JSONPath=".spec.secretNamespace",description="The namespace Secrets named in the storage config are read from"
+
+// StorageProfile is the Schema for the StorageProfiles API. A StorageProfile is a named storage config that
+// Modules, Providers and Depots reference instead of embedding one, so a backend can be changed in one place.
+type StorageProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StorageProfileSpec `json:"spec,omitempty"`
+}
+
+// StorageProfileSpec defines the storage config of a StorageProfile.
+type StorageProfileSpec struct {
+	// The storage configuration settings used by every Version that references the profile.
+	StorageConfig StorageConfig `json:"storageConfig"`
+	// The namespace the Secrets named in the storage config are read from. When omitted, they are read from
+	// the namespace of the Version being stored or downloaded.
+	SecretNamespace *string `json:"secretNamespace,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StorageProfileList contains a list of StorageProfile.
+type StorageProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StorageProfile `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="LastAudit",type="string",JSONPath=".status.lastAuditAt",description="The time the last audit ran"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedVersions",description="The number of Versions whose archive is missing or corrupt"
+// +kubebuilder:printcolumn:name="Orphans",type="integer",JSONPath=".status.orphanedObjects",description="The number of stored objects no Version references"
+
+// StorageAudit is the Schema for the StorageAudits API. A StorageAudit periodically checks that the archives of the
+// Versions in its namespace are stored with their checksum, and finds stored objects that no Version references.
+type StorageAudit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StorageAuditSpec   `json:"spec,omitempty"`
+	Status StorageAuditStatus `json:"status,omitempty"`
+}
+
+// StorageAuditSpec defines the Versions a StorageAudit checks and what it does with its findings.
+type StorageAuditSpec struct {
+	// The interval in minutes between audits. Defaults to 1440.
+	IntervalMinutes *int `json:"intervalMinutes,omitempty"`
+	// Selects the Versions in the StorageAudit's namespace to audit. When omitted, every Version is audited.
+	VersionSelector *metav1.LabelSelector `json:"versionSelector,omitempty"`
+	// Whether Versions whose archive is missing or does not match their checksum are repaired. The archive is copied
+	// from a storage replica that holds it or, failing that, the Version is synced again from upstream. Defaults to false.
+	Repair bool `json:"repair,omitempty"`
+	// Whether orphaned objects are deleted once they have gone unmodified for the grace period. Defaults to false.
+	DeleteOrphans bool `json:"deleteOrphans,omitempty"`
+	// The minutes an orphaned object must go unmodified before it is deleted. Defaults to 1440.
+	OrphanGracePeriodMinutes *int `json:"orphanGracePeriodMinutes,omitempty"`
+	// Whether the audit only reports its findings. When true nothing is repaired or deleted and Versions are not
+	// flagged. Defaults to false.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// StorageAuditStatus defines the result of the last audit of a StorageAudit.
+type StorageAuditStatus struct {
+	// The generation of the spec the last audit ran with. Changing the spec runs the audit again.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// RFC3339 timestamp at which the last audit ran.
+	LastAuditAt string `json:"lastAuditAt,omitempty"`
+	// The number of Versions audited.
+	CheckedVersions int `json:"checkedVersions"`
+	// The number of Versions whose archive is missing or does not match their checksum in at least one replica.
+	FailedVersions int `json:"failedVersions"`
+	// The archives found missing or corrupt. At most 100 are listed.
+	Findings []StorageAuditFinding `json:"findings,omitempty"`
+	// The number of stored objects that no Version references.
+	OrphanedObjects int `json:"orphanedObjects"`
+	// The number of orphaned objects deleted by the last audit.
+	DeletedObjects int `json:"deletedObjects"`
+	// The orphaned objects. At most 100 are listed.
+	Orphans []StorageAuditOrphan `json:"orphans,omitempty"`
+	// The audit's status.
+	Message string `json:"message,omitempty"`
+}
+
+// StorageAuditFinding records the archive of a Version that is missing or corrupt in a storage replica.
+type StorageAuditFinding struct {
+	// The name of the Version.
+	Version string `json:"version"`
+	// The name of the storage replica. 'primary' for the backend configured directly on the storage config.
+	Replica string `json:"replica"`
+	// The problem found. One of 'Missing', 'ChecksumMismatch' or 'Error'.
+	Problem string `json:"problem"`
+	// A description of the problem and of the repair.
+	Message string `json:"message,omitempty"`
+	// Whether the archive was copied to the replica from another replica.
+	Repaired bool `json:"repaired,omitempty"`
+}
+
+// StorageAuditOrphan records a stored object that no Version references.
+type StorageAuditOrphan struct {
+	// The backend the object is stored in, ie: 's3://opendepot-modules'.
+	Backend string `json:"backend"`
+	// The path of the object in the backend.
+	Path string `json:"path"`
+	// RFC3339 timestamp at which the object was last modified. Empty when the backend does not record it.
+	LastModified string `json:"lastModified,omitempty"`
+	// Whether the object was deleted.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// StorageAuditList contains a list of StorageAudit.
+type StorageAuditList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StorageAudit `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the migration"
+// +kubebuilder:printcolumn:name="Migrated",type="integer",JSONPath=".status.migratedVersions",description="The number of Versions migrated"
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.totalVersions",description="The number of Versions selected by the migration"
+
+// StorageMigration is the Schema for the StorageMigrations API. A StorageMigration copies the archives of the
+// Versions in its namespace to a target storage config and points their config references at it.
+type StorageMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StorageMigrationSpec   `json:"spec,omitempty"`
+	Status StorageMigrationStatus `json:"status,omitempty"`
+}
+
+// StorageMigrationSpec defines the target of a StorageMigration and the Versions it migrates. Exactly one of
+// TargetStorageConfig and TargetStorageProfileName must be set.
+type StorageMigrationSpec struct {
+	// The storage config archives are copied to.
+	TargetStorageConfig *StorageConfig `json:"targetStorageConfig,omitempty"`
+	// The name of the StorageProfile archives are copied to.
+	TargetStorageProfileName *string `json:"targetStorageProfileName,omitempty"`
+	// Selects the Versions in the StorageMigration's namespace to migrate. When omitted, every Version is migrated.
+	VersionSelector *metav1.LabelSelector `json:"versionSelector,omitempty"`
+	// Whether archives are deleted from their previous storage once their Version points at the target.
+	// Defaults to false.
+	DeleteSource bool `json:"deleteSource,omitempty"`
+}
+
+// StorageMigrationStatus defines the progress of a StorageMigration.
+type StorageMigrationStatus struct {
+	// The generation of the spec the progress was recorded for. Changing the spec restarts the migration.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The phase of the migration. One of 'Pending', 'Running', 'Succeeded' or 'Failed'.
+	Phase string `json:"phase,omitempty"`
+	// The number of Versions selected by the migration.
+	TotalVersions int `json:"totalVersions"`
+	// The number of Versions whose archives are stored in the target and whose config references point at it.
+	MigratedVersions int `json:"migratedVersions"`
+	// The Versions that could not be migrated. They are retried when the spec changes.
+	// +listType=map
+	// +listMapKey=name
+	FailedVersions []StorageMigrationFailure `json:"failedVersions,omitempty"`
+	// RFC3339 timestamp at which the migration started.
+	StartedAt string `json:"startedAt,omitempty"`
+	// RFC3339 timestamp at which the migration finished.
+	CompletedAt string `json:"completedAt,omitempty"`
+	// The migration's status.
+	Message string `json:"message,omitempty"`
+}
+
+// StorageMigrationFailure records a Version a StorageMigration could not migrate.
+type StorageMigrationFailure struct {
+	// The name of the Version.
+	Name string `json:"name"`
+	// The reason the Version could not be migrated.
+	Message string `json:"message"`
+}
+
+// +kubebuilder:object:root=true
+
+// StorageMigrationList contains a list of StorageMigration.
+type StorageMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StorageMigration `json:"items"`
+}
+
 func init() {
 	SchemeBuilder.Register(&Depot{}, &DepotList{})
 	SchemeBuilder.Register(&Module{}, &ModuleList{})
 	SchemeBuilder.Register(&Provider{}, &ProviderList{})
+	SchemeBuilder.Register(&StorageAudit{}, &StorageAuditList{})
+	SchemeBuilder.Register(&StorageMigration{}, &StorageMigrationList{})
+	SchemeBuilder.Register(&StorageProfile{}, &StorageProfileList{})
 	SchemeBuilder.Register(&Version{}, &VersionList{})
 }
@@ -21,6 +21,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -32,6 +33,21 @@ func (in *AmazonS3Config) DeepCopyInto(out *AmazonS3Config) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.CABundleSecretName != nil {
+		in, out := &in.CABundleSecretName, &out.CABundleSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CredentialsSecretName != nil {
+		in, out := &in.CredentialsSecretName, &out.CredentialsSecretName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmazonS3Config.
@@ -47,6 +63,11 @@ func (in *AmazonS3Config) DeepCopy() *AmazonS3Config {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureStorageConfig) DeepCopyInto(out *AzureStorageConfig) {
 	*out = *in
+	if in.CredentialsSecretName != nil {
+		in, out := &in.CredentialsSecretName, &out.CredentialsSecretName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureStorageConfig.
@@ -118,6 +139,51 @@ func (in *DepotList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DepotPullThroughConfig) DeepCopyInto(out *DepotPullThroughConfig) {
+	*out = *in
+	if in.ModuleOwners != nil {
+		in, out := &in.ModuleOwners, &out.ModuleOwners
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxVersionsPerHour != nil {
+		in, out := &in.MaxVersionsPerHour, &out.MaxVersionsPerHour
+		*out = new(int)
+		**out = **in
+	}
+	if in.ProviderNamespaces != nil {
+		in, out := &in.ProviderNamespaces, &out.ProviderNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ProviderRegistry != nil {
+		in, out := &in.ProviderRegistry, &out.ProviderRegistry
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProviderRegistrySecretName != nil {
+		in, out := &in.ProviderRegistrySecretName, &out.ProviderRegistrySecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SyncTimeoutSeconds != nil {
+		in, out := &in.SyncTimeoutSeconds, &out.SyncTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepotPullThroughConfig.
+func (in *DepotPullThroughConfig) DeepCopy() *DepotPullThroughConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DepotPullThroughConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DepotSpec) DeepCopyInto(out *DepotSpec) {
 	*out = *in
@@ -145,6 +211,16 @@ func (in *DepotSpec) DeepCopyInto(out *DepotSpec) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.PullThrough != nil {
+		in, out := &in.PullThrough, &out.PullThrough
+		*out = new(DepotPullThroughConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WebhookConfig != nil {
+		in, out := &in.WebhookConfig, &out.WebhookConfig
+		*out = new(DepotWebhookConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepotSpec.
@@ -160,6 +236,11 @@ func (in *DepotSpec) DeepCopy() *DepotSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DepotStatus) DeepCopyInto(out *DepotStatus) {
 	*out = *in
+	if in.GithubRateLimits != nil {
+		in, out := &in.GithubRateLimits, &out.GithubRateLimits
+		*out = make([]GithubRateLimitStatus, len(*in))
+		copy(*out, *in)
+	}
 	if in.Modules != nil {
 		in, out := &in.Modules, &out.Modules
 		*out = make([]string, len(*in))
@@ -182,6 +263,21 @@ func (in *DepotStatus) DeepCopy() *DepotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DepotWebhookConfig) DeepCopyInto(out *DepotWebhookConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DepotWebhookConfig.
+func (in *DepotWebhookConfig) DeepCopy() *DepotWebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DepotWebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FileSystemConfig) DeepCopyInto(out *FileSystemConfig) {
 	*out = *in
@@ -205,6 +301,26 @@ func (in *FileSystemConfig) DeepCopy() *FileSystemConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubClientConfig) DeepCopyInto(out *GithubClientConfig) {
 	*out = *in
+	if in.BaseURL != nil {
+		in, out := &in.BaseURL, &out.BaseURL
+		*out = new(string)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(string)
+		**out = **in
+	}
+	if in.SecretName != nil {
+		in, out := &in.SecretName, &out.SecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.UploadURL != nil {
+		in, out := &in.UploadURL, &out.UploadURL
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubClientConfig.
@@ -217,13 +333,28 @@ func (in *GithubClientConfig) DeepCopy() *GithubClientConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubRateLimitStatus) DeepCopyInto(out *GithubRateLimitStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubRateLimitStatus.
+func (in *GithubRateLimitStatus) DeepCopy() *GithubRateLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubRateLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 	*out = *in
 	if in.GithubClientConfig != nil {
 		in, out := &in.GithubClientConfig, &out.GithubClientConfig
 		*out = new(GithubClientConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.ModuleConfig != nil {
 		in, out := &in.ModuleConfig, &out.ModuleConfig
@@ -235,6 +366,11 @@ func (in *GlobalConfig) DeepCopyInto(out *GlobalConfig) {
 		*out = new(StorageConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.StorageProfileName != nil {
+		in, out := &in.StorageProfileName, &out.StorageProfileName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalConfig.
@@ -250,6 +386,11 @@ func (in *GlobalConfig) DeepCopy() *GlobalConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GoogleCloudStorageConfig) DeepCopyInto(out *GoogleCloudStorageConfig) {
 	*out = *in
+	if in.CredentialsSecretName != nil {
+		in, out := &in.CredentialsSecretName, &out.CredentialsSecretName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleCloudStorageConfig.
@@ -292,6 +433,21 @@ func (in *Module) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ModuleConfig) DeepCopyInto(out *ModuleConfig) {
 	*out = *in
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = new(ModuleDependencyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DiscoveryMode != nil {
+		in, out := &in.DiscoveryMode, &out.DiscoveryMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExcludePatterns != nil {
+		in, out := &in.ExcludePatterns, &out.ExcludePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.FileFormat != nil {
 		in, out := &in.FileFormat, &out.FileFormat
 		*out = new(string)
@@ -300,6 +456,11 @@ func (in *ModuleConfig) DeepCopyInto(out *ModuleConfig) {
 	if in.GithubClientConfig != nil {
 		in, out := &in.GithubClientConfig, &out.GithubClientConfig
 		*out = new(GithubClientConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetagPolicy != nil {
+		in, out := &in.RetagPolicy, &out.RetagPolicy
+		*out = new(string)
 		**out = **in
 	}
 	if in.Immutable != nil {
@@ -307,6 +468,16 @@ func (in *ModuleConfig) DeepCopyInto(out *ModuleConfig) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.IncludeDrafts != nil {
+		in, out := &in.IncludeDrafts, &out.IncludeDrafts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IncludePrereleases != nil {
+		in, out := &in.IncludePrereleases, &out.IncludePrereleases
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Name != nil {
 		in, out := &in.Name, &out.Name
 		*out = new(string)
@@ -322,6 +493,11 @@ func (in *ModuleConfig) DeepCopyInto(out *ModuleConfig) {
 		*out = new(StorageConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.StorageProfileName != nil {
+		in, out := &in.StorageProfileName, &out.StorageProfileName
+		*out = new(string)
+		**out = **in
+	}
 	if in.VersionHistoryLimit != nil {
 		in, out := &in.VersionHistoryLimit, &out.VersionHistoryLimit
 		*out = new(int)
@@ -339,6 +515,80 @@ func (in *ModuleConfig) DeepCopy() *ModuleConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleDependencies) DeepCopyInto(out *ModuleDependencies) {
+	*out = *in
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]ProviderDependency, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Modules != nil {
+		in, out := &in.Modules, &out.Modules
+		*out = make([]ModuleDependency, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleDependencies.
+func (in *ModuleDependencies) DeepCopy() *ModuleDependencies {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleDependencies)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleDependency) DeepCopyInto(out *ModuleDependency) {
+	*out = *in
+	if in.ModuleRef != nil {
+		in, out := &in.ModuleRef, &out.ModuleRef
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleDependency.
+func (in *ModuleDependency) DeepCopy() *ModuleDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleDependencyConfig) DeepCopyInto(out *ModuleDependencyConfig) {
+	*out = *in
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Architectures != nil {
+		in, out := &in.Architectures, &out.Architectures
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleDependencyConfig.
+func (in *ModuleDependencyConfig) DeepCopy() *ModuleDependencyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleDependencyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ModuleList) DeepCopyInto(out *ModuleList) {
 	*out = *in
@@ -470,6 +720,66 @@ func (in *ModuleVersion) DeepCopy() *ModuleVersion {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleVersionDigest) DeepCopyInto(out *ModuleVersionDigest) {
+	*out = *in
+	if in.FileName != nil {
+		in, out := &in.FileName, &out.FileName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleVersionDigest.
+func (in *ModuleVersionDigest) DeepCopy() *ModuleVersionDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleVersionDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleVersionSource) DeepCopyInto(out *ModuleVersionSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleVersionSource.
+func (in *ModuleVersionSource) DeepCopy() *ModuleVersionSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleVersionSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIStorageConfig) DeepCopyInto(out *OCIStorageConfig) {
+	*out = *in
+	if in.CABundleSecretName != nil {
+		in, out := &in.CABundleSecretName, &out.CABundleSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.CredentialsSecretName != nil {
+		in, out := &in.CredentialsSecretName, &out.CredentialsSecretName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIStorageConfig.
+func (in *OCIStorageConfig) DeepCopy() *OCIStorageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIStorageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Provider) DeepCopyInto(out *Provider) {
 	*out = *in
@@ -530,6 +840,21 @@ func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Registry != nil {
+		in, out := &in.Registry, &out.Registry
+		*out = new(string)
+		**out = **in
+	}
+	if in.RegistrySecretName != nil {
+		in, out := &in.RegistrySecretName, &out.RegistrySecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.SigningKeysSecretName != nil {
+		in, out := &in.SigningKeysSecretName, &out.SigningKeysSecretName
+		*out = new(string)
+		**out = **in
+	}
 	if in.OperatingSystems != nil {
 		in, out := &in.OperatingSystems, &out.OperatingSystems
 		*out = make([]string, len(*in))
@@ -543,7 +868,7 @@ func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
 	if in.GithubClientConfig != nil {
 		in, out := &in.GithubClientConfig, &out.GithubClientConfig
 		*out = new(GithubClientConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.SourceRepository != nil {
 		in, out := &in.SourceRepository, &out.SourceRepository
@@ -555,6 +880,11 @@ func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
 		*out = new(StorageConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.StorageProfileName != nil {
+		in, out := &in.StorageProfileName, &out.StorageProfileName
+		*out = new(string)
+		**out = **in
+	}
 	if in.VersionHistoryLimit != nil {
 		in, out := &in.VersionHistoryLimit, &out.VersionHistoryLimit
 		*out = new(int)
@@ -572,6 +902,26 @@ func (in *ProviderConfig) DeepCopy() *ProviderConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderDependency) DeepCopyInto(out *ProviderDependency) {
+	*out = *in
+	if in.ProviderRef != nil {
+		in, out := &in.ProviderRef, &out.ProviderRef
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderDependency.
+func (in *ProviderDependency) DeepCopy() *ProviderDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderList) DeepCopyInto(out *ProviderList) {
 	*out = *in
@@ -605,29 +955,59 @@ func (in *ProviderList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ProviderSourceScan) DeepCopyInto(out *ProviderSourceScan) {
+func (in *ProviderSignatureVerification) DeepCopyInto(out *ProviderSignatureVerification) {
 	*out = *in
-	if in.Findings != nil {
-		in, out := &in.Findings, &out.Findings
-		*out = make([]SecurityFinding, len(*in))
+	if in.TrustedKeyIDs != nil {
+		in, out := &in.TrustedKeyIDs, &out.TrustedKeyIDs
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SignedByKeyID != nil {
+		in, out := &in.SignedByKeyID, &out.SignedByKeyID
+		*out = new(string)
+		**out = **in
+	}
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderSourceScan.
-func (in *ProviderSourceScan) DeepCopy() *ProviderSourceScan {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderSignatureVerification.
+func (in *ProviderSignatureVerification) DeepCopy() *ProviderSignatureVerification {
 	if in == nil {
 		return nil
 	}
-	out := new(ProviderSourceScan)
+	out := new(ProviderSignatureVerification)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ProviderSpec) DeepCopyInto(out *ProviderSpec) {
+func (in *ProviderSourceScan) DeepCopyInto(out *ProviderSourceScan) {
 	*out = *in
-	in.ProviderConfig.DeepCopyInto(&out.ProviderConfig)
+	if in.Findings != nil {
+		in, out := &in.Findings, &out.Findings
+		*out = make([]SecurityFinding, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderSourceScan.
+func (in *ProviderSourceScan) DeepCopy() *ProviderSourceScan {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderSourceScan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderSpec) DeepCopyInto(out *ProviderSpec) {
+	*out = *in
+	in.ProviderConfig.DeepCopyInto(&out.ProviderConfig)
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
 		*out = make([]ProviderVersion, len(*in))
@@ -716,13 +1096,157 @@ func (in *SecurityFinding) DeepCopy() *SecurityFinding {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAudit) DeepCopyInto(out *StorageAudit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAudit.
+func (in *StorageAudit) DeepCopy() *StorageAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageAudit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAuditFinding) DeepCopyInto(out *StorageAuditFinding) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAuditFinding.
+func (in *StorageAuditFinding) DeepCopy() *StorageAuditFinding {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAuditFinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAuditList) DeepCopyInto(out *StorageAuditList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StorageAudit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAuditList.
+func (in *StorageAuditList) DeepCopy() *StorageAuditList {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAuditList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageAuditList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAuditOrphan) DeepCopyInto(out *StorageAuditOrphan) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAuditOrphan.
+func (in *StorageAuditOrphan) DeepCopy() *StorageAuditOrphan {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAuditOrphan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAuditSpec) DeepCopyInto(out *StorageAuditSpec) {
+	*out = *in
+	if in.IntervalMinutes != nil {
+		in, out := &in.IntervalMinutes, &out.IntervalMinutes
+		*out = new(int)
+		**out = **in
+	}
+	if in.VersionSelector != nil {
+		in, out := &in.VersionSelector, &out.VersionSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OrphanGracePeriodMinutes != nil {
+		in, out := &in.OrphanGracePeriodMinutes, &out.OrphanGracePeriodMinutes
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAuditSpec.
+func (in *StorageAuditSpec) DeepCopy() *StorageAuditSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAuditSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageAuditStatus) DeepCopyInto(out *StorageAuditStatus) {
+	*out = *in
+	if in.Findings != nil {
+		in, out := &in.Findings, &out.Findings
+		*out = make([]StorageAuditFinding, len(*in))
+		copy(*out, *in)
+	}
+	if in.Orphans != nil {
+		in, out := &in.Orphans, &out.Orphans
+		*out = make([]StorageAuditOrphan, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageAuditStatus.
+func (in *StorageAuditStatus) DeepCopy() *StorageAuditStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageAuditStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	*out = *in
 	if in.AzureStorage != nil {
 		in, out := &in.AzureStorage, &out.AzureStorage
 		*out = new(AzureStorageConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.FileSystem != nil {
 		in, out := &in.FileSystem, &out.FileSystem
@@ -737,6 +1261,33 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 	if in.GCS != nil {
 		in, out := &in.GCS, &out.GCS
 		*out = new(GoogleCloudStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OCI != nil {
+		in, out := &in.OCI, &out.OCI
+		*out = new(OCIStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]StorageReplicaConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReadOrder != nil {
+		in, out := &in.ReadOrder, &out.ReadOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(StorageEncryptionConfig)
+		**out = **in
+	}
+	if in.Layout != nil {
+		in, out := &in.Layout, &out.Layout
+		*out = new(string)
 		**out = **in
 	}
 }
@@ -751,6 +1302,284 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageEncryptionConfig) DeepCopyInto(out *StorageEncryptionConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageEncryptionConfig.
+func (in *StorageEncryptionConfig) DeepCopy() *StorageEncryptionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageEncryptionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigration) DeepCopyInto(out *StorageMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigration.
+func (in *StorageMigration) DeepCopy() *StorageMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationFailure) DeepCopyInto(out *StorageMigrationFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationFailure.
+func (in *StorageMigrationFailure) DeepCopy() *StorageMigrationFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationList) DeepCopyInto(out *StorageMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StorageMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationList.
+func (in *StorageMigrationList) DeepCopy() *StorageMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationSpec) DeepCopyInto(out *StorageMigrationSpec) {
+	*out = *in
+	if in.TargetStorageConfig != nil {
+		in, out := &in.TargetStorageConfig, &out.TargetStorageConfig
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TargetStorageProfileName != nil {
+		in, out := &in.TargetStorageProfileName, &out.TargetStorageProfileName
+		*out = new(string)
+		**out = **in
+	}
+	if in.VersionSelector != nil {
+		in, out := &in.VersionSelector, &out.VersionSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationSpec.
+func (in *StorageMigrationSpec) DeepCopy() *StorageMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageMigrationStatus) DeepCopyInto(out *StorageMigrationStatus) {
+	*out = *in
+	if in.FailedVersions != nil {
+		in, out := &in.FailedVersions, &out.FailedVersions
+		*out = make([]StorageMigrationFailure, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageMigrationStatus.
+func (in *StorageMigrationStatus) DeepCopy() *StorageMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageProfile) DeepCopyInto(out *StorageProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageProfile.
+func (in *StorageProfile) DeepCopy() *StorageProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageProfileList) DeepCopyInto(out *StorageProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StorageProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageProfileList.
+func (in *StorageProfileList) DeepCopy() *StorageProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorageProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageProfileSpec) DeepCopyInto(out *StorageProfileSpec) {
+	*out = *in
+	in.StorageConfig.DeepCopyInto(&out.StorageConfig)
+	if in.SecretNamespace != nil {
+		in, out := &in.SecretNamespace, &out.SecretNamespace
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageProfileSpec.
+func (in *StorageProfileSpec) DeepCopy() *StorageProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageReplicaConfig) DeepCopyInto(out *StorageReplicaConfig) {
+	*out = *in
+	if in.AzureStorage != nil {
+		in, out := &in.AzureStorage, &out.AzureStorage
+		*out = new(AzureStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileSystem != nil {
+		in, out := &in.FileSystem, &out.FileSystem
+		*out = new(FileSystemConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(AmazonS3Config)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(GoogleCloudStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OCI != nil {
+		in, out := &in.OCI, &out.OCI
+		*out = new(OCIStorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageReplicaConfig.
+func (in *StorageReplicaConfig) DeepCopy() *StorageReplicaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageReplicaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageReplicaStatus) DeepCopyInto(out *StorageReplicaStatus) {
+	*out = *in
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageReplicaStatus.
+func (in *StorageReplicaStatus) DeepCopy() *StorageReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Version) DeepCopyInto(out *Version) {
 	*out = *in
@@ -848,6 +1677,11 @@ func (in *VersionStatus) DeepCopyInto(out *VersionStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PackageHash != nil {
+		in, out := &in.PackageHash, &out.PackageHash
+		*out = new(string)
+		**out = **in
+	}
 	if in.BinaryScan != nil {
 		in, out := &in.BinaryScan, &out.BinaryScan
 		*out = new(ProviderBinaryScan)
@@ -858,6 +1692,47 @@ func (in *VersionStatus) DeepCopyInto(out *VersionStatus) {
 		*out = new(ModuleSourceScan)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SignatureVerification != nil {
+		in, out := &in.SignatureVerification, &out.SignatureVerification
+		*out = new(ProviderSignatureVerification)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ModuleVersionSource)
+		**out = **in
+	}
+	if in.DigestHistory != nil {
+		in, out := &in.DigestHistory, &out.DigestHistory
+		*out = make([]ModuleVersionDigest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quarantined != nil {
+		in, out := &in.Quarantined, &out.Quarantined
+		*out = new(ModuleVersionDigest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Dependencies != nil {
+		in, out := &in.Dependencies, &out.Dependencies
+		*out = new(ModuleDependencies)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = make([]StorageReplicaStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionStatus.
@@ -0,0 +1,167 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// conditionalCacheMaxEntries bounds the number of responses kept by the conditional request cache.
+	conditionalCacheMaxEntries = 2048
+	// conditionalCacheMaxBodyBytes bounds the size of a single cached response body.
+	conditionalCacheMaxBodyBytes = 1 << 20
+)
+
+// conditionalCache is the process wide cache of GitHub API responses used for conditional requests.
+var conditionalCache = &responseCache{
+	entries: map[string]*cachedResponse{},
+}
+
+// cachedResponse is a GitHub API response stored along with its validators.
+type cachedResponse struct {
+	body         []byte
+	etag         string
+	header       http.Header
+	lastModified string
+}
+
+// responseCache is a bounded cache of GitHub API responses. The oldest entry is evicted once the cache is full.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	order   []string
+}
+
+// conditionalTransport sends conditional GET requests to the GitHub API using the ETag and Last-Modified values
+// of previously cached responses. A 304 Not Modified response, which does not count against the GitHub API rate
+// limit, is replayed from the cache as a 200 OK carrying the fresh rate limit headers.
+type conditionalTransport struct {
+	Transport http.RoundTripper
+	cache     *responseCache
+}
+
+// RoundTrip executes a single HTTP transaction, adding conditional request headers to GET requests with a cached response.
+func (t *conditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Transport.RoundTrip(req)
+	}
+
+	key := conditionalCacheKey(req)
+	cached := t.cache.get(key)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached.replay(req, resp.Header), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || !isConditionallyCacheable(resp) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, conditionalCacheMaxBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to read GitHub API response: %w", err)
+	}
+
+	if len(body) > conditionalCacheMaxBodyBytes {
+		// Too large to cache: hand back the bytes already read followed by the rest of the stream.
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.put(key, &cachedResponse{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		header:       resp.Header.Clone(),
+		lastModified: resp.Header.Get("Last-Modified"),
+	})
+
+	return resp, nil
+}
+
+// replay returns the cached response for req with the rate limit headers of the fresh 304 response applied.
+func (c *cachedResponse) replay(req *http.Request, freshHeader http.Header) *http.Response {
+	header := c.header.Clone()
+	for name, values := range freshHeader {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Ratelimit-") {
+			header[name] = values
+		}
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// get returns the cached response stored under key, or nil when there is none.
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
+
+// put stores response under key, evicting the oldest entry when the cache is full.
+func (c *responseCache) put(key string, response *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = response
+
+	for len(c.order) > conditionalCacheMaxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// conditionalCacheKey returns the cache key of req. The Authorization header is part of the key so responses
+// visible to one set of credentials are never replayed for another.
+func conditionalCacheKey(req *http.Request) string {
+	authDigest := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + "|" + hex.EncodeToString(authDigest[:])
+}
+
+// isConditionallyCacheable reports whether resp is a JSON API response with a validator for conditional requests.
+// Archive downloads and other non-JSON responses are never cached.
+func isConditionallyCacheable(resp *http.Response) bool {
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return false
+	}
+	return strings.Contains(resp.Header.Get("Content-Type"), "json")
+}
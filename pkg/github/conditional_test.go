@@ -0,0 +1,206 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestConditionalClient returns a client sending requests through a conditionalTransport with an empty cache.
+func newTestConditionalClient() *http.Client {
+	return &http.Client{Transport: &conditionalTransport{
+		Transport: http.DefaultTransport,
+		cache:     &responseCache{entries: map[string]*cachedResponse{}},
+	}}
+}
+
+// getBody sends a GET request for url with the authorization header and returns the response and its body.
+func getBody(t *testing.T, httpClient *http.Client, url, authorization string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+func TestConditionalTransportReplaysNotModified(t *testing.T) {
+	var conditionalRequests int
+	remaining := []string{"4999"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", remaining[0])
+		remaining = remaining[1:]
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditionalRequests++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"tag_name":"v1.0.0"}]`))
+	}))
+	defer server.Close()
+
+	httpClient := newTestConditionalClient()
+	_, first := getBody(t, httpClient, server.URL, "")
+
+	remaining = []string{"4998"}
+	resp, replayed := getBody(t, httpClient, server.URL, "")
+	if conditionalRequests != 1 {
+		t.Fatalf("sent %d conditional requests, want 1", conditionalRequests)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("replayed status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if !bytes.Equal(replayed, first) {
+		t.Fatalf("replayed body %q, want %q", replayed, first)
+	}
+
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "4998" {
+		t.Fatalf("replayed X-RateLimit-Remaining %q, want the fresh value '4998'", got)
+	}
+
+	if got := resp.Header.Get("ETag"); got != `"v1"` {
+		t.Fatalf("replayed ETag %q, want the cached value", got)
+	}
+}
+
+func TestConditionalCacheKey(t *testing.T) {
+	newRequest := func(url, authorization string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		a        *http.Request
+		b        *http.Request
+		expected bool
+	}{
+		{
+			name:     "same URL and credentials",
+			a:        newRequest("https://api.github.com/repos/defdev/vpc/releases", "Bearer team-a"),
+			b:        newRequest("https://api.github.com/repos/defdev/vpc/releases", "Bearer team-a"),
+			expected: true,
+		},
+		{
+			name: "other credentials",
+			a:    newRequest("https://api.github.com/repos/defdev/vpc/releases", "Bearer team-a"),
+			b:    newRequest("https://api.github.com/repos/defdev/vpc/releases", "Bearer team-b"),
+		},
+		{
+			name: "unauthenticated",
+			a:    newRequest("https://api.github.com/repos/defdev/vpc/releases", "Bearer team-a"),
+			b:    newRequest("https://api.github.com/repos/defdev/vpc/releases", ""),
+		},
+		{
+			name: "other page",
+			a:    newRequest("https://api.github.com/repos/defdev/vpc/releases?page=1", "Bearer team-a"),
+			b:    newRequest("https://api.github.com/repos/defdev/vpc/releases?page=2", "Bearer team-a"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyA, keyB := conditionalCacheKey(tt.a), conditionalCacheKey(tt.b)
+			if (keyA == keyB) != tt.expected {
+				t.Fatalf("keys %q and %q: equal is %t, want %t", keyA, keyB, keyA == keyB, tt.expected)
+			}
+
+			if bytes.Contains([]byte(keyA), []byte("team-a")) {
+				t.Fatalf("key %q holds the Authorization header in the clear", keyA)
+			}
+		})
+	}
+}
+
+func TestConditionalTransportCachesPerCredential(t *testing.T) {
+	var conditionalRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditionalRequests++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"`+r.Header.Get("Authorization")+`"`)
+		_, _ = w.Write([]byte(`{"visibleTo":"` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer server.Close()
+
+	httpClient := newTestConditionalClient()
+	getBody(t, httpClient, server.URL, "Bearer team-a")
+
+	_, body := getBody(t, httpClient, server.URL, "Bearer team-b")
+	if conditionalRequests != 0 {
+		t.Fatalf("sent %d conditional requests for other credentials, want 0", conditionalRequests)
+	}
+
+	if string(body) != `{"visibleTo":"Bearer team-b"}` {
+		t.Fatalf("got body %q, want the response for team-b", body)
+	}
+
+	getBody(t, httpClient, server.URL, "Bearer team-a")
+	if conditionalRequests != 1 {
+		t.Fatalf("sent %d conditional requests, want 1", conditionalRequests)
+	}
+}
+
+func TestConditionalTransportPassesLargeBodiesThrough(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), conditionalCacheMaxBodyBytes+512)
+	var conditionalRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditionalRequests++
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"large"`)
+		_, _ = w.Write(large)
+	}))
+	defer server.Close()
+
+	httpClient := newTestConditionalClient()
+	for i := range 2 {
+		resp, body := getBody(t, httpClient, server.URL, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: status %d, want %d", i, resp.StatusCode, http.StatusOK)
+		}
+
+		if !bytes.Equal(body, large) {
+			t.Fatalf("request %d: read %d bytes, want the %d bytes sent", i, len(body), len(large))
+		}
+	}
+
+	if conditionalRequests != 0 {
+		t.Fatalf("sent %d conditional requests for a response too large to cache, want 0", conditionalRequests)
+	}
+}
@@ -2,7 +2,9 @@ package github
 
 import (
 	"context"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
@@ -10,13 +12,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/go-github/v81/github"
+	"github.com/hashicorp/go-version"
 	"golang.org/x/oauth2"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +30,27 @@ import (
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 )
 
+// installationTokenExpiryDelta is how long before expiry a cached installation token is refreshed.
+const installationTokenExpiryDelta = 5 * time.Minute
+
+// tokenCache holds the installation token sources and resolved installation IDs shared across reconciles.
+var tokenCache = struct {
+	mu              sync.Mutex
+	installationIDs map[string]int64
+	tokenSources    map[string]oauth2.TokenSource
+}{
+	installationIDs: map[string]int64{},
+	tokenSources:    map[string]oauth2.TokenSource{},
+}
+
+// installationTokenSource is an oauth2.TokenSource that mints Github application installation tokens.
+type installationTokenSource struct {
+	githubConfig   *GithubClientConfig
+	installationID int64
+	privateKey     *rsa.PrivateKey
+	transport      http.RoundTripper
+}
+
 // jwtTransport is a custom HTTP transport that adds the JWT to the Authorization header.
 type jwtTransport struct {
 	Transport http.RoundTripper
@@ -34,39 +61,175 @@ type jwtTransport struct {
 type GithubClientConfig struct {
 	// The Github application's ID.
 	AppID int64
-	// The Github application's install ID.
+	// The Github application's install ID. When zero the installation is resolved from the Owner.
 	InstallationID int64
+	// The repository owner used to resolve the Github application's installation.
+	Owner string
 	// The Github application's private key as a byte slice.
 	PrivateKeyData []byte
+	// The GitHub Enterprise Server API base URL. When empty the client targets api.github.com.
+	BaseURL string
+	// The GitHub Enterprise Server upload URL. When empty the host of the BaseURL is used in its place.
+	UploadURL string
+	// A PEM encoded CA bundle trusted in addition to the system roots.
+	CABundle []byte
+	// A personal access token. When set it is used instead of the Github application credentials.
+	Token string
 }
 
 // CreateGithubClient creates an authenticated client with the provided GithubClientConfig.
 // If the client config is nil a github.Client is returned with a default http.Client type.
+// The BaseURL, UploadURL and CABundle of the config are honoured for both authenticated and
+// unauthenticated clients.
 func CreateGithubClient(ctx context.Context, useAuthenticatedClient bool, githubConfig *GithubClientConfig) (*github.Client, error) {
 	if useAuthenticatedClient && githubConfig == nil {
 		return nil, fmt.Errorf("resource is marked to UseAuthenticatedClient but GithubClientConfig is nil")
 	}
 
 	if useAuthenticatedClient && githubConfig != nil {
-		authClient, err := GenerateAuthenticatedGithubClient(ctx, githubConfig)
+		var authClient *github.Client
+		var err error
+		if githubConfig.Token != "" {
+			authClient, err = generateTokenGithubClient(ctx, githubConfig)
+		} else {
+			authClient, err = GenerateAuthenticatedGithubClient(ctx, githubConfig)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("unable to generate authenticated github client: %v", err)
 		}
 		return authClient, nil
 	}
 
-	return github.NewClient(nil), nil
+	transport, err := newHTTPTransport(githubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGithubClient(&http.Client{Transport: transport}, githubConfig)
+}
+
+// NewGithubClientConfig returns a GithubClientConfig populated with the GitHub Enterprise Server
+// settings of the provided resource config. A nil config returns an empty GithubClientConfig.
+func NewGithubClientConfig(githubClientConfig *opendepotv1alpha1.GithubClientConfig) *GithubClientConfig {
+	config := &GithubClientConfig{}
+	if githubClientConfig == nil {
+		return config
+	}
+
+	if githubClientConfig.BaseURL != nil {
+		config.BaseURL = *githubClientConfig.BaseURL
+	}
+
+	if githubClientConfig.UploadURL != nil {
+		config.UploadURL = *githubClientConfig.UploadURL
+	}
+
+	if githubClientConfig.CABundle != nil {
+		config.CABundle = []byte(*githubClientConfig.CABundle)
+	}
+
+	return config
+}
+
+// GetGithubClientConfig returns the GithubClientConfig for the provided resource config. When the resource config
+// sets UseAuthenticatedClient the credentials are read from the kubernetes secret referenced by SecretName in
+// secretNamespace and merged with the GitHub Enterprise Server settings. The owner is the repository owner used to
+// resolve the Github application's installation when the secret has no githubInstallID.
+func GetGithubClientConfig(ctx context.Context, k8sClient client.Client, secretNamespace, owner string, githubClientConfig *opendepotv1alpha1.GithubClientConfig) (*GithubClientConfig, error) {
+	config := NewGithubClientConfig(githubClientConfig)
+	config.Owner = owner
+	if githubClientConfig == nil || !githubClientConfig.UseAuthenticatedClient {
+		return config, nil
+	}
+
+	secretName := opendepotv1alpha1.OpenDepotGithubSecretName
+	if githubClientConfig.SecretName != nil && *githubClientConfig.SecretName != "" {
+		secretName = *githubClientConfig.SecretName
+	}
+
+	secretConfig, err := GetGithubSecret(ctx, k8sClient, secretNamespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	config.AppID = secretConfig.AppID
+	config.InstallationID = secretConfig.InstallationID
+	config.PrivateKeyData = secretConfig.PrivateKeyData
+	config.Token = secretConfig.Token
+	return config, nil
+}
+
+// GetRepositoryURL returns the web URL of the repository owner/name. When the resource config sets a BaseURL
+// the repository is addressed on the GitHub Enterprise Server host, otherwise on github.com.
+func GetRepositoryURL(githubClientConfig *opendepotv1alpha1.GithubClientConfig, owner, name string) string {
+	host := "https://github.com"
+	if githubClientConfig != nil && githubClientConfig.BaseURL != nil {
+		if baseURL, err := url.Parse(*githubClientConfig.BaseURL); err == nil && baseURL.Host != "" {
+			host = fmt.Sprintf("%s://%s", baseURL.Scheme, baseURL.Host)
+		}
+	}
+
+	return fmt.Sprintf("%s/%s/%s", host, owner, name)
+}
+
+// newHTTPTransport returns the transport used for requests to GitHub. When the config carries a CA bundle
+// it is added to the system roots so GitHub Enterprise Server instances using an internal CA can be verified.
+// GET requests are sent as conditional requests when a cached response for the same URL and credentials exists.
+func newHTTPTransport(githubConfig *GithubClientConfig) (http.RoundTripper, error) {
+	if githubConfig == nil || len(githubConfig.CABundle) == 0 {
+		return &conditionalTransport{Transport: http.DefaultTransport, cache: conditionalCache}, nil
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if ok := rootCAs.AppendCertsFromPEM(githubConfig.CABundle); !ok {
+		return nil, errors.New("failed to parse any certificates from the GitHub CA bundle")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    rootCAs,
+	}
+
+	return &conditionalTransport{Transport: transport, cache: conditionalCache}, nil
+}
+
+// newGithubClient creates a github.Client using httpClient that targets the GitHub Enterprise Server
+// URLs of the config when a BaseURL is set.
+func newGithubClient(httpClient *http.Client, githubConfig *GithubClientConfig) (*github.Client, error) {
+	githubClient := github.NewClient(httpClient)
+	if githubConfig == nil || githubConfig.BaseURL == "" {
+		return githubClient, nil
+	}
+
+	// WithEnterpriseURLs appends api/uploads/ to an upload URL missing it, so the api/v3 suffix of the BaseURL is
+	// trimmed first, ie: https://github.example.com/api/v3/ uploads to https://github.example.com/api/uploads/.
+	uploadURL := githubConfig.UploadURL
+	if uploadURL == "" {
+		uploadURL = strings.TrimSuffix(strings.TrimSuffix(githubConfig.BaseURL, "/"), "/api/v3")
+	}
+
+	enterpriseClient, err := githubClient.WithEnterpriseURLs(githubConfig.BaseURL, uploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure GitHub Enterprise Server URLs: %w", err)
+	}
+
+	return enterpriseClient, nil
 }
 
 // GetModuleArchiveFromRef gets a module from Github based on its ref and returns a byte slice and the file's base64 encoded SHA256 checksum.
-func GetModuleArchiveFromRef(ctx context.Context, log logr.Logger, githubClient *github.Client, version *opendepotv1alpha1.Version, format github.ArchiveFormat) (moduleBytes []byte, checksum *string, err error) {
+func GetModuleArchiveFromRef(ctx context.Context, log logr.Logger, githubClient *github.Client, githubConfig *GithubClientConfig, version *opendepotv1alpha1.Version, format github.ArchiveFormat) (moduleBytes []byte, checksum *string, err error) {
 	ref := version.Spec.Version
 	if !strings.HasPrefix(ref, "v") {
 		ref = "v" + ref
 	}
 
 	var moduleReq *http.Response
-	moduleReq, err = GetArchiveRequest(ctx, githubClient, version, format, ref)
+	moduleReq, err = GetArchiveRequest(ctx, githubClient, githubConfig, version, format, ref)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -81,7 +244,7 @@ func GetModuleArchiveFromRef(ctx context.Context, log logr.Logger, githubClient
 			var moduleReq *http.Response
 
 			refNoV := strings.TrimPrefix(ref, "v")
-			moduleReq, err = GetArchiveRequest(ctx, githubClient, version, format, refNoV)
+			moduleReq, err = GetArchiveRequest(ctx, githubClient, githubConfig, version, format, refNoV)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -119,8 +282,81 @@ func GetModuleArchiveFromRef(ctx context.Context, log logr.Logger, githubClient
 	return
 }
 
+// maxTagDepth is the number of annotated tags followed when a tag points at another tag.
+const maxTagDepth = 5
+
+// ModuleTag is the git tag of a module version and the commit it points to.
+type ModuleTag struct {
+	// The name of the tag, e.g. 'v1.2.0'.
+	Name string
+	// The SHA of the commit the tag points to.
+	CommitSHA string
+	// The SHA of the tag object of an annotated tag. Empty for lightweight tags.
+	ObjectSHA string
+}
+
+// ResolveModuleTag resolves the git tag of a module version to the commit it points to. Both 'v{version}' and
+// bare '{version}' tags are tried, matching the retry pattern used by GetModuleArchiveFromRef. Nil is returned
+// without an error when neither tag exists.
+func ResolveModuleTag(ctx context.Context, githubClient *github.Client, owner, repo, version string) (*ModuleTag, error) {
+	bare := strings.TrimPrefix(version, "v")
+	for _, tag := range []string{"v" + bare, bare} {
+		ref, resp, err := githubClient.Git.GetRef(ctx, owner, repo, "tags/"+tag)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get tag '%s': %w", tag, err)
+		}
+
+		moduleTag := &ModuleTag{Name: tag}
+		object := ref.GetObject()
+		if object.GetType() == "tag" {
+			moduleTag.ObjectSHA = object.GetSHA()
+		}
+
+		for depth := 0; object.GetType() == "tag" && depth < maxTagDepth; depth++ {
+			tagObject, _, err := githubClient.Git.GetTag(ctx, owner, repo, object.GetSHA())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get tag object '%s': %w", object.GetSHA(), err)
+			}
+			object = tagObject.GetObject()
+		}
+
+		if object.GetType() != "commit" {
+			return nil, fmt.Errorf("tag '%s' does not point to a commit", tag)
+		}
+
+		moduleTag.CommitSHA = object.GetSHA()
+		return moduleTag, nil
+	}
+
+	return nil, nil
+}
+
+// GetModuleArchiveFromCommit gets the archive of a module at a commit SHA and returns it as a byte slice.
+func GetModuleArchiveFromCommit(ctx context.Context, githubClient *github.Client, githubConfig *GithubClientConfig, version *opendepotv1alpha1.Version, format github.ArchiveFormat, commitSHA string) ([]byte, error) {
+	moduleReq, err := GetArchiveRequest(ctx, githubClient, githubConfig, version, format, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+	defer moduleReq.Body.Close()
+
+	if moduleReq.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get module archive from Github: status code %d", moduleReq.StatusCode)
+	}
+
+	moduleBytes, err := io.ReadAll(moduleReq.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module archive data: %w", err)
+	}
+
+	return moduleBytes, nil
+}
+
 // GetArchiveRequest retrieves the archive link for a given repository and reference (branch, tag, or commit SHA).
-func GetArchiveRequest(ctx context.Context, githubClient *github.Client, version *opendepotv1alpha1.Version, format github.ArchiveFormat, ref string) (*http.Response, error) {
+// The archive link is requested with githubClient and the archive is downloaded without its credentials.
+func GetArchiveRequest(ctx context.Context, githubClient *github.Client, githubConfig *GithubClientConfig, version *opendepotv1alpha1.Version, format github.ArchiveFormat, ref string) (*http.Response, error) {
 	al, alResp, err := githubClient.Repositories.GetArchiveLink(ctx, version.Spec.ModuleConfigRef.RepoOwner, *version.Spec.ModuleConfigRef.Name, format, &github.RepositoryContentGetOptions{
 		Ref: ref,
 	}, 10)
@@ -147,7 +383,15 @@ func GetArchiveRequest(ctx context.Context, githubClient *github.Client, version
 		return nil, fmt.Errorf("failed to create HTTP request for archive link: %w", err)
 	}
 
-	archiveResp, err := http.DefaultClient.Do(archiveReq)
+	// The archive link is pre-signed and may redirect to another host, ie: codeload or the storage of a GitHub
+	// Enterprise Server, so it is downloaded without the credentials of githubClient. The CA bundle of the config
+	// is still trusted for the download.
+	transport, err := newHTTPTransport(githubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveResp, err := (&http.Client{Transport: transport}).Do(archiveReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute HTTP request for archive link: %w", err)
 	}
@@ -155,7 +399,9 @@ func GetArchiveRequest(ctx context.Context, githubClient *github.Client, version
 	return archiveResp, nil
 }
 
-// GenerateGithubClient creates a GitHub client using a GitHub Application for authentication.
+// GenerateAuthenticatedGithubClient creates a GitHub client using a GitHub Application for authentication.
+// When the config has no InstallationID the installation is resolved from the config's Owner. Installation
+// tokens are cached per application and installation, and are reused across calls until they near expiry.
 func GenerateAuthenticatedGithubClient(ctx context.Context, githubClientConfig *GithubClientConfig) (*github.Client, error) {
 	// Parse the private key
 	block, _ := pem.Decode(githubClientConfig.PrivateKeyData)
@@ -168,6 +414,56 @@ func GenerateAuthenticatedGithubClient(ctx context.Context, githubClientConfig *
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	transport, err := newHTTPTransport(githubClientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDigest := sha256.Sum256(githubClientConfig.PrivateKeyData)
+	appKey := fmt.Sprintf("%s|%d|%x", githubClientConfig.BaseURL, githubClientConfig.AppID, keyDigest)
+
+	installationID := githubClientConfig.InstallationID
+	if installationID == 0 {
+		installationID, err = resolveInstallationID(ctx, appKey, githubClientConfig, transport, privateKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tokenSource := cachedInstallationTokenSource(fmt.Sprintf("%s|%d", appKey, installationID), &installationTokenSource{
+		githubConfig:   githubClientConfig,
+		installationID: installationID,
+		privateKey:     privateKey,
+		transport:      transport,
+	})
+
+	// Fetch the installation token up front so that authentication failures surface here
+	// rather than on the first API request.
+	if _, err := tokenSource.Token(); err != nil {
+		return nil, fmt.Errorf("failed to create installation token: %w", err)
+	}
+
+	// Create an authenticated GitHub client with the installation token
+	oauthCtx := context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	oauthClient := oauth2.NewClient(oauthCtx, tokenSource)
+	return newGithubClient(oauthClient, githubClientConfig)
+}
+
+// generateTokenGithubClient creates a GitHub client that authenticates with the personal access token of the config.
+func generateTokenGithubClient(ctx context.Context, githubClientConfig *GithubClientConfig) (*github.Client, error) {
+	transport, err := newHTTPTransport(githubClientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: githubClientConfig.Token})
+	oauthCtx := context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	oauthClient := oauth2.NewClient(oauthCtx, ts)
+	return newGithubClient(oauthClient, githubClientConfig)
+}
+
+// newJWTGithubClient creates a GitHub client authenticated as the Github application itself using a short lived JWT.
+func newJWTGithubClient(githubClientConfig *GithubClientConfig, transport http.RoundTripper, privateKey *rsa.PrivateKey) (*github.Client, error) {
 	// Create a JWT token
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
 		"iat": time.Now().Unix(),
@@ -183,30 +479,99 @@ func GenerateAuthenticatedGithubClient(ctx context.Context, githubClientConfig *
 	// Create a custom HTTP client with the JWT in the Authorization header
 	jwtHTTPClient := &http.Client{
 		Transport: &jwtTransport{
-			Transport: http.DefaultTransport,
+			Transport: transport,
 			JWT:       signedToken,
 		},
 	}
-	jwtClient := github.NewClient(jwtHTTPClient)
+
+	return newGithubClient(jwtHTTPClient, githubClientConfig)
+}
+
+// resolveInstallationID looks up the installation of the Github application on the config's Owner, first as an
+// organization and then as a user. Resolved installation IDs are cached per application and owner.
+func resolveInstallationID(ctx context.Context, appKey string, githubClientConfig *GithubClientConfig, transport http.RoundTripper, privateKey *rsa.PrivateKey) (int64, error) {
+	if githubClientConfig.Owner == "" {
+		return 0, fmt.Errorf("'%s' is not set and no repository owner is available to resolve the Github application installation", opendepotv1alpha1.OpenDepotGithubSecretDataFieldInstallID)
+	}
+
+	cacheKey := fmt.Sprintf("%s|%s", appKey, strings.ToLower(githubClientConfig.Owner))
+
+	tokenCache.mu.Lock()
+	installationID, ok := tokenCache.installationIDs[cacheKey]
+	tokenCache.mu.Unlock()
+	if ok {
+		return installationID, nil
+	}
+
+	jwtClient, err := newJWTGithubClient(githubClientConfig, transport, privateKey)
+	if err != nil {
+		return 0, err
+	}
+
+	installation, resp, err := jwtClient.Apps.FindOrganizationInstallation(ctx, githubClientConfig.Owner)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		installation, _, err = jwtClient.Apps.FindUserInstallation(ctx, githubClientConfig.Owner)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to find Github application installation for '%s': %w", githubClientConfig.Owner, err)
+	}
+
+	tokenCache.mu.Lock()
+	tokenCache.installationIDs[cacheKey] = installation.GetID()
+	tokenCache.mu.Unlock()
+
+	return installation.GetID(), nil
+}
+
+// cachedInstallationTokenSource returns the cached token source stored under key, storing source wrapped in an
+// oauth2.ReuseTokenSource when no token source has been cached yet.
+func cachedInstallationTokenSource(key string, source *installationTokenSource) oauth2.TokenSource {
+	tokenCache.mu.Lock()
+	defer tokenCache.mu.Unlock()
+
+	if tokenSource, ok := tokenCache.tokenSources[key]; ok {
+		return tokenSource
+	}
+
+	tokenSource := oauth2.ReuseTokenSource(nil, source)
+	tokenCache.tokenSources[key] = tokenSource
+	return tokenSource
+}
+
+// Token mints a JWT for the Github application and exchanges it for an installation token.
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	jwtClient, err := newJWTGithubClient(s.githubConfig, s.transport, s.privateKey)
+	if err != nil {
+		return nil, err
+	}
 
 	// Use the JWT-authenticated client to fetch the installation token
-	instToken, _, err := jwtClient.Apps.CreateInstallationToken(ctx, githubClientConfig.InstallationID, &github.InstallationTokenOptions{})
+	instToken, _, err := jwtClient.Apps.CreateInstallationToken(context.Background(), s.installationID, &github.InstallationTokenOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create installation token: %w", err)
+		return nil, err
 	}
 
-	// Create an authenticated GitHub client with the installation token
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: instToken.GetToken()})
-	oauthClient := oauth2.NewClient(ctx, ts)
-	return github.NewClient(oauthClient), nil
+	// Refresh the token slightly before GitHub expires it so in-flight requests never use a stale token.
+	return &oauth2.Token{
+		AccessToken: instToken.GetToken(),
+		Expiry:      instToken.GetExpiresAt().Add(-installationTokenExpiryDelta),
+	}, nil
 }
 
 // GetGithubApplicationSecret retrieves the opendepot-github-application-secret kubernetes secret from the cluster
 // using the client received by k8sClient. It returns a GithubClientConfig for making authenticated requests to the Github API.
 // The k8sClient parameter should be received by the controller's client.
 func GetGithubApplicationSecret(ctx context.Context, k8sClient client.Client, secretNamespace string) (*GithubClientConfig, error) {
+	return GetGithubSecret(ctx, k8sClient, secretNamespace, opendepotv1alpha1.OpenDepotGithubSecretName)
+}
+
+// GetGithubSecret retrieves the GitHub credentials kubernetes secret named secretName from the cluster. When the secret
+// contains a githubToken field a GithubClientConfig for personal access token authentication is returned, otherwise the
+// Github application fields are parsed. The githubInstallID field is optional for Github applications.
+func GetGithubSecret(ctx context.Context, k8sClient client.Client, secretNamespace, secretName string) (*GithubClientConfig, error) {
 	object := client.ObjectKey{
-		Name:      opendepotv1alpha1.OpenDepotGithubSecretName,
+		Name:      secretName,
 		Namespace: secretNamespace,
 	}
 
@@ -215,14 +580,21 @@ func GetGithubApplicationSecret(ctx context.Context, k8sClient client.Client, se
 		return nil, err
 	}
 
+	if token := strings.TrimSpace(string(secret.Data[opendepotv1alpha1.OpenDepotGithubSecretDataFieldToken])); token != "" {
+		return &GithubClientConfig{Token: token}, nil
+	}
+
 	appID, err := strconv.ParseInt(string(secret.Data[opendepotv1alpha1.OpenDepotGithubSecretDataFieldAppID]), 0, 64)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse '%s' as int64: %w", opendepotv1alpha1.OpenDepotGithubSecretDataFieldAppID, err)
 	}
 
-	installID, err := strconv.ParseInt(string(secret.Data[opendepotv1alpha1.OpenDepotGithubSecretDataFieldInstallID]), 0, 64)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse '%s' as int64: %w", opendepotv1alpha1.OpenDepotGithubSecretDataFieldInstallID, err)
+	var installID int64
+	if installIDData := secret.Data[opendepotv1alpha1.OpenDepotGithubSecretDataFieldInstallID]; len(installIDData) > 0 {
+		installID, err = strconv.ParseInt(string(installIDData), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s' as int64: %w", opendepotv1alpha1.OpenDepotGithubSecretDataFieldInstallID, err)
+		}
 	}
 
 	keyData, err := base64.StdEncoding.DecodeString(string(secret.Data[opendepotv1alpha1.OpenDepotGithubSecretDataFieldPrivateKey]))
@@ -239,6 +611,38 @@ func GetGithubApplicationSecret(ctx context.Context, k8sClient client.Client, se
 	return githubClientConfig, nil
 }
 
+// ListReleaseVersions returns the semantic versions of the published, non-prerelease GitHub releases of a repository.
+func ListReleaseVersions(ctx context.Context, githubClient *github.Client, owner, repo string) ([]string, error) {
+	var versions []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := githubClient.Repositories.ListReleases(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range releases {
+			if release.GetDraft() || release.GetPrerelease() {
+				continue
+			}
+
+			v, err := version.NewVersion(release.GetTagName())
+			if err != nil || slices.Contains(versions, v.String()) {
+				continue
+			}
+
+			versions = append(versions, v.String())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return versions, nil
+}
+
 // GetProviderGoMod fetches the go.mod file for a provider version from its GitHub source repository
 // using the provided GitHub client (authenticated or unauthenticated). Both 'v{version}' and bare
 // '{version}' ref formats are tried, matching the retry pattern used by GetModuleArchiveFromRef.
@@ -0,0 +1,199 @@
+package github
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v81/github"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+// serverCABundle returns the PEM encoded certificate of a TLS test server.
+func serverCABundle(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestNewGithubClientEnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            *GithubClientConfig
+		expectedBaseURL   string
+		expectedUploadURL string
+	}{
+		{
+			name:              "github.com",
+			config:            nil,
+			expectedBaseURL:   "https://api.github.com/",
+			expectedUploadURL: "https://uploads.github.com/",
+		},
+		{
+			name:              "base URL without the API suffix",
+			config:            &GithubClientConfig{BaseURL: "https://github.example.com"},
+			expectedBaseURL:   "https://github.example.com/api/v3/",
+			expectedUploadURL: "https://github.example.com/api/uploads/",
+		},
+		{
+			name:              "base URL with the API suffix",
+			config:            &GithubClientConfig{BaseURL: "https://github.example.com/api/v3/"},
+			expectedBaseURL:   "https://github.example.com/api/v3/",
+			expectedUploadURL: "https://github.example.com/api/uploads/",
+		},
+		{
+			name:              "explicit upload URL",
+			config:            &GithubClientConfig{BaseURL: "https://github.example.com/api/v3", UploadURL: "https://uploads.example.com/api/uploads/"},
+			expectedBaseURL:   "https://github.example.com/api/v3/",
+			expectedUploadURL: "https://uploads.example.com/api/uploads/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			githubClient, err := newGithubClient(http.DefaultClient, tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := githubClient.BaseURL.String(); got != tt.expectedBaseURL {
+				t.Fatalf("base URL '%s', want '%s'", got, tt.expectedBaseURL)
+			}
+
+			if got := githubClient.UploadURL.String(); got != tt.expectedUploadURL {
+				t.Fatalf("upload URL '%s', want '%s'", got, tt.expectedUploadURL)
+			}
+		})
+	}
+}
+
+func TestGetRepositoryURL(t *testing.T) {
+	baseURL := func(value string) *opendepotv1alpha1.GithubClientConfig {
+		return &opendepotv1alpha1.GithubClientConfig{BaseURL: &value}
+	}
+
+	tests := []struct {
+		name     string
+		config   *opendepotv1alpha1.GithubClientConfig
+		expected string
+	}{
+		{name: "github.com", config: nil, expected: "https://github.com/defdev/terraform-aws-vpc"},
+		{name: "config without base URL", config: &opendepotv1alpha1.GithubClientConfig{}, expected: "https://github.com/defdev/terraform-aws-vpc"},
+		{name: "base URL without the API suffix", config: baseURL("https://github.example.com"), expected: "https://github.example.com/defdev/terraform-aws-vpc"},
+		{name: "base URL with the API suffix", config: baseURL("https://github.example.com/api/v3/"), expected: "https://github.example.com/defdev/terraform-aws-vpc"},
+		{name: "base URL with a port", config: baseURL("http://github.example.com:8080/api/v3/"), expected: "http://github.example.com:8080/defdev/terraform-aws-vpc"},
+		{name: "invalid base URL", config: baseURL("github.example.com"), expected: "https://github.com/defdev/terraform-aws-vpc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetRepositoryURL(tt.config, "defdev", "terraform-aws-vpc"); got != tt.expected {
+				t.Fatalf("GetRepositoryURL returned '%s', want '%s'", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewHTTPTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      *GithubClientConfig
+		expectedErr string
+	}{
+		{name: "trusted CA bundle", config: &GithubClientConfig{CABundle: serverCABundle(server)}},
+		{name: "no CA bundle", config: &GithubClientConfig{}, expectedErr: "certificate"},
+		{name: "invalid CA bundle", config: &GithubClientConfig{CABundle: []byte("not a certificate")}, expectedErr: "failed to parse any certificates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := newHTTPTransport(tt.config)
+			if err == nil {
+				var resp *http.Response
+				resp, err = (&http.Client{Transport: transport}).Get(server.URL)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("got error %v, want '%s'", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGetArchiveRequestWithoutCredentials(t *testing.T) {
+	archive := []byte("module archive")
+	var archiveAuthorization []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/repos/defdev/terraform-aws-vpc/tarball/v1.0.0":
+			if r.Header.Get("Authorization") != "Bearer ghp_token" {
+				http.Error(w, "missing credentials", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, "https://"+r.Host+"/codeload/defdev/terraform-aws-vpc/tar.gz/v1.0.0?token=presigned", http.StatusFound)
+		case "/codeload/defdev/terraform-aws-vpc/tar.gz/v1.0.0":
+			archiveAuthorization = append(archiveAuthorization, r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/x-gzip")
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	githubConfig := &GithubClientConfig{
+		BaseURL:  server.URL + "/api/v3/",
+		CABundle: serverCABundle(server),
+		Token:    "ghp_token",
+	}
+
+	githubClient, err := CreateGithubClient(context.Background(), true, githubConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "terraform-aws-vpc"
+	version := &opendepotv1alpha1.Version{
+		Spec: opendepotv1alpha1.VersionSpec{
+			ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, RepoOwner: "defdev"},
+			Version:         "1.0.0",
+		},
+	}
+
+	resp, err := GetArchiveRequest(context.Background(), githubClient, githubConfig, version, github.Tarball, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || string(body) != string(archive) {
+		t.Fatalf("downloaded status %d and body %q, want the archive", resp.StatusCode, body)
+	}
+
+	if len(archiveAuthorization) != 1 || archiveAuthorization[0] != "" {
+		t.Fatalf("the archive was requested with Authorization headers %q, want none", archiveAuthorization)
+	}
+}
@@ -0,0 +1,378 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+const (
+	// DefaultHost is the upstream registry used when a provider config does not name one.
+	DefaultHost = "registry.opentofu.org"
+	// DefaultProviderNamespace is the provider namespace used when a provider config does not name one.
+	DefaultProviderNamespace = "hashicorp"
+	// ProvidersV1 is the service discovery identifier of the provider registry protocol.
+	ProvidersV1 = "providers.v1"
+
+	// discoveryCacheTTL is how long a registry's service discovery document is reused.
+	discoveryCacheTTL = time.Hour
+	// requestTimeout bounds each request made to a registry.
+	requestTimeout = 30 * time.Second
+)
+
+// discoveryCache holds the service discovery documents of the registries queried by the process.
+var discoveryCache = struct {
+	mu        sync.Mutex
+	documents map[string]discoveryDocument
+}{
+	documents: map[string]discoveryDocument{},
+}
+
+// discoveryDocument is a registry's parsed '.well-known/terraform.json' and the time it expires from the cache.
+type discoveryDocument struct {
+	expiresAt time.Time
+	services  map[string]any
+}
+
+// Client queries an upstream registry that implements the provider registry protocol.
+type Client struct {
+	// The base URL of the registry host, e.g. 'https://registry.opentofu.org'.
+	BaseURL string
+	// An optional bearer token sent to the registry.
+	Token string
+
+	httpClient *http.Client
+}
+
+// ProviderVersions is the response of the provider registry protocol's list available versions endpoint.
+// See: https://opentofu.org/docs/internals/provider-registry-protocol/#list-available-versions
+type ProviderVersions struct {
+	Versions []ProviderVersion `json:"versions"`
+}
+
+// ProviderVersion is a single provider version and the platforms it is published for.
+type ProviderVersion struct {
+	Version   string             `json:"version"`
+	Protocols []string           `json:"protocols,omitempty"`
+	Platforms []ProviderPlatform `json:"platforms"`
+}
+
+// ProviderPlatform is an os/arch combination a provider version is published for.
+type ProviderPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// ProviderDownload holds the fields of a provider registry protocol find a provider package response.
+// See: https://opentofu.org/docs/internals/provider-registry-protocol/#find-a-provider-package
+type ProviderDownload struct {
+	DownloadURL         string              `json:"download_url"`
+	Filename            string              `json:"filename"`
+	Shasum              string              `json:"shasum"`
+	ShasumsURL          string              `json:"shasums_url"`
+	ShasumsSignatureURL string              `json:"shasums_signature_url"`
+	SigningKeys         ProviderSigningKeys `json:"signing_keys"`
+}
+
+// ProviderSigningKeys holds the public keys that may have signed a provider package's SHA256SUMS.
+type ProviderSigningKeys struct {
+	GPGPublicKeys []GPGPublicKey `json:"gpg_public_keys"`
+}
+
+// GPGPublicKey is an ASCII armored GPG public key published by the registry for a provider.
+type GPGPublicKey struct {
+	KeyID      string `json:"key_id"`
+	ASCIIArmor string `json:"ascii_armor"`
+}
+
+// NewClient returns a Client for the registry host. The host may include a scheme and defaults to https
+// when it does not. An empty host selects DefaultHost.
+func NewClient(host, token string) *Client {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		host = DefaultHost
+	}
+
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	return &Client{
+		BaseURL:    strings.TrimRight(host, "/"),
+		Token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// GetProviderRegistryClient returns a Client for the upstream registry of providerConfig. When the provider config
+// names a registry Secret, its token is read from the Secret in secretNamespace.
+func GetProviderRegistryClient(ctx context.Context, k8sClient client.Client, secretNamespace string, providerConfig opendepotv1alpha1.ProviderConfig) (*Client, error) {
+	var host string
+	if providerConfig.Registry != nil {
+		host = *providerConfig.Registry
+	}
+
+	var token string
+	if providerConfig.RegistrySecretName != nil && strings.TrimSpace(*providerConfig.RegistrySecretName) != "" {
+		object := client.ObjectKey{
+			Name:      strings.TrimSpace(*providerConfig.RegistrySecretName),
+			Namespace: secretNamespace,
+		}
+
+		secret := corev1.Secret{}
+		if err := k8sClient.Get(ctx, object, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get registry secret '%s': %w", object.Name, err)
+		}
+
+		token = strings.TrimSpace(string(secret.Data[opendepotv1alpha1.OpenDepotRegistrySecretDataFieldToken]))
+		if token == "" {
+			return nil, fmt.Errorf("registry secret '%s' has no '%s' field", object.Name, opendepotv1alpha1.OpenDepotRegistrySecretDataFieldToken)
+		}
+	}
+
+	return NewClient(host, token), nil
+}
+
+// ProviderNamespace returns the registry namespace of providerConfig, defaulting to DefaultProviderNamespace.
+func ProviderNamespace(providerConfig opendepotv1alpha1.ProviderConfig) string {
+	if providerConfig.Namespace != nil {
+		if namespace := strings.TrimSpace(*providerConfig.Namespace); namespace != "" {
+			return namespace
+		}
+	}
+
+	return DefaultProviderNamespace
+}
+
+// IsDefaultHost reports whether the client targets DefaultHost.
+func (c *Client) IsDefaultHost() bool {
+	return c.BaseURL == "https://"+DefaultHost
+}
+
+// ServiceURL resolves the URL of a service, such as ProvidersV1, from the registry's service discovery document.
+// See: https://opentofu.org/docs/internals/remote-service-discovery/
+func (c *Client) ServiceURL(ctx context.Context, service string) (*url.URL, error) {
+	services, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := services[service].(string)
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("registry '%s' does not support the '%s' service", c.BaseURL, service)
+	}
+
+	base, err := url.Parse(c.BaseURL + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL '%s': %w", c.BaseURL, err)
+	}
+
+	serviceURL, err := base.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' service URL '%s' for registry '%s': %w", service, value, c.BaseURL, err)
+	}
+
+	if !strings.HasSuffix(serviceURL.Path, "/") {
+		serviceURL.Path += "/"
+	}
+
+	return serviceURL, nil
+}
+
+// ListProviderVersions returns the available versions of a provider and the platforms each is published for.
+func (c *Client) ListProviderVersions(ctx context.Context, namespace, name string) (*ProviderVersions, error) {
+	endpoint, err := c.providerEndpoint(ctx, namespace, name, "versions")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions ProviderVersions
+	if err := c.getJSON(ctx, endpoint, &versions); err != nil {
+		return nil, err
+	}
+
+	return &versions, nil
+}
+
+// GetProviderDownload returns the package metadata of a provider version for a single platform.
+func (c *Client) GetProviderDownload(ctx context.Context, namespace, name, version, os, arch string) (*ProviderDownload, error) {
+	endpoint, err := c.providerEndpoint(ctx, namespace, name,
+		strings.TrimPrefix(strings.TrimSpace(version), "v"),
+		"download",
+		strings.ToLower(strings.TrimSpace(os)),
+		strings.ToLower(strings.TrimSpace(arch)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var download ProviderDownload
+	if err := c.getJSON(ctx, endpoint, &download); err != nil {
+		return nil, err
+	}
+
+	return &download, nil
+}
+
+// GetFile downloads a file referenced by a registry response, such as a provider's SHA256SUMS. Relative URLs
+// are resolved against the registry host, and the bearer token is only sent to the registry host itself.
+func (c *Client) GetFile(ctx context.Context, fileURL string) ([]byte, error) {
+	resolved, authenticate, err := c.resolveFileURL(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.get(ctx, resolved, authenticate, "")
+}
+
+// OpenFile streams a file referenced by a registry response, such as a provider package, resolving its URL and
+// sending the bearer token like GetFile. The download is only bounded by ctx, so large packages are not cut off by
+// the request timeout. The returned body must be closed by the caller.
+func (c *Client) OpenFile(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	resolved, authenticate, err := c.resolveFileURL(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, resolved, authenticate, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Redirects to another host drop the Authorization header.
+	resp, err := (&http.Client{Transport: c.httpClient.Transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for '%s': %w", resolved, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("request to '%s' failed with status %d", resolved, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// resolveFileURL resolves fileURL against the registry host and reports whether it is on the registry host, which
+// is the only host the bearer token is sent to.
+func (c *Client) resolveFileURL(fileURL string) (string, bool, error) {
+	base, err := url.Parse(c.BaseURL + "/")
+	if err != nil {
+		return "", false, fmt.Errorf("invalid registry URL '%s': %w", c.BaseURL, err)
+	}
+
+	resolved, err := base.Parse(strings.TrimSpace(fileURL))
+	if err != nil {
+		return "", false, fmt.Errorf("invalid file URL '%s': %w", fileURL, err)
+	}
+
+	return resolved.String(), resolved.Host == base.Host, nil
+}
+
+// providerEndpoint returns the URL of a provider registry protocol endpoint for the provider namespace/name.
+func (c *Client) providerEndpoint(ctx context.Context, namespace, name string, segments ...string) (string, error) {
+	serviceURL, err := c.ServiceURL(ctx, ProvidersV1)
+	if err != nil {
+		return "", err
+	}
+
+	path := append([]string{
+		strings.ToLower(strings.TrimSpace(namespace)),
+		strings.ToLower(strings.TrimSpace(name)),
+	}, segments...)
+
+	return serviceURL.JoinPath(path...).String(), nil
+}
+
+// discover returns the registry's service discovery document, fetching it when it is not cached.
+func (c *Client) discover(ctx context.Context) (map[string]any, error) {
+	discoveryCache.mu.Lock()
+	document, ok := discoveryCache.documents[c.BaseURL]
+	discoveryCache.mu.Unlock()
+
+	if ok && time.Now().Before(document.expiresAt) {
+		return document.services, nil
+	}
+
+	var services map[string]any
+	if err := c.getJSON(ctx, c.BaseURL+"/.well-known/terraform.json", &services); err != nil {
+		return nil, fmt.Errorf("service discovery failed for registry '%s': %w", c.BaseURL, err)
+	}
+
+	discoveryCache.mu.Lock()
+	discoveryCache.documents[c.BaseURL] = discoveryDocument{
+		expiresAt: time.Now().Add(discoveryCacheTTL),
+		services:  services,
+	}
+	discoveryCache.mu.Unlock()
+
+	return services, nil
+}
+
+// getJSON performs an HTTP GET against the registry and unmarshals the response payload into out.
+func (c *Client) getJSON(ctx context.Context, requestURL string, out any) error {
+	body, err := c.get(ctx, requestURL, true, "application/json")
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unable to parse JSON from '%s': %w", requestURL, err)
+	}
+
+	return nil
+}
+
+// newRequest returns an HTTP GET request for requestURL, carrying the bearer token when authenticate is true.
+func (c *Client) newRequest(ctx context.Context, requestURL string, authenticate bool, accept string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	if authenticate && c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	return req, nil
+}
+
+// get performs an HTTP GET and returns the response body, sending the bearer token when authenticate is true.
+func (c *Client) get(ctx context.Context, requestURL string, authenticate bool, accept string) ([]byte, error) {
+	req, err := c.newRequest(ctx, requestURL, authenticate, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed for '%s': %w", requestURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body for '%s': %w", requestURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to '%s' failed with status %d", requestURL, resp.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// publicHosts are the public registries that serve the same provider and module namespaces.
+	publicHosts = map[string]bool{
+		DefaultHost:             true,
+		"registry.terraform.io": true,
+	}
+
+	// registryModuleSourcePattern matches '[hostname/]namespace/name/provider[//subdir]' module registry addresses.
+	registryModuleSourcePattern = regexp.MustCompile(`^(?:([^/]+\.[^/]+)/)?([0-9A-Za-z][0-9A-Za-z_-]*)/([0-9A-Za-z][0-9A-Za-z_-]*)/([0-9a-z]+)(?://.*)?$`)
+	// moduleRepositoryPattern matches the 'terraform-<provider>-<name>' naming convention of module repositories.
+	moduleRepositoryPattern = regexp.MustCompile(`^terraform-([0-9a-z]+)-.+$`)
+)
+
+// ProviderSource is a parsed provider source address.
+type ProviderSource struct {
+	Host      string
+	Namespace string
+	Name      string
+}
+
+// ModuleSource is the GitHub repository a module source resolves to.
+type ModuleSource struct {
+	Owner    string
+	Repo     string
+	Provider string
+	// The 'ref' of a Git source, which pins the module's version.
+	Ref string
+}
+
+// IsPublicHost reports whether host is one of the public registries, which serve the same provider and module
+// namespaces and are mirrored from DefaultHost.
+func IsPublicHost(host string) bool {
+	return publicHosts[strings.ToLower(strings.TrimSpace(host))]
+}
+
+// ParseProviderSource parses a provider source address of the form '[hostname/][namespace/]type'. The hostname
+// defaults to DefaultHost and the namespace to DefaultProviderNamespace. Every part is lowercased.
+func ParseProviderSource(source string) (ProviderSource, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(source)), "/")
+	for _, part := range parts {
+		if part == "" {
+			return ProviderSource{}, fmt.Errorf("invalid provider source address '%s'", source)
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		return ProviderSource{Host: DefaultHost, Namespace: DefaultProviderNamespace, Name: parts[0]}, nil
+	case 2:
+		return ProviderSource{Host: DefaultHost, Namespace: parts[0], Name: parts[1]}, nil
+	case 3:
+		return ProviderSource{Host: parts[0], Namespace: parts[1], Name: parts[2]}, nil
+	}
+
+	return ProviderSource{}, fmt.Errorf("invalid provider source address '%s'", source)
+}
+
+// ParseModuleSource resolves a module source to the GitHub repository it is mirrored from. Module registry
+// addresses on the public registries follow the 'terraform-<provider>-<name>' repository convention. A nil
+// source and nil error are returned for local paths.
+func ParseModuleSource(source string) (*ModuleSource, error) {
+	source = strings.TrimSpace(source)
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return nil, nil
+	}
+
+	if repository, ok := parseGithubModuleSource(source); ok {
+		if err := repository.validate(source); err != nil {
+			return nil, err
+		}
+
+		return repository, nil
+	}
+
+	matches := registryModuleSourcePattern.FindStringSubmatch(source)
+	if matches == nil {
+		return nil, fmt.Errorf("unsupported module source '%s'", source)
+	}
+
+	if host := matches[1]; host != "" && !IsPublicHost(host) {
+		return nil, fmt.Errorf("module source '%s' is on registry '%s', which cannot be mirrored from GitHub", source, host)
+	}
+
+	return &ModuleSource{
+		Owner:    matches[2],
+		Repo:     fmt.Sprintf("terraform-%s-%s", matches[4], matches[3]),
+		Provider: matches[4],
+	}, nil
+}
+
+// parseGithubModuleSource parses the GitHub forms of a module source, such as 'github.com/owner/repo',
+// 'git::https://github.com/owner/repo.git?ref=v1.0.0' and 'git@github.com:owner/repo.git'.
+func parseGithubModuleSource(source string) (*ModuleSource, bool) {
+	address := strings.TrimPrefix(source, "git::")
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git@"} {
+		address = strings.TrimPrefix(address, prefix)
+	}
+	address = strings.Replace(address, "github.com:", "github.com/", 1)
+
+	if !strings.HasPrefix(strings.ToLower(address), "github.com/") {
+		return nil, false
+	}
+	address = address[len("github.com/"):]
+
+	var ref string
+	if path, query, ok := strings.Cut(address, "?"); ok {
+		address = path
+		if values, err := url.ParseQuery(query); err == nil {
+			ref = values.Get("ref")
+		}
+	}
+
+	address, _, _ = strings.Cut(address, "//")
+	owner, repo, _ := strings.Cut(address, "/")
+
+	return &ModuleSource{
+		Owner: owner,
+		Repo:  strings.TrimSuffix(repo, ".git"),
+		Ref:   ref,
+	}, true
+}
+
+// validate checks that a GitHub module source names a repository following the 'terraform-<provider>-<name>'
+// convention and sets the module's provider from it.
+func (s *ModuleSource) validate(source string) error {
+	if s.Owner == "" || s.Repo == "" || strings.Contains(s.Repo, "/") {
+		return fmt.Errorf("invalid GitHub module source '%s'", source)
+	}
+
+	matches := moduleRepositoryPattern.FindStringSubmatch(s.Repo)
+	if matches == nil {
+		return fmt.Errorf("repository '%s' does not follow the 'terraform-<provider>-<name>' naming convention", s.Repo)
+	}
+	s.Provider = matches[1]
+
+	return nil
+}
@@ -3,29 +3,100 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 
 	storagetypes "github.com/tonedefdev/opendepot/pkg/storage/types"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 type AmazonS3Storage struct {
 	client *s3.Client
 }
 
-// NewClient initializes a new AWS S3 storage client.
-func (storage *AmazonS3Storage) NewClient(ctx context.Context, region string) error {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+// AmazonS3ClientOptions configures an S3 client for an S3 compatible endpoint such as MinIO, Ceph or Cloudflare R2.
+type AmazonS3ClientOptions struct {
+	// The URL of the S3 compatible endpoint. When nil the AWS endpoint for the region is used.
+	Endpoint *string
+	// Whether the bucket is addressed in the request path instead of the host name.
+	UsePathStyle bool
+	// PEM encoded certificates trusted in addition to the system roots when connecting to the endpoint.
+	CABundle []byte
+	// Static credentials. When AccessKeyID is empty the default AWS credential chain is used.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// The ARN of an IAM role to assume with the static credentials or the default AWS credential chain.
+	RoleARN string
+	// The external ID required by the trust policy of the role, if any.
+	ExternalID string
+}
+
+// NewClient initializes a new AWS S3 storage client. When options is nil the client uses the AWS endpoint for
+// the region and the default AWS credential chain.
+func (storage *AmazonS3Storage) NewClient(ctx context.Context, region string, options *AmazonS3ClientOptions) error {
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if options != nil && options.AccessKeyID != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(options.AccessKeyID, options.SecretAccessKey, options.SessionToken),
+		))
+	}
+
+	if options != nil && len(options.CABundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		if !rootCAs.AppendCertsFromPEM(options.CABundle) {
+			return fmt.Errorf("unable to parse CA bundle: no PEM encoded certificates found")
+		}
+
+		httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(transport *http.Transport) {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			transport.TLSClientConfig.RootCAs = rootCAs
+		})
+		loadOptions = append(loadOptions, config.WithHTTPClient(httpClient))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return fmt.Errorf("unable to load SDK config: %w", err)
 	}
 
-	storage.client = s3.NewFromConfig(cfg)
+	if options != nil && options.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), options.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if options.ExternalID != "" {
+				o.ExternalID = aws.String(options.ExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	storage.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if options == nil {
+			return
+		}
+
+		if options.Endpoint != nil && *options.Endpoint != "" {
+			o.BaseEndpoint = aws.String(*options.Endpoint)
+		}
+		o.UsePathStyle = options.UsePathStyle
+	})
 	return nil
 }
 
@@ -33,7 +104,7 @@ func (storage *AmazonS3Storage) NewClient(ctx context.Context, region string) er
 func (storage *AmazonS3Storage) GetObject(ctx context.Context, soi *storagetypes.StorageObjectInput) (io.Reader, error) {
 	resp, err := storage.client.GetObject(ctx, &s3.GetObjectInput{
 		ChecksumMode: types.ChecksumModeEnabled,
-		Bucket:       &soi.Version.Spec.ModuleConfigRef.StorageConfig.S3.Bucket,
+		Bucket:       &soi.StorageConfig.S3.Bucket,
 		Key:          soi.FilePath,
 	})
 
@@ -55,7 +126,7 @@ func (storage *AmazonS3Storage) GetObject(ctx context.Context, soi *storagetypes
 func (storage *AmazonS3Storage) GetObjectChecksum(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
 	resp, err := storage.client.GetObject(ctx, &s3.GetObjectInput{
 		ChecksumMode: types.ChecksumModeEnabled,
-		Bucket:       &soi.Version.Spec.ModuleConfigRef.StorageConfig.S3.Bucket,
+		Bucket:       &soi.StorageConfig.S3.Bucket,
 		Key:          soi.FilePath,
 	})
 	if err != nil {
@@ -81,7 +152,7 @@ func (storage *AmazonS3Storage) GetObjectChecksum(ctx context.Context, soi *stor
 // DeleteObject deletes the Version file from the specified bucket.
 func (storage *AmazonS3Storage) DeleteObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
 	_, err := storage.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: &soi.Version.Spec.ModuleConfigRef.StorageConfig.S3.Bucket,
+		Bucket: &soi.StorageConfig.S3.Bucket,
 		Key:    soi.FilePath,
 	})
 	if err != nil {
@@ -91,6 +162,32 @@ func (storage *AmazonS3Storage) DeleteObject(ctx context.Context, soi *storagety
 	return nil
 }
 
+// ListObjects lists the keys in the specified bucket that start with the prefix received by soi.
+func (storage *AmazonS3Storage) ListObjects(ctx context.Context, soi *storagetypes.StorageObjectInput) ([]storagetypes.StorageObject, error) {
+	var objects []storagetypes.StorageObject
+	paginator := s3.NewListObjectsV2Paginator(storage.client, &s3.ListObjectsV2Input{
+		Bucket: &soi.StorageConfig.S3.Bucket,
+		Prefix: soi.FilePath,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range page.Contents {
+			listed := storagetypes.StorageObject{Path: aws.ToString(object.Key)}
+			if object.LastModified != nil {
+				listed.LastModified = *object.LastModified
+			}
+			objects = append(objects, listed)
+		}
+	}
+
+	return objects, nil
+}
+
 // PutObject puts the Version file in the specified bucket with its computed base64 encoded SHA256 checksum.
 func (storage *AmazonS3Storage) PutObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
 	var body io.ReadSeeker
@@ -103,7 +200,7 @@ func (storage *AmazonS3Storage) PutObject(ctx context.Context, soi *storagetypes
 	_, err := storage.client.PutObject(ctx, &s3.PutObjectInput{
 		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
 		ChecksumSHA256:    soi.ArchiveChecksum,
-		Bucket:            &soi.Version.Spec.ModuleConfigRef.StorageConfig.S3.Bucket,
+		Bucket:            &soi.StorageConfig.S3.Bucket,
 		Key:               soi.FilePath,
 		Body:              body,
 	})
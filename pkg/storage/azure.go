@@ -3,25 +3,62 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
+	versionv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 	storagetypes "github.com/tonedefdev/opendepot/pkg/storage/types"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage/v3"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 )
 
 type AzureBlobStorage struct {
-	blobClient    *azblob.Client
+	blobClient *azblob.Client
+	// storageClient is nil when the client authenticates with a connection string or SAS token, which cannot
+	// access the management plane. Container metadata is then read and written through the data plane.
 	storageClient *armstorage.BlobContainersClient
 }
 
+// AzureBlobClientOptions configures explicit credentials for an Azure Storage Account. When both fields are empty
+// DefaultAzureCredential is used.
+type AzureBlobClientOptions struct {
+	// A Storage Account connection string.
+	ConnectionString string
+	// A SAS token for the Storage Account, with or without the leading '?'.
+	SASToken string
+}
+
 // NewClients creates a new azblob.Client and armstorage.BlobContainersClient to interact with the
-// Azure storage systems.
-func (storage *AzureBlobStorage) NewClients(subscriptionID string, storageAccountUrl string) error {
+// Azure storage systems. When options holds a connection string or SAS token only the azblob.Client is created.
+func (storage *AzureBlobStorage) NewClients(subscriptionID string, storageAccountUrl string, options *AzureBlobClientOptions) error {
+	if options != nil && options.ConnectionString != "" {
+		blobClient, err := azblob.NewClientFromConnectionString(options.ConnectionString, nil)
+		if err != nil {
+			return err
+		}
+
+		storage.blobClient = blobClient
+		return nil
+	}
+
+	if options != nil && options.SASToken != "" {
+		serviceURL := fmt.Sprintf("%s?%s", strings.TrimRight(storageAccountUrl, "/"), strings.TrimPrefix(options.SASToken, "?"))
+		blobClient, err := azblob.NewClientWithNoCredential(serviceURL, nil)
+		if err != nil {
+			return err
+		}
+
+		storage.blobClient = blobClient
+		return nil
+	}
+
 	cred, err := azidentity.NewDefaultAzureCredential(nil)
 	if err != nil {
 		return err
@@ -45,7 +82,7 @@ func (storage *AzureBlobStorage) NewClients(subscriptionID string, storageAccoun
 // GetObject retrieves the object from the Azure Blob and returns an io.Reader to stream the file from the server
 func (storage *AzureBlobStorage) GetObject(ctx context.Context, soi *storagetypes.StorageObjectInput) (io.Reader, error) {
 	blob, err := storage.blobClient.DownloadStream(ctx,
-		*soi.Version.Spec.ModuleConfigRef.Name,
+		containerName(soi.Version),
 		*soi.FilePath,
 		&azblob.DownloadStreamOptions{},
 	)
@@ -59,10 +96,25 @@ func (storage *AzureBlobStorage) GetObject(ctx context.Context, soi *storagetype
 // GetObjectChecksum retrieves the sha256 checksum from the container's metadata and sets it on the soi receiver's field `ObjectChecksum`.
 // If the container can be found the function sets the soi receiver's field for `FileExists` to `true`.
 func (storage *AzureBlobStorage) GetObjectChecksum(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
+	if storage.storageClient == nil {
+		props, err := storage.blobClient.ServiceClient().NewContainerClient(containerName(soi.Version)).GetProperties(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		for key, value := range props.Metadata {
+			if strings.EqualFold(key, "Checksum") {
+				soi.ObjectChecksum = value
+			}
+		}
+		soi.FileExists = true
+		return nil
+	}
+
 	ctr, err := storage.storageClient.Get(ctx,
-		soi.Version.Spec.ModuleConfigRef.StorageConfig.AzureStorage.ResourceGroup,
-		soi.Version.Spec.ModuleConfigRef.StorageConfig.AzureStorage.AccountName,
-		*soi.Version.Spec.ModuleConfigRef.Name,
+		soi.StorageConfig.AzureStorage.ResourceGroup,
+		soi.StorageConfig.AzureStorage.AccountName,
+		containerName(soi.Version),
 		nil,
 	)
 	if err != nil {
@@ -82,26 +134,49 @@ func (storage *AzureBlobStorage) GetObjectChecksum(ctx context.Context, soi *sto
 // DeleteObject deletes the Version file from the specified container.
 func (storage *AzureBlobStorage) DeleteObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
 	_, err := storage.blobClient.DeleteBlob(ctx,
-		*soi.Version.Spec.ModuleConfigRef.Name,
+		containerName(soi.Version),
 		*soi.FilePath,
 		&azblob.DeleteBlobOptions{},
 	)
 	return err
 }
 
+// ListObjects lists the blobs in the container of the Version whose names start with the prefix received by soi.
+func (storage *AzureBlobStorage) ListObjects(ctx context.Context, soi *storagetypes.StorageObjectInput) ([]storagetypes.StorageObject, error) {
+	var objects []storagetypes.StorageObject
+	pager := storage.blobClient.NewListBlobsFlatPager(containerName(soi.Version), &azblob.ListBlobsFlatOptions{
+		Prefix: soi.FilePath,
+	})
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if bloberror.HasCode(err, bloberror.ContainerNotFound) {
+			return objects, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name == nil {
+				continue
+			}
+
+			listed := storagetypes.StorageObject{Path: *blob.Name}
+			if blob.Properties != nil && blob.Properties.LastModified != nil {
+				listed.LastModified = *blob.Properties.LastModified
+			}
+			objects = append(objects, listed)
+		}
+	}
+
+	return objects, nil
+}
+
 // PutObject puts the Version file in the specified bucket with its computed base64 encoded SHA256 checksum.
 func (storage *AzureBlobStorage) PutObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
-	ctr, err := storage.storageClient.Create(ctx,
-		soi.Version.Spec.ModuleConfigRef.StorageConfig.AzureStorage.ResourceGroup,
-		soi.Version.Spec.ModuleConfigRef.StorageConfig.AzureStorage.AccountName,
-		*soi.Version.Spec.ModuleConfigRef.Name,
-		armstorage.BlobContainer{
-			ContainerProperties: &armstorage.ContainerProperties{
-				Metadata: map[string]*string{
-					"Checksum": soi.ArchiveChecksum,
-				},
-			},
-		}, nil)
+	containerName, err := storage.putContainer(ctx, soi)
 	if err != nil {
 		return err
 	}
@@ -110,12 +185,12 @@ func (storage *AzureBlobStorage) PutObject(ctx context.Context, soi *storagetype
 		streamOptions := &azblob.UploadStreamOptions{
 			Concurrency: 10,
 		}
-		_, err = storage.blobClient.UploadStream(ctx, *ctr.Name, *soi.FilePath, soi.FileReader, streamOptions)
+		_, err = storage.blobClient.UploadStream(ctx, containerName, *soi.FilePath, soi.FileReader, streamOptions)
 	} else {
 		bufferOptions := &azblob.UploadBufferOptions{
 			Concurrency: 10,
 		}
-		_, err = storage.blobClient.UploadBuffer(ctx, *ctr.Name, *soi.FilePath, soi.FileBytes, bufferOptions)
+		_, err = storage.blobClient.UploadBuffer(ctx, containerName, *soi.FilePath, soi.FileBytes, bufferOptions)
 	}
 	if err != nil {
 		return err
@@ -123,3 +198,54 @@ func (storage *AzureBlobStorage) PutObject(ctx context.Context, soi *storagetype
 
 	return nil
 }
+
+// putContainer creates the container of the Version, or updates its metadata when it exists, with the checksum of
+// the Version file and returns its name.
+func (storage *AzureBlobStorage) putContainer(ctx context.Context, soi *storagetypes.StorageObjectInput) (string, error) {
+	metadata := map[string]*string{
+		"Checksum": soi.ArchiveChecksum,
+	}
+
+	if storage.storageClient == nil {
+		containerName := containerName(soi.Version)
+		_, err := storage.blobClient.CreateContainer(ctx, containerName, &azblob.CreateContainerOptions{Metadata: metadata})
+		if bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+			_, err = storage.blobClient.ServiceClient().NewContainerClient(containerName).SetMetadata(ctx, &container.SetMetadataOptions{Metadata: metadata})
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return containerName, nil
+	}
+
+	ctr, err := storage.storageClient.Create(ctx,
+		soi.StorageConfig.AzureStorage.ResourceGroup,
+		soi.StorageConfig.AzureStorage.AccountName,
+		containerName(soi.Version),
+		armstorage.BlobContainer{
+			ContainerProperties: &armstorage.ContainerProperties{
+				Metadata: metadata,
+			},
+		}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return *ctr.Name, nil
+}
+
+// containerName returns the name of the container the files of version are stored in, which is the name of its module
+// or provider.
+func containerName(version *versionv1alpha1.Version) string {
+	if version.Spec.ModuleConfigRef != nil && version.Spec.ModuleConfigRef.Name != nil {
+		return *version.Spec.ModuleConfigRef.Name
+	}
+
+	if version.Spec.ProviderConfigRef != nil && version.Spec.ProviderConfigRef.Name != nil {
+		return *version.Spec.ProviderConfigRef.Name
+	}
+
+	return ""
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	versionv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+// SecretGetter reads the Kubernetes Secrets named by storage configs.
+type SecretGetter interface {
+	// GetSecretData returns the data of the Secret name in namespace.
+	GetSecretData(ctx context.Context, namespace, name string) (map[string][]byte, error)
+}
+
+// StorageConfigGetter reads the Kubernetes resources the storage config of a Version is resolved from.
+type StorageConfigGetter interface {
+	SecretGetter
+	// GetDefaultStorageProfileName returns the StorageProfile named by the 'opendepot.defdev.io/storage-profile'
+	// annotation of namespace, or an empty string when it has none.
+	GetDefaultStorageProfileName(ctx context.Context, namespace string) (string, error)
+	// GetStorageProfile returns the StorageProfile named name.
+	GetStorageProfile(ctx context.Context, name string) (*versionv1alpha1.StorageProfile, error)
+}
+
+// VersionStorageConfig resolves the storage config of a Version and the namespace the Secrets it names are read
+// from. An inline storage config is used as is. Otherwise the StorageProfile named by the config reference, or by the
+// 'opendepot.defdev.io/storage-profile' annotation of the Version's namespace, is read with getter.
+func VersionStorageConfig(ctx context.Context, getter StorageConfigGetter, version *versionv1alpha1.Version) (*versionv1alpha1.StorageConfig, string, error) {
+	var storageConfig *versionv1alpha1.StorageConfig
+	var profileName string
+	if version.Spec.ModuleConfigRef != nil {
+		storageConfig = version.Spec.ModuleConfigRef.StorageConfig
+		if version.Spec.ModuleConfigRef.StorageProfileName != nil {
+			profileName = *version.Spec.ModuleConfigRef.StorageProfileName
+		}
+	} else if version.Spec.ProviderConfigRef != nil {
+		storageConfig = version.Spec.ProviderConfigRef.StorageConfig
+		if version.Spec.ProviderConfigRef.StorageProfileName != nil {
+			profileName = *version.Spec.ProviderConfigRef.StorageProfileName
+		}
+	}
+
+	if storageConfig != nil {
+		return storageConfig, version.Namespace, nil
+	}
+
+	if profileName == "" {
+		defaultProfileName, err := getter.GetDefaultStorageProfileName(ctx, version.Namespace)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get namespace '%s': %w", version.Namespace, err)
+		}
+		profileName = defaultProfileName
+	}
+
+	if profileName == "" {
+		return nil, "", fmt.Errorf("storage config is not configured on moduleConfigRef or providerConfigRef and namespace '%s' has no default storage profile", version.Namespace)
+	}
+
+	profile, err := getter.GetStorageProfile(ctx, profileName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get storage profile '%s': %w", profileName, err)
+	}
+
+	secretNamespace := version.Namespace
+	if profile.Spec.SecretNamespace != nil && *profile.Spec.SecretNamespace != "" {
+		secretNamespace = *profile.Spec.SecretNamespace
+	}
+
+	return &profile.Spec.StorageConfig, secretNamespace, nil
+}
+
+// GetStorageSecretData returns the data of a Secret referenced by a storage config, where kind describes the Secret
+// in errors. An empty name returns nil, and a Secret without data returns an empty map.
+func GetStorageSecretData(ctx context.Context, secrets SecretGetter, namespace string, name *string, kind string) (map[string][]byte, error) {
+	if name == nil || *name == "" {
+		return nil, nil
+	}
+
+	data, err := secrets.GetSecretData(ctx, namespace, *name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s secret '%s': %w", kind, *name, err)
+	}
+
+	if data == nil {
+		return map[string][]byte{}, nil
+	}
+
+	return data, nil
+}
+
+// GetAmazonS3ClientOptions builds the S3 client options of an S3 config, reading its CA bundle and credentials
+// from the Secrets it names in namespace.
+func GetAmazonS3ClientOptions(ctx context.Context, secrets SecretGetter, namespace string, s3Config *versionv1alpha1.AmazonS3Config) (*AmazonS3ClientOptions, error) {
+	options := &AmazonS3ClientOptions{
+		Endpoint:     s3Config.Endpoint,
+		UsePathStyle: s3Config.UsePathStyle,
+	}
+
+	caBundle, err := GetStorageSecretData(ctx, secrets, namespace, s3Config.CABundleSecretName, "S3 CA bundle")
+	if err != nil {
+		return nil, err
+	}
+
+	if caBundle != nil {
+		options.CABundle = caBundle[versionv1alpha1.OpenDepotS3SecretDataFieldCABundle]
+		if len(options.CABundle) == 0 {
+			return nil, fmt.Errorf("S3 CA bundle secret '%s' has no '%s' field", *s3Config.CABundleSecretName, versionv1alpha1.OpenDepotS3SecretDataFieldCABundle)
+		}
+	}
+
+	credentials, err := GetStorageSecretData(ctx, secrets, namespace, s3Config.CredentialsSecretName, "S3 credentials")
+	if err != nil {
+		return nil, err
+	}
+
+	if credentials != nil {
+		options.AccessKeyID = string(credentials[versionv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID])
+		options.SecretAccessKey = string(credentials[versionv1alpha1.OpenDepotS3SecretDataFieldSecretKey])
+		options.SessionToken = string(credentials[versionv1alpha1.OpenDepotS3SecretDataFieldSessionToken])
+		options.RoleARN = string(credentials[versionv1alpha1.OpenDepotS3SecretDataFieldRoleARN])
+		options.ExternalID = string(credentials[versionv1alpha1.OpenDepotS3SecretDataFieldExternalID])
+
+		hasKeys := options.AccessKeyID != "" && options.SecretAccessKey != ""
+		if !hasKeys && (options.RoleARN == "" || options.AccessKeyID != "" || options.SecretAccessKey != "") {
+			return nil, fmt.Errorf("S3 credentials secret '%s' must have '%s' and '%s' fields or a '%s' field", *s3Config.CredentialsSecretName,
+				versionv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID, versionv1alpha1.OpenDepotS3SecretDataFieldSecretKey,
+				versionv1alpha1.OpenDepotS3SecretDataFieldRoleARN)
+		}
+	}
+
+	return options, nil
+}
+
+// GetAzureBlobClientOptions builds the Azure Blob client options of an Azure Storage config, reading its connection
+// string or SAS token from the Secret it names in namespace. Nil is returned when no Secret is named.
+func GetAzureBlobClientOptions(ctx context.Context, secrets SecretGetter, namespace string, azureConfig *versionv1alpha1.AzureStorageConfig) (*AzureBlobClientOptions, error) {
+	credentials, err := GetStorageSecretData(ctx, secrets, namespace, azureConfig.CredentialsSecretName, "Azure Storage credentials")
+	if err != nil || credentials == nil {
+		return nil, err
+	}
+
+	options := &AzureBlobClientOptions{
+		ConnectionString: string(credentials[versionv1alpha1.OpenDepotAzureSecretDataFieldConnection]),
+		SASToken:         string(credentials[versionv1alpha1.OpenDepotAzureSecretDataFieldSASToken]),
+	}
+
+	if options.ConnectionString == "" && options.SASToken == "" {
+		return nil, fmt.Errorf("Azure Storage credentials secret '%s' must have a '%s' or '%s' field", *azureConfig.CredentialsSecretName,
+			versionv1alpha1.OpenDepotAzureSecretDataFieldConnection, versionv1alpha1.OpenDepotAzureSecretDataFieldSASToken)
+	}
+
+	return options, nil
+}
+
+// GetGoogleCloudStorageClientOptions builds the GCS client options of a GCS config, reading its service account key
+// from the Secret it names in namespace. Nil is returned when no Secret is named.
+func GetGoogleCloudStorageClientOptions(ctx context.Context, secrets SecretGetter, namespace string, gcsConfig *versionv1alpha1.GoogleCloudStorageConfig) (*GoogleCloudStorageClientOptions, error) {
+	credentials, err := GetStorageSecretData(ctx, secrets, namespace, gcsConfig.CredentialsSecretName, "GCS credentials")
+	if err != nil || credentials == nil {
+		return nil, err
+	}
+
+	options := &GoogleCloudStorageClientOptions{
+		ServiceAccountJSON: credentials[versionv1alpha1.OpenDepotGCSSecretDataFieldAccountJSON],
+	}
+
+	if len(options.ServiceAccountJSON) == 0 {
+		return nil, fmt.Errorf("GCS credentials secret '%s' has no '%s' field", *gcsConfig.CredentialsSecretName, versionv1alpha1.OpenDepotGCSSecretDataFieldAccountJSON)
+	}
+
+	return options, nil
+}
+
+// GetOCIClientOptions builds the OCI client options of an OCI storage config, reading its CA bundle and registry
+// credentials from the Secrets it names in namespace.
+func GetOCIClientOptions(ctx context.Context, secrets SecretGetter, namespace string, ociConfig *versionv1alpha1.OCIStorageConfig) (*OCIClientOptions, error) {
+	options := &OCIClientOptions{
+		Registry:   ociConfig.Registry,
+		Repository: ociConfig.Repository,
+		PlainHTTP:  ociConfig.PlainHTTP,
+	}
+
+	caBundle, err := GetStorageSecretData(ctx, secrets, namespace, ociConfig.CABundleSecretName, "OCI CA bundle")
+	if err != nil {
+		return nil, err
+	}
+
+	if caBundle != nil {
+		options.CABundle = caBundle[versionv1alpha1.OpenDepotOCISecretDataFieldCABundle]
+		if len(options.CABundle) == 0 {
+			return nil, fmt.Errorf("OCI CA bundle secret '%s' has no '%s' field", *ociConfig.CABundleSecretName, versionv1alpha1.OpenDepotOCISecretDataFieldCABundle)
+		}
+	}
+
+	credentials, err := GetStorageSecretData(ctx, secrets, namespace, ociConfig.CredentialsSecretName, "OCI credentials")
+	if err != nil {
+		return nil, err
+	}
+
+	if credentials != nil {
+		options.Username = string(credentials[versionv1alpha1.OpenDepotOCISecretDataFieldUsername])
+		options.Password = string(credentials[versionv1alpha1.OpenDepotOCISecretDataFieldPassword])
+		if options.Username == "" || options.Password == "" {
+			return nil, fmt.Errorf("OCI credentials secret '%s' must have '%s' and '%s' fields", *ociConfig.CredentialsSecretName,
+				versionv1alpha1.OpenDepotOCISecretDataFieldUsername, versionv1alpha1.OpenDepotOCISecretDataFieldPassword)
+		}
+	}
+
+	return options, nil
+}
+
+// NewStorage initializes a client for the backend configured on storageConfig, reading the Secrets it names from
+// namespace. The client encrypts and decrypts archives when storageConfig configures encryption.
+func NewStorage(ctx context.Context, secrets SecretGetter, namespace string, storageConfig *versionv1alpha1.StorageConfig) (Storage, error) {
+	backend, err := newStorageBackend(ctx, secrets, namespace, storageConfig)
+	if err != nil || storageConfig.Encryption == nil {
+		return backend, err
+	}
+
+	keyring, err := GetStorageSecretData(ctx, secrets, namespace, &storageConfig.Encryption.KeyringSecretName, "encryption keyring")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEncryptedStorage(backend, keyring, storageConfig.Encryption.PrimaryKeyID)
+}
+
+// newStorageBackend initializes a client for the backend configured on storageConfig, reading the Secrets it names
+// from namespace.
+func newStorageBackend(ctx context.Context, secrets SecretGetter, namespace string, storageConfig *versionv1alpha1.StorageConfig) (Storage, error) {
+	if storageConfig.FileSystem != nil {
+		return &FileSystem{}, nil
+	}
+
+	if storageConfig.S3 != nil {
+		options, err := GetAmazonS3ClientOptions(ctx, secrets, namespace, storageConfig.S3)
+		if err != nil {
+			return nil, err
+		}
+
+		amazonS3Storage := &AmazonS3Storage{}
+		if err := amazonS3Storage.NewClient(ctx, storageConfig.S3.Region, options); err != nil {
+			return nil, err
+		}
+		return amazonS3Storage, nil
+	}
+
+	if storageConfig.AzureStorage != nil {
+		options, err := GetAzureBlobClientOptions(ctx, secrets, namespace, storageConfig.AzureStorage)
+		if err != nil {
+			return nil, err
+		}
+
+		azureBlobStorage := &AzureBlobStorage{}
+		if err := azureBlobStorage.NewClients(storageConfig.AzureStorage.SubscriptionID, storageConfig.AzureStorage.AccountUrl, options); err != nil {
+			return nil, err
+		}
+		return azureBlobStorage, nil
+	}
+
+	if storageConfig.GCS != nil {
+		options, err := GetGoogleCloudStorageClientOptions(ctx, secrets, namespace, storageConfig.GCS)
+		if err != nil {
+			return nil, err
+		}
+
+		gcsStorage := &GoogleCloudStorage{}
+		if err := gcsStorage.NewClient(ctx, options); err != nil {
+			return nil, err
+		}
+		return gcsStorage, nil
+	}
+
+	if storageConfig.OCI != nil {
+		options, err := GetOCIClientOptions(ctx, secrets, namespace, storageConfig.OCI)
+		if err != nil {
+			return nil, err
+		}
+
+		ociStorage := &OCIStorage{}
+		if err := ociStorage.NewClient(options); err != nil {
+			return nil, err
+		}
+		return ociStorage, nil
+	}
+
+	return nil, fmt.Errorf("at least one StorageConfig backend must be configured")
+}
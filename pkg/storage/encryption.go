@@ -0,0 +1,394 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+const (
+	// encryptionMagic starts every object stored by EncryptedStorage and names the version of the envelope format.
+	encryptionMagic = "ODENCv2\n"
+	// encryptionChunkSize is the size of the plaintext chunks sealed one at a time, so objects are streamed without
+	// being held in memory.
+	encryptionChunkSize = 64 * 1024
+	// encryptionKeySize is the size of AES-256 keys, for both keyring keys and data keys.
+	encryptionKeySize = 32
+)
+
+// errEncryptedObjectCorrupt is returned when an encrypted object fails authentication.
+var errEncryptedObjectCorrupt = errors.New("the encrypted object is corrupt or was tampered with")
+
+// EncryptedStorage wraps a Storage backend with client-side envelope encryption. Each object is encrypted with its
+// own AES-256-GCM data key, which is stored in the object's header wrapped by a key of the keyring. The header also
+// holds the checksum of the plaintext, authenticated along with the wrapped data key, so encrypted and unencrypted
+// copies of an archive have the same checksum and it is read without downloading the whole object.
+type EncryptedStorage struct {
+	// The backend the encrypted objects are stored in.
+	Storage Storage
+	// The AES-256 keys that wrap data keys, by key ID.
+	Keyring map[string][]byte
+	// The ID of the key that wraps the data keys of newly stored objects.
+	PrimaryKeyID string
+}
+
+// NewEncryptedStorage returns an EncryptedStorage storing objects in backend. Each value of keyring must be a 32 byte
+// AES-256 key, either raw or base64 encoded, and primaryKeyID must name one of them.
+func NewEncryptedStorage(backend Storage, keyring map[string][]byte, primaryKeyID string) (*EncryptedStorage, error) {
+	keys := make(map[string][]byte, len(keyring))
+	for keyID, key := range keyring {
+		if len(keyID) == 0 || len(keyID) > 255 {
+			return nil, fmt.Errorf("the encryption key ID '%s' must be between 1 and 255 bytes long", keyID)
+		}
+
+		if len(key) != encryptionKeySize {
+			decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(key)))
+			if err != nil || len(decoded) != encryptionKeySize {
+				return nil, fmt.Errorf("the encryption key '%s' must be a 32 byte AES-256 key, raw or base64 encoded", keyID)
+			}
+			key = decoded
+		}
+
+		keys[keyID] = key
+	}
+
+	if _, ok := keys[primaryKeyID]; !ok {
+		return nil, fmt.Errorf("the primary encryption key '%s' is not in the keyring", primaryKeyID)
+	}
+
+	return &EncryptedStorage{Storage: backend, Keyring: keys, PrimaryKeyID: primaryKeyID}, nil
+}
+
+// DeleteObject deletes the encrypted object from the backend.
+func (storage *EncryptedStorage) DeleteObject(ctx context.Context, soi *types.StorageObjectInput) error {
+	return storage.Storage.DeleteObject(ctx, soi)
+}
+
+// ListObjects lists the encrypted objects of the backend.
+func (storage *EncryptedStorage) ListObjects(ctx context.Context, soi *types.StorageObjectInput) ([]types.StorageObject, error) {
+	return storage.Storage.ListObjects(ctx, soi)
+}
+
+// GetObject returns an io.Reader streaming the decrypted object. Reads fail when a chunk fails authentication or the
+// object is truncated. The reader is an io.Closer that closes the reader returned by the backend.
+func (storage *EncryptedStorage) GetObject(ctx context.Context, soi *types.StorageObjectInput) (io.Reader, error) {
+	reader, err := storage.Storage.GetObject(ctx, soi)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypter, err := storage.newDecrypter(reader)
+	if err != nil {
+		closeReader(reader)
+		return nil, err
+	}
+
+	return decrypter, nil
+}
+
+// GetObjectChecksum reads the header of the object and sets the soi receiver's field `ObjectChecksum` with the base64
+// encoded sha256 checksum of its plaintext. The chunks of the object are not read, so a chunk that was tampered with
+// is only detected when the object is read. The soi receiver's field `EncryptionKeyStale` is set when the data key of
+// the object is not wrapped by the primary key. It is also set when the object has no encryption header or its header
+// fails authentication, ie: an archive stored before encryption was enabled, which keeps the checksum reported
+// by the backend, so the object is stored again.
+func (storage *EncryptedStorage) GetObjectChecksum(ctx context.Context, soi *types.StorageObjectInput) error {
+	if err := storage.Storage.GetObjectChecksum(ctx, soi); err != nil || !soi.FileExists {
+		return err
+	}
+
+	reader, err := storage.Storage.GetObject(ctx, soi)
+	if err != nil {
+		return err
+	}
+	defer closeReader(reader)
+
+	decrypter, err := storage.newDecrypter(reader)
+	if errors.Is(err, errEncryptedObjectCorrupt) {
+		soi.EncryptionKeyStale = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	checksum := base64.StdEncoding.EncodeToString(decrypter.checksum)
+	soi.ObjectChecksum = &checksum
+	soi.EncryptionKeyStale = decrypter.keyID != storage.PrimaryKeyID
+	return nil
+}
+
+// PutObject encrypts the file with a new data key wrapped by the primary key and puts it into the backend. The
+// ciphertext is staged in a temporary file so large archives are kept off the heap.
+func (storage *EncryptedStorage) PutObject(ctx context.Context, soi *types.StorageObjectInput) error {
+	var plaintext io.Reader = bytes.NewReader(soi.FileBytes)
+	if soi.FileReader != nil {
+		plaintext = soi.FileReader
+	}
+
+	ciphertext, err := os.CreateTemp("", "opendepot-encrypted-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(ciphertext.Name())
+	defer ciphertext.Close()
+
+	if err := storage.encrypt(ciphertext, plaintext); err != nil {
+		return fmt.Errorf("failed to encrypt '%s': %w", *soi.FilePath, err)
+	}
+
+	if _, err := ciphertext.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, ciphertext); err != nil {
+		return err
+	}
+
+	if _, err := ciphertext.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	checksum := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	encryptedSoi := *soi
+	encryptedSoi.ArchiveChecksum = &checksum
+	encryptedSoi.FileBytes = nil
+	encryptedSoi.FileReader = ciphertext
+	return storage.Storage.PutObject(ctx, &encryptedSoi)
+}
+
+// encrypt writes the envelope of plaintext to ciphertext: the header holding the checksum of the plaintext and the
+// wrapped data key, followed by the sealed chunks of plaintext. The header is written once the plaintext has been
+// read, since its checksum is only known then. The last chunk is always shorter than a full chunk, and is empty when
+// the plaintext is a multiple of the chunk size, so truncated objects are detected.
+func (storage *EncryptedStorage) encrypt(ciphertext *os.File, plaintext io.Reader) error {
+	dataKey := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+
+	keyID := storage.PrimaryKeyID
+	keyAEAD, err := newAEAD(storage.Keyring[keyID])
+	if err != nil {
+		return err
+	}
+
+	headerSize := len(encryptionMagic) + 1 + len(keyID) + sha256.Size + 2 + keyAEAD.NonceSize() + encryptionKeySize + keyAEAD.Overhead()
+	if _, err := ciphertext.Seek(int64(headerSize), io.SeekStart); err != nil {
+		return err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	chunk := make([]byte, encryptionChunkSize)
+	sealed := make([]byte, 0, encryptionChunkSize+dataAEAD.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(plaintext, chunk)
+		final := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !final {
+			return err
+		}
+
+		h.Write(chunk[:n])
+		sealed = dataAEAD.Seal(sealed[:0], chunkNonce(counter, final), chunk[:n], nil)
+		if _, err := ciphertext.Write(sealed); err != nil {
+			return err
+		}
+
+		if final {
+			break
+		}
+	}
+
+	header := []byte(encryptionMagic)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = h.Sum(header)
+
+	wrapNonce := make([]byte, keyAEAD.NonceSize())
+	if _, err := rand.Read(wrapNonce); err != nil {
+		return err
+	}
+
+	wrappedKey := keyAEAD.Seal(wrapNonce, wrapNonce, dataKey, header)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrappedKey)))
+	header = append(header, wrappedKey...)
+	_, err = ciphertext.WriteAt(header, 0)
+	return err
+}
+
+// newDecrypter reads the header of the envelope from r and returns a reader of its plaintext.
+func (storage *EncryptedStorage) newDecrypter(r io.Reader) (*decrypter, error) {
+	header := make([]byte, len(encryptionMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: the object has no encryption header", errEncryptedObjectCorrupt)
+	}
+
+	if string(header[:len(encryptionMagic)]) != encryptionMagic {
+		return nil, fmt.Errorf("%w: the object has no encryption header", errEncryptedObjectCorrupt)
+	}
+
+	keyID := make([]byte, header[len(encryptionMagic)])
+	if _, err := io.ReadFull(r, keyID); err != nil {
+		return nil, fmt.Errorf("%w: %v", errEncryptedObjectCorrupt, err)
+	}
+	header = append(header, keyID...)
+
+	checksum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, checksum); err != nil {
+		return nil, fmt.Errorf("%w: %v", errEncryptedObjectCorrupt, err)
+	}
+	header = append(header, checksum...)
+
+	key, ok := storage.Keyring[string(keyID)]
+	if !ok {
+		return nil, fmt.Errorf("the object is encrypted with the key '%s', which is not in the keyring", keyID)
+	}
+
+	var wrappedKeySize uint16
+	if err := binary.Read(r, binary.BigEndian, &wrappedKeySize); err != nil {
+		return nil, fmt.Errorf("%w: %v", errEncryptedObjectCorrupt, err)
+	}
+
+	wrappedKey := make([]byte, wrappedKeySize)
+	if _, err := io.ReadFull(r, wrappedKey); err != nil {
+		return nil, fmt.Errorf("%w: %v", errEncryptedObjectCorrupt, err)
+	}
+
+	keyAEAD, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedKey) < keyAEAD.NonceSize() {
+		return nil, fmt.Errorf("%w: the wrapped data key is too short", errEncryptedObjectCorrupt)
+	}
+
+	dataKey, err := keyAEAD.Open(nil, wrappedKey[:keyAEAD.NonceSize()], wrappedKey[keyAEAD.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the data key could not be unwrapped", errEncryptedObjectCorrupt)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decrypter{
+		keyID:    string(keyID),
+		checksum: checksum,
+		source:   r,
+		aead:     dataAEAD,
+		sealed:   make([]byte, encryptionChunkSize+dataAEAD.Overhead()),
+	}, nil
+}
+
+// decrypter streams the plaintext of the sealed chunks of an envelope.
+type decrypter struct {
+	// The ID of the key that wrapped the data key.
+	keyID string
+	// The sha256 checksum of the plaintext.
+	checksum []byte
+	source   io.Reader
+	aead     cipher.AEAD
+	counter  uint64
+	sealed   []byte
+	// The decrypted chunk not yet returned by Read.
+	plaintext []byte
+	done      bool
+	err       error
+}
+
+// Read implements io.Reader.
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.plaintext) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		if d.done {
+			return 0, io.EOF
+		}
+
+		d.plaintext, d.err = d.openChunk()
+	}
+
+	n := copy(p, d.plaintext)
+	d.plaintext = d.plaintext[n:]
+	return n, nil
+}
+
+// Close closes the reader of the object returned by the backend, ie: the body of an S3 response or an open file.
+func (d *decrypter) Close() error {
+	if closer, ok := d.source.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// openChunk reads and opens the next sealed chunk. A chunk shorter than a full chunk must be the final one.
+func (d *decrypter) openChunk() ([]byte, error) {
+	n, err := io.ReadFull(d.source, d.sealed)
+	final := errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	if err != nil && !final {
+		return nil, err
+	}
+
+	if n < d.aead.Overhead() {
+		return nil, fmt.Errorf("%w: the object is truncated", errEncryptedObjectCorrupt)
+	}
+
+	plaintext, err := d.aead.Open(d.sealed[:0], chunkNonce(d.counter, final), d.sealed[:n], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: chunk %d failed authentication", errEncryptedObjectCorrupt, d.counter)
+	}
+
+	d.counter++
+	d.done = final
+	return plaintext, nil
+}
+
+// chunkNonce returns the nonce of a chunk. Data keys are never reused, so the chunk counter is a unique nonce, and
+// the final chunk is sealed with a distinct nonce so it cannot be swapped with another chunk.
+func chunkNonce(counter uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	if final {
+		nonce[0] = 1
+	}
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
+
+// newAEAD returns an AES-GCM cipher using key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// closeReader closes reader when the backend returned an io.ReadCloser.
+func closeReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
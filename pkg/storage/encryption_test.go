@@ -0,0 +1,423 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+// plaintextChecksum returns the base64 encoded sha256 checksum of data, as reported by GetObjectChecksum.
+func plaintextChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// testArchive returns an archive of size bytes.
+func testArchive(size int) []byte {
+	return bytes.Repeat([]byte("module archive "), size/15+1)[:size]
+}
+
+// putEncryptedArchive stores archive at filePath with encrypted and fails the test on error.
+func putEncryptedArchive(t *testing.T, encrypted *EncryptedStorage, filePath string, archive []byte) {
+	t.Helper()
+	if err := encrypted.PutObject(context.Background(), &types.StorageObjectInput{FileBytes: archive, FilePath: &filePath}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readEncryptedArchive reads the plaintext of the object at filePath with encrypted.
+func readEncryptedArchive(encrypted *EncryptedStorage, filePath string) ([]byte, error) {
+	reader, err := encrypted.GetObject(context.Background(), &types.StorageObjectInput{FilePath: &filePath})
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(reader)
+
+	return io.ReadAll(reader)
+}
+
+func TestEncryptedStorageRoundTrip(t *testing.T) {
+	encrypted, err := NewEncryptedStorage(&FileSystem{}, map[string][]byte{"2026-10": bytes.Repeat([]byte{7}, 32)}, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty archive", size: 0},
+		{name: "archive smaller than a chunk", size: 1000},
+		{name: "archive of exactly one chunk", size: encryptionChunkSize},
+		{name: "archive of several chunks", size: 150 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := testArchive(tt.size)
+			filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+			putEncryptedArchive(t, encrypted, filePath, archive)
+
+			stored, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.HasPrefix(stored, []byte(encryptionMagic)) {
+				t.Fatalf("stored object does not start with '%q'", encryptionMagic)
+			}
+
+			if tt.size > 0 && bytes.Contains(stored, archive[:tt.size/2+1]) {
+				t.Fatal("stored object contains plaintext")
+			}
+
+			soi := &types.StorageObjectInput{FilePath: &filePath}
+			if err := encrypted.GetObjectChecksum(context.Background(), soi); err != nil {
+				t.Fatal(err)
+			}
+
+			if !soi.FileExists || soi.ObjectChecksum == nil || *soi.ObjectChecksum != plaintextChecksum(archive) {
+				t.Fatalf("GetObjectChecksum reported %v, want the plaintext checksum '%s'", soi.ObjectChecksum, plaintextChecksum(archive))
+			}
+
+			if soi.EncryptionKeyStale {
+				t.Fatal("object stored with the primary key is reported as stale")
+			}
+
+			plaintext, err := readEncryptedArchive(encrypted, filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(plaintext, archive) {
+				t.Fatalf("read %d bytes, want the %d bytes stored", len(plaintext), len(archive))
+			}
+		})
+	}
+}
+
+func TestEncryptedStorageTamper(t *testing.T) {
+	encrypted, err := NewEncryptedStorage(&FileSystem{}, map[string][]byte{"2026-10": bytes.Repeat([]byte{7}, 32)}, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := testArchive(150 * 1024)
+	headerSize := len(encryptionMagic) + 1 + len("2026-10")
+
+	tests := []struct {
+		name string
+		// tamper changes the stored object.
+		tamper func(stored []byte) []byte
+		// expectedChecksum is whether GetObjectChecksum still reports the plaintext checksum.
+		expectedChecksum bool
+		expectedErr      string
+	}{
+		{
+			name:             "chunk changed",
+			tamper:           func(stored []byte) []byte { stored[len(stored)/2] ^= 0xff; return stored },
+			expectedChecksum: true,
+			expectedErr:      "failed authentication",
+		},
+		{
+			name:             "object truncated",
+			tamper:           func(stored []byte) []byte { return stored[:len(stored)-20] },
+			expectedChecksum: true,
+			expectedErr:      "failed authentication",
+		},
+		{
+			name:        "checksum in the header changed",
+			tamper:      func(stored []byte) []byte { stored[headerSize] ^= 0xff; return stored },
+			expectedErr: "data key could not be unwrapped",
+		},
+		{
+			name:        "header missing",
+			tamper:      func(stored []byte) []byte { return stored[len(encryptionMagic):] },
+			expectedErr: "no encryption header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+			putEncryptedArchive(t, encrypted, filePath, archive)
+
+			stored, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filePath, tt.tamper(stored), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			soi := &types.StorageObjectInput{FilePath: &filePath}
+			if err := encrypted.GetObjectChecksum(context.Background(), soi); err != nil {
+				t.Fatal(err)
+			}
+
+			if !soi.FileExists {
+				t.Fatal("tampered object is reported as missing")
+			}
+
+			if matches := *soi.ObjectChecksum == plaintextChecksum(archive); matches != tt.expectedChecksum {
+				t.Fatalf("GetObjectChecksum reported the plaintext checksum: %t, want %t", matches, tt.expectedChecksum)
+			}
+
+			_, err = readEncryptedArchive(encrypted, filePath)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("reading the object returned error %v, want '%s'", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestEncryptedStorageKeyRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)))
+	archive := testArchive(1000)
+	filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+
+	oldStorage, err := NewEncryptedStorage(&FileSystem{}, map[string][]byte{"old": oldKey}, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotatedStorage, err := NewEncryptedStorage(&FileSystem{}, map[string][]byte{"old": oldKey, "new": newKey}, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putEncryptedArchive(t, oldStorage, filePath, archive)
+
+	for i, expectedStale := range []bool{true, false} {
+		soi := &types.StorageObjectInput{FilePath: &filePath}
+		if err := rotatedStorage.GetObjectChecksum(context.Background(), soi); err != nil {
+			t.Fatal(err)
+		}
+
+		if *soi.ObjectChecksum != plaintextChecksum(archive) {
+			t.Fatalf("pass %d: GetObjectChecksum reported '%s', want '%s'", i, *soi.ObjectChecksum, plaintextChecksum(archive))
+		}
+
+		if soi.EncryptionKeyStale != expectedStale {
+			t.Fatalf("pass %d: EncryptionKeyStale is %t, want %t", i, soi.EncryptionKeyStale, expectedStale)
+		}
+
+		plaintext, err := readEncryptedArchive(rotatedStorage, filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, archive) {
+			t.Fatalf("pass %d: read %d bytes, want the %d bytes stored", i, len(plaintext), len(archive))
+		}
+
+		putEncryptedArchive(t, rotatedStorage, filePath, archive)
+	}
+
+	_, err = readEncryptedArchive(oldStorage, filePath)
+	if err == nil || !strings.Contains(err.Error(), "'new', which is not in the keyring") {
+		t.Fatalf("reading the rotated object with the old keyring returned error %v", err)
+	}
+}
+
+// TestEncryptedStoragePlaintextObject checks that an object stored before encryption was enabled is reported as stale,
+// so it is stored again encrypted instead of being served through the decrypting route.
+func TestEncryptedStoragePlaintextObject(t *testing.T) {
+	encrypted, err := NewEncryptedStorage(&FileSystem{}, map[string][]byte{"2026-10": bytes.Repeat([]byte{7}, 32)}, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := testArchive(1000)
+	filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	if err := os.WriteFile(filePath, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expectedStale := range []bool{true, false} {
+		soi := &types.StorageObjectInput{FilePath: &filePath}
+		if err := encrypted.GetObjectChecksum(context.Background(), soi); err != nil {
+			t.Fatal(err)
+		}
+
+		if !soi.FileExists || *soi.ObjectChecksum != plaintextChecksum(archive) {
+			t.Fatalf("pass %d: GetObjectChecksum reported '%v', want '%s'", i, soi.ObjectChecksum, plaintextChecksum(archive))
+		}
+
+		if soi.EncryptionKeyStale != expectedStale {
+			t.Fatalf("pass %d: EncryptionKeyStale is %t, want %t", i, soi.EncryptionKeyStale, expectedStale)
+		}
+
+		putEncryptedArchive(t, encrypted, filePath, archive)
+	}
+
+	plaintext, err := readEncryptedArchive(encrypted, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, archive) {
+		t.Fatalf("read %d bytes, want the %d bytes stored", len(plaintext), len(archive))
+	}
+}
+
+func TestNewEncryptedStorage(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	tests := []struct {
+		name         string
+		keyring      map[string][]byte
+		primaryKeyID string
+		expectedErr  string
+	}{
+		{name: "raw key", keyring: map[string][]byte{"raw": key}, primaryKeyID: "raw"},
+		{name: "base64 encoded key", keyring: map[string][]byte{"encoded": []byte(base64.StdEncoding.EncodeToString(key) + "\n")}, primaryKeyID: "encoded"},
+		{name: "short key", keyring: map[string][]byte{"short": []byte("key")}, primaryKeyID: "short", expectedErr: "32 byte AES-256 key"},
+		{name: "missing primary key", keyring: map[string][]byte{"raw": key}, primaryKeyID: "missing", expectedErr: "not in the keyring"},
+		{name: "key ID too long", keyring: map[string][]byte{strings.Repeat("k", 256): key}, primaryKeyID: "raw", expectedErr: "between 1 and 255 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEncryptedStorage(&FileSystem{}, tt.keyring, tt.primaryKeyID)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("NewEncryptedStorage returned error %v, want '%s'", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+// TestEncryptedStorageChecksumFromHeader checks that the checksum of an object is read from its header, so an object
+// is not downloaded in full to report its checksum.
+func TestEncryptedStorageChecksumFromHeader(t *testing.T) {
+	encrypted, err := NewEncryptedStorage(&countingStorage{}, map[string][]byte{"2026-10": bytes.Repeat([]byte{7}, 32)}, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := testArchive(10 * encryptionChunkSize)
+	filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	putEncryptedArchive(t, encrypted, filePath, archive)
+
+	backend := encrypted.Storage.(*countingStorage)
+	soi := &types.StorageObjectInput{FilePath: &filePath}
+	if err := encrypted.GetObjectChecksum(context.Background(), soi); err != nil {
+		t.Fatal(err)
+	}
+
+	if *soi.ObjectChecksum != plaintextChecksum(archive) {
+		t.Fatalf("GetObjectChecksum reported '%s', want '%s'", *soi.ObjectChecksum, plaintextChecksum(archive))
+	}
+
+	if backend.read >= encryptionChunkSize {
+		t.Fatalf("GetObjectChecksum read %d bytes of the object, want only its header", backend.read)
+	}
+}
+
+func TestEncryptedStorageClosesBackendReader(t *testing.T) {
+	encrypted, err := NewEncryptedStorage(&countingStorage{}, map[string][]byte{"2026-10": bytes.Repeat([]byte{7}, 32)}, "2026-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := testArchive(3 * encryptionChunkSize)
+	filePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+	putEncryptedArchive(t, encrypted, filePath, archive)
+
+	backend := encrypted.Storage.(*countingStorage)
+	reader, err := encrypted.GetObject(context.Background(), &types.StorageObjectInput{FilePath: &filePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closer, ok := reader.(io.Closer)
+	if !ok {
+		t.Fatal("GetObject returned a reader that cannot be closed")
+	}
+
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := closer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if backend.closed != 1 {
+		t.Fatalf("closing the decrypted reader closed %d backend readers, want 1", backend.closed)
+	}
+
+	if err := encrypted.GetObjectChecksum(context.Background(), &types.StorageObjectInput{FilePath: &filePath}); err != nil {
+		t.Fatal(err)
+	}
+
+	if backend.closed != 2 {
+		t.Fatalf("GetObjectChecksum left %d backend readers open, want none", 2-backend.closed)
+	}
+}
+
+// countingStorage is a FileSystem that counts the bytes read from the objects it returns and how many of their
+// readers are closed, ignoring the checksum the backend computes itself.
+type countingStorage struct {
+	FileSystem
+	read   int
+	closed int
+}
+
+// GetObjectChecksum sets the soi receiver's field `FileExists` without reading the object.
+func (storage *countingStorage) GetObjectChecksum(ctx context.Context, soi *types.StorageObjectInput) error {
+	exists, err := storage.fileExists(*soi.FilePath)
+	if err != nil {
+		return err
+	}
+
+	soi.FileExists = exists
+	return nil
+}
+
+// GetObject returns a reader of the object that counts the bytes read from it.
+func (storage *countingStorage) GetObject(ctx context.Context, soi *types.StorageObjectInput) (io.Reader, error) {
+	reader, err := storage.FileSystem.GetObject(ctx, soi)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingReader{reader: reader, storage: storage}, nil
+}
+
+// countingReader adds the bytes read from reader to the count of its storage.
+type countingReader struct {
+	reader  io.Reader
+	storage *countingStorage
+}
+
+// Read implements io.Reader.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.storage.read += n
+	return n, err
+}
+
+// Close closes reader and counts it as closed.
+func (r *countingReader) Close() error {
+	r.storage.closed++
+	closeReader(r.reader)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/tonedefdev/opendepot/pkg/storage/types"
 )
@@ -87,6 +88,37 @@ func (storage *FileSystem) DeleteObject(ctx context.Context, soi *types.StorageO
 	return nil
 }
 
+// ListObjects lists the files in the directory received by soi. Subdirectories are not listed.
+func (storage *FileSystem) ListObjects(ctx context.Context, soi *types.StorageObjectInput) ([]types.StorageObject, error) {
+	entries, err := os.ReadDir(*soi.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []types.StorageObject
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, types.StorageObject{
+			Path:         strings.TrimSuffix(*soi.FilePath, "/") + "/" + entry.Name(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
 // PutObject puts the Version file in the directory specified by StorageConfig.FileSystem.DirectoryPath. If a directory
 // for the Module's name is not found the function will create it first.
 func (storage *FileSystem) PutObject(ctx context.Context, soi *types.StorageObjectInput) error {
@@ -11,15 +11,29 @@ import (
 
 	"cloud.google.com/go/storage"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 type GoogleCloudStorage struct {
 	client *storage.Client
 }
 
-// NewClient initializes a new Google Cloud Storage client using Application Default Credentials.
-func (gcs *GoogleCloudStorage) NewClient(ctx context.Context) error {
-	client, err := storage.NewClient(ctx)
+// GoogleCloudStorageClientOptions configures explicit credentials for Google Cloud Storage.
+type GoogleCloudStorageClientOptions struct {
+	// A service account key in JSON format. When empty Application Default Credentials are used.
+	ServiceAccountJSON []byte
+}
+
+// NewClient initializes a new Google Cloud Storage client using the service account key in options, or
+// Application Default Credentials when options is nil.
+func (gcs *GoogleCloudStorage) NewClient(ctx context.Context, options *GoogleCloudStorageClientOptions) error {
+	var clientOptions []option.ClientOption
+	if options != nil && len(options.ServiceAccountJSON) > 0 {
+		clientOptions = append(clientOptions, option.WithAuthCredentialsJSON(option.ServiceAccount, options.ServiceAccountJSON))
+	}
+
+	client, err := storage.NewClient(ctx, clientOptions...)
 	if err != nil {
 		return err
 	}
@@ -31,7 +45,7 @@ func (gcs *GoogleCloudStorage) NewClient(ctx context.Context) error {
 // If the object is found, it sets the soi receiver's field 'ObjectChecksum' and 'FileExists'.
 // If the object cannot be found the function returns an error.
 func (gcs *GoogleCloudStorage) GetObjectChecksum(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
-	bucketName := soi.Version.Spec.ModuleConfigRef.StorageConfig.GCS.Bucket
+	bucketName := soi.StorageConfig.GCS.Bucket
 	objectName := *soi.FilePath
 
 	bucket := gcs.client.Bucket(bucketName)
@@ -55,7 +69,7 @@ func (gcs *GoogleCloudStorage) GetObjectChecksum(ctx context.Context, soi *stora
 
 // GetObject retrieves the object from Google Cloud Storage and returns an io.Reader to stream the file.
 func (gcs *GoogleCloudStorage) GetObject(ctx context.Context, soi *storagetypes.StorageObjectInput) (io.Reader, error) {
-	bucketName := soi.Version.Spec.ModuleConfigRef.StorageConfig.GCS.Bucket
+	bucketName := soi.StorageConfig.GCS.Bucket
 	objectName := *soi.FilePath
 
 	bucket := gcs.client.Bucket(bucketName)
@@ -74,7 +88,7 @@ func (gcs *GoogleCloudStorage) GetObject(ctx context.Context, soi *storagetypes.
 
 // DeleteObject deletes the Version file from the specified GCS bucket.
 func (gcs *GoogleCloudStorage) DeleteObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
-	bucketName := soi.Version.Spec.ModuleConfigRef.StorageConfig.GCS.Bucket
+	bucketName := soi.StorageConfig.GCS.Bucket
 	objectName := *soi.FilePath
 
 	bucket := gcs.client.Bucket(bucketName)
@@ -91,10 +105,32 @@ func (gcs *GoogleCloudStorage) DeleteObject(ctx context.Context, soi *storagetyp
 	return nil
 }
 
+// ListObjects lists the objects in the specified GCS bucket whose names start with the prefix received by soi.
+func (gcs *GoogleCloudStorage) ListObjects(ctx context.Context, soi *storagetypes.StorageObjectInput) ([]storagetypes.StorageObject, error) {
+	bucketName := soi.StorageConfig.GCS.Bucket
+
+	var objects []storagetypes.StorageObject
+	it := gcs.client.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: *soi.FilePath})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, storagetypes.StorageObject{Path: attrs.Name, LastModified: attrs.Updated})
+	}
+
+	return objects, nil
+}
+
 // PutObject uploads the Version file to the specified GCS bucket with its computed base64 encoded SHA256 checksum
 // stored in the object metadata.
 func (gcs *GoogleCloudStorage) PutObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
-	bucketName := soi.Version.Spec.ModuleConfigRef.StorageConfig.GCS.Bucket
+	bucketName := soi.StorageConfig.GCS.Bucket
 	objectName := *soi.FilePath
 
 	bucket := gcs.client.Bucket(bucketName)
@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	versionv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	storagetypes "github.com/tonedefdev/opendepot/pkg/storage/types"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+const (
+	// OCIArtifactTypeModule is the artifact type of the manifest of a module archive.
+	OCIArtifactTypeModule = "application/vnd.opendepot.module.v1"
+	// OCIArtifactTypeProvider is the artifact type of the manifest of a provider package.
+	OCIArtifactTypeProvider = "application/vnd.opendepot.provider.v1"
+	// OCIMediaTypeModuleZip is the media type of the layer holding a zip module archive.
+	OCIMediaTypeModuleZip = "application/vnd.opendepot.module.layer.v1.zip"
+	// OCIMediaTypeModuleTarGzip is the media type of the layer holding a tar.gz module archive.
+	OCIMediaTypeModuleTarGzip = "application/vnd.opendepot.module.layer.v1.tar+gzip"
+	// OCIMediaTypeProviderZip is the media type of the layer holding a provider package.
+	OCIMediaTypeProviderZip = "application/vnd.opendepot.provider.layer.v1.zip"
+
+	// OCIAnnotationName is the manifest annotation holding the module or provider name.
+	OCIAnnotationName = "io.opendepot.name"
+	// OCIAnnotationOS is the manifest annotation holding the operating system of a provider package.
+	OCIAnnotationOS = "io.opendepot.os"
+	// OCIAnnotationArch is the manifest annotation holding the architecture of a provider package.
+	OCIAnnotationArch = "io.opendepot.arch"
+	// OCIAnnotationChecksum is the manifest annotation holding the base64 encoded SHA256 checksum of the archive.
+	OCIAnnotationChecksum = "io.opendepot.checksum.sha256"
+)
+
+// ociTagInvalidChars matches the characters that are not allowed in an OCI tag.
+var ociTagInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// OCIClientOptions configures an OCI storage client for an OCI distribution registry.
+type OCIClientOptions struct {
+	// The registry host, ie: 'harbor.example.com'.
+	Registry string
+	// The repository prefix artifacts are pushed under, ie: 'opendepot'.
+	Repository string
+	// Whether to connect to the registry over HTTP instead of HTTPS.
+	PlainHTTP bool
+	// PEM encoded certificates trusted in addition to the system roots when connecting to the registry.
+	CABundle []byte
+	// Basic auth credentials. When Username is empty the registry is accessed anonymously.
+	Username string
+	Password string
+}
+
+// OCIStorage stores Versions as OCI artifacts in an OCI distribution registry. Each module or provider is a
+// repository under the configured repository prefix and each file is a tag holding a single layer.
+type OCIStorage struct {
+	client     *auth.Client
+	plainHTTP  bool
+	registry   string
+	repository string
+}
+
+// NewClient initializes a new OCI registry storage client.
+func (storage *OCIStorage) NewClient(options *OCIClientOptions) error {
+	if options == nil || options.Registry == "" || options.Repository == "" {
+		return fmt.Errorf("the OCI registry and repository are required")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(options.CABundle) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		if !rootCAs.AppendCertsFromPEM(options.CABundle) {
+			return fmt.Errorf("unable to parse CA bundle: no PEM encoded certificates found")
+		}
+
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	}
+
+	storage.client = &auth.Client{
+		Client: &http.Client{Transport: retry.NewTransport(transport)},
+		Cache:  auth.NewCache(),
+	}
+
+	if options.Username != "" {
+		storage.client.Credential = auth.StaticCredential(options.Registry, auth.Credential{
+			Username: options.Username,
+			Password: options.Password,
+		})
+	}
+
+	storage.plainHTTP = options.PlainHTTP
+	storage.registry = options.Registry
+	storage.repository = strings.Trim(options.Repository, "/")
+	return nil
+}
+
+// GetObject retrieves the layer of the artifact tagged for the file and returns an io.Reader to stream it.
+func (storage *OCIStorage) GetObject(ctx context.Context, soi *storagetypes.StorageObjectInput) (io.Reader, error) {
+	repository, tag, err := storage.reference(soi)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := fetchOCIManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Layers) != 1 {
+		return nil, fmt.Errorf("the artifact '%s:%s' has %d layers, expected 1", repository.Reference.Repository, tag, len(manifest.Layers))
+	}
+
+	return repository.Fetch(ctx, manifest.Layers[0])
+}
+
+// GetObjectChecksum retrieves the sha256 checksum from the annotations of the artifact tagged for the file and sets it
+// on the soi receiver's field 'ObjectChecksum'. If the tag does not exist the soi receiver's field 'FileExists' is left false.
+func (storage *OCIStorage) GetObjectChecksum(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
+	repository, tag, err := storage.reference(soi)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := fetchOCIManifest(ctx, repository, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if checksum, ok := manifest.Annotations[OCIAnnotationChecksum]; ok {
+		soi.ObjectChecksum = &checksum
+	}
+
+	soi.FileExists = true
+	return nil
+}
+
+// DeleteObject deletes the manifest tagged for the file. Registries garbage collect the unreferenced layer.
+func (storage *OCIStorage) DeleteObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
+	repository, tag, err := storage.reference(soi)
+	if err != nil {
+		return err
+	}
+
+	descriptor, err := repository.Resolve(ctx, tag)
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := repository.Delete(ctx, descriptor); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// ListObjects lists the tags of the repository of the module or provider whose directory is received by soi. The
+// path of each tag is the directory joined with the tag, and it is last modified when its manifest was created.
+func (storage *OCIStorage) ListObjects(ctx context.Context, soi *storagetypes.StorageObjectInput) ([]storagetypes.StorageObject, error) {
+	if soi.FilePath == nil {
+		return nil, fmt.Errorf("the file path is required")
+	}
+
+	directory := strings.Trim(*soi.FilePath, "/")
+	repositoryPath := path.Join(directory, "_")
+	repository, _, err := storage.reference(&storagetypes.StorageObjectInput{FilePath: &repositoryPath})
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repository.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	var errorResponse *errcode.ErrorResponse
+	if errors.Is(err, errdef.ErrNotFound) || (errors.As(err, &errorResponse) && errorResponse.StatusCode == http.StatusNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]storagetypes.StorageObject, 0, len(tags))
+	for _, tag := range tags {
+		listed := storagetypes.StorageObject{Path: path.Join(directory, tag)}
+		manifest, err := fetchOCIManifest(ctx, repository, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if created, err := time.Parse(time.RFC3339, manifest.Annotations[ocispec.AnnotationCreated]); err == nil {
+			listed.LastModified = created
+		}
+		objects = append(objects, listed)
+	}
+
+	return objects, nil
+}
+
+// PutObject pushes the file as the single layer of an artifact and tags it with the file name. The manifest is
+// annotated with the name, version, platform and base64 encoded SHA256 checksum of the file.
+func (storage *OCIStorage) PutObject(ctx context.Context, soi *storagetypes.StorageObjectInput) error {
+	repository, tag, err := storage.reference(soi)
+	if err != nil {
+		return err
+	}
+
+	var body io.ReadSeeker
+	if soi.FileReader != nil {
+		body = soi.FileReader
+	} else {
+		body = bytes.NewReader(soi.FileBytes)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return fmt.Errorf("unable to compute the digest of '%s': %w", *soi.FilePath, err)
+	}
+
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	sum := hash.Sum(nil)
+	checksum := base64.StdEncoding.EncodeToString(sum)
+	if soi.ArchiveChecksum != nil && *soi.ArchiveChecksum != checksum {
+		return fmt.Errorf("checksum mismatch for '%s': want %s, computed %s", *soi.FilePath, *soi.ArchiveChecksum, checksum)
+	}
+
+	artifactType, mediaType := ociMediaTypes(soi.Version, *soi.FilePath)
+	fileName := path.Base(*soi.FilePath)
+	layer := ocispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest.NewDigestFromBytes(digest.SHA256, sum),
+		Size:        size,
+		Annotations: map[string]string{ocispec.AnnotationTitle: fileName},
+	}
+
+	exists, err := repository.Exists(ctx, layer)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := repository.Push(ctx, layer, body); err != nil {
+			return fmt.Errorf("unable to push '%s': %w", *soi.FilePath, err)
+		}
+	}
+
+	annotations := map[string]string{
+		OCIAnnotationChecksum:   checksum,
+		OCIAnnotationName:       path.Dir(*soi.FilePath),
+		ocispec.AnnotationTitle: fileName,
+	}
+
+	if soi.Version != nil {
+		annotations[ocispec.AnnotationVersion] = soi.Version.Spec.Version
+		if soi.Version.Spec.OperatingSystem != "" {
+			annotations[OCIAnnotationOS] = soi.Version.Spec.OperatingSystem
+		}
+		if soi.Version.Spec.Architecture != "" {
+			annotations[OCIAnnotationArch] = soi.Version.Spec.Architecture
+		}
+	}
+
+	manifest, err := oras.PackManifest(ctx, repository, oras.PackManifestVersion1_1, artifactType, oras.PackManifestOptions{
+		Layers:              []ocispec.Descriptor{layer},
+		ManifestAnnotations: annotations,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to push the manifest of '%s': %w", *soi.FilePath, err)
+	}
+
+	if err := repository.Tag(ctx, manifest, tag); err != nil {
+		return fmt.Errorf("unable to tag '%s': %w", *soi.FilePath, err)
+	}
+
+	return nil
+}
+
+// reference returns the repository and tag of the file at soi.FilePath. The directory of the file path, which is the
+// module or provider name, is appended to the repository prefix and the file name becomes the tag.
+func (storage *OCIStorage) reference(soi *storagetypes.StorageObjectInput) (*remote.Repository, string, error) {
+	if soi.FilePath == nil {
+		return nil, "", fmt.Errorf("the file path is required")
+	}
+
+	name := strings.Trim(path.Dir(*soi.FilePath), "/.")
+	reference := fmt.Sprintf("%s/%s", storage.registry, storage.repository)
+	if name != "" {
+		reference = fmt.Sprintf("%s/%s", reference, strings.ToLower(name))
+	}
+
+	repository, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, "", err
+	}
+
+	repository.Client = storage.client
+	repository.PlainHTTP = storage.plainHTTP
+	return repository, OCITag(path.Base(*soi.FilePath)), nil
+}
+
+// OCITag returns the tag a file is stored under. Characters that are not allowed in a tag are replaced with '_' and
+// the tag is truncated to 128 characters.
+func OCITag(fileName string) string {
+	tag := ociTagInvalidChars.ReplaceAllString(fileName, "_")
+	if strings.HasPrefix(tag, ".") || strings.HasPrefix(tag, "-") {
+		tag = "_" + tag[1:]
+	}
+
+	if len(tag) > 128 {
+		tag = tag[:128]
+	}
+
+	return tag
+}
+
+// ociMediaTypes returns the artifact type and layer media type of a file.
+func ociMediaTypes(version *versionv1alpha1.Version, filePath string) (string, string) {
+	if version != nil && version.Spec.Type == versionv1alpha1.OpenDepotProvider {
+		return OCIArtifactTypeProvider, OCIMediaTypeProviderZip
+	}
+
+	if strings.HasSuffix(filePath, ".zip") {
+		return OCIArtifactTypeModule, OCIMediaTypeModuleZip
+	}
+
+	return OCIArtifactTypeModule, OCIMediaTypeModuleTarGzip
+}
+
+// fetchOCIManifest fetches and decodes the manifest tagged tag.
+func fetchOCIManifest(ctx context.Context, repository *remote.Repository, tag string) (*ocispec.Manifest, error) {
+	_, manifestBytes, err := oras.FetchBytes(ctx, repository, tag, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to decode the manifest of '%s:%s': %w", repository.Reference.Repository, tag, err)
+	}
+
+	return &manifest, nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path"
+	"slices"
 
+	versionv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 	"github.com/tonedefdev/opendepot/pkg/storage/types"
 )
 
@@ -19,6 +22,9 @@ type Storage interface {
 	GetObjectChecksum(ctx context.Context, soi *types.StorageObjectInput) error
 	// Puts a new file into the configured storage system.
 	PutObject(ctx context.Context, soi *types.StorageObjectInput) error
+	// Lists the files stored under the path prefix received by the soi receiver's field `FilePath`. A prefix
+	// without files returns an empty list.
+	ListObjects(ctx context.Context, soi *types.StorageObjectInput) ([]types.StorageObject, error)
 }
 
 // RemoveTrailingSlash removes trailing slash characters from the string received by s.
@@ -37,3 +43,113 @@ func RemoveTrailingSlash(s *string) (*string, error) {
 
 	return s, nil
 }
+
+// StorageReplica is one backend of a storage config.
+type StorageReplica struct {
+	// The name of the replica. The backend configured on the storage config itself is named 'primary'.
+	Name string
+	// A storage config holding only the backend of the replica.
+	StorageConfig *versionv1alpha1.StorageConfig
+}
+
+// StorageReplicas returns the primary backend of storageConfig followed by its replicas in their declared order. The
+// encryption of storageConfig applies to every replica. An error is returned when a replica has no backend or more
+// than one, or when replica names are not unique.
+func StorageReplicas(storageConfig *versionv1alpha1.StorageConfig) ([]StorageReplica, error) {
+	if storageConfig == nil {
+		return nil, fmt.Errorf("the storage config was nil")
+	}
+
+	primary := *storageConfig
+	primary.Replicas = nil
+	primary.ReadOrder = nil
+	replicas := []StorageReplica{{Name: versionv1alpha1.OpenDepotStoragePrimaryReplica, StorageConfig: &primary}}
+
+	for _, replica := range storageConfig.Replicas {
+		if replica.Name == "" {
+			return nil, fmt.Errorf("storage replicas must have a name")
+		}
+
+		if slices.ContainsFunc(replicas, func(existing StorageReplica) bool { return existing.Name == replica.Name }) {
+			return nil, fmt.Errorf("the storage replica name '%s' is used more than once", replica.Name)
+		}
+
+		replicaConfig := &versionv1alpha1.StorageConfig{
+			AzureStorage: replica.AzureStorage,
+			FileSystem:   replica.FileSystem,
+			S3:           replica.S3,
+			GCS:          replica.GCS,
+			OCI:          replica.OCI,
+			Encryption:   storageConfig.Encryption,
+		}
+
+		backends := 0
+		for _, configured := range []bool{replica.AzureStorage != nil, replica.FileSystem != nil, replica.S3 != nil, replica.GCS != nil, replica.OCI != nil} {
+			if configured {
+				backends++
+			}
+		}
+
+		if backends != 1 {
+			return nil, fmt.Errorf("the storage replica '%s' must configure exactly one backend", replica.Name)
+		}
+
+		replicas = append(replicas, StorageReplica{Name: replica.Name, StorageConfig: replicaConfig})
+	}
+
+	return replicas, nil
+}
+
+// ReadOrder returns the replicas of storageConfig in the order they are read from: the replicas named by its
+// ReadOrder first, followed by the remaining replicas in their declared order.
+func ReadOrder(storageConfig *versionv1alpha1.StorageConfig) ([]StorageReplica, error) {
+	replicas, err := StorageReplicas(storageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]StorageReplica, 0, len(replicas))
+	for _, name := range storageConfig.ReadOrder {
+		index := slices.IndexFunc(replicas, func(replica StorageReplica) bool { return replica.Name == name })
+		if index < 0 {
+			return nil, fmt.Errorf("the read order names the unknown storage replica '%s'", name)
+		}
+
+		ordered = append(ordered, replicas[index])
+		replicas = slices.Delete(replicas, index, index+1)
+	}
+
+	return append(ordered, replicas...), nil
+}
+
+// StoredObjectPath returns the path ListObjects lists the object at objectPath under in the backend of storageConfig.
+// OCI registries list files by their tag, so the file name of an OCI object path is replaced with its tag.
+func StoredObjectPath(storageConfig *versionv1alpha1.StorageConfig, objectPath string) string {
+	if storageConfig.OCI != nil {
+		return path.Join(path.Dir(objectPath), OCITag(path.Base(objectPath)))
+	}
+
+	return objectPath
+}
+
+// ObjectPath returns the path the file fileName of the module or provider name is stored under in the backend of
+// storageConfig. S3 keys and filesystem directories prefix the path.
+func ObjectPath(storageConfig *versionv1alpha1.StorageConfig, name string, fileName string) (string, error) {
+	if storageConfig.S3 != nil && storageConfig.S3.Key != nil && *storageConfig.S3.Key != "" {
+		sanitized, err := RemoveTrailingSlash(storageConfig.S3.Key)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/%s/%s", *sanitized, name, fileName), nil
+	}
+
+	if storageConfig.FileSystem != nil && storageConfig.FileSystem.DirectoryPath != nil && *storageConfig.FileSystem.DirectoryPath != "" {
+		sanitized, err := RemoveTrailingSlash(storageConfig.FileSystem.DirectoryPath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/%s/%s", *sanitized, name, fileName), nil
+	}
+
+	return fmt.Sprintf("%s/%s", name, fileName), nil
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"io"
+	"time"
 
 	versionv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 )
@@ -29,11 +30,26 @@ type StorageObjectInput struct {
 	FileReader io.ReadSeeker
 	// A flag set to true when the storage system determines the file exists.
 	FileExists bool
+	// A flag set to true by encrypted storage when the data key of the file is not wrapped by the primary key of the
+	// keyring, or when the file was stored without the checksum of its plaintext in its header. The file should be put
+	// again to rotate it to the primary key.
+	EncryptionKeyStale bool
 	// The file path of the storage object. This may be a reference to a cloud storage path such as an `AWS S3 Bucket` key or an `Azure Storage Blob`.
 	// It may also refer to a filesystem path like `/foo/bar` on *nix based systems or `C:\foo\bar` for Windows.
 	FilePath *string
 	// The sha256 checksum of the object from the storage system as a base64 encoded string.
 	ObjectChecksum *string
+	// The resolved storage config of the storage system, whether it is set on the Version or comes from a
+	// StorageProfile. Storage systems read their bucket, container and account from it.
+	StorageConfig *versionv1alpha1.StorageConfig
 	// The Version spec of the object Version.
 	Version *versionv1alpha1.Version
 }
+
+// StorageObject is an object listed from a storage system.
+type StorageObject struct {
+	// The path of the object, in the form the FilePath field of StorageObjectInput takes.
+	Path string
+	// The time the object was last modified. Zero when the storage system does not record it.
+	LastModified time.Time
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 
 	"golang.org/x/mod/semver"
 
@@ -143,3 +144,15 @@ func SanitizeVersion(version string) string {
 	}
 	return version
 }
+
+// ProviderVersionResourceName returns the name of the Version resource of a provider version and platform, ie:
+// 'aws-5-80-0-linux-amd64'. The Provider controller creates Versions under this name and the server's pull-through
+// cache waits for them by it.
+func ProviderVersionResourceName(providerName, version, osName, arch string) string {
+	raw := fmt.Sprintf("%s-%s-%s-%s", providerName, version, osName, arch)
+	raw = strings.ToLower(raw)
+	raw = strings.ReplaceAll(raw, ".", "-")
+	raw = strings.ReplaceAll(raw, "_", "-")
+	raw = strings.ReplaceAll(raw, "/", "-")
+	return raw
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command import reads OpenTofu and Terraform configuration directories and lock files and writes a Depot that
+// mirrors the providers and modules they use, or patches the provider and module configs of an existing Depot.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/services/depot/internal/importer"
+)
+
+// stringSliceFlag is a flag that may be repeated or given a comma-separated list.
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+
+	return nil
+}
+
+// depotManifest is the Depot written by the command. Only the metadata that belongs in a manifest is kept.
+type depotManifest struct {
+	APIVersion string                      `json:"apiVersion"`
+	Kind       string                      `json:"kind"`
+	Metadata   depotMetadata               `json:"metadata"`
+	Spec       opendepotv1alpha1.DepotSpec `json:"spec"`
+}
+
+type depotMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func main() {
+	var dirs, lockFiles, platforms stringSliceFlag
+	var depotFile, name, namespace, output string
+	var resolvePlatforms bool
+	flag.Var(&dirs, "dir", "A configuration directory to import, including its lock file and the directories below it. "+
+		"May be repeated.")
+	flag.Var(&lockFiles, "lock-file", "A '.terraform.lock.hcl' file to import. May be repeated.")
+	flag.Var(&platforms, "platform", "An '{os}_{arch}' platform, e.g. 'linux_amd64', that providers are limited to. "+
+		"May be repeated. When omitted, every platform seen in the lock files is imported.")
+	flag.StringVar(&depotFile, "depot", "", "An existing Depot manifest to patch. When omitted, a new Depot is written.")
+	flag.StringVar(&name, "name", "", "The name of the Depot. Overrides the name of the patched Depot.")
+	flag.StringVar(&namespace, "namespace", "", "The namespace of the Depot. Overrides the namespace of the patched Depot.")
+	flag.StringVar(&output, "output", "", "The file the Depot is written to. Defaults to stdout.")
+	flag.BoolVar(&resolvePlatforms, "resolve-platforms", true, "Look up the platform of each lock file hash in the "+
+		"upstream registry's SHA256SUMS. Disable to import providers for the -platform list only.")
+	flag.Parse()
+
+	if err := run(dirs, lockFiles, platforms, depotFile, name, namespace, output, resolvePlatforms); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dirs, lockFiles, platforms []string, depotFile, name, namespace, output string, resolvePlatforms bool) error {
+	if len(dirs) == 0 && len(lockFiles) == 0 {
+		return fmt.Errorf("at least one -dir or -lock-file is required")
+	}
+
+	for _, platform := range platforms {
+		if _, err := importer.ParsePlatform(platform); err != nil {
+			return err
+		}
+	}
+
+	manifest := depotManifest{
+		APIVersion: opendepotv1alpha1.GroupVersion.String(),
+		Kind:       "Depot",
+	}
+
+	if depotFile != "" {
+		data, err := os.ReadFile(depotFile)
+		if err != nil {
+			return fmt.Errorf("unable to read Depot manifest: %w", err)
+		}
+
+		if err := yaml.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("unable to parse Depot manifest '%s': %w", depotFile, err)
+		}
+	}
+
+	if name != "" {
+		manifest.Metadata.Name = name
+	}
+
+	if namespace != "" {
+		manifest.Metadata.Namespace = namespace
+	}
+
+	if manifest.Metadata.Name == "" {
+		return fmt.Errorf("-name is required when -depot is not set")
+	}
+
+	imports := importer.New(importer.Options{
+		Platforms:        platforms,
+		ResolvePlatforms: resolvePlatforms,
+	})
+
+	for _, dir := range dirs {
+		if err := imports.ImportDir(dir); err != nil {
+			return fmt.Errorf("unable to import directory '%s': %w", dir, err)
+		}
+	}
+
+	for _, lockFile := range lockFiles {
+		if err := imports.ImportLockFile(lockFile); err != nil {
+			return fmt.Errorf("unable to import lock file '%s': %w", lockFile, err)
+		}
+	}
+
+	providerConfigs, err := imports.ProviderConfigs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	importer.PatchDepotSpec(&manifest.Spec, providerConfigs, imports.ModuleConfigs())
+
+	for _, warning := range imports.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("unable to marshal Depot: %w", err)
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(output, data, 0o644)
+}
@@ -61,12 +61,15 @@ func main() {
 	var webhookCertPath, webhookCertName, webhookCertKey string
 	var enableLeaderElection bool
 	var probeAddr string
+	var webhookReceiverAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8084", "The address the probe endpoint binds to.")
+	flag.StringVar(&webhookReceiverAddr, "webhook-receiver-bind-address", "0", "The address the repository webhook "+
+		"receiver binds to, e.g. :8085. Leave as 0 to disable the webhook receiver.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -203,14 +206,26 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := (&controller.DepotReconciler{
+	depotReconciler := &controller.DepotReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		Log:    logger,
-	}).SetupWithManager(mgr); err != nil {
+	}
+	if err := depotReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Depot")
 		os.Exit(1)
 	}
+
+	if webhookReceiverAddr != "0" && webhookReceiverAddr != "" {
+		if err := mgr.Add(&controller.WebhookReceiver{
+			BindAddress: webhookReceiverAddr,
+			Log:         logger.WithName("webhook-receiver"),
+			Reconciler:  depotReconciler,
+		}); err != nil {
+			setupLog.Error(err, "unable to add webhook receiver to manager")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -18,10 +18,7 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"slices"
 	"time"
 
@@ -33,14 +30,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
-)
-
-const (
-	hashicorpReleasesAPI = "https://api.releases.hashicorp.com"
+	"github.com/tonedefdev/opendepot/pkg/registry"
 )
 
 // Depot reconciles a Depot object
@@ -48,6 +44,9 @@ type DepotReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// moduleSyncer syncs a single module config of a Depot. It defaults to syncModule.
+	moduleSyncer func(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error)
 }
 
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=depots,verbs=get;list;watch;create;update;patch;delete
@@ -77,173 +76,52 @@ func (r *DepotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		"depot", depot.ObjectMeta.Name,
 	)
 
-	var managedModules []string
+	// Modules requested by webhooks are synced on their own instead of with a full sync of the Depot, unless the spec
+	// changed since the last full sync. The full sync then syncs them along with every other module, since the
+	// generation change that requested it is not delivered again.
+	requested := webhookSyncModules(&depot)
+	if len(requested) > 0 && depot.Status.ObservedGeneration == depot.Generation {
+		return r.reconcileWebhookSyncs(ctx, &depot, requested)
+	}
+	syncedGeneration := depot.Generation
+
+	// Modules whose credentials exhausted the GitHub API rate limit are deferred until it resets, while modules using
+	// other credentials are still synced.
+	githubRateLimits := slices.Clone(depot.Status.GithubRateLimits)
+	var retryAfter time.Duration
+	var managedModules, deferredModules []string
 	if len(depot.Spec.ModuleConfigs) > 0 {
 		for _, moduleConfig := range depot.Spec.ModuleConfigs {
-			// Set global configs if not set on module config
-			if moduleConfig.StorageConfig == nil && depot.Spec.GlobalConfig != nil {
-				moduleConfig.StorageConfig = depot.Spec.GlobalConfig.StorageConfig
-			}
-
-			if moduleConfig.GithubClientConfig == nil && depot.Spec.GlobalConfig != nil {
-				moduleConfig.GithubClientConfig = depot.Spec.GlobalConfig.GithubClientConfig
-			}
-
-			if moduleConfig.FileFormat == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
-				moduleConfig.FileFormat = depot.Spec.GlobalConfig.ModuleConfig.FileFormat
-			}
-
-			if moduleConfig.Immutable == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
-				moduleConfig.Immutable = depot.Spec.GlobalConfig.ModuleConfig.Immutable
-			}
-
-			if moduleConfig.RepoUrl == nil {
-				repoUrl := fmt.Sprintf("https://github.com/%s/%s", moduleConfig.RepoOwner, *moduleConfig.Name)
-				moduleConfig.RepoUrl = &repoUrl
-			}
-
-			module := opendepotv1alpha1.Module{
-				ObjectMeta: v1.ObjectMeta{
-					Name:      *moduleConfig.Name,
-					Namespace: req.Namespace,
-				},
-				Spec: opendepotv1alpha1.ModuleSpec{
-					ModuleConfig: moduleConfig,
-				},
-			}
-
-			moduleObject := client.ObjectKey{
-				Name:      module.ObjectMeta.Name,
-				Namespace: module.ObjectMeta.Namespace,
-			}
-
-			var githubClient *github.Client
-
-			useAuthClient := false
-			if module.Spec.ModuleConfig.GithubClientConfig != nil {
-				useAuthClient = module.Spec.ModuleConfig.GithubClientConfig.UseAuthenticatedClient
-			}
-
-			var githubConfig *opendepotGithub.GithubClientConfig
-			if useAuthClient {
-				githubConfig, err = opendepotGithub.GetGithubApplicationSecret(ctx, r.Client, depot.Namespace)
-				if err != nil {
-					return ctrl.Result{}, err
-				}
-			}
-
-			authGithubClient, err := opendepotGithub.CreateGithubClient(ctx, useAuthClient, githubConfig)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
-
-			githubClient = authGithubClient
-			opt := &github.ListOptions{
-				Page:    1,
-				PerPage: 100,
-			}
+			moduleConfig = applyGlobalModuleConfig(&depot, moduleConfig)
+			managedModules = append(managedModules, *moduleConfig.Name)
 
-			constraints, err := version.NewConstraint(moduleConfig.VersionConstraints)
+			moduleRetryAfter, deferred, err := r.syncModuleWithinRateLimit(ctx, &depot, moduleConfig, &githubRateLimits)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			var matchedVersions []string
-			for {
-				releases, resp, err := githubClient.Repositories.ListReleases(ctx, moduleConfig.RepoOwner, *moduleConfig.Name, opt)
-				if err != nil {
-					return ctrl.Result{}, err
-				}
-
-				if releases == nil || resp == nil {
-					return ctrl.Result{}, fmt.Errorf("releases was nil")
+			if deferred {
+				r.Log.Info("GitHub API rate limit exhausted: deferring module sync until reset",
+					"depot", depot.ObjectMeta.Name,
+					"module", *moduleConfig.Name,
+					"retryAfter", moduleRetryAfter,
+				)
+				deferredModules = append(deferredModules, *moduleConfig.Name)
+				if retryAfter == 0 || moduleRetryAfter < retryAfter {
+					retryAfter = moduleRetryAfter
 				}
-
-				for _, release := range releases {
-					if release.TagName == nil {
-						continue
-					}
-
-					releaseVersion, err := version.NewVersion(*release.TagName)
-					if err != nil {
-						r.Log.V(5).Info("Skipping non-semver release tag", "tag", *release.TagName)
-						continue
-					}
-
-					// Constraints returned from version.NewConstraint use AND semantics,
-					// so a release must satisfy the full expression (e.g. >=6.0.0, <=7.0.0).
-					if !constraints.Check(releaseVersion) {
-						continue
-					}
-
-					if slices.Contains(matchedVersions, releaseVersion.String()) {
-						continue
-					}
-
-					matchedVersions = append(matchedVersions, releaseVersion.String())
-				}
-
-				if resp.NextPage == 0 {
-					break
-				}
-
-				opt.Page = resp.NextPage
-			}
-
-			r.Log.Info("Matched versions for module", "module", moduleConfig.Name, "versions", matchedVersions)
-
-			var versions []opendepotv1alpha1.ModuleVersion
-			for _, version := range matchedVersions {
-				moduleVersion := opendepotv1alpha1.ModuleVersion{
-					Version: version,
-				}
-				versions = append(versions, moduleVersion)
 			}
-
-			module.Spec.Versions = versions
-
-			var currentModule opendepotv1alpha1.Module
-			err = r.Get(ctx, moduleObject, &currentModule)
-			if err != nil {
-				if !errors.IsNotFound(err) {
-					return ctrl.Result{}, err
-				}
-
-				if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-					if err := r.Create(ctx, &module); err != nil {
-						return err
-					}
-					return nil
-				}); err != nil {
-					return ctrl.Result{}, err
-				}
-			} else {
-				if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-					if err := r.Get(ctx, moduleObject, &currentModule); err != nil {
-						return err
-					}
-
-					currentModule.Spec.ModuleConfig = moduleConfig
-					currentModule.Spec.Versions = module.Spec.Versions
-					if err := r.Update(ctx, &currentModule); err != nil {
-						return err
-					}
-					return nil
-				}); err != nil {
-					return ctrl.Result{}, err
-				}
-			}
-
-			managedModules = append(managedModules, *moduleConfig.Name)
 		}
 	}
+	rateLimited := len(deferredModules) > 0
 
 	var managedProviders []string
 	if len(depot.Spec.ProviderConfigs) > 0 {
 		for _, providerConfig := range depot.Spec.ProviderConfigs {
 			// Apply global storage config if not set on this provider config.
-			if providerConfig.StorageConfig == nil && depot.Spec.GlobalConfig != nil {
+			if providerConfig.StorageConfig == nil && providerConfig.StorageProfileName == nil && depot.Spec.GlobalConfig != nil {
 				providerConfig.StorageConfig = depot.Spec.GlobalConfig.StorageConfig
+				providerConfig.StorageProfileName = depot.Spec.GlobalConfig.StorageProfileName
 			}
 
 			providerName := ""
@@ -255,20 +133,18 @@ func (r *DepotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				return ctrl.Result{}, fmt.Errorf("provider config name is required")
 			}
 
-			matchedVersions, err := r.listHashiCorpProviderVersions(ctx, providerName, providerConfig.VersionConstraints)
+			registryClient, err := registry.GetProviderRegistryClient(ctx, r.Client, req.Namespace, providerConfig)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Log.Info("Matched versions for provider", "provider", providerName, "versions", matchedVersions)
-
-			var providerVersions []opendepotv1alpha1.ProviderVersion
-			for _, v := range matchedVersions {
-				providerVersions = append(providerVersions, opendepotv1alpha1.ProviderVersion{
-					Version: v,
-				})
+			providerVersions, err := r.listRegistryProviderVersions(ctx, registryClient, providerName, providerConfig)
+			if err != nil {
+				return ctrl.Result{}, err
 			}
 
+			r.Log.Info("Matched versions for provider", "provider", providerName, "versions", len(providerVersions))
+
 			provider := opendepotv1alpha1.Provider{
 				ObjectMeta: v1.ObjectMeta{
 					Name:      providerName,
@@ -321,6 +197,12 @@ func (r *DepotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
+	if len(requested) > 0 {
+		if err := r.clearWebhookSyncs(ctx, &depot, requested, deferredModules); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		if err := r.Get(ctx, req.NamespacedName, &depot); err != nil {
 			return err
@@ -328,6 +210,11 @@ func (r *DepotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 		depot.Status.Modules = managedModules
 		depot.Status.Providers = managedProviders
+		depot.Status.GithubRateLimits = pruneRateLimits(githubRateLimits, time.Now())
+		// A sync that deferred modules is run in full again once the rate limit resets.
+		if len(deferredModules) == 0 {
+			depot.Status.ObservedGeneration = syncedGeneration
+		}
 		if err := r.Status().Update(ctx, &depot); err != nil {
 			return err
 		}
@@ -337,148 +224,354 @@ func (r *DepotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, err
 	}
 
+	var pollingInterval time.Duration
 	if depot.Spec.PollingIntervalMinutes != nil {
-		return ctrl.Result{RequeueAfter: time.Duration(*depot.Spec.PollingIntervalMinutes) * time.Minute}, nil
+		pollingInterval = time.Duration(*depot.Spec.PollingIntervalMinutes) * time.Minute
+	}
+
+	// Retry deferred module syncs once the rate limit resets, unless the next poll comes sooner.
+	if rateLimited && (pollingInterval == 0 || retryAfter < pollingInterval) {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	if pollingInterval > 0 {
+		return ctrl.Result{RequeueAfter: pollingInterval}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
-// hashicorpReleaseListItem is a single release entry returned by the HashiCorp Releases list endpoint.
-type hashicorpReleaseListItem struct {
-	Version          string `json:"version"`
-	TimestampCreated string `json:"timestamp_created"`
+// applyGlobalModuleConfig returns moduleConfig with any unset fields populated from the Depot's global config
+// and with a default repository URL when none is set.
+func applyGlobalModuleConfig(depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) opendepotv1alpha1.ModuleConfig {
+	if moduleConfig.StorageConfig == nil && moduleConfig.StorageProfileName == nil && depot.Spec.GlobalConfig != nil {
+		moduleConfig.StorageConfig = depot.Spec.GlobalConfig.StorageConfig
+		moduleConfig.StorageProfileName = depot.Spec.GlobalConfig.StorageProfileName
+	}
+
+	if moduleConfig.GithubClientConfig == nil && depot.Spec.GlobalConfig != nil {
+		moduleConfig.GithubClientConfig = depot.Spec.GlobalConfig.GithubClientConfig
+	}
+
+	if moduleConfig.FileFormat == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.FileFormat = depot.Spec.GlobalConfig.ModuleConfig.FileFormat
+	}
+
+	if moduleConfig.Immutable == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.Immutable = depot.Spec.GlobalConfig.ModuleConfig.Immutable
+	}
+
+	if moduleConfig.DiscoveryMode == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.DiscoveryMode = depot.Spec.GlobalConfig.ModuleConfig.DiscoveryMode
+	}
+
+	if moduleConfig.IncludeDrafts == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.IncludeDrafts = depot.Spec.GlobalConfig.ModuleConfig.IncludeDrafts
+	}
+
+	if moduleConfig.IncludePrereleases == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.IncludePrereleases = depot.Spec.GlobalConfig.ModuleConfig.IncludePrereleases
+	}
+
+	if moduleConfig.Dependencies == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.Dependencies = depot.Spec.GlobalConfig.ModuleConfig.Dependencies
+	}
+
+	if moduleConfig.ExcludePatterns == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.ExcludePatterns = depot.Spec.GlobalConfig.ModuleConfig.ExcludePatterns
+	}
+
+	if moduleConfig.RetagPolicy == nil && depot.Spec.GlobalConfig != nil && depot.Spec.GlobalConfig.ModuleConfig != nil {
+		moduleConfig.RetagPolicy = depot.Spec.GlobalConfig.ModuleConfig.RetagPolicy
+	}
+
+	if moduleConfig.RepoUrl == nil {
+		repoUrl := opendepotGithub.GetRepositoryURL(moduleConfig.GithubClientConfig, moduleConfig.RepoOwner, *moduleConfig.Name)
+		moduleConfig.RepoUrl = &repoUrl
+	}
+
+	return moduleConfig
 }
 
-// listHashiCorpProviderVersions queries the HashiCorp Releases API and returns all versions
-// that satisfy the given version constraints string.
-func (r *DepotReconciler) listHashiCorpProviderVersions(ctx context.Context, providerName, versionConstraints string) ([]string, error) {
-	constraints, err := version.NewConstraint(versionConstraints)
+// syncModule discovers the versions of a single module config of the Depot and creates or updates its Module resource.
+// The GitHub API rate limit observed while discovering versions is returned.
+func (r *DepotReconciler) syncModule(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error) {
+	module := opendepotv1alpha1.Module{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      *moduleConfig.Name,
+			Namespace: depot.Namespace,
+		},
+		Spec: opendepotv1alpha1.ModuleSpec{
+			ModuleConfig: moduleConfig,
+		},
+	}
+
+	moduleObject := client.ObjectKey{
+		Name:      module.ObjectMeta.Name,
+		Namespace: module.ObjectMeta.Namespace,
+	}
+
+	var githubClient *github.Client
+
+	useAuthClient := false
+	if module.Spec.ModuleConfig.GithubClientConfig != nil {
+		useAuthClient = module.Spec.ModuleConfig.GithubClientConfig.UseAuthenticatedClient
+	}
+
+	githubConfig, err := opendepotGithub.GetGithubClientConfig(ctx, r.Client, depot.Namespace, moduleConfig.RepoOwner, module.Spec.ModuleConfig.GithubClientConfig)
 	if err != nil {
-		return nil, fmt.Errorf("invalid version constraints %q: %w", versionConstraints, err)
+		return github.Rate{}, err
 	}
 
-	candidates := getProviderProductCandidates(providerName)
+	authGithubClient, err := opendepotGithub.CreateGithubClient(ctx, useAuthClient, githubConfig)
+	if err != nil {
+		return github.Rate{}, err
+	}
+
+	githubClient = authGithubClient
+	matchedVersions, rate, err := r.discoverModuleVersions(ctx, githubClient, moduleConfig)
+	if err != nil {
+		return rate, err
+	}
 
-	var releases []hashicorpReleaseListItem
-	var lastErr error
+	r.Log.Info("Matched versions for module", "module", moduleConfig.Name, "versions", matchedVersions)
 
-	for _, productName := range candidates {
-		releases, lastErr = r.fetchHashiCorpReleaseList(ctx, productName)
-		if lastErr == nil {
-			break
+	var versions []opendepotv1alpha1.ModuleVersion
+	for _, version := range matchedVersions {
+		moduleVersion := opendepotv1alpha1.ModuleVersion{
+			Version: version,
+		}
+		versions = append(versions, moduleVersion)
+	}
+
+	module.Spec.Versions = versions
+
+	var currentModule opendepotv1alpha1.Module
+	err = r.Get(ctx, moduleObject, &currentModule)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return rate, err
+		}
+
+		if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			if err := r.Create(ctx, &module); err != nil {
+				return err
+			}
+			return nil
+		}); err != nil {
+			return rate, err
+		}
+	} else {
+		if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			if err := r.Get(ctx, moduleObject, &currentModule); err != nil {
+				return err
+			}
+
+			currentModule.Spec.ModuleConfig = moduleConfig
+			currentModule.Spec.Versions = module.Spec.Versions
+			if err := r.Update(ctx, &currentModule); err != nil {
+				return err
+			}
+			return nil
+		}); err != nil {
+			return rate, err
 		}
 	}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("failed to list provider versions from HashiCorp Releases API for %q: %w", providerName, lastErr)
+	return rate, nil
+}
+
+// discoverModuleVersions returns the versions of a module that satisfy its version constraints. Versions are
+// discovered from the GitHub releases, the git tags, or both, according to the module's discovery mode.
+// The GitHub API rate limit observed on the last response is also returned.
+func (r *DepotReconciler) discoverModuleVersions(ctx context.Context, githubClient *github.Client, moduleConfig opendepotv1alpha1.ModuleConfig) ([]string, github.Rate, error) {
+	var rate github.Rate
+	constraints, err := version.NewConstraint(moduleConfig.VersionConstraints)
+	if err != nil {
+		return nil, rate, err
+	}
+
+	discoveryMode := opendepotv1alpha1.OpenDepotDiscoveryModeReleases
+	if moduleConfig.DiscoveryMode != nil && *moduleConfig.DiscoveryMode != "" {
+		discoveryMode = *moduleConfig.DiscoveryMode
+	}
+
+	includeDrafts := moduleConfig.IncludeDrafts != nil && *moduleConfig.IncludeDrafts
+	includePrereleases := moduleConfig.IncludePrereleases != nil && *moduleConfig.IncludePrereleases
+
+	var candidateTags []string
+	switch discoveryMode {
+	case opendepotv1alpha1.OpenDepotDiscoveryModeReleases:
+		candidateTags, _, rate, err = r.listReleaseTags(ctx, githubClient, moduleConfig, includeDrafts, includePrereleases)
+		if err != nil {
+			return nil, rate, err
+		}
+	case opendepotv1alpha1.OpenDepotDiscoveryModeTags:
+		candidateTags, rate, err = r.listGitTags(ctx, githubClient, moduleConfig)
+		if err != nil {
+			return nil, rate, err
+		}
+	case opendepotv1alpha1.OpenDepotDiscoveryModeReleasesAndTags:
+		var releaseTags, skippedTags, gitTags []string
+		releaseTags, skippedTags, rate, err = r.listReleaseTags(ctx, githubClient, moduleConfig, includeDrafts, includePrereleases)
+		if err != nil {
+			return nil, rate, err
+		}
+
+		gitTags, rate, err = r.listGitTags(ctx, githubClient, moduleConfig)
+		if err != nil {
+			return nil, rate, err
+		}
+
+		// The tags of skipped draft and prerelease releases are also git tags, which must not add them back.
+		gitTags = slices.DeleteFunc(gitTags, func(tag string) bool {
+			return slices.Contains(skippedTags, tag)
+		})
+
+		candidateTags = append(releaseTags, gitTags...)
+	default:
+		return nil, rate, fmt.Errorf("unsupported discoveryMode '%s' for module '%s': must be one of '%s', '%s' or '%s'",
+			discoveryMode,
+			*moduleConfig.Name,
+			opendepotv1alpha1.OpenDepotDiscoveryModeReleases,
+			opendepotv1alpha1.OpenDepotDiscoveryModeTags,
+			opendepotv1alpha1.OpenDepotDiscoveryModeReleasesAndTags,
+		)
 	}
 
-	var matched []string
-	for _, rel := range releases {
-		v, err := version.NewVersion(rel.Version)
+	var matchedVersions []string
+	for _, tag := range candidateTags {
+		tagVersion, err := version.NewVersion(tag)
 		if err != nil {
-			r.Log.V(5).Info("Skipping non-semver provider release", "version", rel.Version)
+			r.Log.V(5).Info("Skipping non-semver tag", "tag", tag)
+			continue
+		}
+
+		if tagVersion.Prerelease() != "" && !includePrereleases {
 			continue
 		}
 
-		if !constraints.Check(v) {
+		// Constraints returned from version.NewConstraint use AND semantics,
+		// so a version must satisfy the full expression (e.g. >=6.0.0, <=7.0.0).
+		if !constraints.Check(tagVersion) {
 			continue
 		}
 
-		if slices.Contains(matched, v.String()) {
+		if slices.Contains(matchedVersions, tagVersion.String()) {
 			continue
 		}
 
-		matched = append(matched, v.String())
+		matchedVersions = append(matchedVersions, tagVersion.String())
 	}
 
-	return matched, nil
+	return matchedVersions, rate, nil
 }
 
-// fetchHashiCorpReleaseList retrieves all release versions from the HashiCorp Releases API for a product,
-// paginating through all pages.
-func (r *DepotReconciler) fetchHashiCorpReleaseList(ctx context.Context, productName string) ([]hashicorpReleaseListItem, error) {
-	var all []hashicorpReleaseListItem
-	pageToken := ""
+// listReleaseTags returns the tag names of the module repository's GitHub releases, the tag names of the releases
+// it skipped and the rate limit observed on the last response. Draft and prerelease releases are skipped unless
+// includeDrafts or includePrereleases is set.
+func (r *DepotReconciler) listReleaseTags(ctx context.Context, githubClient *github.Client, moduleConfig opendepotv1alpha1.ModuleConfig, includeDrafts, includePrereleases bool) ([]string, []string, github.Rate, error) {
+	opt := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
 
+	var rate github.Rate
+	var tags, skippedTags []string
 	for {
-		url := fmt.Sprintf("%s/v1/releases/%s?limit=20", hashicorpReleasesAPI, productName)
-		if pageToken != "" {
-			url += "&after=" + pageToken
+		releases, resp, err := githubClient.Repositories.ListReleases(ctx, moduleConfig.RepoOwner, *moduleConfig.Name, opt)
+		if resp != nil {
+			rate = resp.Rate
 		}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, rate, err
 		}
 
-		httpClient := &http.Client{Timeout: 30 * time.Second}
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("request failed for %q: %w", url, err)
+		if releases == nil || resp == nil {
+			return nil, nil, rate, fmt.Errorf("releases was nil")
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response for %q: %w", url, err)
-		}
+		for _, release := range releases {
+			if release.TagName == nil {
+				continue
+			}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("request to %q failed with status %d", url, resp.StatusCode)
-		}
+			if release.GetDraft() && !includeDrafts {
+				r.Log.V(5).Info("Skipping draft release", "tag", *release.TagName)
+				skippedTags = append(skippedTags, *release.TagName)
+				continue
+			}
 
-		var page []hashicorpReleaseListItem
-		if err := json.Unmarshal(body, &page); err != nil {
-			return nil, fmt.Errorf("failed to parse response from %q: %w", url, err)
-		}
+			if release.GetPrerelease() && !includePrereleases {
+				r.Log.V(5).Info("Skipping prerelease", "tag", *release.TagName)
+				skippedTags = append(skippedTags, *release.TagName)
+				continue
+			}
 
-		all = append(all, page...)
+			tags = append(tags, *release.TagName)
+		}
 
-		// The API returns at most `limit` entries per page. When fewer than
-		// the limit are returned we have reached the end.
-		if len(page) < 20 {
+		if resp.NextPage == 0 {
 			break
 		}
 
-		// Use the timestamp_created of the last item as the cursor for the next page.
-		pageToken = page[len(page)-1].TimestampCreated
+		opt.Page = resp.NextPage
 	}
 
-	return all, nil
+	return tags, skippedTags, rate, nil
 }
 
-// getProviderProductCandidates returns ordered HashiCorp product name candidates for a provider.
-func getProviderProductCandidates(providerName string) []string {
-	if providerName == "" {
-		return nil
+// listGitTags returns the names of the module repository's git tags and the rate limit observed on the last response.
+func (r *DepotReconciler) listGitTags(ctx context.Context, githubClient *github.Client, moduleConfig opendepotv1alpha1.ModuleConfig) ([]string, github.Rate, error) {
+	opt := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
 	}
 
-	seen := map[string]struct{}{}
-	var candidates []string
+	var rate github.Rate
+	var tags []string
+	for {
+		repoTags, resp, err := githubClient.Repositories.ListTags(ctx, moduleConfig.RepoOwner, *moduleConfig.Name, opt)
+		if resp != nil {
+			rate = resp.Rate
+		}
 
-	add := func(s string) {
-		if s == "" {
-			return
+		if err != nil {
+			return nil, rate, err
 		}
-		if _, ok := seen[s]; ok {
-			return
+
+		if repoTags == nil || resp == nil {
+			return nil, rate, fmt.Errorf("tags was nil")
 		}
-		seen[s] = struct{}{}
-		candidates = append(candidates, s)
-	}
 
-	add(providerName)
-	add(fmt.Sprintf("terraform-provider-%s", providerName))
+		for _, tag := range repoTags {
+			if tag.Name == nil {
+				continue
+			}
+
+			tags = append(tags, *tag.Name)
+		}
 
-	return candidates
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return tags, rate, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. Depots are reconciled when their spec changes or a
+// webhook requests a module sync, so removing a handled request or updating the status does not start a full sync.
 func (r *DepotReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	webhookSyncRequested := predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetAnnotations()[opendepotv1alpha1.OpenDepotWebhookSyncAnnotation] != ""
+	})
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&opendepotv1alpha1.Depot{}).
+		For(&opendepotv1alpha1.Depot{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, webhookSyncRequested))).
 		Named("depot").
 		Complete(r)
 }
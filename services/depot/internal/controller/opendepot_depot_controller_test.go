@@ -18,16 +18,31 @@ package controller
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v81/github"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
 )
 
 var _ = Describe("Depot Controller", func() {
@@ -81,4 +96,331 @@ var _ = Describe("Depot Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When discovering module versions", func() {
+		ctx := context.Background()
+
+		var (
+			githubClient *github.Client
+			reconciler   *DepotReconciler
+			server       *httptest.Server
+		)
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v3/repos/defdev/terraform-aws-vpc/releases", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode([]map[string]any{
+					{"tag_name": "v1.0.0"},
+					{"tag_name": "v1.1.0", "prerelease": true},
+					{"tag_name": "v1.2.0", "draft": true},
+					{"tag_name": "v2.0.0"},
+				})
+			})
+			mux.HandleFunc("/api/v3/repos/defdev/terraform-aws-vpc/tags", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode([]map[string]any{
+					{"name": "v1.0.0"},
+					{"name": "v1.0.1"},
+					{"name": "v1.1.0"},
+					{"name": "v1.3.0-rc.1"},
+					{"name": "latest"},
+				})
+			})
+			server = httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+
+			var err error
+			githubClient, err = github.NewClient(nil).WithEnterpriseURLs(server.URL+"/api/v3/", server.URL+"/api/uploads/")
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler = &DepotReconciler{
+				Log: logr.Discard(),
+			}
+		})
+
+		moduleConfig := func(discoveryMode string, includeDrafts, includePrereleases bool) opendepotv1alpha1.ModuleConfig {
+			name := "terraform-aws-vpc"
+			return opendepotv1alpha1.ModuleConfig{
+				DiscoveryMode:      &discoveryMode,
+				IncludeDrafts:      &includeDrafts,
+				IncludePrereleases: &includePrereleases,
+				Name:               &name,
+				RepoOwner:          "defdev",
+				VersionConstraints: ">= 1.0.0",
+			}
+		}
+
+		It("skips drafts and prereleases from releases by default", func() {
+			versions, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeReleases, false, false))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.0.0", "2.0.0"}))
+		})
+
+		It("includes drafts and prereleases from releases when enabled", func() {
+			versions, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeReleases, true, true))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(ConsistOf("1.0.0", "1.1.0", "1.2.0", "2.0.0"))
+		})
+
+		It("discovers versions from git tags and skips non-semver tags", func() {
+			versions, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeTags, false, false))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.0.0", "1.0.1", "1.1.0"}))
+		})
+
+		It("combines and deduplicates releases and tags", func() {
+			versions, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeReleasesAndTags, false, false))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.0.0", "2.0.0", "1.0.1"}))
+		})
+
+		It("does not add the tags of skipped prereleases back when combining releases and tags", func() {
+			versions, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeReleasesAndTags, false, false))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).NotTo(ContainElement("1.1.0"))
+
+			versions, _, err = reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig(opendepotv1alpha1.OpenDepotDiscoveryModeReleasesAndTags, false, true))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]string{"1.0.0", "1.1.0", "2.0.0", "1.0.1"}))
+		})
+
+		It("returns an error for an unsupported discovery mode", func() {
+			_, _, err := reconciler.discoverModuleVersions(ctx, githubClient, moduleConfig("Branches", false, false))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unsupported discoveryMode"))
+		})
+	})
+
+	Context("When discovering provider versions", func() {
+		ctx := context.Background()
+
+		var (
+			reconciler     *DepotReconciler
+			registryClient *registry.Client
+		)
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"providers.v1": "/api/providers/v1/"})
+			})
+			mux.HandleFunc("/api/providers/v1/datadog/datadog/versions", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer registry-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"versions": []map[string]any{
+						{"version": "3.40.0", "platforms": []map[string]string{
+							{"os": "linux", "arch": "amd64"},
+							{"os": "linux", "arch": "arm64"},
+							{"os": "darwin", "arch": "arm64"},
+						}},
+						{"version": "3.41.0-beta.1", "platforms": []map[string]string{{"os": "linux", "arch": "amd64"}}},
+						{"version": "3.41.0", "platforms": []map[string]string{{"os": "windows", "arch": "amd64"}}},
+						{"version": "2.0.0", "platforms": []map[string]string{{"os": "linux", "arch": "amd64"}}},
+					},
+				})
+			})
+			server := httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+
+			registryClient = registry.NewClient(server.URL, "registry-token")
+			reconciler = &DepotReconciler{
+				Log: logr.Discard(),
+			}
+		})
+
+		It("lists matching versions from the upstream registry with their platforms", func() {
+			namespace := "DataDog"
+			versions, err := reconciler.listRegistryProviderVersions(ctx, registryClient, "datadog", opendepotv1alpha1.ProviderConfig{
+				Namespace:          &namespace,
+				VersionConstraints: ">= 3.0.0",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]opendepotv1alpha1.ProviderVersion{
+				{Version: "3.40.0", OperatingSystem: "linux", Architecture: "amd64"},
+				{Version: "3.40.0", OperatingSystem: "linux", Architecture: "arm64"},
+				{Version: "3.40.0", OperatingSystem: "darwin", Architecture: "arm64"},
+				{Version: "3.41.0", OperatingSystem: "windows", Architecture: "amd64"},
+			}))
+		})
+
+		It("limits platforms to the configured operating systems and architectures", func() {
+			namespace := "DataDog"
+			versions, err := reconciler.listRegistryProviderVersions(ctx, registryClient, "datadog", opendepotv1alpha1.ProviderConfig{
+				Architectures:      []string{"amd64"},
+				Namespace:          &namespace,
+				OperatingSystems:   []string{"linux"},
+				VersionConstraints: ">= 3.0.0",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(Equal([]opendepotv1alpha1.ProviderVersion{
+				{Version: "3.40.0", OperatingSystem: "linux", Architecture: "amd64"},
+			}))
+		})
+
+		It("returns an error when the provider is not in the registry", func() {
+			_, err := reconciler.listRegistryProviderVersions(ctx, registryClient, "missing", opendepotv1alpha1.ProviderConfig{
+				VersionConstraints: ">= 1.0.0",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("hashicorp/missing"))
+		})
+	})
+
+	Context("When tracking the GitHub API rate limit", func() {
+		ctx := context.Background()
+
+		It("defers until the reset time only when the quota is exhausted", func() {
+			now := time.Now()
+			exhausted := &opendepotv1alpha1.GithubRateLimitStatus{
+				Limit:     5000,
+				Remaining: 0,
+				ResetAt:   now.Add(10 * time.Minute).UTC().Format(time.RFC3339),
+			}
+
+			retryAfter, rateLimited := rateLimitExhausted(exhausted, now)
+			Expect(rateLimited).To(BeTrue())
+			Expect(retryAfter).To(BeNumerically(">", 9*time.Minute))
+
+			exhausted.ResetAt = now.Add(-time.Minute).UTC().Format(time.RFC3339)
+			_, rateLimited = rateLimitExhausted(exhausted, now)
+			Expect(rateLimited).To(BeFalse())
+
+			_, rateLimited = rateLimitExhausted(&opendepotv1alpha1.GithubRateLimitStatus{Limit: 5000, Remaining: 12}, now)
+			Expect(rateLimited).To(BeFalse())
+		})
+
+		It("keeps one rate limit for each set of credentials", func() {
+			now := time.Now()
+			low := rateLimitStatus(github.Rate{Limit: 5000, Remaining: 10, Reset: github.Timestamp{Time: now.Add(time.Hour)}})
+			low.Credential = "team-a"
+			high := rateLimitStatus(github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: now.Add(time.Hour)}})
+			high.Credential = "team-b"
+
+			limits := setRateLimit(nil, low)
+			limits = setRateLimit(limits, high)
+			Expect(limits).To(HaveLen(2))
+			Expect(findRateLimit(limits, "team-a").Remaining).To(Equal(10))
+
+			refreshed := *low
+			refreshed.Remaining = 4999
+			limits = setRateLimit(limits, &refreshed)
+			Expect(limits).To(HaveLen(2))
+			Expect(findRateLimit(limits, "team-a").Remaining).To(Equal(4999))
+			Expect(findRateLimit(limits, "anonymous")).To(BeNil())
+
+			limits[1].ResetAt = now.Add(-time.Minute).UTC().Format(time.RFC3339)
+			Expect(pruneRateLimits(limits, now)).To(ConsistOf(refreshed))
+			Expect(rateLimitStatus(github.Rate{})).To(BeNil())
+		})
+
+		It("keys rate limits by the Secret and GitHub App installation of a module", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(opendepotv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			tokenSecret, appSecret := "team-token", "team-app"
+			baseURL := "https://github.example.com/api/v3/"
+			reconciler := &DepotReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: tokenSecret, Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotGithubSecretDataFieldToken: []byte("ghp_token")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: appSecret, Namespace: "default"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotGithubSecretDataFieldAppID:      []byte("1"),
+						opendepotv1alpha1.OpenDepotGithubSecretDataFieldPrivateKey: []byte(base64.StdEncoding.EncodeToString([]byte("key"))),
+					},
+				},
+			).Build()}
+
+			moduleConfig := func(githubClientConfig *opendepotv1alpha1.GithubClientConfig) opendepotv1alpha1.ModuleConfig {
+				return opendepotv1alpha1.ModuleConfig{RepoOwner: "defdev", GithubClientConfig: githubClientConfig}
+			}
+
+			Expect(reconciler.githubCredential(ctx, "default", moduleConfig(nil))).To(Equal("anonymous"))
+			Expect(reconciler.githubCredential(ctx, "default", moduleConfig(&opendepotv1alpha1.GithubClientConfig{
+				UseAuthenticatedClient: true,
+				SecretName:             &tokenSecret,
+			}))).To(Equal(tokenSecret))
+			Expect(reconciler.githubCredential(ctx, "default", moduleConfig(&opendepotv1alpha1.GithubClientConfig{
+				UseAuthenticatedClient: true,
+				SecretName:             &appSecret,
+				BaseURL:                &baseURL,
+			}))).To(Equal("github.example.com/team-app/defdev"))
+		})
+
+		It("defers only the modules whose credentials exhausted the rate limit", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(opendepotv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			vpc, eks := "terraform-aws-vpc", "terraform-aws-eks"
+			tokenSecret := "team-token"
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{Name: "rate-limited-depot", Namespace: "default"},
+				Spec: opendepotv1alpha1.DepotSpec{
+					ModuleConfigs: []opendepotv1alpha1.ModuleConfig{
+						{Name: &vpc, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+						{
+							Name:               &eks,
+							RepoOwner:          "defdev",
+							VersionConstraints: ">= 1.0.0",
+							GithubClientConfig: &opendepotv1alpha1.GithubClientConfig{UseAuthenticatedClient: true, SecretName: &tokenSecret},
+						},
+					},
+				},
+				Status: opendepotv1alpha1.DepotStatus{
+					GithubRateLimits: []opendepotv1alpha1.GithubRateLimitStatus{{
+						Credential: "anonymous",
+						Limit:      60,
+						ResetAt:    time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339),
+					}},
+				},
+			}
+
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&opendepotv1alpha1.Depot{}).WithObjects(
+				depot,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: tokenSecret, Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotGithubSecretDataFieldToken: []byte("ghp_token")},
+				},
+			).Build()
+
+			var synced []string
+			reconciler := &DepotReconciler{Client: fakeClient, Scheme: scheme, Log: logr.Discard()}
+			reconciler.moduleSyncer = func(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error) {
+				synced = append(synced, *moduleConfig.Name)
+				return github.Rate{Limit: 5000, Remaining: 4999, Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}}, nil
+			}
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(depot)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(synced).To(Equal([]string{eks}))
+			Expect(result.RequeueAfter).To(BeNumerically(">", 9*time.Minute))
+
+			current := &opendepotv1alpha1.Depot{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(depot), current)).To(Succeed())
+			Expect(current.Status.GithubRateLimits).To(HaveLen(2))
+			Expect(findRateLimit(current.Status.GithubRateLimits, tokenSecret).Remaining).To(Equal(4999))
+			Expect(findRateLimit(current.Status.GithubRateLimits, "anonymous").Remaining).To(Equal(0))
+		})
+
+		It("treats primary and secondary rate limit errors as an exhausted quota", func() {
+			retryAfter := 30 * time.Second
+			limit, ok := rateLimitFromError(&github.AbuseRateLimitError{RetryAfter: &retryAfter})
+			Expect(ok).To(BeTrue())
+			Expect(limit.Remaining).To(Equal(0))
+
+			limit, ok = rateLimitFromError(&github.RateLimitError{Rate: github.Rate{Limit: 60, Remaining: 0, Reset: github.Timestamp{Time: time.Now().Add(time.Hour)}}})
+			Expect(ok).To(BeTrue())
+			Expect(limit.Limit).To(Equal(60))
+
+			_, ok = rateLimitFromError(fmt.Errorf("not a rate limit error"))
+			Expect(ok).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,197 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v81/github"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+)
+
+const (
+	// rateLimitResetBuffer is added to the rate limit reset time before a deferred sync is retried.
+	rateLimitResetBuffer = 5 * time.Second
+	// secondaryRateLimitDefaultRetry is used when a secondary rate limit response has no Retry-After header.
+	secondaryRateLimitDefaultRetry = time.Minute
+	// anonymousGithubCredential is the credential of the rate limit shared by unauthenticated GitHub clients.
+	anonymousGithubCredential = "anonymous"
+)
+
+// rateLimitStatus returns the status for a rate observed on a GitHub API response, or nil when the
+// response carried no rate limit headers.
+func rateLimitStatus(rate github.Rate) *opendepotv1alpha1.GithubRateLimitStatus {
+	if rate.Limit == 0 {
+		return nil
+	}
+
+	return &opendepotv1alpha1.GithubRateLimitStatus{
+		Limit:     rate.Limit,
+		Remaining: rate.Remaining,
+		ResetAt:   rate.Reset.UTC().Format(time.RFC3339),
+	}
+}
+
+// rateLimitFromError returns the rate limit status carried by a GitHub primary or secondary rate limit error.
+func rateLimitFromError(err error) (*opendepotv1alpha1.GithubRateLimitStatus, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		status := rateLimitStatus(rateLimitErr.Rate)
+		if status == nil {
+			status = &opendepotv1alpha1.GithubRateLimitStatus{
+				ResetAt: time.Now().Add(secondaryRateLimitDefaultRetry).UTC().Format(time.RFC3339),
+			}
+		}
+		status.Remaining = 0
+		return status, true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		retryAfter := secondaryRateLimitDefaultRetry
+		if abuseErr.RetryAfter != nil {
+			retryAfter = *abuseErr.RetryAfter
+		}
+
+		return &opendepotv1alpha1.GithubRateLimitStatus{
+			Remaining: 0,
+			ResetAt:   time.Now().Add(retryAfter).UTC().Format(time.RFC3339),
+		}, true
+	}
+
+	return nil, false
+}
+
+// findRateLimit returns the rate limit of credential in limits, or nil when none was observed.
+func findRateLimit(limits []opendepotv1alpha1.GithubRateLimitStatus, credential string) *opendepotv1alpha1.GithubRateLimitStatus {
+	for i := range limits {
+		if limits[i].Credential == credential {
+			return &limits[i]
+		}
+	}
+
+	return nil
+}
+
+// setRateLimit returns limits with the rate limit of status.Credential replaced by status.
+func setRateLimit(limits []opendepotv1alpha1.GithubRateLimitStatus, status *opendepotv1alpha1.GithubRateLimitStatus) []opendepotv1alpha1.GithubRateLimitStatus {
+	if current := findRateLimit(limits, status.Credential); current != nil {
+		*current = *status
+		return limits
+	}
+
+	return append(limits, *status)
+}
+
+// pruneRateLimits returns limits without the rate limits that have reset, which no longer defer any module sync.
+func pruneRateLimits(limits []opendepotv1alpha1.GithubRateLimitStatus, now time.Time) []opendepotv1alpha1.GithubRateLimitStatus {
+	return slices.DeleteFunc(limits, func(status opendepotv1alpha1.GithubRateLimitStatus) bool {
+		resetAt, err := time.Parse(time.RFC3339, status.ResetAt)
+		return err != nil || !resetAt.After(now)
+	})
+}
+
+// rateLimitExhausted reports whether the rate limit has no remaining requests and has not yet reset. When it is
+// exhausted, the duration until the sync should be retried is returned.
+func rateLimitExhausted(status *opendepotv1alpha1.GithubRateLimitStatus, now time.Time) (time.Duration, bool) {
+	if status == nil || status.Remaining > 0 {
+		return 0, false
+	}
+
+	resetAt, err := time.Parse(time.RFC3339, status.ResetAt)
+	if err != nil || !resetAt.After(now) {
+		return 0, false
+	}
+
+	return resetAt.Sub(now) + rateLimitResetBuffer, true
+}
+
+// githubCredential returns the credentials the GitHub client of moduleConfig counts requests against: the name of the
+// Secret holding them and, for GitHub App credentials, the installation ID or the repository owner it is resolved
+// from. Credentials that cannot be read are keyed by the Secret name alone, since the sync reports the error.
+func (r *DepotReconciler) githubCredential(ctx context.Context, namespace string, moduleConfig opendepotv1alpha1.ModuleConfig) string {
+	credential := anonymousGithubCredential
+	if githubClientConfig := moduleConfig.GithubClientConfig; githubClientConfig != nil && githubClientConfig.UseAuthenticatedClient {
+		credential = opendepotv1alpha1.OpenDepotGithubSecretName
+		if githubClientConfig.SecretName != nil && *githubClientConfig.SecretName != "" {
+			credential = *githubClientConfig.SecretName
+		}
+
+		githubConfig, err := opendepotGithub.GetGithubClientConfig(ctx, r.Client, namespace, moduleConfig.RepoOwner, githubClientConfig)
+		if err == nil && githubConfig.Token == "" {
+			installation := moduleConfig.RepoOwner
+			if githubConfig.InstallationID != 0 {
+				installation = strconv.FormatInt(githubConfig.InstallationID, 10)
+			}
+			credential = fmt.Sprintf("%s/%s", credential, installation)
+		}
+	}
+
+	if moduleConfig.GithubClientConfig != nil && moduleConfig.GithubClientConfig.BaseURL != nil {
+		if baseURL, err := url.Parse(*moduleConfig.GithubClientConfig.BaseURL); err == nil && baseURL.Host != "" {
+			credential = fmt.Sprintf("%s/%s", baseURL.Host, credential)
+		}
+	}
+
+	return credential
+}
+
+// syncModuleWithinRateLimit syncs moduleConfig unless the GitHub API rate limit of its credentials is exhausted, and
+// records the rate limit observed by the sync in limits. When the sync is deferred until the rate limit resets, the
+// duration until it should be retried is returned.
+func (r *DepotReconciler) syncModuleWithinRateLimit(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig, limits *[]opendepotv1alpha1.GithubRateLimitStatus) (time.Duration, bool, error) {
+	syncModule := r.moduleSyncer
+	if syncModule == nil {
+		syncModule = r.syncModule
+	}
+
+	credential := r.githubCredential(ctx, depot.Namespace, moduleConfig)
+	if retryAfter, rateLimited := rateLimitExhausted(findRateLimit(*limits, credential), time.Now()); rateLimited {
+		return retryAfter, true, nil
+	}
+
+	rate, err := syncModule(ctx, depot, moduleConfig)
+	if limit, ok := rateLimitFromError(err); ok {
+		limit.Credential = credential
+		*limits = setRateLimit(*limits, limit)
+
+		retryAfter, rateLimited := rateLimitExhausted(limit, time.Now())
+		if !rateLimited {
+			retryAfter = rateLimitResetBuffer
+		}
+		return retryAfter, true, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	if status := rateLimitStatus(rate); status != nil {
+		status.Credential = credential
+		*limits = setRateLimit(*limits, status)
+	}
+
+	return 0, false, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/go-version"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+// listRegistryProviderVersions queries the upstream provider registry for the versions of a provider that satisfy
+// its version constraints. Each matched version is returned once per platform it is published for, limited to the
+// operating systems and architectures set on providerConfig when they are not empty.
+func (r *DepotReconciler) listRegistryProviderVersions(ctx context.Context, registryClient *registry.Client, providerName string, providerConfig opendepotv1alpha1.ProviderConfig) ([]opendepotv1alpha1.ProviderVersion, error) {
+	constraints, err := version.NewConstraint(providerConfig.VersionConstraints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraints %q: %w", providerConfig.VersionConstraints, err)
+	}
+
+	namespace := registry.ProviderNamespace(providerConfig)
+	available, err := registryClient.ListProviderVersions(ctx, namespace, providerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provider versions for %s/%s: %w", namespace, providerName, err)
+	}
+
+	var matched []string
+	var providerVersions []opendepotv1alpha1.ProviderVersion
+	for _, registryVersion := range available.Versions {
+		v, err := version.NewVersion(registryVersion.Version)
+		if err != nil {
+			r.Log.V(5).Info("Skipping non-semver provider release", "version", registryVersion.Version)
+			continue
+		}
+
+		if !constraints.Check(v) || slices.Contains(matched, v.String()) {
+			continue
+		}
+		matched = append(matched, v.String())
+
+		// Registries that do not publish platforms fall back to the os/arch lists on the provider config.
+		if len(registryVersion.Platforms) == 0 {
+			providerVersions = append(providerVersions, opendepotv1alpha1.ProviderVersion{Version: v.String()})
+			continue
+		}
+
+		supported := false
+		for _, platform := range registryVersion.Platforms {
+			if !platformAllowed(providerConfig.OperatingSystems, platform.OS) || !platformAllowed(providerConfig.Architectures, platform.Arch) {
+				continue
+			}
+
+			supported = true
+			providerVersions = append(providerVersions, opendepotv1alpha1.ProviderVersion{
+				Architecture:    platform.Arch,
+				OperatingSystem: platform.OS,
+				Version:         v.String(),
+			})
+		}
+
+		if !supported {
+			r.Log.V(5).Info("Skipping provider version without a configured platform", "provider", providerName, "version", v.String())
+		}
+	}
+
+	return providerVersions, nil
+}
+
+// platformAllowed reports whether value is in allowed, treating an empty list as allowing every value.
+func platformAllowed(allowed []string, value string) bool {
+	return len(allowed) == 0 || slices.Contains(allowed, value)
+}
@@ -0,0 +1,475 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+const (
+	webhookSourceGeneric = "generic"
+	webhookSourceGithub  = "github"
+	webhookSourceGitlab  = "gitlab"
+
+	// webhookMaxBodyBytes bounds the size of an accepted webhook payload.
+	webhookMaxBodyBytes = 5 << 20
+)
+
+// errWebhookSignature is returned when a webhook payload fails signature verification.
+var errWebhookSignature = errors.New("webhook signature verification failed")
+
+// WebhookReceiver serves repository webhook events and requests an immediate sync of the Depot module that
+// manages the repository. The request is recorded in the webhook sync annotation of the Depot, which the Depot
+// controller acts on, so each module is synced once by the leader however many replicas receive its webhooks.
+// It implements manager.Runnable so it can be added to the controller manager.
+type WebhookReceiver struct {
+	// The address the webhook receiver binds to, e.g. ':8085'.
+	BindAddress string
+	Log         logr.Logger
+	Reconciler  *DepotReconciler
+}
+
+// webhookRepositoryEvent is the repository a verified webhook event refers to.
+type webhookRepositoryEvent struct {
+	// The repository in 'owner/name' form. For GitLab the owner may contain nested groups.
+	FullName string
+	// The event that was received, used for logging.
+	Event string
+}
+
+// githubWebhookPayload contains the fields OpenDepot reads from GitHub release, create, delete and push events.
+type githubWebhookPayload struct {
+	Ref        string `json:"ref"`
+	RefType    string `json:"ref_type"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// gitlabWebhookPayload contains the fields OpenDepot reads from GitLab tag push and release events.
+type gitlabWebhookPayload struct {
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+}
+
+// genericWebhookPayload is the payload accepted by the generic webhook endpoint.
+type genericWebhookPayload struct {
+	// The repository in 'owner/name' form.
+	Repository string `json:"repository"`
+}
+
+// Start serves webhook requests until ctx is cancelled.
+func (w *WebhookReceiver) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              w.BindAddress,
+		Handler:           w,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		w.Log.Info("Starting webhook receiver", "address", w.BindAddress)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+// NeedLeaderElection returns false so every replica serves webhooks, not only the leader.
+func (w *WebhookReceiver) NeedLeaderElection() bool {
+	return false
+}
+
+// ServeHTTP handles POST /webhooks/{source}/{namespace}/{depot}, where source is one of github, gitlab or generic.
+// GitLab events are matched like any other, but the matching module is still synced from GitHub.
+func (w *WebhookReceiver) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[0] != "webhooks" {
+		http.NotFound(rw, req)
+		return
+	}
+	source, namespace, depotName := parts[1], parts[2], parts[3]
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, webhookMaxBodyBytes))
+	if err != nil {
+		http.Error(rw, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx := req.Context()
+	depot := &opendepotv1alpha1.Depot{}
+	if err := w.Reconciler.Get(ctx, client.ObjectKey{Name: depotName, Namespace: namespace}, depot); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(rw, req)
+			return
+		}
+		w.Log.Error(err, "Failed to get Depot for webhook", "depot", depotName, "namespace", namespace)
+		http.Error(rw, "failed to get depot", http.StatusInternalServerError)
+		return
+	}
+
+	if depot.Spec.WebhookConfig == nil || depot.Spec.WebhookConfig.SecretName == "" {
+		http.Error(rw, "webhooks are not enabled for this depot", http.StatusNotFound)
+		return
+	}
+
+	webhookSecret, err := w.getWebhookSecret(ctx, namespace, depot.Spec.WebhookConfig.SecretName)
+	if err != nil {
+		w.Log.Error(err, "Failed to get webhook secret", "depot", depotName, "namespace", namespace)
+		http.Error(rw, "failed to get webhook secret", http.StatusInternalServerError)
+		return
+	}
+
+	event, err := parseWebhookEvent(source, req.Header, body, webhookSecret)
+	if err != nil {
+		if errors.Is(err, errWebhookSignature) {
+			w.Log.Info("Rejected webhook with invalid signature", "depot", depotName, "namespace", namespace, "source", source)
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if event == nil {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var matched []opendepotv1alpha1.ModuleConfig
+	for _, moduleConfig := range depot.Spec.ModuleConfigs {
+		moduleConfig = applyGlobalModuleConfig(depot, moduleConfig)
+		if moduleConfigMatchesRepository(moduleConfig, event.FullName) {
+			matched = append(matched, moduleConfig)
+		}
+	}
+
+	if len(matched) == 0 {
+		w.Log.V(5).Info("No module config matches webhook repository", "depot", depotName, "repository", event.FullName)
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var moduleNames []string
+	for _, moduleConfig := range matched {
+		moduleNames = append(moduleNames, *moduleConfig.Name)
+	}
+
+	if err := requestModuleSyncs(ctx, w.Reconciler.Client, client.ObjectKeyFromObject(depot), moduleNames); err != nil {
+		w.Log.Error(err, "Failed to request webhook triggered module sync", "depot", depotName, "namespace", namespace)
+		http.Error(rw, "failed to request module sync", http.StatusInternalServerError)
+		return
+	}
+
+	w.Log.Info("Webhook requested module sync", "depot", depotName, "modules", moduleNames, "event", event.Event)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// requestModuleSyncs adds moduleNames to the webhook sync annotation of the Depot. Modules that are already
+// requested are not added again, so retried deliveries of the same event request a single sync.
+func requestModuleSyncs(ctx context.Context, c client.Client, key client.ObjectKey, moduleNames []string) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		depot := &opendepotv1alpha1.Depot{}
+		if err := c.Get(ctx, key, depot); err != nil {
+			return err
+		}
+
+		requested := webhookSyncModules(depot)
+		pending := requested
+		for _, moduleName := range moduleNames {
+			if !slices.Contains(pending, moduleName) {
+				pending = append(pending, moduleName)
+			}
+		}
+
+		if len(pending) == len(requested) {
+			return nil
+		}
+
+		if depot.Annotations == nil {
+			depot.Annotations = map[string]string{}
+		}
+		depot.Annotations[opendepotv1alpha1.OpenDepotWebhookSyncAnnotation] = strings.Join(pending, ",")
+		return c.Update(ctx, depot)
+	})
+}
+
+// webhookSyncModules returns the names of the modules of depot that webhooks requested a sync of.
+func webhookSyncModules(depot *opendepotv1alpha1.Depot) []string {
+	value := depot.Annotations[opendepotv1alpha1.OpenDepotWebhookSyncAnnotation]
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// reconcileWebhookSyncs syncs the modules of depot requested by webhooks, without a full sync of the Depot, and
+// removes them from its webhook sync annotation. A module that fails to sync is left to the next poll. Modules whose
+// credentials exhausted the GitHub API rate limit stay requested until it resets.
+func (r *DepotReconciler) reconcileWebhookSyncs(ctx context.Context, depot *opendepotv1alpha1.Depot, requested []string) (ctrl.Result, error) {
+	githubRateLimits := slices.Clone(depot.Status.GithubRateLimits)
+	var retryAfter time.Duration
+
+	// Requested modules the Depot no longer manages are handled by dropping them.
+	var pending []string
+	for _, moduleConfig := range depot.Spec.ModuleConfigs {
+		moduleConfig = applyGlobalModuleConfig(depot, moduleConfig)
+		if !slices.Contains(requested, *moduleConfig.Name) {
+			continue
+		}
+
+		moduleRetryAfter, deferred, err := r.syncModuleWithinRateLimit(ctx, depot, moduleConfig, &githubRateLimits)
+		if deferred {
+			r.Log.Info("GitHub API rate limit exhausted: deferring webhook triggered module sync until reset",
+				"depot", depot.Name,
+				"module", *moduleConfig.Name,
+				"retryAfter", moduleRetryAfter,
+			)
+			pending = append(pending, *moduleConfig.Name)
+			if retryAfter == 0 || moduleRetryAfter < retryAfter {
+				retryAfter = moduleRetryAfter
+			}
+		} else if err != nil {
+			r.Log.Error(err, "Webhook triggered module sync failed", "depot", depot.Name, "module", *moduleConfig.Name)
+		} else {
+			r.Log.Info("Synced webhook triggered module", "depot", depot.Name, "module", *moduleConfig.Name)
+		}
+	}
+
+	if err := r.clearWebhookSyncs(ctx, depot, requested, pending); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	key := client.ObjectKeyFromObject(depot)
+
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := r.Get(ctx, key, depot); err != nil {
+			return err
+		}
+
+		depot.Status.GithubRateLimits = pruneRateLimits(githubRateLimits, time.Now())
+		return r.Status().Update(ctx, depot)
+	}); err != nil {
+		r.Log.Error(err, "Failed to update Depot status", "depot", depot.Name)
+		return ctrl.Result{}, err
+	}
+
+	if len(pending) > 0 {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	// A poll that was due is not lost to the webhook triggered syncs.
+	if depot.Spec.PollingIntervalMinutes != nil {
+		return ctrl.Result{RequeueAfter: time.Duration(*depot.Spec.PollingIntervalMinutes) * time.Minute}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// clearWebhookSyncs removes the requested modules of depot that are not pending from its webhook sync annotation.
+// Modules requested while the syncs ran stay requested.
+func (r *DepotReconciler) clearWebhookSyncs(ctx context.Context, depot *opendepotv1alpha1.Depot, requested, pending []string) error {
+	key := client.ObjectKeyFromObject(depot)
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := r.Get(ctx, key, depot); err != nil {
+			return err
+		}
+
+		remaining := slices.DeleteFunc(webhookSyncModules(depot), func(moduleName string) bool {
+			return slices.Contains(requested, moduleName) && !slices.Contains(pending, moduleName)
+		})
+
+		if len(remaining) == 0 {
+			delete(depot.Annotations, opendepotv1alpha1.OpenDepotWebhookSyncAnnotation)
+		} else {
+			depot.Annotations[opendepotv1alpha1.OpenDepotWebhookSyncAnnotation] = strings.Join(remaining, ",")
+		}
+		return r.Update(ctx, depot)
+	})
+}
+
+// getWebhookSecret returns the shared webhook secret stored in the named Secret.
+func (w *WebhookReceiver) getWebhookSecret(ctx context.Context, namespace, secretName string) ([]byte, error) {
+	secret := corev1.Secret{}
+	if err := w.Reconciler.Get(ctx, client.ObjectKey{Name: secretName, Namespace: namespace}, &secret); err != nil {
+		return nil, err
+	}
+
+	webhookSecret := secret.Data[opendepotv1alpha1.OpenDepotWebhookSecretDataField]
+	if len(webhookSecret) == 0 {
+		return nil, fmt.Errorf("secret '%s' has no '%s' field", secretName, opendepotv1alpha1.OpenDepotWebhookSecretDataField)
+	}
+
+	return webhookSecret, nil
+}
+
+// parseWebhookEvent verifies the payload of a webhook sent by source and returns the repository it refers to.
+// A nil event with a nil error is returned for verified events that should not trigger a sync.
+func parseWebhookEvent(source string, header http.Header, body, webhookSecret []byte) (*webhookRepositoryEvent, error) {
+	switch source {
+	case webhookSourceGithub:
+		if !verifyHMACSignature(header.Get("X-Hub-Signature-256"), body, webhookSecret) {
+			return nil, errWebhookSignature
+		}
+		return parseGithubWebhookEvent(header.Get("X-GitHub-Event"), body)
+	case webhookSourceGitlab:
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), webhookSecret) != 1 {
+			return nil, errWebhookSignature
+		}
+		return parseGitlabWebhookEvent(header.Get("X-Gitlab-Event"), body)
+	case webhookSourceGeneric:
+		if !verifyHMACSignature(header.Get("X-OpenDepot-Signature-256"), body, webhookSecret) {
+			return nil, errWebhookSignature
+		}
+
+		payload := genericWebhookPayload{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+		}
+
+		if payload.Repository == "" {
+			return nil, fmt.Errorf("webhook payload is missing the repository field")
+		}
+		return &webhookRepositoryEvent{FullName: payload.Repository, Event: webhookSourceGeneric}, nil
+	default:
+		return nil, fmt.Errorf("unsupported webhook source '%s': must be one of '%s', '%s' or '%s'",
+			source, webhookSourceGithub, webhookSourceGitlab, webhookSourceGeneric)
+	}
+}
+
+// parseGithubWebhookEvent returns the repository of GitHub release events and of tag create, delete and push events.
+func parseGithubWebhookEvent(eventType string, body []byte) (*webhookRepositoryEvent, error) {
+	payload := githubWebhookPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+
+	switch eventType {
+	case "release":
+	case "create", "delete":
+		if payload.RefType != "tag" {
+			return nil, nil
+		}
+	case "push":
+		if !strings.HasPrefix(payload.Ref, "refs/tags/") {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	if payload.Repository.FullName == "" {
+		return nil, fmt.Errorf("webhook payload is missing repository.full_name")
+	}
+
+	return &webhookRepositoryEvent{FullName: payload.Repository.FullName, Event: eventType}, nil
+}
+
+// parseGitlabWebhookEvent returns the project of GitLab tag push and release events.
+func parseGitlabWebhookEvent(eventType string, body []byte) (*webhookRepositoryEvent, error) {
+	if eventType != "Tag Push Hook" && eventType != "Release Hook" {
+		return nil, nil
+	}
+
+	payload := gitlabWebhookPayload{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+
+	if payload.Project.PathWithNamespace == "" {
+		return nil, fmt.Errorf("webhook payload is missing project.path_with_namespace")
+	}
+
+	return &webhookRepositoryEvent{FullName: payload.Project.PathWithNamespace, Event: eventType}, nil
+}
+
+// verifyHMACSignature reports whether signature is the 'sha256=<hex>' HMAC-SHA256 of body keyed with webhookSecret.
+func verifyHMACSignature(signature string, body, webhookSecret []byte) bool {
+	signatureHex, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, webhookSecret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// moduleConfigMatchesRepository reports whether the module config manages the 'owner/name' repository,
+// either through its repoOwner and name or through the path of its repoUrl.
+func moduleConfigMatchesRepository(moduleConfig opendepotv1alpha1.ModuleConfig, fullName string) bool {
+	fullName = strings.Trim(fullName, "/")
+	if moduleConfig.Name != nil && strings.EqualFold(fmt.Sprintf("%s/%s", moduleConfig.RepoOwner, *moduleConfig.Name), fullName) {
+		return true
+	}
+
+	if moduleConfig.RepoUrl == nil {
+		return false
+	}
+
+	repoUrl, err := url.Parse(*moduleConfig.RepoUrl)
+	if err != nil {
+		return false
+	}
+
+	repoPath := strings.TrimSuffix(strings.Trim(repoUrl.Path, "/"), ".git")
+	return strings.EqualFold(repoPath, fullName)
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-github/v81/github"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+func signWebhookBody(body, webhookSecret []byte) string {
+	mac := hmac.New(sha256.New, webhookSecret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var _ = Describe("Webhook Receiver", func() {
+	webhookSecret := []byte("s3cr3t")
+
+	Context("When parsing webhook events", func() {
+		It("accepts a signed GitHub release event", func() {
+			body := []byte(`{"action":"published","repository":{"full_name":"defdev/terraform-aws-vpc"}}`)
+			header := http.Header{}
+			header.Set("X-GitHub-Event", "release")
+			header.Set("X-Hub-Signature-256", signWebhookBody(body, webhookSecret))
+
+			event, err := parseWebhookEvent(webhookSourceGithub, header, body, webhookSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).NotTo(BeNil())
+			Expect(event.FullName).To(Equal("defdev/terraform-aws-vpc"))
+		})
+
+		It("rejects a GitHub event with an invalid signature", func() {
+			body := []byte(`{"repository":{"full_name":"defdev/terraform-aws-vpc"}}`)
+			header := http.Header{}
+			header.Set("X-GitHub-Event", "release")
+			header.Set("X-Hub-Signature-256", signWebhookBody(body, []byte("wrong")))
+
+			_, err := parseWebhookEvent(webhookSourceGithub, header, body, webhookSecret)
+			Expect(err).To(MatchError(errWebhookSignature))
+		})
+
+		It("ignores GitHub branch pushes", func() {
+			body := []byte(`{"ref":"refs/heads/main","repository":{"full_name":"defdev/terraform-aws-vpc"}}`)
+			header := http.Header{}
+			header.Set("X-GitHub-Event", "push")
+			header.Set("X-Hub-Signature-256", signWebhookBody(body, webhookSecret))
+
+			event, err := parseWebhookEvent(webhookSourceGithub, header, body, webhookSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).To(BeNil())
+		})
+
+		It("accepts a GitLab tag push event with a matching token", func() {
+			body := []byte(`{"ref":"refs/tags/v1.0.0","project":{"path_with_namespace":"platform/modules/terraform-aws-vpc"}}`)
+			header := http.Header{}
+			header.Set("X-Gitlab-Event", "Tag Push Hook")
+			header.Set("X-Gitlab-Token", string(webhookSecret))
+
+			event, err := parseWebhookEvent(webhookSourceGitlab, header, body, webhookSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.FullName).To(Equal("platform/modules/terraform-aws-vpc"))
+		})
+
+		It("accepts a signed generic event", func() {
+			body := []byte(`{"repository":"defdev/terraform-aws-vpc"}`)
+			header := http.Header{}
+			header.Set("X-OpenDepot-Signature-256", signWebhookBody(body, webhookSecret))
+
+			event, err := parseWebhookEvent(webhookSourceGeneric, header, body, webhookSecret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.FullName).To(Equal("defdev/terraform-aws-vpc"))
+		})
+	})
+
+	Context("When matching repositories to module configs", func() {
+		It("matches on repoOwner and name or on the repoUrl path", func() {
+			name := "terraform-aws-vpc"
+			repoUrl := "https://gitlab.example.com/platform/modules/terraform-aws-vpc.git"
+
+			Expect(moduleConfigMatchesRepository(opendepotv1alpha1.ModuleConfig{
+				Name:      &name,
+				RepoOwner: "DefDev",
+			}, "defdev/terraform-aws-vpc")).To(BeTrue())
+
+			Expect(moduleConfigMatchesRepository(opendepotv1alpha1.ModuleConfig{
+				Name:      &name,
+				RepoOwner: "platform",
+				RepoUrl:   &repoUrl,
+			}, "platform/modules/terraform-aws-vpc")).To(BeTrue())
+
+			Expect(moduleConfigMatchesRepository(opendepotv1alpha1.ModuleConfig{
+				Name:      &name,
+				RepoOwner: "defdev",
+			}, "defdev/terraform-aws-eks")).To(BeFalse())
+		})
+	})
+
+	Context("When receiving a webhook request", func() {
+		const namespace = "default"
+		ctx := context.Background()
+
+		newFakeDepotReconciler := func(objs ...client.Object) *DepotReconciler {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(opendepotv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objs...).
+				WithStatusSubresource(&opendepotv1alpha1.Depot{}).
+				Build()
+			return &DepotReconciler{Client: fakeClient, Scheme: scheme, Log: logr.Discard()}
+		}
+
+		sendWebhook := func(receiver *WebhookReceiver, repository string) int {
+			body := []byte(`{"action":"published","repository":{"full_name":"` + repository + `"}}`)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/github/default/webhook-depot", bytes.NewReader(body))
+			req.Header.Set("X-GitHub-Event", "release")
+			req.Header.Set("X-Hub-Signature-256", signWebhookBody(body, webhookSecret))
+			recorder := httptest.NewRecorder()
+
+			receiver.ServeHTTP(recorder, req)
+			return recorder.Code
+		}
+
+		It("requests a sync of only the matching module once per module", func() {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "depot-webhook-secret", Namespace: namespace},
+				Data:       map[string][]byte{opendepotv1alpha1.OpenDepotWebhookSecretDataField: webhookSecret},
+			}
+
+			vpc, eks, rds := "terraform-aws-vpc", "terraform-aws-eks", "terraform-aws-rds"
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-depot", Namespace: namespace},
+				Spec: opendepotv1alpha1.DepotSpec{
+					ModuleConfigs: []opendepotv1alpha1.ModuleConfig{
+						{Name: &vpc, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+						{Name: &eks, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+						{Name: &rds, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+					},
+					WebhookConfig: &opendepotv1alpha1.DepotWebhookConfig{SecretName: secret.Name},
+				},
+			}
+
+			reconciler := newFakeDepotReconciler(secret, depot)
+			receiver := &WebhookReceiver{Log: logr.Discard(), Reconciler: reconciler}
+
+			// Retried deliveries of the same event request a single sync.
+			Expect(sendWebhook(receiver, "defdev/terraform-aws-vpc")).To(Equal(http.StatusAccepted))
+			Expect(sendWebhook(receiver, "defdev/terraform-aws-vpc")).To(Equal(http.StatusAccepted))
+			Expect(sendWebhook(receiver, "defdev/terraform-aws-eks")).To(Equal(http.StatusAccepted))
+
+			current := &opendepotv1alpha1.Depot{}
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(depot), current)).To(Succeed())
+			Expect(current.Annotations).To(HaveKeyWithValue(opendepotv1alpha1.OpenDepotWebhookSyncAnnotation, "terraform-aws-vpc,terraform-aws-eks"))
+		})
+
+		It("syncs only the requested modules and clears the request", func() {
+			vpc, eks := "terraform-aws-vpc", "terraform-aws-eks"
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "webhook-depot",
+					Namespace:   namespace,
+					Annotations: map[string]string{opendepotv1alpha1.OpenDepotWebhookSyncAnnotation: "terraform-aws-vpc,terraform-aws-removed"},
+				},
+				Spec: opendepotv1alpha1.DepotSpec{
+					ModuleConfigs: []opendepotv1alpha1.ModuleConfig{
+						{Name: &vpc, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+						{Name: &eks, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+					},
+				},
+			}
+
+			var synced []string
+			reconciler := newFakeDepotReconciler(depot)
+			reconciler.moduleSyncer = func(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error) {
+				synced = append(synced, *moduleConfig.Name)
+				return github.Rate{}, nil
+			}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(depot)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(synced).To(Equal([]string{vpc}))
+
+			current := &opendepotv1alpha1.Depot{}
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(depot), current)).To(Succeed())
+			Expect(current.Annotations).NotTo(HaveKey(opendepotv1alpha1.OpenDepotWebhookSyncAnnotation))
+		})
+
+		It("syncs every module when the spec changed since the last full sync", func() {
+			vpc, eks := "terraform-aws-vpc", "terraform-aws-eks"
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "webhook-depot",
+					Namespace:   namespace,
+					Generation:  2,
+					Annotations: map[string]string{opendepotv1alpha1.OpenDepotWebhookSyncAnnotation: vpc},
+				},
+				Spec: opendepotv1alpha1.DepotSpec{
+					ModuleConfigs: []opendepotv1alpha1.ModuleConfig{
+						{Name: &vpc, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+						{Name: &eks, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+					},
+				},
+				Status: opendepotv1alpha1.DepotStatus{ObservedGeneration: 1},
+			}
+
+			var synced []string
+			reconciler := newFakeDepotReconciler(depot)
+			reconciler.moduleSyncer = func(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error) {
+				synced = append(synced, *moduleConfig.Name)
+				return github.Rate{}, nil
+			}
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(depot)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(synced).To(Equal([]string{vpc, eks}))
+
+			current := &opendepotv1alpha1.Depot{}
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(depot), current)).To(Succeed())
+			Expect(current.Annotations).NotTo(HaveKey(opendepotv1alpha1.OpenDepotWebhookSyncAnnotation))
+			Expect(current.Status.ObservedGeneration).To(Equal(current.Generation))
+
+			// Once the spec is synced, webhooks sync only the requested module again.
+			synced = nil
+			current.Annotations = map[string]string{opendepotv1alpha1.OpenDepotWebhookSyncAnnotation: eks}
+			Expect(reconciler.Update(ctx, current)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(depot)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(synced).To(Equal([]string{eks}))
+		})
+
+		It("keeps requested modules until the GitHub API rate limit resets", func() {
+			vpc := "terraform-aws-vpc"
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "webhook-depot",
+					Namespace:   namespace,
+					Annotations: map[string]string{opendepotv1alpha1.OpenDepotWebhookSyncAnnotation: vpc},
+				},
+				Spec: opendepotv1alpha1.DepotSpec{
+					ModuleConfigs: []opendepotv1alpha1.ModuleConfig{
+						{Name: &vpc, RepoOwner: "defdev", VersionConstraints: ">= 1.0.0"},
+					},
+				},
+				Status: opendepotv1alpha1.DepotStatus{
+					GithubRateLimits: []opendepotv1alpha1.GithubRateLimitStatus{{
+						Credential: "anonymous",
+						Limit:      5000,
+						ResetAt:    time.Now().Add(10 * time.Minute).UTC().Format(time.RFC3339),
+					}},
+				},
+			}
+
+			reconciler := newFakeDepotReconciler(depot)
+			reconciler.moduleSyncer = func(ctx context.Context, depot *opendepotv1alpha1.Depot, moduleConfig opendepotv1alpha1.ModuleConfig) (github.Rate, error) {
+				Fail("module synced while the GitHub API rate limit is exhausted")
+				return github.Rate{}, nil
+			}
+
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(depot)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			current := &opendepotv1alpha1.Depot{}
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(depot), current)).To(Succeed())
+			Expect(current.Annotations).To(HaveKeyWithValue(opendepotv1alpha1.OpenDepotWebhookSyncAnnotation, vpc))
+		})
+
+		It("returns not found for a Depot without a webhook config", func() {
+			depot := &opendepotv1alpha1.Depot{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-webhook-depot", Namespace: namespace},
+			}
+			Expect(k8sClient.Create(ctx, depot)).To(Succeed())
+			DeferCleanup(func() {
+				_ = k8sClient.Delete(ctx, depot)
+			})
+
+			receiver := &WebhookReceiver{
+				Log:        logr.Discard(),
+				Reconciler: &DepotReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Log: logr.Discard()},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/github/default/no-webhook-depot", bytes.NewReader([]byte(`{}`)))
+			recorder := httptest.NewRecorder()
+
+			receiver.ServeHTTP(recorder, req)
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -0,0 +1,651 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer builds the provider and module configs of a Depot from OpenTofu and Terraform configuration
+// directories and '.terraform.lock.hcl' files.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+// LockFileName is the name of the dependency lock file written by 'tofu init' and 'terraform init'.
+const LockFileName = ".terraform.lock.hcl"
+
+var (
+	configFileSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "terraform"},
+			{Type: "module", LabelNames: []string{"name"}},
+		},
+	}
+	terraformBlockSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "required_providers"},
+		},
+	}
+	moduleBlockSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "source"},
+			{Name: "version"},
+		},
+	}
+)
+
+// Options configures an Importer.
+type Options struct {
+	// Platforms limits the platforms imported from lock files to these '{os}_{arch}' pairs, e.g. 'linux_amd64'.
+	// When empty, every platform seen in a lock file is imported.
+	Platforms []string
+	// ResolvePlatforms looks up the platform of each 'zh:' lock file hash in the upstream registry's SHA256SUMS.
+	// When false, imported providers are limited to Platforms, or to no platforms at all when it is empty.
+	ResolvePlatforms bool
+	// NewRegistryClient returns the client used to query the upstream registry host of a provider.
+	// Defaults to an unauthenticated registry.NewClient.
+	NewRegistryClient func(host string) *registry.Client
+}
+
+// Importer collects the providers and modules required by configuration directories and lock files.
+type Importer struct {
+	options   Options
+	parser    *hclparse.Parser
+	providers map[string]*providerRequirement
+	modules   map[string]*moduleRequirement
+	warnings  []string
+}
+
+// providerRequirement is a provider required by the imported configuration or pinned by an imported lock file.
+type providerRequirement struct {
+	// The registry host of the provider address. Empty for the public registries.
+	registryHost string
+	// The host lock file hashes were recorded from, used to resolve their platforms.
+	lockHost    string
+	namespace   string
+	name        string
+	constraints versionRequirement
+	locked      map[string]*lockedVersion
+}
+
+// lockedVersion is a provider version pinned by a lock file and the hashes recorded for it.
+type lockedVersion struct {
+	version *version.Version
+	hashes  []string
+}
+
+// moduleRequirement is a module called by the imported configuration.
+type moduleRequirement struct {
+	owner       string
+	repo        string
+	provider    string
+	constraints versionRequirement
+}
+
+// versionRequirement collects the exact versions and the version constraints a dependency is required at.
+type versionRequirement struct {
+	pinned      map[string]*version.Version
+	constraints []string
+}
+
+// lockFile is the subset of '.terraform.lock.hcl' read by the importer.
+type lockFile struct {
+	Providers []lockFileProvider `hcl:"provider,block"`
+	Remain    hcl.Body           `hcl:",remain"`
+}
+
+// lockFileProvider is a single provider block of a lock file.
+type lockFileProvider struct {
+	Address     string   `hcl:"address,label"`
+	Version     string   `hcl:"version"`
+	Constraints *string  `hcl:"constraints,optional"`
+	Hashes      []string `hcl:"hashes,optional"`
+	Remain      hcl.Body `hcl:",remain"`
+}
+
+// New returns an Importer configured with options.
+func New(options Options) *Importer {
+	if options.NewRegistryClient == nil {
+		options.NewRegistryClient = func(host string) *registry.Client {
+			return registry.NewClient(host, "")
+		}
+	}
+
+	return &Importer{
+		options:   options,
+		parser:    hclparse.NewParser(),
+		providers: map[string]*providerRequirement{},
+		modules:   map[string]*moduleRequirement{},
+	}
+}
+
+// Warnings returns the dependencies that were skipped or imported with a wider constraint than they are used at.
+func (i *Importer) Warnings() []string {
+	return i.warnings
+}
+
+func (i *Importer) warn(format string, args ...any) {
+	i.warnings = append(i.warnings, fmt.Sprintf(format, args...))
+}
+
+// ImportDir reads the configuration files and lock file of dir and of every directory below it. Hidden
+// directories, such as '.terraform' and '.git', are skipped.
+func (i *Importer) ImportDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		switch {
+		case entry.Name() == LockFileName:
+			return i.ImportLockFile(path)
+		case isConfigFile(entry.Name()):
+			return i.importConfigFile(path)
+		}
+
+		return nil
+	})
+}
+
+// isConfigFile reports whether name is an OpenTofu or Terraform configuration file in native or JSON syntax.
+func isConfigFile(name string) bool {
+	for _, suffix := range []string{".tf", ".tofu", ".tf.json", ".tofu.json"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importConfigFile collects the 'required_providers' entries and module calls of a configuration file.
+func (i *Importer) importConfigFile(path string) error {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = i.parser.ParseJSONFile(path)
+	} else {
+		file, diags = i.parser.ParseHCLFile(path)
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+
+	content, _, diags := file.Body.PartialContent(configFileSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "terraform":
+			if err := i.importTerraformBlock(block); err != nil {
+				return err
+			}
+		case "module":
+			if err := i.importModuleBlock(block); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Importer) importTerraformBlock(block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(terraformBlockSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, requiredProviders := range content.Blocks {
+		attributes, diags := requiredProviders.Body.JustAttributes()
+		if diags.HasErrors() {
+			return diags
+		}
+
+		localNames := make([]string, 0, len(attributes))
+		for localName := range attributes {
+			localNames = append(localNames, localName)
+		}
+		sort.Strings(localNames)
+
+		for _, localName := range localNames {
+			i.importRequiredProvider(attributes[localName])
+		}
+	}
+
+	return nil
+}
+
+// importRequiredProvider collects a single 'required_providers' entry in either its object form or its legacy form,
+// which sets the version constraint directly, e.g. 'aws = "~> 5.0"'.
+func (i *Importer) importRequiredProvider(attribute *hcl.Attribute) {
+	source := attribute.Name
+	var constraint string
+
+	pairs, diags := hcl.ExprMap(attribute.Expr)
+	if diags.HasErrors() {
+		if diags := gohcl.DecodeExpression(attribute.Expr, nil, &constraint); diags.HasErrors() {
+			i.warn("%s: skipping required provider '%s': %s", attribute.Range, attribute.Name, diags.Error())
+			return
+		}
+	}
+
+	for _, pair := range pairs {
+		var key string
+		if diags := gohcl.DecodeExpression(pair.Key, nil, &key); diags.HasErrors() {
+			continue
+		}
+
+		var target *string
+		switch key {
+		case "source":
+			target = &source
+		case "version":
+			target = &constraint
+		default:
+			continue
+		}
+
+		if diags := gohcl.DecodeExpression(pair.Value, nil, target); diags.HasErrors() {
+			i.warn("%s: skipping required provider '%s': %s", attribute.Range, attribute.Name, diags.Error())
+			return
+		}
+	}
+
+	providerSource, err := registry.ParseProviderSource(source)
+	if err != nil {
+		i.warn("%s: skipping required provider '%s': %s", attribute.Range, attribute.Name, err)
+		return
+	}
+
+	i.provider(providerSource).constraints.add(constraint)
+}
+
+func (i *Importer) importModuleBlock(block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(moduleBlockSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	moduleName := block.Labels[0]
+
+	sourceAttribute, ok := content.Attributes["source"]
+	if !ok {
+		i.warn("%s: skipping module '%s': it has no source", block.DefRange, moduleName)
+		return nil
+	}
+
+	var source string
+	if diags := gohcl.DecodeExpression(sourceAttribute.Expr, nil, &source); diags.HasErrors() {
+		i.warn("%s: skipping module '%s': %s", block.DefRange, moduleName, diags.Error())
+		return nil
+	}
+
+	var constraint string
+	if versionAttribute, ok := content.Attributes["version"]; ok {
+		if diags := gohcl.DecodeExpression(versionAttribute.Expr, nil, &constraint); diags.HasErrors() {
+			i.warn("%s: skipping module '%s': %s", block.DefRange, moduleName, diags.Error())
+			return nil
+		}
+	}
+
+	moduleSource, err := registry.ParseModuleSource(source)
+	if err != nil {
+		i.warn("%s: skipping module '%s': %s", block.DefRange, moduleName, err)
+		return nil
+	}
+
+	// Local modules are part of the imported configuration and are not mirrored.
+	if moduleSource == nil {
+		return nil
+	}
+
+	if moduleSource.Ref != "" {
+		refVersion, err := version.NewVersion(moduleSource.Ref)
+		if err != nil {
+			i.warn("%s: skipping module '%s': ref '%s' is not a version", block.DefRange, moduleName, moduleSource.Ref)
+			return nil
+		}
+
+		constraint = refVersion.String()
+	}
+
+	key := strings.ToLower(moduleSource.Owner + "/" + moduleSource.Repo)
+	module, ok := i.modules[key]
+	if !ok {
+		module = &moduleRequirement{
+			owner:    moduleSource.Owner,
+			repo:     moduleSource.Repo,
+			provider: moduleSource.Provider,
+		}
+		i.modules[key] = module
+	}
+
+	module.constraints.add(constraint)
+	return nil
+}
+
+// ImportLockFile reads the provider versions and hashes pinned by a '.terraform.lock.hcl' file.
+func (i *Importer) ImportLockFile(path string) error {
+	file, diags := i.parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	var parsed lockFile
+	if diags := gohcl.DecodeBody(file.Body, nil, &parsed); diags.HasErrors() {
+		return diags
+	}
+
+	for _, lockedProvider := range parsed.Providers {
+		source, err := registry.ParseProviderSource(lockedProvider.Address)
+		if err != nil {
+			i.warn("%s: skipping locked provider '%s': %s", path, lockedProvider.Address, err)
+			continue
+		}
+		provider := i.provider(source)
+
+		pinnedVersion, err := version.NewVersion(lockedProvider.Version)
+		if err != nil {
+			i.warn("%s: skipping locked provider '%s': invalid version '%s'", path, lockedProvider.Address, lockedProvider.Version)
+			continue
+		}
+
+		if provider.lockHost == "" {
+			provider.lockHost = source.Host
+		}
+
+		locked, ok := provider.locked[pinnedVersion.String()]
+		if !ok {
+			locked = &lockedVersion{version: pinnedVersion}
+			provider.locked[pinnedVersion.String()] = locked
+		}
+
+		for _, hash := range lockedProvider.Hashes {
+			if !slices.Contains(locked.hashes, hash) {
+				locked.hashes = append(locked.hashes, hash)
+			}
+		}
+	}
+
+	return nil
+}
+
+// provider returns the requirement of the provider at source, creating it on first use. Addresses on the public
+// registries share a requirement, so a provider locked by OpenTofu and required by Terraform is imported once.
+// Providers on the public registries are imported without a registry so they are mirrored from the Depot's default one.
+func (i *Importer) provider(source registry.ProviderSource) *providerRequirement {
+	registryHost := source.Host
+	if registry.IsPublicHost(registryHost) {
+		registryHost = ""
+	}
+
+	key := strings.Join([]string{registryHost, source.Namespace, source.Name}, "/")
+	provider, ok := i.providers[key]
+	if !ok {
+		provider = &providerRequirement{
+			registryHost: registryHost,
+			namespace:    source.Namespace,
+			name:         source.Name,
+			locked:       map[string]*lockedVersion{},
+		}
+		i.providers[key] = provider
+	}
+
+	return provider
+}
+
+// ProviderConfigs returns a provider config for every imported provider, sorted by namespace and name. Providers pinned
+// by a lock file are constrained to exactly the locked versions, and the others to their 'required_providers' constraints.
+func (i *Importer) ProviderConfigs(ctx context.Context) ([]opendepotv1alpha1.ProviderConfig, error) {
+	keys := make([]string, 0, len(i.providers))
+	for key := range i.providers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	providerConfigs := make([]opendepotv1alpha1.ProviderConfig, 0, len(keys))
+	for _, key := range keys {
+		provider := i.providers[key]
+		address := provider.namespace + "/" + provider.name
+
+		requirement := provider.constraints
+		if len(provider.locked) > 0 {
+			requirement = versionRequirement{}
+			for _, locked := range provider.locked {
+				requirement.pin(locked.version)
+			}
+
+			if len(provider.locked) > 1 {
+				i.warn("provider '%s' is locked at versions %s, so every version between them is also mirrored", address, requirement.pinnedList())
+			}
+		}
+
+		constraint := requirement.constraint()
+		if constraint == "" {
+			i.warn("skipping provider '%s': it has no version constraint and is not in a lock file", address)
+			continue
+		}
+
+		if _, err := version.NewConstraint(constraint); err != nil {
+			i.warn("skipping provider '%s': invalid version constraint '%s'", address, constraint)
+			continue
+		}
+
+		providerConfig := opendepotv1alpha1.ProviderConfig{
+			Name:               &provider.name,
+			Namespace:          &provider.namespace,
+			VersionConstraints: constraint,
+		}
+
+		if provider.registryHost != "" {
+			providerConfig.Registry = &provider.registryHost
+		}
+
+		platforms, err := i.providerPlatforms(ctx, provider)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve the platforms of provider '%s': %w", address, err)
+		}
+
+		for _, platform := range platforms {
+			if !slices.Contains(providerConfig.OperatingSystems, platform.OS) {
+				providerConfig.OperatingSystems = append(providerConfig.OperatingSystems, platform.OS)
+			}
+
+			if !slices.Contains(providerConfig.Architectures, platform.Arch) {
+				providerConfig.Architectures = append(providerConfig.Architectures, platform.Arch)
+			}
+		}
+		sort.Strings(providerConfig.OperatingSystems)
+		sort.Strings(providerConfig.Architectures)
+
+		providerConfigs = append(providerConfigs, providerConfig)
+	}
+
+	return providerConfigs, nil
+}
+
+// ModuleConfigs returns a module config for every imported module, sorted by repository owner and name.
+func (i *Importer) ModuleConfigs() []opendepotv1alpha1.ModuleConfig {
+	keys := make([]string, 0, len(i.modules))
+	for key := range i.modules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	moduleConfigs := make([]opendepotv1alpha1.ModuleConfig, 0, len(keys))
+	for _, key := range keys {
+		module := i.modules[key]
+		address := module.owner + "/" + module.repo
+
+		if len(module.constraints.pinned) > 1 {
+			i.warn("module '%s' is pinned at versions %s, so every version between them is also mirrored", address, module.constraints.pinnedList())
+		}
+
+		if len(module.constraints.pinned) > 0 && len(module.constraints.constraints) > 0 {
+			i.warn("module '%s' is also called with constraints '%s', which are ignored in favor of its pinned versions", address, strings.Join(module.constraints.constraints, "', '"))
+		}
+
+		if len(module.constraints.pinned) == 0 && len(module.constraints.constraints) > 1 {
+			i.warn("module '%s' is called with several version constraints, which are combined so each must be satisfied", address)
+		}
+
+		constraint := module.constraints.constraint()
+		if constraint == "" {
+			i.warn("skipping module '%s': it has no version", address)
+			continue
+		}
+
+		if _, err := version.NewConstraint(constraint); err != nil {
+			i.warn("skipping module '%s': invalid version constraint '%s'", address, constraint)
+			continue
+		}
+
+		moduleConfigs = append(moduleConfigs, opendepotv1alpha1.ModuleConfig{
+			Name:               &module.repo,
+			Provider:           module.provider,
+			RepoOwner:          module.owner,
+			VersionConstraints: constraint,
+		})
+	}
+
+	return moduleConfigs
+}
+
+// add records constraint, treating a single exact version, optionally prefixed with '=', as a pinned version.
+func (v *versionRequirement) add(constraint string) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return
+	}
+
+	if pinned, err := version.NewVersion(strings.TrimSpace(strings.TrimPrefix(constraint, "="))); err == nil {
+		v.pin(pinned)
+		return
+	}
+
+	if !slices.Contains(v.constraints, constraint) {
+		v.constraints = append(v.constraints, constraint)
+	}
+}
+
+func (v *versionRequirement) pin(pinned *version.Version) {
+	if v.pinned == nil {
+		v.pinned = map[string]*version.Version{}
+	}
+
+	v.pinned[pinned.String()] = pinned
+}
+
+// sortedPinned returns the pinned versions in ascending order.
+func (v *versionRequirement) sortedPinned() []*version.Version {
+	pinned := make([]*version.Version, 0, len(v.pinned))
+	for _, item := range v.pinned {
+		pinned = append(pinned, item)
+	}
+	sort.Sort(version.Collection(pinned))
+
+	return pinned
+}
+
+// pinnedList returns the pinned versions as a comma-separated list for warnings.
+func (v *versionRequirement) pinnedList() string {
+	pinned := v.sortedPinned()
+	items := make([]string, 0, len(pinned))
+	for _, item := range pinned {
+		items = append(items, item.String())
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// constraint returns the Depot version constraint of the requirement. A single pinned version is matched exactly and
+// several pinned versions by the range between them. Without pinned versions, every constraint must be satisfied.
+func (v *versionRequirement) constraint() string {
+	pinned := v.sortedPinned()
+	switch len(pinned) {
+	case 0:
+		return strings.Join(v.constraints, ", ")
+	case 1:
+		return "= " + pinned[0].String()
+	}
+
+	return fmt.Sprintf(">= %s, <= %s", pinned[0].String(), pinned[len(pinned)-1].String())
+}
+
+// PatchDepotSpec merges imported provider and module configs into spec. Existing entries, matched by provider
+// namespace and name or by module repository owner and name, have their version constraints and platforms replaced
+// and keep every other field. New entries are appended.
+func PatchDepotSpec(spec *opendepotv1alpha1.DepotSpec, providerConfigs []opendepotv1alpha1.ProviderConfig, moduleConfigs []opendepotv1alpha1.ModuleConfig) {
+	for _, providerConfig := range providerConfigs {
+		index := slices.IndexFunc(spec.ProviderConfigs, func(existing opendepotv1alpha1.ProviderConfig) bool {
+			return existing.Name != nil && strings.EqualFold(*existing.Name, *providerConfig.Name) &&
+				strings.EqualFold(registry.ProviderNamespace(existing), registry.ProviderNamespace(providerConfig))
+		})
+
+		if index < 0 {
+			spec.ProviderConfigs = append(spec.ProviderConfigs, providerConfig)
+			continue
+		}
+
+		existing := &spec.ProviderConfigs[index]
+		existing.VersionConstraints = providerConfig.VersionConstraints
+		if len(providerConfig.OperatingSystems) > 0 {
+			existing.OperatingSystems = providerConfig.OperatingSystems
+		}
+
+		if len(providerConfig.Architectures) > 0 {
+			existing.Architectures = providerConfig.Architectures
+		}
+	}
+
+	for _, moduleConfig := range moduleConfigs {
+		index := slices.IndexFunc(spec.ModuleConfigs, func(existing opendepotv1alpha1.ModuleConfig) bool {
+			return existing.Name != nil && strings.EqualFold(*existing.Name, *moduleConfig.Name) &&
+				strings.EqualFold(existing.RepoOwner, moduleConfig.RepoOwner)
+		})
+
+		if index < 0 {
+			spec.ModuleConfigs = append(spec.ModuleConfigs, moduleConfig)
+			continue
+		}
+
+		spec.ModuleConfigs[index].VersionConstraints = moduleConfig.VersionConstraints
+	}
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+const (
+	linuxAmd64Checksum  = "1111111111111111111111111111111111111111111111111111111111111111"
+	darwinArm64Checksum = "2222222222222222222222222222222222222222222222222222222222222222"
+	windowsChecksum     = "3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+func writeFile(dir, name, content string) {
+	path := filepath.Join(dir, name)
+	Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+	Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+}
+
+func findProvider(providerConfigs []opendepotv1alpha1.ProviderConfig, name string) *opendepotv1alpha1.ProviderConfig {
+	for index := range providerConfigs {
+		if *providerConfigs[index].Name == name {
+			return &providerConfigs[index]
+		}
+	}
+
+	return nil
+}
+
+var _ = Describe("Importer", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	Context("When importing configuration directories", func() {
+		It("collects required providers and module calls", func() {
+			writeFile(dir, "main.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0"
+      configuration_aliases = [aws.east]
+    }
+    random  = "~> 3.0"
+    datadog = {
+      source  = "registry.terraform.io/DataDog/datadog"
+      version = ">= 3.0"
+    }
+    internal = {
+      source  = "registry.example.com/acme/internal"
+      version = "1.2.0"
+    }
+  }
+}
+
+module "eks" {
+  source  = "terraform-aws-modules/eks/aws"
+  version = "20.8.4"
+}
+
+module "vpc" {
+  source = "git::https://github.com/terraform-aws-modules/terraform-aws-vpc.git?ref=v5.1.0"
+}
+
+module "network" {
+  source = "./modules/network"
+}
+`)
+			writeFile(dir, "modules/network/main.tf", `
+module "labels" {
+  source  = "cloudposse/label/null"
+  version = "~> 0.25"
+}
+`)
+			writeFile(dir, ".terraform/modules/eks/main.tf", `
+module "ignored" {
+  source  = "acme/ignored/aws"
+  version = "1.0.0"
+}
+`)
+
+			imports := New(Options{})
+			Expect(imports.ImportDir(dir)).To(Succeed())
+
+			providerConfigs, err := imports.ProviderConfigs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerConfigs).To(HaveLen(4))
+
+			aws := findProvider(providerConfigs, "aws")
+			Expect(aws).NotTo(BeNil())
+			Expect(*aws.Namespace).To(Equal("hashicorp"))
+			Expect(aws.Registry).To(BeNil())
+			Expect(aws.VersionConstraints).To(Equal("~> 5.0"))
+
+			Expect(findProvider(providerConfigs, "random").VersionConstraints).To(Equal("~> 3.0"))
+
+			datadog := findProvider(providerConfigs, "datadog")
+			Expect(*datadog.Namespace).To(Equal("datadog"))
+			Expect(datadog.Registry).To(BeNil())
+
+			internal := findProvider(providerConfigs, "internal")
+			Expect(*internal.Registry).To(Equal("registry.example.com"))
+			Expect(internal.VersionConstraints).To(Equal("= 1.2.0"))
+
+			moduleConfigs := imports.ModuleConfigs()
+			Expect(moduleConfigs).To(HaveLen(3))
+			Expect(*moduleConfigs[0].Name).To(Equal("terraform-null-label"))
+			Expect(moduleConfigs[0].RepoOwner).To(Equal("cloudposse"))
+			Expect(moduleConfigs[0].Provider).To(Equal("null"))
+			Expect(moduleConfigs[0].VersionConstraints).To(Equal("~> 0.25"))
+			Expect(*moduleConfigs[1].Name).To(Equal("terraform-aws-eks"))
+			Expect(moduleConfigs[1].VersionConstraints).To(Equal("= 20.8.4"))
+			Expect(*moduleConfigs[2].Name).To(Equal("terraform-aws-vpc"))
+			Expect(moduleConfigs[2].Provider).To(Equal("aws"))
+			Expect(moduleConfigs[2].VersionConstraints).To(Equal("= 5.1.0"))
+			Expect(imports.Warnings()).To(BeEmpty())
+		})
+
+		It("pins providers to the versions in the lock file", func() {
+			writeFile(dir, "versions.tf", `
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+`)
+			writeFile(dir, LockFileName, `
+provider "registry.opentofu.org/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+  hashes      = ["h1:abc="]
+}
+`)
+			writeFile(dir, "other/"+LockFileName, `
+provider "registry.terraform.io/hashicorp/aws" {
+  version = "5.40.0"
+}
+`)
+
+			imports := New(Options{})
+			Expect(imports.ImportDir(dir)).To(Succeed())
+
+			providerConfigs, err := imports.ProviderConfigs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerConfigs).To(HaveLen(1))
+			Expect(providerConfigs[0].VersionConstraints).To(Equal(">= 5.31.0, <= 5.40.0"))
+			Expect(imports.Warnings()).To(ContainElement(ContainSubstring("locked at versions 5.31.0, 5.40.0")))
+		})
+
+		It("skips modules that cannot be mirrored", func() {
+			writeFile(dir, "main.tf", `
+module "gitlab" {
+  source = "git::https://gitlab.com/acme/network.git"
+}
+
+module "unversioned" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+module "branch" {
+  source = "github.com/terraform-aws-modules/terraform-aws-iam?ref=main"
+}
+`)
+
+			imports := New(Options{})
+			Expect(imports.ImportDir(dir)).To(Succeed())
+			Expect(imports.ModuleConfigs()).To(BeEmpty())
+			Expect(imports.Warnings()).To(HaveLen(3))
+		})
+	})
+
+	Context("When parsing module sources", func() {
+		DescribeTable("resolves the GitHub repository of a module",
+			func(source, owner, repo, provider, ref string) {
+				parsed, err := registry.ParseModuleSource(source)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(parsed).To(Equal(&registry.ModuleSource{Owner: owner, Repo: repo, Provider: provider, Ref: ref}))
+			},
+			Entry("registry address", "terraform-aws-modules/vpc/aws", "terraform-aws-modules", "terraform-aws-vpc", "aws", ""),
+			Entry("registry address with host", "registry.terraform.io/Azure/aks/azurerm", "Azure", "terraform-azurerm-aks", "azurerm", ""),
+			Entry("registry address with subdirectory", "terraform-aws-modules/iam/aws//modules/iam-role", "terraform-aws-modules", "terraform-aws-iam", "aws", ""),
+			Entry("GitHub shorthand", "github.com/defdev/terraform-aws-vpc//modules/subnet?ref=v1.0.0", "defdev", "terraform-aws-vpc", "aws", "v1.0.0"),
+			Entry("Git over HTTPS", "git::https://github.com/defdev/terraform-aws-vpc.git?ref=1.2.3", "defdev", "terraform-aws-vpc", "aws", "1.2.3"),
+			Entry("Git over SSH", "git@github.com:defdev/terraform-google-gke.git", "defdev", "terraform-google-gke", "google", ""),
+		)
+
+		It("ignores local paths", func() {
+			parsed, err := registry.ParseModuleSource("../modules/network")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(BeNil())
+		})
+
+		It("rejects modules on a private registry", func() {
+			_, err := registry.ParseModuleSource("app.terraform.io/acme/vpc/aws")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When resolving platforms from lock file hashes", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(map[string]string{registry.ProvidersV1: "/v1/providers/"})
+			})
+			mux.HandleFunc("/v1/providers/hashicorp/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(registry.ProviderVersions{Versions: []registry.ProviderVersion{{
+					Version: "5.31.0",
+					Platforms: []registry.ProviderPlatform{
+						{OS: "linux", Arch: "amd64"},
+						{OS: "darwin", Arch: "arm64"},
+						{OS: "windows", Arch: "amd64"},
+					},
+				}}})
+			})
+			mux.HandleFunc("/v1/providers/hashicorp/aws/5.31.0/download/linux/amd64", func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(registry.ProviderDownload{ShasumsURL: "/files/SHA256SUMS"})
+			})
+			mux.HandleFunc("/files/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "%s  terraform-provider-aws_5.31.0_linux_amd64.zip\n", linuxAmd64Checksum)
+				fmt.Fprintf(w, "%s  terraform-provider-aws_5.31.0_darwin_arm64.zip\n", darwinArm64Checksum)
+				fmt.Fprintf(w, "%s  terraform-provider-aws_5.31.0_windows_amd64.zip\n", windowsChecksum)
+			})
+			server = httptest.NewServer(mux)
+
+			writeFile(dir, LockFileName, fmt.Sprintf(`
+provider "registry.opentofu.org/hashicorp/aws" {
+  version = "5.31.0"
+  hashes = [
+    "h1:abc=",
+    "zh:%s",
+    "zh:%s",
+  ]
+}
+`, linuxAmd64Checksum, darwinArm64Checksum))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newImporter := func(platforms ...string) *Importer {
+			return New(Options{
+				Platforms:        platforms,
+				ResolvePlatforms: true,
+				NewRegistryClient: func(host string) *registry.Client {
+					Expect(host).To(Equal(registry.DefaultHost))
+					return registry.NewClient(server.URL, "")
+				},
+			})
+		}
+
+		It("imports the platforms whose checksums are in the lock file", func() {
+			imports := newImporter()
+			Expect(imports.ImportLockFile(filepath.Join(dir, LockFileName))).To(Succeed())
+
+			providerConfigs, err := imports.ProviderConfigs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerConfigs).To(HaveLen(1))
+			Expect(providerConfigs[0].VersionConstraints).To(Equal("= 5.31.0"))
+			Expect(providerConfigs[0].OperatingSystems).To(Equal([]string{"darwin", "linux"}))
+			Expect(providerConfigs[0].Architectures).To(Equal([]string{"amd64", "arm64"}))
+		})
+
+		It("limits the platforms to the requested ones", func() {
+			imports := newImporter("linux_amd64", "windows_amd64")
+			Expect(imports.ImportLockFile(filepath.Join(dir, LockFileName))).To(Succeed())
+
+			providerConfigs, err := imports.ProviderConfigs(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerConfigs[0].OperatingSystems).To(Equal([]string{"linux"}))
+			Expect(providerConfigs[0].Architectures).To(Equal([]string{"amd64"}))
+		})
+	})
+
+	Context("When patching a Depot", func() {
+		It("updates existing entries and appends new ones", func() {
+			awsName := "aws"
+			googleName := "google"
+			eksName := "terraform-aws-eks"
+			vpcName := "terraform-aws-vpc"
+			versionHistoryLimit := 5
+
+			spec := opendepotv1alpha1.DepotSpec{
+				ProviderConfigs: []opendepotv1alpha1.ProviderConfig{{
+					Name:                &awsName,
+					VersionConstraints:  ">= 5.0.0",
+					OperatingSystems:    []string{"linux"},
+					VersionHistoryLimit: &versionHistoryLimit,
+				}},
+				ModuleConfigs: []opendepotv1alpha1.ModuleConfig{{
+					Name:               &eksName,
+					Provider:           "aws",
+					RepoOwner:          "terraform-aws-modules",
+					VersionConstraints: ">= 20.0.0",
+				}},
+			}
+
+			hashicorp := "hashicorp"
+			PatchDepotSpec(&spec,
+				[]opendepotv1alpha1.ProviderConfig{
+					{Name: &awsName, Namespace: &hashicorp, VersionConstraints: "= 5.31.0"},
+					{Name: &googleName, Namespace: &hashicorp, VersionConstraints: "= 6.0.0"},
+				},
+				[]opendepotv1alpha1.ModuleConfig{
+					{Name: &eksName, Provider: "aws", RepoOwner: "Terraform-AWS-Modules", VersionConstraints: "= 20.8.4"},
+					{Name: &vpcName, Provider: "aws", RepoOwner: "terraform-aws-modules", VersionConstraints: "= 5.1.0"},
+				},
+			)
+
+			Expect(spec.ProviderConfigs).To(HaveLen(2))
+			Expect(spec.ProviderConfigs[0].VersionConstraints).To(Equal("= 5.31.0"))
+			Expect(spec.ProviderConfigs[0].OperatingSystems).To(Equal([]string{"linux"}))
+			Expect(*spec.ProviderConfigs[0].VersionHistoryLimit).To(Equal(5))
+			Expect(*spec.ProviderConfigs[1].Name).To(Equal("google"))
+
+			Expect(spec.ModuleConfigs).To(HaveLen(2))
+			Expect(spec.ModuleConfigs[0].VersionConstraints).To(Equal("= 20.8.4"))
+			Expect(spec.ModuleConfigs[0].RepoOwner).To(Equal("terraform-aws-modules"))
+			Expect(*spec.ModuleConfigs[1].Name).To(Equal("terraform-aws-vpc"))
+		})
+	})
+})
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+// ParsePlatform parses an '{os}_{arch}' pair such as 'linux_amd64'.
+func ParsePlatform(platform string) (registry.ProviderPlatform, error) {
+	os, arch, ok := strings.Cut(strings.ToLower(strings.TrimSpace(platform)), "_")
+	if !ok || os == "" || arch == "" || strings.Contains(arch, "_") {
+		return registry.ProviderPlatform{}, fmt.Errorf("invalid platform '%s': must be in the form '{os}_{arch}', e.g. 'linux_amd64'", platform)
+	}
+
+	return registry.ProviderPlatform{OS: os, Arch: arch}, nil
+}
+
+// providerPlatforms returns the platforms a provider is imported for. When platforms are resolved, these are the
+// platforms whose package checksums are recorded as 'zh:' hashes for a locked version, limited to Options.Platforms.
+// Otherwise they are Options.Platforms.
+func (i *Importer) providerPlatforms(ctx context.Context, provider *providerRequirement) ([]registry.ProviderPlatform, error) {
+	allowed := make([]registry.ProviderPlatform, 0, len(i.options.Platforms))
+	for _, item := range i.options.Platforms {
+		platform, err := ParsePlatform(item)
+		if err != nil {
+			return nil, err
+		}
+
+		allowed = append(allowed, platform)
+	}
+
+	if !i.options.ResolvePlatforms || len(provider.locked) == 0 {
+		return allowed, nil
+	}
+
+	address := provider.namespace + "/" + provider.name
+	registryClient := i.options.NewRegistryClient(provider.lockHost)
+
+	published, err := registryClient.ListProviderVersions(ctx, provider.namespace, provider.name)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedVersions := make([]*version.Version, 0, len(provider.locked))
+	for _, locked := range provider.locked {
+		lockedVersions = append(lockedVersions, locked.version)
+	}
+	slices.SortFunc(lockedVersions, func(a, b *version.Version) int { return a.Compare(b) })
+
+	var platforms []registry.ProviderPlatform
+	for _, lockedVersion := range lockedVersions {
+		locked := provider.locked[lockedVersion.String()]
+
+		checksums := map[string]struct{}{}
+		for _, hash := range locked.hashes {
+			if checksum, ok := strings.CutPrefix(hash, "zh:"); ok {
+				checksums[strings.ToLower(checksum)] = struct{}{}
+			}
+		}
+
+		if len(checksums) == 0 {
+			i.warn("provider '%s' %s has no 'zh:' hashes in the lock file, so its platforms cannot be resolved", address, lockedVersion)
+			continue
+		}
+
+		index := slices.IndexFunc(published.Versions, func(item registry.ProviderVersion) bool {
+			publishedVersion, err := version.NewVersion(item.Version)
+			return err == nil && publishedVersion.Equal(lockedVersion)
+		})
+
+		if index < 0 || len(published.Versions[index].Platforms) == 0 {
+			i.warn("provider '%s' %s is not published by registry '%s', so its platforms cannot be resolved", address, lockedVersion, registryClient.BaseURL)
+			continue
+		}
+
+		// Every platform of a version shares the same SHA256SUMS, so any platform's download response locates it.
+		first := published.Versions[index].Platforms[0]
+		download, err := registryClient.GetProviderDownload(ctx, provider.namespace, provider.name, published.Versions[index].Version, first.OS, first.Arch)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(download.ShasumsURL) == "" {
+			i.warn("registry '%s' did not return a shasums_url for provider '%s' %s, so its platforms cannot be resolved", registryClient.BaseURL, address, lockedVersion)
+			continue
+		}
+
+		shasums, err := registryClient.GetFile(ctx, download.ShasumsURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to download SHA256SUMS: %w", err)
+		}
+
+		for _, line := range strings.Split(string(shasums), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+
+			if _, ok := checksums[strings.ToLower(fields[0])]; !ok {
+				continue
+			}
+
+			platform, ok := platformOfPackage(strings.TrimPrefix(fields[1], "*"))
+			if !ok || slices.Contains(platforms, platform) {
+				continue
+			}
+
+			if len(allowed) > 0 && !slices.Contains(allowed, platform) {
+				continue
+			}
+
+			platforms = append(platforms, platform)
+		}
+	}
+
+	if len(platforms) == 0 {
+		i.warn("no locked platform of provider '%s' was resolved, so it is imported without operating systems or architectures", address)
+	}
+
+	return platforms, nil
+}
+
+// platformOfPackage returns the platform of a provider package named 'terraform-provider-{type}_{version}_{os}_{arch}.zip'.
+func platformOfPackage(fileName string) (registry.ProviderPlatform, bool) {
+	name, ok := strings.CutSuffix(fileName, ".zip")
+	if !ok {
+		return registry.ProviderPlatform{}, false
+	}
+
+	parts := strings.Split(name, "_")
+	if len(parts) < 4 {
+		return registry.ProviderPlatform{}, false
+	}
+
+	return registry.ProviderPlatform{OS: parts[len(parts)-2], Arch: parts[len(parts)-1]}, true
+}
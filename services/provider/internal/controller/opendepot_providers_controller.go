@@ -19,7 +19,6 @@ package controller
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -72,62 +71,58 @@ func (r *ProviderReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
-	if len(provider.Spec.ProviderConfig.OperatingSystems) == 0 {
-		return ctrl.Result{}, fmt.Errorf("the provider operatingSystems field cannot be empty")
-	}
-
-	if len(provider.Spec.ProviderConfig.Architectures) == 0 {
-		return ctrl.Result{}, fmt.Errorf("the provider architectures field cannot be empty")
-	}
-
 	desiredVersionNames := map[string]struct{}{}
 	providerVersionRefs := make(map[string]*opendepotv1alpha1.ProviderVersion)
 
 	for _, providerVersion := range provider.Spec.Versions {
 		sanitizedVersion := utils.SanitizeVersion(providerVersion.Version)
 
-		for _, osName := range provider.Spec.ProviderConfig.OperatingSystems {
-			for _, arch := range provider.Spec.ProviderConfig.Architectures {
-				resourceName := providerVersionResourceName(*providerName, sanitizedVersion, osName, arch)
-				desiredVersionNames[resourceName] = struct{}{}
-
-				refKey := fmt.Sprintf("%s-%s-%s", sanitizedVersion, osName, arch)
-				providerVersionRefs[refKey] = &opendepotv1alpha1.ProviderVersion{
-					Name:            resourceName,
-					Version:         sanitizedVersion,
-					OperatingSystem: osName,
-					Architecture:    arch,
-				}
-
-				objectKey := client.ObjectKey{Name: resourceName, Namespace: provider.Namespace}
-				existingVersion := &opendepotv1alpha1.Version{}
+		platforms, err := providerVersionPlatforms(provider.Spec.ProviderConfig, providerVersion)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 
-				if err := r.Get(ctx, objectKey, existingVersion); err != nil {
-					if !errors.IsNotFound(err) {
-						return ctrl.Result{}, err
-					}
+		for _, platform := range platforms {
+			osName, arch := platform.os, platform.arch
+			resourceName := utils.ProviderVersionResourceName(*providerName, sanitizedVersion, osName, arch)
+			desiredVersionNames[resourceName] = struct{}{}
+
+			refKey := fmt.Sprintf("%s-%s-%s", sanitizedVersion, osName, arch)
+			providerVersionRefs[refKey] = &opendepotv1alpha1.ProviderVersion{
+				Name:            resourceName,
+				Version:         sanitizedVersion,
+				OperatingSystem: osName,
+				Architecture:    arch,
+			}
 
-					newVersion, err := r.versionForProvider(provider, providerName, objectKey, sanitizedVersion, osName, arch)
-					if err != nil {
-						return ctrl.Result{}, err
-					}
+			objectKey := client.ObjectKey{Name: resourceName, Namespace: provider.Namespace}
+			existingVersion := &opendepotv1alpha1.Version{}
 
-					if err = r.Create(ctx, newVersion, &client.CreateOptions{FieldManager: opendepotControllerName}); err != nil {
-						return ctrl.Result{}, err
-					}
-					continue
+			if err := r.Get(ctx, objectKey, existingVersion); err != nil {
+				if !errors.IsNotFound(err) {
+					return ctrl.Result{}, err
 				}
 
-				updatedVersion, err := r.versionForProvider(provider, providerName, objectKey, sanitizedVersion, osName, arch)
+				newVersion, err := r.versionForProvider(provider, providerName, objectKey, sanitizedVersion, osName, arch)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
 
-				updatedVersion.ObjectMeta.ResourceVersion = existingVersion.ObjectMeta.ResourceVersion
-				updatedVersion.Spec.FileName = existingVersion.Spec.FileName
-				if err = r.Update(ctx, updatedVersion, &client.UpdateOptions{FieldManager: opendepotControllerName}); err != nil {
+				if err = r.Create(ctx, newVersion, &client.CreateOptions{FieldManager: opendepotControllerName}); err != nil {
 					return ctrl.Result{}, err
 				}
+				continue
+			}
+
+			updatedVersion, err := r.versionForProvider(provider, providerName, objectKey, sanitizedVersion, osName, arch)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			updatedVersion.ObjectMeta.ResourceVersion = existingVersion.ObjectMeta.ResourceVersion
+			updatedVersion.Spec.FileName = existingVersion.Spec.FileName
+			if err = r.Update(ctx, updatedVersion, &client.UpdateOptions{FieldManager: opendepotControllerName}); err != nil {
+				return ctrl.Result{}, err
 			}
 		}
 	}
@@ -224,13 +219,36 @@ func (r *ProviderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-func providerVersionResourceName(providerName, version, osName, arch string) string {
-	raw := fmt.Sprintf("%s-%s-%s-%s", providerName, version, osName, arch)
-	raw = strings.ToLower(raw)
-	raw = strings.ReplaceAll(raw, ".", "-")
-	raw = strings.ReplaceAll(raw, "_", "-")
-	raw = strings.ReplaceAll(raw, "/", "-")
-	return raw
+// providerPlatform is an operating system and architecture combination a provider version is mirrored for.
+type providerPlatform struct {
+	os   string
+	arch string
+}
+
+// providerVersionPlatforms returns the platforms to create Version resources for. A provider version that names
+// its own operating system and architecture, as versions discovered by a Depot do, is mirrored for that platform
+// only. Otherwise every combination of the provider config's operating systems and architectures is used.
+func providerVersionPlatforms(providerConfig opendepotv1alpha1.ProviderConfig, providerVersion opendepotv1alpha1.ProviderVersion) ([]providerPlatform, error) {
+	if providerVersion.OperatingSystem != "" && providerVersion.Architecture != "" {
+		return []providerPlatform{{os: providerVersion.OperatingSystem, arch: providerVersion.Architecture}}, nil
+	}
+
+	if len(providerConfig.OperatingSystems) == 0 {
+		return nil, fmt.Errorf("the provider operatingSystems field cannot be empty")
+	}
+
+	if len(providerConfig.Architectures) == 0 {
+		return nil, fmt.Errorf("the provider architectures field cannot be empty")
+	}
+
+	var platforms []providerPlatform
+	for _, osName := range providerConfig.OperatingSystems {
+		for _, arch := range providerConfig.Architectures {
+			platforms = append(platforms, providerPlatform{os: osName, arch: arch})
+		}
+	}
+
+	return platforms, nil
 }
 
 // versionForProvider creates the desired Version resource for a specific provider version/os/arch tuple.
@@ -173,6 +173,44 @@ var _ = Describe("Provider Controller", func() {
 			Expect(err.Error()).To(ContainSubstring("architectures"))
 		})
 
+		It("creates Version CRs only for the platform named on a version", func() {
+			providerName := "platform-provider"
+			namespacedName := types.NamespacedName{Name: providerName, Namespace: testNamespace}
+
+			provider := &opendepotv1alpha1.Provider{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      providerName,
+					Namespace: testNamespace,
+				},
+				Spec: opendepotv1alpha1.ProviderSpec{
+					Versions: []opendepotv1alpha1.ProviderVersion{
+						{Version: "1.0.0", OperatingSystem: "linux", Architecture: "amd64"},
+						{Version: "1.0.0", OperatingSystem: "darwin", Architecture: "arm64"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, provider)).To(Succeed())
+			DeferCleanup(func() {
+				_ = k8sClient.Delete(ctx, provider)
+			})
+
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: namespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			versionList := &opendepotv1alpha1.VersionList{}
+			Expect(k8sClient.List(ctx, versionList,
+				client.InNamespace(testNamespace),
+				client.MatchingLabels{"opendepot.defdev.io/provider": providerName},
+			)).To(Succeed())
+			Expect(versionList.Items).To(HaveLen(2))
+
+			var names []string
+			for _, v := range versionList.Items {
+				names = append(names, v.Name)
+			}
+			Expect(names).To(ConsistOf("platform-provider-1-0-0-linux-amd64", "platform-provider-1-0-0-darwin-arm64"))
+		})
+
 		It("resets ForceSync to false after reconciliation", func() {
 			providerName := "force-sync-provider"
 			namespacedName := types.NamespacedName{Name: providerName, Namespace: testNamespace}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// downloadCache caches downloaded archives on the server's disk. It is nil when the cache is disabled.
+var downloadCache *artifactCache
+
+// cacheSourcesSuffix is the suffix of the file next to a cached archive that lists the download sources it was
+// verified for, so they are kept across restarts.
+const cacheSourcesSuffix = ".sources"
+
+// artifactCache is a least recently used cache of archives on disk, keyed by the SHA256 checksum of the archive.
+// An archive is only served from the cache to a download source that verified it against its storage backend, so
+// knowing the checksum of an archive is not enough to download it from another source.
+type artifactCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List
+	fills   map[string]*cacheFill
+
+	hits       atomic.Int64
+	misses     atomic.Int64
+	coalesced  atomic.Int64
+	evictions  atomic.Int64
+	fillErrors atomic.Int64
+}
+
+// cacheEntry is an archive stored in the cache and the download sources it was verified for.
+type cacheEntry struct {
+	key     string
+	size    int64
+	sources map[string]bool
+}
+
+// cacheFill is a fetch of an archive in progress. Requests for the same archive wait for it instead of fetching it
+// again.
+type cacheFill struct {
+	done chan struct{}
+	err  error
+}
+
+// newArtifactCache returns a cache of at most maxBytes in dir, indexing the archives left in dir by a previous run
+// from the least to the most recently used along with the download sources they were verified for.
+func newArtifactCache(dir string, maxBytes int64) (*artifactCache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("the cache size must be positive")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	cache := &artifactCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		fills:    map[string]*cacheFill{},
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type cachedFile struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cachedFile
+	var sourceFiles []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		if strings.HasSuffix(dirEntry.Name(), cacheSourcesSuffix) {
+			sourceFiles = append(sourceFiles, dirEntry.Name())
+			continue
+		}
+
+		// Archives that were being fetched when the server stopped are incomplete.
+		if strings.HasPrefix(dirEntry.Name(), ".fill-") {
+			_ = os.Remove(filepath.Join(dir, dirEntry.Name()))
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, cachedFile{key: dirEntry.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+
+	slices.SortFunc(files, func(a, b cachedFile) int { return a.modTime.Compare(b.modTime) })
+	for _, file := range files {
+		cache.entries[file.key] = cache.lru.PushFront(&cacheEntry{key: file.key, size: file.size, sources: cache.readSources(file.key)})
+		cache.size += file.size
+	}
+
+	// The sources of an archive that was evicted while the server stopped are left behind.
+	for _, sourceFile := range sourceFiles {
+		if _, ok := cache.entries[strings.TrimSuffix(sourceFile, cacheSourcesSuffix)]; !ok {
+			_ = os.Remove(filepath.Join(dir, sourceFile))
+		}
+	}
+
+	cache.mu.Lock()
+	cache.evict()
+	cache.mu.Unlock()
+
+	return cache, nil
+}
+
+// cacheKey returns the hex encoded SHA256 checksum of the base64 encoded checksum, which names the archive in the
+// cache. It returns an empty string when checksum is not a SHA256 checksum.
+func cacheKey(checksum string) string {
+	sum, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return ""
+	}
+
+	return hex.EncodeToString(sum)
+}
+
+// readSources returns the download sources the archive named key was verified for by a previous run. An archive
+// whose sources cannot be read is verified again for every source.
+func (c *artifactCache) readSources(key string) map[string]bool {
+	sources := map[string]bool{}
+	content, err := os.ReadFile(filepath.Join(c.dir, key+cacheSourcesSuffix))
+	if err != nil {
+		return sources
+	}
+
+	var list []string
+	if err := json.Unmarshal(content, &list); err != nil {
+		logger.Warn("failed to read the download sources of a cached archive", "error", err, "key", key)
+		return sources
+	}
+
+	for _, source := range list {
+		sources[source] = true
+	}
+
+	return sources
+}
+
+// writeSources stores the download sources entry was verified for next to its archive. A source that fails to be
+// stored is still served until the server restarts. It must be called with c.mu held.
+func (c *artifactCache) writeSources(entry *cacheEntry) {
+	list := make([]string, 0, len(entry.sources))
+	for source := range entry.sources {
+		list = append(list, source)
+	}
+	slices.Sort(list)
+
+	content, err := json.Marshal(list)
+	if err == nil {
+		// The sources are written to a fill file first, so a crash never leaves them half written.
+		var tmp *os.File
+		tmp, err = os.CreateTemp(c.dir, ".fill-*")
+		if err == nil {
+			_, err = tmp.Write(content)
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+
+			if err == nil {
+				err = os.Rename(tmp.Name(), filepath.Join(c.dir, entry.key+cacheSourcesSuffix))
+			}
+
+			if err != nil {
+				os.Remove(tmp.Name())
+			}
+		}
+	}
+
+	if err != nil {
+		logger.Warn("failed to store the download sources of a cached archive", "error", err, "key", entry.key)
+	}
+}
+
+// open returns the cached archive with checksum once source has verified it. When the archive is cached for another
+// source, verify is called to read the checksum of the archive from the storage backend of source. On a miss, fetch is
+// called to read the archive from its storage backend, which verifies it for source. Concurrent requests for the same
+// archive share one fetch. The returned file must be closed by the caller.
+func (c *artifactCache) open(ctx context.Context, checksum, source string, verify func() (*string, error), fetch func() (io.Reader, error)) (*os.File, error) {
+	key := cacheKey(checksum)
+	if key == "" {
+		return nil, fmt.Errorf("invalid archive checksum '%s'", checksum)
+	}
+
+	for {
+		c.mu.Lock()
+		if element, ok := c.entries[key]; ok && element.Value.(*cacheEntry).sources[source] {
+			c.lru.MoveToFront(element)
+			// The file stays readable once it is open, even when it is evicted while being served.
+			file, err := os.Open(filepath.Join(c.dir, key))
+			c.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+
+			c.hits.Add(1)
+			return file, nil
+		}
+
+		if fill, ok := c.fills[key]; ok {
+			c.mu.Unlock()
+			c.coalesced.Add(1)
+			select {
+			case <-fill.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			// A fetch cancelled by the client that started it is retried by the requests waiting for it.
+			if fill.err != nil && !errors.Is(fill.err, context.Canceled) {
+				return nil, fill.err
+			}
+			continue
+		}
+
+		fill := &cacheFill{done: make(chan struct{})}
+		c.fills[key] = fill
+		c.mu.Unlock()
+		c.misses.Add(1)
+
+		file, err := c.fill(key, checksum, source, verify, fetch)
+		if err != nil {
+			c.fillErrors.Add(1)
+		}
+
+		c.mu.Lock()
+		delete(c.fills, key)
+		c.mu.Unlock()
+
+		fill.err = err
+		close(fill.done)
+		return file, err
+	}
+}
+
+// fill fetches the archive named key with checksum and stores it in the cache as verified for source. An archive that
+// is already cached is only verified for source once its storage backend reports the same checksum, and an archive
+// larger than the cache is served without being stored.
+func (c *artifactCache) fill(key, checksum, source string, verify func() (*string, error), fetch func() (io.Reader, error)) (*os.File, error) {
+	c.mu.Lock()
+	_, cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached {
+		if err := verifyCachedArchive(key, checksum, verify, fetch); err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		element, ok := c.entries[key]
+		if !ok {
+			// The archive was evicted while it was verified, so it is fetched again to be stored.
+			c.mu.Unlock()
+			return c.fill(key, checksum, source, verify, fetch)
+		}
+
+		entry := element.Value.(*cacheEntry)
+		entry.sources[source] = true
+		c.writeSources(entry)
+		c.lru.MoveToFront(element)
+		file, err := os.Open(filepath.Join(c.dir, key))
+		c.mu.Unlock()
+		return file, err
+	}
+
+	reader, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(reader)
+
+	tmp, err := os.CreateTemp(c.dir, ".fill-*")
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), reader)
+	if err == nil {
+		err = verifyCacheKey(key, h)
+	}
+
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	if size > c.maxBytes {
+		// The open file is still served once it is removed.
+		os.Remove(tmp.Name())
+		return tmp, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, key)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	entry := &cacheEntry{key: key, size: size, sources: map[string]bool{source: true}}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.writeSources(entry)
+	c.size += size
+	c.evict()
+	return tmp, nil
+}
+
+// verifyCachedArchive verifies that the storage backend of a download source holds the cached archive named key with
+// checksum. The checksum reported by verify is compared without downloading the archive, which is only read in full
+// when the storage backend reports no checksum for it.
+func verifyCachedArchive(key, checksum string, verify func() (*string, error), fetch func() (io.Reader, error)) error {
+	objectChecksum, err := verify()
+	if err != nil {
+		return err
+	}
+
+	if objectChecksum != nil {
+		if *objectChecksum != checksum {
+			return fmt.Errorf("checksum mismatch from storage system: want %s, received %s", checksum, *objectChecksum)
+		}
+		return nil
+	}
+
+	reader, err := fetch()
+	if err != nil {
+		return err
+	}
+	defer closeReader(reader)
+
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return err
+	}
+
+	return verifyCacheKey(key, h)
+}
+
+// verifyCacheKey returns an error when the checksum of the archive written to h does not match key.
+func verifyCacheKey(key string, h hash.Hash) error {
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != key {
+		return fmt.Errorf("checksum mismatch of archive read from storage system: want %s, received %s", key, sum)
+	}
+
+	return nil
+}
+
+// evict removes the least recently used archives until the cache fits in its size. It must be called with c.mu held.
+func (c *artifactCache) evict() {
+	for c.size > c.maxBytes {
+		element := c.lru.Back()
+		if element == nil {
+			return
+		}
+
+		entry := element.Value.(*cacheEntry)
+		if err := os.Remove(filepath.Join(c.dir, entry.key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("failed to evict archive from the download cache", "error", err, "key", entry.key)
+		}
+		_ = os.Remove(filepath.Join(c.dir, entry.key+cacheSourcesSuffix))
+
+		c.lru.Remove(element)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+		c.evictions.Add(1)
+	}
+}
+
+// writeMetrics writes the metrics of the cache in the Prometheus text format.
+func (c *artifactCache) writeMetrics(w io.Writer) {
+	c.mu.Lock()
+	size, objects := c.size, c.lru.Len()
+	c.mu.Unlock()
+
+	metrics := []struct {
+		name, kind, help string
+		value            int64
+	}{
+		{"opendepot_server_cache_hits_total", "counter", "Downloads served from the download cache.", c.hits.Load()},
+		{"opendepot_server_cache_misses_total", "counter", "Downloads that fetched the archive from its storage backend.", c.misses.Load()},
+		{"opendepot_server_cache_coalesced_total", "counter", "Downloads that waited for a fetch of the same archive by another download.", c.coalesced.Load()},
+		{"opendepot_server_cache_evictions_total", "counter", "Archives evicted from the download cache.", c.evictions.Load()},
+		{"opendepot_server_cache_fetch_errors_total", "counter", "Fetches of archives from their storage backend that failed.", c.fillErrors.Load()},
+		{"opendepot_server_cache_size_bytes", "gauge", "Size of the archives in the download cache.", size},
+		{"opendepot_server_cache_max_size_bytes", "gauge", "Maximum size of the archives in the download cache.", c.maxBytes},
+		{"opendepot_server_cache_objects", "gauge", "Archives in the download cache.", int64(objects)},
+	}
+
+	for _, metric := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value)
+	}
+}
+
+// serveMetrics serves the metrics of the download cache.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	downloadCache.writeMetrics(w)
+}
+
+// serveCachedObject serves the archive fileName with checksum from the download cache, calling verify to read its
+// checksum from its storage backend when it is cached for another download source, and fetch to read it on a miss.
+// Range and conditional requests are answered from the cached file.
+func serveCachedObject(w http.ResponseWriter, r *http.Request, fileName, checksum string, verify func() (*string, error), fetch func() (io.Reader, error)) {
+	file, err := downloadCache.open(r.Context(), checksum, downloadSource(r), verify, fetch)
+	if err != nil {
+		logger.Error("failed to get module from storage system", "error", err)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	setArchiveContentType(w, fileName)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, cacheKey(checksum)))
+	http.ServeContent(w, r, fileName, time.Time{}, file)
+}
+
+// downloadSource identifies the storage object a download request reads, which is the route of the request and the
+// Version whose storage config it is read with.
+func downloadSource(r *http.Request) string {
+	return fmt.Sprintf("%s?namespace=%s&version=%s", r.URL.Path, r.URL.Query().Get("namespace"), r.URL.Query().Get("version"))
+}
+
+// closeReader closes reader when the storage system returned an io.ReadCloser.
+func closeReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// archiveChecksum returns the base64 encoded SHA256 checksum of archive, as recorded in a Version's status.
+func archiveChecksum(archive []byte) string {
+	sum := sha256.Sum256(archive)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// fakeFetch returns a fetch function reading archive that counts its calls.
+func fakeFetch(archive []byte, calls *atomic.Int64) func() (io.Reader, error) {
+	return func() (io.Reader, error) {
+		calls.Add(1)
+		return bytes.NewReader(archive), nil
+	}
+}
+
+// noChecksum is a verify function for a storage backend that reports no checksum, so archives are read in full to be
+// verified.
+func noChecksum() (*string, error) {
+	return nil, nil
+}
+
+// readCached opens the archive with checksum for source and returns its content.
+func readCached(t *testing.T, cache *artifactCache, checksum, source string, fetch func() (io.Reader, error)) []byte {
+	t.Helper()
+	file, err := cache.open(context.Background(), checksum, source, noChecksum, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return content
+}
+
+// cachedKeys returns the archives stored in the cache from the most to the least recently used.
+func cachedKeys(cache *artifactCache) []string {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	var keys []string
+	for element := cache.lru.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*cacheEntry).key)
+	}
+
+	return keys
+}
+
+func TestArtifactCacheEviction(t *testing.T) {
+	archives := map[string][]byte{
+		"a": []byte("archive a"),
+		"b": []byte("archive b"),
+		"c": []byte("archive c"),
+	}
+
+	tests := []struct {
+		name string
+		// opens are the archives opened in order, all for the same source.
+		opens             []string
+		expectedKeys      []string
+		expectedFetches   int64
+		expectedEvictions int64
+	}{
+		{
+			name:            "archives that fit are all kept",
+			opens:           []string{"a", "b"},
+			expectedKeys:    []string{"b", "a"},
+			expectedFetches: 2,
+		},
+		{
+			name:              "least recently used archive is evicted",
+			opens:             []string{"a", "b", "c"},
+			expectedKeys:      []string{"c", "b"},
+			expectedFetches:   3,
+			expectedEvictions: 1,
+		},
+		{
+			name:              "hit makes an archive the most recently used",
+			opens:             []string{"a", "b", "a", "c"},
+			expectedKeys:      []string{"c", "a"},
+			expectedFetches:   3,
+			expectedEvictions: 1,
+		},
+		{
+			name:              "evicted archive is fetched again",
+			opens:             []string{"a", "b", "c", "a"},
+			expectedKeys:      []string{"a", "c"},
+			expectedFetches:   4,
+			expectedEvictions: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two archives fit in the cache.
+			cache, err := newArtifactCache(t.TempDir(), int64(2*len(archives["a"])))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fetches atomic.Int64
+			for _, name := range tt.opens {
+				archive := archives[name]
+				if content := readCached(t, cache, archiveChecksum(archive), "source", fakeFetch(archive, &fetches)); !bytes.Equal(content, archive) {
+					t.Fatalf("read '%s' for archive %s", content, name)
+				}
+			}
+
+			var expectedKeys []string
+			for _, name := range tt.expectedKeys {
+				expectedKeys = append(expectedKeys, cacheKey(archiveChecksum(archives[name])))
+			}
+
+			if keys := cachedKeys(cache); !slices.Equal(keys, expectedKeys) {
+				t.Fatalf("cache holds %v, want %v", keys, expectedKeys)
+			}
+
+			for _, key := range expectedKeys {
+				if _, err := os.Stat(filepath.Join(cache.dir, key)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if fetches.Load() != tt.expectedFetches || cache.evictions.Load() != tt.expectedEvictions {
+				t.Fatalf("fetched %d times with %d evictions, want %d and %d", fetches.Load(), cache.evictions.Load(), tt.expectedFetches, tt.expectedEvictions)
+			}
+		})
+	}
+}
+
+func TestArtifactCacheRestart(t *testing.T) {
+	dir := t.TempDir()
+	archive := []byte("archive a")
+	key := cacheKey(archiveChecksum(archive))
+
+	if err := os.WriteFile(filepath.Join(dir, key), archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".fill-123"), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := newArtifactCache(dir, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := cachedKeys(cache); !slices.Equal(keys, []string{key}) {
+		t.Fatalf("cache holds %v, want the archive left by the previous run", keys)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".fill-123")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("the incomplete fill of the previous run was not removed")
+	}
+}
+
+func TestArtifactCacheRestartKeepsSources(t *testing.T) {
+	dir := t.TempDir()
+	archive := []byte("archive a")
+	checksum := archiveChecksum(archive)
+	evicted := cacheKey(archiveChecksum([]byte("archive b")))
+
+	cache, err := newArtifactCache(dir, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int64
+	readCached(t, cache, checksum, "first", fakeFetch(archive, &fetches))
+	readCached(t, cache, checksum, "second", fakeFetch(archive, &fetches))
+
+	// The sources of an archive that was evicted while the server stopped are left behind.
+	if err := os.WriteFile(filepath.Join(dir, evicted+cacheSourcesSuffix), []byte(`["first"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := newArtifactCache(dir, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{"first", "second"} {
+		if content := readCached(t, restarted, checksum, source, fakeFetch(archive, &fetches)); !bytes.Equal(content, archive) {
+			t.Fatalf("read '%s' for source %s, want the archive", content, source)
+		}
+	}
+
+	if fetches.Load() != 2 || restarted.hits.Load() != 2 {
+		t.Fatalf("fetched %d times with %d hits after the restart, want the sources verified before it served from the cache", fetches.Load(), restarted.hits.Load())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, evicted+cacheSourcesSuffix)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("the sources of an archive that is no longer cached were not removed")
+	}
+
+	restarted.mu.Lock()
+	restarted.maxBytes = 0
+	restarted.evict()
+	restarted.mu.Unlock()
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dirEntries) != 0 {
+		t.Fatalf("cache directory has %d files after evicting every archive, want none", len(dirEntries))
+	}
+}
+
+func TestArtifactCacheSources(t *testing.T) {
+	archive := []byte("archive a")
+	checksum := archiveChecksum(archive)
+
+	otherChecksum := archiveChecksum([]byte("archive b"))
+
+	tests := []struct {
+		name string
+		// verify and fetch are the verify and fetch of the second source, once the archive is cached for the first.
+		verify            func() (*string, error)
+		fetch             func() (io.Reader, error)
+		expectedErr       bool
+		expectedVerified  bool
+		expectedFetches   int64
+		expectedFillError int64
+	}{
+		{
+			name:             "matching checksum is verified for the source without reading the archive",
+			verify:           func() (*string, error) { return &checksum, nil },
+			fetch:            func() (io.Reader, error) { return bytes.NewReader(archive), nil },
+			expectedVerified: true,
+		},
+		{
+			name:              "other checksum is not verified for the source",
+			verify:            func() (*string, error) { return &otherChecksum, nil },
+			fetch:             func() (io.Reader, error) { return bytes.NewReader(archive), nil },
+			expectedErr:       true,
+			expectedFillError: 1,
+		},
+		{
+			name:              "failed checksum read is not verified for the source",
+			verify:            func() (*string, error) { return nil, errors.New("access denied") },
+			fetch:             func() (io.Reader, error) { return bytes.NewReader(archive), nil },
+			expectedErr:       true,
+			expectedFillError: 1,
+		},
+		{
+			name:             "matching archive is verified for the source without a checksum",
+			verify:           noChecksum,
+			fetch:            func() (io.Reader, error) { return bytes.NewReader(archive), nil },
+			expectedVerified: true,
+			expectedFetches:  1,
+		},
+		{
+			name:              "other archive is not verified for the source without a checksum",
+			verify:            noChecksum,
+			fetch:             func() (io.Reader, error) { return bytes.NewReader([]byte("archive b")), nil },
+			expectedErr:       true,
+			expectedFetches:   1,
+			expectedFillError: 1,
+		},
+		{
+			name:              "failed fetch is not verified for the source without a checksum",
+			verify:            noChecksum,
+			fetch:             func() (io.Reader, error) { return nil, errors.New("access denied") },
+			expectedErr:       true,
+			expectedFetches:   1,
+			expectedFillError: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, err := newArtifactCache(t.TempDir(), 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var fetches atomic.Int64
+			readCached(t, cache, checksum, "first", fakeFetch(archive, &fetches))
+
+			var secondFetches atomic.Int64
+			fetch := func() (io.Reader, error) {
+				secondFetches.Add(1)
+				return tt.fetch()
+			}
+
+			file, err := cache.open(context.Background(), checksum, "second", tt.verify, fetch)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("open returned error %v, want an error: %t", err, tt.expectedErr)
+			}
+
+			if file != nil {
+				file.Close()
+			}
+
+			cache.mu.Lock()
+			verified := cache.entries[cacheKey(checksum)].Value.(*cacheEntry).sources["second"]
+			cache.mu.Unlock()
+
+			if verified != tt.expectedVerified || cache.fillErrors.Load() != tt.expectedFillError {
+				t.Fatalf("archive verified for the second source: %t with %d fill errors, want %t and %d", verified, cache.fillErrors.Load(), tt.expectedVerified, tt.expectedFillError)
+			}
+
+			if secondFetches.Load() != tt.expectedFetches {
+				t.Fatalf("fetched the archive %d times for the second source, want %d", secondFetches.Load(), tt.expectedFetches)
+			}
+		})
+	}
+}
+
+func TestArtifactCacheSingleFlight(t *testing.T) {
+	const downloads = 5
+
+	cache, err := newArtifactCache(t.TempDir(), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := []byte("archive a")
+	checksum := archiveChecksum(archive)
+
+	var fetches atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func() (io.Reader, error) {
+		if fetches.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return bytes.NewReader(archive), nil
+	}
+
+	var wg sync.WaitGroup
+	contents := make([][]byte, downloads)
+	errs := make([]error, downloads)
+	download := func(i int) {
+		defer wg.Done()
+		file, err := cache.open(context.Background(), checksum, "source", noChecksum, fetch)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		defer file.Close()
+		contents[i], errs[i] = io.ReadAll(file)
+	}
+
+	wg.Add(1)
+	go download(0)
+	<-started
+
+	for i := 1; i < downloads; i++ {
+		wg.Add(1)
+		go download(i)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.coalesced.Load() < downloads-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d downloads waited for the fetch, want %d", cache.coalesced.Load(), downloads-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i := range downloads {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+
+		if !bytes.Equal(contents[i], archive) {
+			t.Fatalf("download %d read '%s'", i, contents[i])
+		}
+	}
+
+	if fetches.Load() != 1 || cache.misses.Load() != 1 {
+		t.Fatalf("fetched %d times with %d misses, want a single fetch", fetches.Load(), cache.misses.Load())
+	}
+}
+
+func TestArtifactCacheOversize(t *testing.T) {
+	archive := []byte("an archive larger than the cache")
+	checksum := archiveChecksum(archive)
+
+	cache, err := newArtifactCache(t.TempDir(), int64(len(archive)-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches atomic.Int64
+	for range 2 {
+		if content := readCached(t, cache, checksum, "source", fakeFetch(archive, &fetches)); !bytes.Equal(content, archive) {
+			t.Fatalf("read '%s', want the archive", content)
+		}
+	}
+
+	if keys := cachedKeys(cache); len(keys) != 0 {
+		t.Fatalf("cache holds %v, want the oversize archive passed through", keys)
+	}
+
+	dirEntries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(dirEntries) != 0 || fetches.Load() != 2 {
+		t.Fatalf("cache directory has %d files after %d fetches, want none after 2", len(dirEntries), fetches.Load())
+	}
+}
+
+func TestServeCachedObject(t *testing.T) {
+	archive := []byte("module archive")
+	checksum := archiveChecksum(archive)
+	etag := fmt.Sprintf(`"%s"`, cacheKey(checksum))
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "full download",
+			expectedStatus: http.StatusOK,
+			expectedBody:   string(archive),
+		},
+		{
+			name:           "range",
+			headers:        map[string]string{"Range": "bytes=0-5"},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "module",
+		},
+		{
+			name:           "range of a matching entity",
+			headers:        map[string]string{"Range": "bytes=7-", "If-Range": etag},
+			expectedStatus: http.StatusPartialContent,
+			expectedBody:   "archive",
+		},
+		{
+			name:           "range of another entity",
+			headers:        map[string]string{"Range": "bytes=7-", "If-Range": `"other"`},
+			expectedStatus: http.StatusOK,
+			expectedBody:   string(archive),
+		},
+		{
+			name:           "matching ETag",
+			headers:        map[string]string{"If-None-Match": etag},
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "other ETag",
+			headers:        map[string]string{"If-None-Match": `"other"`},
+			expectedStatus: http.StatusOK,
+			expectedBody:   string(archive),
+		},
+	}
+
+	cache, err := newArtifactCache(t.TempDir(), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousCache := downloadCache
+	downloadCache = cache
+	t.Cleanup(func() { downloadCache = previousCache })
+
+	var fetches atomic.Int64
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/opendepot/modules/v1/download/replicated/example/archive.tar.gz?namespace=default&version=example-1.0.0", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			serveCachedObject(w, r, "archive.tar.gz", checksum, noChecksum, fakeFetch(archive, &fetches))
+
+			if w.Code != tt.expectedStatus || w.Body.String() != tt.expectedBody {
+				t.Fatalf("served %d '%s', want %d '%s'", w.Code, w.Body.String(), tt.expectedStatus, tt.expectedBody)
+			}
+
+			if w.Code != http.StatusNotModified && w.Header().Get("ETag") != etag {
+				t.Fatalf("served ETag %s, want %s", w.Header().Get("ETag"), etag)
+			}
+		})
+	}
+
+	if fetches.Load() != 1 {
+		t.Fatalf("fetched %d times, want every request after the first served from the cache", fetches.Load())
+	}
+}
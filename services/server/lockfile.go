@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+const (
+	// lockFileMaxBytes bounds the size of a lock file accepted by the compliance endpoint.
+	lockFileMaxBytes = 1 << 20
+	// lockFileDefaultFailOnSeverity is the lowest finding severity that is a violation when none is requested.
+	lockFileDefaultFailOnSeverity = "CRITICAL"
+)
+
+// severityRanks orders finding severities. UNKNOWN findings are reported but never counted as a violation.
+var severityRanks = map[string]int{
+	"UNKNOWN":  0,
+	"LOW":      1,
+	"MEDIUM":   2,
+	"HIGH":     3,
+	"CRITICAL": 4,
+}
+
+// lockFile is the subset of '.terraform.lock.hcl' checked by the compliance endpoint.
+type lockFile struct {
+	Providers []lockFileProvider `hcl:"provider,block"`
+	Remain    hcl.Body           `hcl:",remain"`
+}
+
+// lockFileProvider is a single provider block of a lock file.
+type lockFileProvider struct {
+	Address     string   `hcl:"address,label"`
+	Version     string   `hcl:"version"`
+	Constraints *string  `hcl:"constraints,optional"`
+	Hashes      []string `hcl:"hashes,optional"`
+}
+
+// LockFileCheckResponse is the compliance report of a lock file checked against the providers stored in a namespace.
+type LockFileCheckResponse struct {
+	Compliant bool                     `json:"compliant"`
+	Providers []LockFileProviderReport `json:"providers"`
+}
+
+// LockFileProviderReport is the compliance report of a single provider pinned in a lock file.
+type LockFileProviderReport struct {
+	Address    string                   `json:"address"`
+	Version    string                   `json:"version"`
+	Available  bool                     `json:"available"`
+	Platforms  []LockFilePlatformReport `json:"platforms,omitempty"`
+	Scan       *LockFileScanSummary     `json:"scan,omitempty"`
+	Violations []string                 `json:"violations,omitempty"`
+}
+
+// LockFilePlatformReport reports whether the package stored for a platform matches a hash in the lock file.
+type LockFilePlatformReport struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	HashMatch bool   `json:"hashMatch"`
+}
+
+// LockFileScanSummary counts the distinct findings of a provider version's source and binary scans by severity.
+type LockFileScanSummary struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+	Unknown  int `json:"unknown"`
+}
+
+// parseLockFile parses the provider blocks of a '.terraform.lock.hcl' file.
+func parseLockFile(src []byte) (*lockFile, error) {
+	file, diags := hclsyntax.ParseConfig(src, ".terraform.lock.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var parsed lockFile
+	if diags := gohcl.DecodeBody(file.Body, nil, &parsed); diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &parsed, nil
+}
+
+func checkLockFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clientset, err := getKubeClientFromRequest(w, r)
+	if err != nil {
+		logger.Error("unable to generate kubeclient", "error", err)
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+
+	failOnSeverity := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("failOnSeverity")))
+	if failOnSeverity == "" {
+		failOnSeverity = lockFileDefaultFailOnSeverity
+	}
+
+	if rank, ok := severityRanks[failOnSeverity]; !ok || rank == 0 {
+		http.Error(w, "failOnSeverity must be one of CRITICAL, HIGH, MEDIUM or LOW", http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, lockFileMaxBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "lock file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, "unable to read lock file", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := parseLockFile(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid lock file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := LockFileCheckResponse{
+		Compliant: true,
+		Providers: make([]LockFileProviderReport, 0, len(parsed.Providers)),
+	}
+
+	for _, lockedProvider := range parsed.Providers {
+		report, err := checkLockFileProvider(clientset, r, namespace, lockedProvider, failOnSeverity)
+		if err != nil {
+			logger.Error("unable to check lock file provider", "error", err, "namespace", namespace, "address", lockedProvider.Address)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if len(report.Violations) > 0 {
+			response.Compliant = false
+		}
+
+		response.Providers = append(response.Providers, *report)
+	}
+
+	if !response.Compliant {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkLockFileProvider reports whether the provider version pinned in a lock file is stored in namespace, whether
+// each stored platform matches a hash in the lock file, and whether its scans have findings at or above failOnSeverity.
+func checkLockFileProvider(clientset *kubernetes.Clientset, r *http.Request, namespace string, lockedProvider lockFileProvider, failOnSeverity string) (*LockFileProviderReport, error) {
+	report := &LockFileProviderReport{
+		Address: lockedProvider.Address,
+		Version: normalizeVersion(lockedProvider.Version),
+	}
+
+	addressParts := strings.Split(lockedProvider.Address, "/")
+	if len(addressParts) != 3 {
+		report.Violations = append(report.Violations, "provider address must be in the form 'hostname/namespace/type'")
+		return report, nil
+	}
+	hostname, providerNamespace, providerType := addressParts[0], addressParts[1], addressParts[2]
+
+	provider, err := getProviderResource(clientset, r, namespace, providerType)
+	if err != nil && !k8sApiErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if provider == nil || !lockFileProviderServed(provider, namespace, r.Host, hostname, providerNamespace) {
+		report.Violations = append(report.Violations, "provider is not available in OpenDepot")
+		return report, nil
+	}
+
+	versions, err := listSyncedProviderVersions(clientset, r, namespace, providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedHashes := make(map[string]struct{}, len(lockedProvider.Hashes))
+	for _, hash := range lockedProvider.Hashes {
+		lockedHashes[hash] = struct{}{}
+	}
+
+	var findings []opendepotv1alpha1.SecurityFinding
+	for _, item := range versions {
+		if normalizeVersion(item.Spec.Version) != report.Version {
+			continue
+		}
+		report.Available = true
+
+		checksumHex, err := decodeSHA256Checksum(*item.Status.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode checksum of version '%s': %w", item.Name, err)
+		}
+
+		storedHashes := []string{"zh:" + checksumHex}
+		if item.Status.PackageHash != nil {
+			storedHashes = append(storedHashes, *item.Status.PackageHash)
+		}
+
+		platform := LockFilePlatformReport{OS: item.Spec.OperatingSystem, Arch: item.Spec.Architecture}
+		for _, hash := range storedHashes {
+			if _, ok := lockedHashes[hash]; ok {
+				platform.HashMatch = true
+				break
+			}
+		}
+
+		// A lock file without hashes cannot be checked, and OpenTofu records them on the next 'tofu init'.
+		if len(lockedHashes) > 0 && !platform.HashMatch {
+			report.Violations = append(report.Violations, fmt.Sprintf("the %s_%s package does not match any hash in the lock file", platform.OS, platform.Arch))
+		}
+		report.Platforms = append(report.Platforms, platform)
+
+		if item.Status.BinaryScan != nil {
+			findings = append(findings, item.Status.BinaryScan.Findings...)
+		}
+	}
+
+	if !report.Available {
+		report.Violations = append(report.Violations, fmt.Sprintf("version %s is not available in OpenDepot", report.Version))
+		return report, nil
+	}
+
+	sourceScan := provider.Status.SourceScan
+	if sourceScan != nil && normalizeVersion(sourceScan.Version) == report.Version {
+		findings = append(findings, sourceScan.Findings...)
+	}
+
+	summary, blocking := summarizeFindings(findings, failOnSeverity)
+	report.Scan = summary
+	if blocking > 0 {
+		report.Violations = append(report.Violations, fmt.Sprintf("%d scan findings at or above %s severity", blocking, failOnSeverity))
+	}
+
+	return report, nil
+}
+
+// lockFileProviderServed reports whether the provider address 'hostname/providerNamespace/type' is served by provider,
+// which is stored in namespace. Providers installed from OpenDepot itself are addressed by the server's host and the
+// Kubernetes namespace. Mirrored providers keep the address of their upstream registry, where the public registries
+// are interchangeable since they serve the same providers.
+func lockFileProviderServed(provider *opendepotv1alpha1.Provider, namespace, serverHost, hostname, providerNamespace string) bool {
+	if strings.EqualFold(hostname, serverHost) && strings.EqualFold(providerNamespace, namespace) {
+		return true
+	}
+
+	if !strings.EqualFold(registry.ProviderNamespace(provider.Spec.ProviderConfig), providerNamespace) {
+		return false
+	}
+
+	upstreamHost := registry.DefaultHost
+	if provider.Spec.ProviderConfig.Registry != nil && strings.TrimSpace(*provider.Spec.ProviderConfig.Registry) != "" {
+		upstreamHost = strings.TrimSpace(*provider.Spec.ProviderConfig.Registry)
+		if _, host, ok := strings.Cut(upstreamHost, "://"); ok {
+			upstreamHost = host
+		}
+		upstreamHost = strings.TrimRight(upstreamHost, "/")
+	}
+
+	if registry.IsPublicHost(upstreamHost) && registry.IsPublicHost(hostname) {
+		return true
+	}
+
+	return strings.EqualFold(hostname, upstreamHost)
+}
+
+// summarizeFindings counts distinct findings by severity and returns the number at or above failOnSeverity.
+// Findings are deduplicated because every platform's binary scan reports the same vulnerable packages.
+func summarizeFindings(findings []opendepotv1alpha1.SecurityFinding, failOnSeverity string) (*LockFileScanSummary, int) {
+	summary := &LockFileScanSummary{}
+	seen := make(map[string]struct{}, len(findings))
+	blocking := 0
+
+	for _, finding := range findings {
+		key := strings.Join([]string{finding.VulnerabilityID, finding.PkgName, finding.InstalledVersion}, "|")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		severity := strings.ToUpper(finding.Severity)
+		switch severity {
+		case "CRITICAL":
+			summary.Critical++
+		case "HIGH":
+			summary.High++
+		case "MEDIUM":
+			summary.Medium++
+		case "LOW":
+			summary.Low++
+		default:
+			summary.Unknown++
+		}
+
+		if rank := severityRanks[severity]; rank > 0 && rank >= severityRanks[failOnSeverity] {
+			blocking++
+		}
+	}
+
+	return summary, blocking
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"testing"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+func TestParseLockFile(t *testing.T) {
+	tests := []struct {
+		name              string
+		src               string
+		expectedAddresses []string
+		expectedHashes    int
+		expectErr         bool
+	}{
+		{
+			name: "providers with hashes",
+			src: `
+provider "registry.opentofu.org/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = ">= 5.0.0"
+  hashes = [
+    "h1:abc=",
+    "zh:0123",
+  ]
+}
+
+provider "opendepot.example.com/platform/internal" {
+  version = "1.0.0"
+}
+`,
+			expectedAddresses: []string{"registry.opentofu.org/hashicorp/aws", "opendepot.example.com/platform/internal"},
+			expectedHashes:    2,
+		},
+		{
+			name:              "empty lock file",
+			src:               "",
+			expectedAddresses: nil,
+		},
+		{
+			name:      "provider without a version",
+			src:       `provider "registry.opentofu.org/hashicorp/aws" {}`,
+			expectErr: true,
+		},
+		{
+			name:      "invalid syntax",
+			src:       `provider "registry.opentofu.org/hashicorp/aws" {`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parseLockFile([]byte(tt.src))
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(parsed.Providers) != len(tt.expectedAddresses) {
+				t.Fatalf("parsed %d providers, want %d", len(parsed.Providers), len(tt.expectedAddresses))
+			}
+
+			for i, provider := range parsed.Providers {
+				if provider.Address != tt.expectedAddresses[i] {
+					t.Fatalf("provider %d has address '%s', want '%s'", i, provider.Address, tt.expectedAddresses[i])
+				}
+			}
+
+			if len(parsed.Providers) > 0 && len(parsed.Providers[0].Hashes) != tt.expectedHashes {
+				t.Fatalf("first provider has %d hashes, want %d", len(parsed.Providers[0].Hashes), tt.expectedHashes)
+			}
+		})
+	}
+}
+
+func TestLockFileProviderServed(t *testing.T) {
+	customRegistry := "https://registry.example.com/"
+	integrations := "integrations"
+
+	tests := []struct {
+		name              string
+		providerConfig    opendepotv1alpha1.ProviderConfig
+		hostname          string
+		providerNamespace string
+		expected          bool
+	}{
+		{name: "default registry", hostname: "registry.opentofu.org", providerNamespace: "hashicorp", expected: true},
+		{name: "other public registry", hostname: "registry.terraform.io", providerNamespace: "hashicorp", expected: true},
+		{name: "hostname of another registry", hostname: "example.com", providerNamespace: "hashicorp", expected: false},
+		{name: "namespace of another publisher", hostname: "registry.opentofu.org", providerNamespace: "integrations", expected: false},
+		{name: "configured namespace", providerConfig: opendepotv1alpha1.ProviderConfig{Namespace: &integrations}, hostname: "registry.opentofu.org", providerNamespace: "integrations", expected: true},
+		{name: "configured registry", providerConfig: opendepotv1alpha1.ProviderConfig{Registry: &customRegistry}, hostname: "registry.example.com", providerNamespace: "hashicorp", expected: true},
+		{name: "public registry for a configured registry", providerConfig: opendepotv1alpha1.ProviderConfig{Registry: &customRegistry}, hostname: "registry.opentofu.org", providerNamespace: "hashicorp", expected: false},
+		{name: "installed from OpenDepot", hostname: "opendepot.example.com", providerNamespace: "platform", expected: true},
+		{name: "namespace of OpenDepot on another host", hostname: "example.com", providerNamespace: "platform", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &opendepotv1alpha1.Provider{Spec: opendepotv1alpha1.ProviderSpec{ProviderConfig: tt.providerConfig}}
+			served := lockFileProviderServed(provider, "platform", "opendepot.example.com", tt.hostname, tt.providerNamespace)
+			if served != tt.expected {
+				t.Fatalf("lockFileProviderServed returned %t, want %t", served, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSummarizeFindings(t *testing.T) {
+	finding := func(id, severity string) opendepotv1alpha1.SecurityFinding {
+		return opendepotv1alpha1.SecurityFinding{VulnerabilityID: id, PkgName: "golang.org/x/net", InstalledVersion: "v0.1.0", Severity: severity}
+	}
+
+	tests := []struct {
+		name             string
+		findings         []opendepotv1alpha1.SecurityFinding
+		failOnSeverity   string
+		expectedSummary  LockFileScanSummary
+		expectedBlocking int
+	}{
+		{
+			name:           "no findings",
+			failOnSeverity: "CRITICAL",
+		},
+		{
+			name:             "counts findings by severity",
+			findings:         []opendepotv1alpha1.SecurityFinding{finding("CVE-1", "CRITICAL"), finding("CVE-2", "high"), finding("CVE-3", "MEDIUM"), finding("CVE-4", "LOW"), finding("CVE-5", "")},
+			failOnSeverity:   "HIGH",
+			expectedSummary:  LockFileScanSummary{Critical: 1, High: 1, Medium: 1, Low: 1, Unknown: 1},
+			expectedBlocking: 2,
+		},
+		{
+			name:             "deduplicates findings reported by every platform",
+			findings:         []opendepotv1alpha1.SecurityFinding{finding("CVE-1", "CRITICAL"), finding("CVE-1", "CRITICAL")},
+			failOnSeverity:   "CRITICAL",
+			expectedSummary:  LockFileScanSummary{Critical: 1},
+			expectedBlocking: 1,
+		},
+		{
+			name:             "unknown findings never block",
+			findings:         []opendepotv1alpha1.SecurityFinding{finding("CVE-1", "UNKNOWN")},
+			failOnSeverity:   "LOW",
+			expectedSummary:  LockFileScanSummary{Unknown: 1},
+			expectedBlocking: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, blocking := summarizeFindings(tt.findings, tt.failOnSeverity)
+			if *summary != tt.expectedSummary || blocking != tt.expectedBlocking {
+				t.Fatalf("summarized %+v with %d blocking, want %+v with %d", *summary, blocking, tt.expectedSummary, tt.expectedBlocking)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -45,8 +46,30 @@ func main() {
 	opendepotUseBearerToken = flag.Bool("use-bearer-token", false, "when true use a bearer token instead of a base64 encoded kubeconfig to authenticate with the kubernetes API server")
 	opendepotCertPath := flag.String("tls-cert-path", "", "path to TLS certificate file for HTTPS server")
 	opendepotCertKey := flag.String("tls-cert-key", "", "path to TLS certificate key file for HTTPS server")
+	opendepotEnablePullThrough := flag.Bool("enable-pull-through", false, "when true create the Module, Provider and Version resources of modules and providers requested from namespaces whose Depot enables pull-through")
+	opendepotCacheDir := flag.String("cache-dir", "", "directory downloaded archives are cached in, least recently used first evicted. The cache is disabled when empty")
+	opendepotCacheMaxBytes := flag.Int64("cache-max-bytes", 10<<30, "maximum size in bytes of the archives in the download cache")
+	opendepotMetricsAddress := flag.String("metrics-address", ":9090", "address the metrics of the download cache are served on, apart from the registry API. Metrics are not served when empty")
 	flag.Parse()
 
+	if *opendepotCacheDir != "" {
+		cache, err := newArtifactCache(*opendepotCacheDir, *opendepotCacheMaxBytes)
+		if err != nil {
+			logger.Error("Failed to create download cache", "error", err)
+			os.Exit(1)
+		}
+		downloadCache = cache
+	}
+
+	if *opendepotEnablePullThrough {
+		client, err := newPullThroughClient()
+		if err != nil {
+			logger.Error("Failed to create pull-through client", "error", err)
+			os.Exit(1)
+		}
+		pullThroughClient = client
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Get("/.well-known/terraform.json", serviceDiscoveryHandler)
@@ -57,12 +80,28 @@ func main() {
 	r.Get("/opendepot/providers/v1/download/{namespace}/{type}/{version}", serveProviderPackageDownload)
 	r.Get("/opendepot/providers/v1/{namespace}/{type}/{version}/SHA256SUMS/{os}/{arch}", getProviderPackageSHA256SUMS)
 	r.Get("/opendepot/providers/v1/{namespace}/{type}/{version}/SHA256SUMS.sig/{os}/{arch}", getProviderPackageSHA256SUMSSignature)
+	r.Get("/opendepot/mirror/v1/{namespace}/{hostname}/{providerNamespace}/{type}/index.json", getNetworkMirrorProviderVersions)
+	r.Get("/opendepot/mirror/v1/{namespace}/{hostname}/{providerNamespace}/{type}/{versionFile}", getNetworkMirrorProviderArchives)
+	r.Post("/opendepot/lockfile/v1/{namespace}/check", checkLockFile)
 
 	r.Get("/opendepot/modules/v1/download/azure/{subID}/{rg}/{account}/{accountUrl}/{name}/{fileName}", serveModuleFromAzureBlob)
 	r.Get("/opendepot/modules/v1/download/fileSystem/{directory}/{name}/{fileName}", serveModuleFromFileSystem)
 	r.Get("/opendepot/modules/v1/download/gcs/{bucket}/{name}/{fileName}", serveModuleFromGCS)
+	r.Get("/opendepot/modules/v1/download/oci/{registry}/{repository}/{name}/{fileName}", serveModuleFromOCI)
+	r.Get("/opendepot/modules/v1/download/replicated/{name}/{fileName}", serveModuleFromReplicas)
 	r.Get("/opendepot/modules/v1/download/s3/{bucket}/{region}/{name}/{fileName}", serveModuleFromS3)
 
+	// Metrics are served on their own listener so they are not exposed with the registry API.
+	if downloadCache != nil && *opendepotMetricsAddress != "" {
+		metricsRouter := chi.NewRouter()
+		metricsRouter.Get("/metrics", serveMetrics)
+		go func() {
+			if err := http.ListenAndServe(*opendepotMetricsAddress, metricsRouter); err != nil {
+				logger.Error("Failed to start metrics server", "error", err)
+			}
+		}()
+	}
+
 	if *opendepotCertPath != "" && *opendepotCertKey != "" {
 		http.ListenAndServeTLS("", *opendepotCertPath, *opendepotCertKey, r)
 	} else {
@@ -137,7 +176,9 @@ func normalizeVersion(versionString string) string {
 	return strings.TrimPrefix(strings.TrimSpace(versionString), "v")
 }
 
-func getProviderVersionResource(clientset *kubernetes.Clientset, namespace, providerType, requestedVersion string, ctxName string, ctxReq *http.Request) (*opendepotv1alpha1.Version, error) {
+// getProviderVersionResource returns the Version resource of a provider version. When osName and arch are set, only
+// the Version for that platform is returned.
+func getProviderVersionResource(clientset *kubernetes.Clientset, namespace, providerType, requestedVersion, osName, arch string, ctxName string, ctxReq *http.Request) (*opendepotv1alpha1.Version, error) {
 	result, err := clientset.RESTClient().
 		Get().
 		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
@@ -167,28 +208,39 @@ func getProviderVersionResource(clientset *kubernetes.Clientset, namespace, prov
 			continue
 		}
 
+		if (osName != "" && item.Spec.OperatingSystem != osName) || (arch != "" && item.Spec.Architecture != arch) {
+			continue
+		}
+
 		return &item, nil
 	}
 
 	return nil, nil
 }
 
-func buildDownloadPathFromVersion(versionResource *opendepotv1alpha1.Version) (string, error) {
-	var storageConfig *opendepotv1alpha1.StorageConfig
+func buildDownloadPathFromVersion(ctx context.Context, versionResource *opendepotv1alpha1.Version) (string, error) {
 	var name *string
-
-	if versionResource.Spec.ModuleConfigRef != nil && versionResource.Spec.ModuleConfigRef.StorageConfig != nil {
-		storageConfig = versionResource.Spec.ModuleConfigRef.StorageConfig
+	if versionResource.Spec.ModuleConfigRef != nil {
 		name = versionResource.Spec.ModuleConfigRef.Name
-	} else if versionResource.Spec.ProviderConfigRef != nil && versionResource.Spec.ProviderConfigRef.StorageConfig != nil {
-		storageConfig = versionResource.Spec.ProviderConfigRef.StorageConfig
+	} else if versionResource.Spec.ProviderConfigRef != nil {
 		name = versionResource.Spec.ProviderConfigRef.Name
 	}
 
-	if storageConfig == nil || name == nil || versionResource.Spec.FileName == nil {
+	if name == nil || versionResource.Spec.FileName == nil {
 		return "", fmt.Errorf("storage configuration not available for version '%s'", versionResource.Name)
 	}
 
+	storageConfig, _, err := getVersionStorageConfig(ctx, versionResource)
+	if err != nil {
+		return "", err
+	}
+
+	// Encrypted archives are decrypted with the keyring of the Version's storage config, which only the replicated
+	// route resolves.
+	if len(storageConfig.Replicas) > 0 || storageConfig.Encryption != nil {
+		return fmt.Sprintf("replicated/%s/%s", *name, *versionResource.Spec.FileName), nil
+	}
+
 	if storageConfig.AzureStorage != nil {
 		return fmt.Sprintf("azure/%s/%s/%s/%s/%s/%s",
 			storageConfig.AzureStorage.SubscriptionID,
@@ -216,11 +268,21 @@ func buildDownloadPathFromVersion(versionResource *opendepotv1alpha1.Version) (s
 		), nil
 	}
 
+	if storageConfig.OCI != nil {
+		return fmt.Sprintf("oci/%s/%s/%s/%s",
+			storageConfig.OCI.Registry,
+			base64.RawURLEncoding.EncodeToString([]byte(storageConfig.OCI.Repository)),
+			*name,
+			*versionResource.Spec.FileName,
+		), nil
+	}
+
 	if storageConfig.S3 != nil {
-		return fmt.Sprintf("s3/%s/%s/%s",
+		return fmt.Sprintf("s3/%s/%s/%s/%s",
 			storageConfig.S3.Bucket,
 			storageConfig.S3.Region,
-			*storageConfig.S3.Key,
+			*name,
+			*versionResource.Spec.FileName,
 		), nil
 	}
 
@@ -305,49 +367,38 @@ func getDownloadModuleUrl(w http.ResponseWriter, r *http.Request) {
 	}
 
 	moduleVersion, err := getModuleVersion(clientset, w, r)
-	if err != nil {
+	if err != nil && !k8sApiErrors.IsNotFound(err) {
 		logger.Error("unable to get module version", "error", err)
 		return
 	}
 
-	var downloadPath string
-	if moduleVersion.Spec.ModuleConfigRef.StorageConfig.AzureStorage != nil {
-		downloadPath = fmt.Sprintf("azure/%s/%s/%s/%s/%s/%s",
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.AzureStorage.SubscriptionID,
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.AzureStorage.ResourceGroup,
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.AzureStorage.AccountName,
-			url.PathEscape(moduleVersion.Spec.ModuleConfigRef.StorageConfig.AzureStorage.AccountUrl),
-			*moduleVersion.Spec.ModuleConfigRef.Name,
-			*moduleVersion.Spec.FileName,
-		)
-	}
+	if moduleVersion == nil || moduleVersion.Status.Checksum == nil {
+		namespace := chi.URLParam(r, "namespace")
+		name := chi.URLParam(r, "name")
+		version := chi.URLParam(r, "version")
 
-	if moduleVersion.Spec.ModuleConfigRef.StorageConfig.FileSystem != nil {
-		downloadPath = fmt.Sprintf("fileSystem/%s/%s/%s",
-			base64.RawURLEncoding.EncodeToString([]byte(*moduleVersion.Spec.ModuleConfigRef.StorageConfig.FileSystem.DirectoryPath)),
-			*moduleVersion.Spec.ModuleConfigRef.Name,
-			*moduleVersion.Spec.FileName,
-		)
-	}
+		pulledVersion, err := pullThroughModuleVersion(r.Context(), namespace, name, chi.URLParam(r, "system"), version)
+		if err != nil {
+			logger.Error("unable to pull through module version", "error", err, "namespace", namespace, "name", name, "version", version)
+			writePullThroughError(w, err)
+			return
+		}
 
-	if moduleVersion.Spec.ModuleConfigRef.StorageConfig.GCS != nil {
-		downloadPath = fmt.Sprintf("gcs/%s/%s/%s",
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.GCS.Bucket,
-			*moduleVersion.Spec.ModuleConfigRef.Name,
-			*moduleVersion.Spec.FileName,
-		)
+		if pulledVersion == nil {
+			http.Error(w, "module version not found", http.StatusNotFound)
+			return
+		}
+		moduleVersion = pulledVersion
 	}
 
-	if moduleVersion.Spec.ModuleConfigRef.StorageConfig.S3 != nil {
-		downloadPath = fmt.Sprintf("s3/%s/%s/%s",
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.S3.Bucket,
-			moduleVersion.Spec.ModuleConfigRef.StorageConfig.S3.Region,
-			*moduleVersion.Spec.ModuleConfigRef.StorageConfig.S3.Key,
-		)
+	downloadPath, err := buildDownloadPathFromVersion(r.Context(), moduleVersion)
+	if err != nil {
+		logger.Error("unable to build download path for module version", "error", err, "version", moduleVersion.Name)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
 	}
 
-	checksumQuery := url.QueryEscape(*moduleVersion.Status.Checksum)
-	w.Header().Set("X-Terraform-Get", fmt.Sprintf("/opendepot/modules/v1/download/%s?fileChecksum=%s", downloadPath, checksumQuery))
+	w.Header().Set("X-Terraform-Get", downloadURL(moduleVersion, downloadPath))
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -368,33 +419,53 @@ func serveModuleFromAzureBlob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var options *storage.AzureBlobClientOptions
+	if namespace, versionName := r.URL.Query().Get("namespace"), r.URL.Query().Get("version"); namespace != "" && versionName != "" {
+		storageConfig, secretNamespace, err := getDownloadStorageConfig(r, namespace, versionName)
+		if err != nil {
+			logger.Error("failed to get storage config of version", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+
+		azureConfig := storageConfig.AzureStorage
+		if azureConfig == nil || azureConfig.AccountUrl != accountUrl || azureConfig.SubscriptionID != subID {
+			logger.Error("azure storage account does not match version", "storageAccountName", accountName, "namespace", namespace, "version", versionName)
+			http.Error(w, "module not found", http.StatusNotFound)
+			return
+		}
+
+		options, err = storage.GetAzureBlobClientOptions(r.Context(), serviceAccountStorageGetter{}, secretNamespace, azureConfig)
+		if err != nil {
+			logger.Error("failed to get azure client options", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	storage := &storage.AzureBlobStorage{}
-	if err := storage.NewClients(subID, accountUrl); err != nil {
+	if err := storage.NewClients(subID, accountUrl, options); err != nil {
 		logger.Error("failed to init azure clients", "error", err, "storageAccountName", accountName)
 		http.Error(w, "failed to get module", http.StatusInternalServerError)
 		return
 	}
 
-	version := &opendepotv1alpha1.Version{
-		Spec: opendepotv1alpha1.VersionSpec{
-			ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
-				Name: &name,
-				StorageConfig: &opendepotv1alpha1.StorageConfig{
-					AzureStorage: &opendepotv1alpha1.AzureStorageConfig{
-						AccountName:    accountName,
-						AccountUrl:     accountUrl,
-						ResourceGroup:  rg,
-						SubscriptionID: subID,
-					},
-				},
-			},
-		},
-	}
-
 	soi := &storageTypes.StorageObjectInput{
 		FilePath: &fileName,
 		Method:   storageTypes.Get,
-		Version:  version,
+		StorageConfig: &opendepotv1alpha1.StorageConfig{
+			AzureStorage: &opendepotv1alpha1.AzureStorageConfig{
+				AccountName:    accountName,
+				AccountUrl:     accountUrl,
+				ResourceGroup:  rg,
+				SubscriptionID: subID,
+			},
+		},
+		Version: &opendepotv1alpha1.Version{
+			Spec: opendepotv1alpha1.VersionSpec{
+				ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name},
+			},
+		},
 	}
 
 	getObjectFromStorageSystem(w, r, storage, soi, checksum)
@@ -473,29 +544,44 @@ func serveModuleFromGCS(w http.ResponseWriter, r *http.Request) {
 	fileName := chi.URLParam(r, "fileName")
 	checksum := r.URL.Query().Get("fileChecksum")
 
+	var options *storage.GoogleCloudStorageClientOptions
+	if namespace, versionName := r.URL.Query().Get("namespace"), r.URL.Query().Get("version"); namespace != "" && versionName != "" {
+		storageConfig, secretNamespace, err := getDownloadStorageConfig(r, namespace, versionName)
+		if err != nil {
+			logger.Error("failed to get storage config of version", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+
+		if storageConfig.GCS == nil || storageConfig.GCS.Bucket != bucket {
+			logger.Error("gcs bucket does not match version", "bucket", bucket, "namespace", namespace, "version", versionName)
+			http.Error(w, "module not found", http.StatusNotFound)
+			return
+		}
+
+		options, err = storage.GetGoogleCloudStorageClientOptions(r.Context(), serviceAccountStorageGetter{}, secretNamespace, storageConfig.GCS)
+		if err != nil {
+			logger.Error("failed to get gcs client options", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	gcsStorage := &storage.GoogleCloudStorage{}
-	if err := gcsStorage.NewClient(r.Context()); err != nil {
+	if err := gcsStorage.NewClient(r.Context(), options); err != nil {
 		logger.Error("failed to init gcs client", "error", err, "bucket", bucket)
 		http.Error(w, "failed to get module", http.StatusInternalServerError)
 		return
 	}
 
-	version := &opendepotv1alpha1.Version{
-		Spec: opendepotv1alpha1.VersionSpec{
-			ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
-				StorageConfig: &opendepotv1alpha1.StorageConfig{
-					GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{
-						Bucket: bucket,
-					},
-				},
-			},
-		},
-	}
-
 	soi := &storageTypes.StorageObjectInput{
 		FilePath: aws.String(fmt.Sprintf("%s/%s", name, fileName)),
 		Method:   storageTypes.Get,
-		Version:  version,
+		StorageConfig: &opendepotv1alpha1.StorageConfig{
+			GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{
+				Bucket: bucket,
+			},
+		},
 	}
 
 	getObjectFromStorageSystem(w, r, gcsStorage, soi, checksum)
@@ -507,69 +593,190 @@ func serveModuleFromS3(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	fileName := chi.URLParam(r, "fileName")
 	checksum := r.URL.Query().Get("fileChecksum")
+	filePath := fmt.Sprintf("%s/%s", name, fileName)
+
+	var options *storage.AmazonS3ClientOptions
+	if namespace, versionName := r.URL.Query().Get("namespace"), r.URL.Query().Get("version"); namespace != "" && versionName != "" {
+		storageConfig, secretNamespace, err := getDownloadStorageConfig(r, namespace, versionName)
+		if err != nil {
+			logger.Error("failed to get storage config of version", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+
+		s3Config := storageConfig.S3
+		if s3Config == nil || s3Config.Bucket != bucket || s3Config.Region != region {
+			logger.Error("s3 bucket does not match version", "bucket", bucket, "namespace", namespace, "version", versionName)
+			http.Error(w, "module not found", http.StatusNotFound)
+			return
+		}
+
+		options, err = storage.GetAmazonS3ClientOptions(r.Context(), serviceAccountStorageGetter{}, secretNamespace, s3Config)
+		if err != nil {
+			logger.Error("failed to get s3 client options", "error", err, "namespace", namespace, "version", versionName)
+			http.Error(w, "failed to get module", http.StatusInternalServerError)
+			return
+		}
+
+		if s3Config.Key != nil && *s3Config.Key != "" {
+			key, err := storage.RemoveTrailingSlash(s3Config.Key)
+			if err != nil {
+				http.Error(w, "failed to get module", http.StatusInternalServerError)
+				return
+			}
+			filePath = fmt.Sprintf("%s/%s", *key, filePath)
+		}
+	}
 
 	storage := &storage.AmazonS3Storage{}
-	if err := storage.NewClient(r.Context(), region); err != nil {
+	if err := storage.NewClient(r.Context(), region, options); err != nil {
 		logger.Error("failed to init s3 client", "error", err, "bucket", bucket)
 		http.Error(w, "failed to get module", http.StatusInternalServerError)
 		return
 	}
 
-	version := opendepotv1alpha1.Version{
-		Spec: opendepotv1alpha1.VersionSpec{
-			ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
-				StorageConfig: &opendepotv1alpha1.StorageConfig{
-					S3: &opendepotv1alpha1.AmazonS3Config{
-						Bucket: bucket,
-					},
-				},
+	soi := &storageTypes.StorageObjectInput{
+		FilePath: aws.String(filePath),
+		Method:   storageTypes.Get,
+		StorageConfig: &opendepotv1alpha1.StorageConfig{
+			S3: &opendepotv1alpha1.AmazonS3Config{
+				Bucket: bucket,
 			},
 		},
 	}
 
+	getObjectFromStorageSystem(w, r, storage, soi, checksum)
+}
+
+func serveModuleFromOCI(w http.ResponseWriter, r *http.Request) {
+	registry := chi.URLParam(r, "registry")
+	encodedRepository := chi.URLParam(r, "repository")
+	name := chi.URLParam(r, "name")
+	fileName := chi.URLParam(r, "fileName")
+	checksum := r.URL.Query().Get("fileChecksum")
+	namespace := r.URL.Query().Get("namespace")
+	versionName := r.URL.Query().Get("version")
+
+	repository, err := base64.RawURLEncoding.DecodeString(encodedRepository)
+	if err != nil {
+		http.Error(w, "invalid repository", http.StatusBadRequest)
+		return
+	}
+
+	if namespace == "" || versionName == "" {
+		http.Error(w, "the namespace and version query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	storageConfig, secretNamespace, err := getDownloadStorageConfig(r, namespace, versionName)
+	if err != nil {
+		logger.Error("failed to get storage config of version", "error", err, "namespace", namespace, "version", versionName)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
+	ociConfig := storageConfig.OCI
+	if ociConfig == nil || ociConfig.Registry != registry || ociConfig.Repository != string(repository) {
+		logger.Error("oci repository does not match version", "registry", registry, "namespace", namespace, "version", versionName)
+		http.Error(w, "module not found", http.StatusNotFound)
+		return
+	}
+
+	options, err := storage.GetOCIClientOptions(r.Context(), serviceAccountStorageGetter{}, secretNamespace, ociConfig)
+	if err != nil {
+		logger.Error("failed to get oci client options", "error", err, "namespace", namespace, "version", versionName)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
+	ociStorage := &storage.OCIStorage{}
+	if err := ociStorage.NewClient(options); err != nil {
+		logger.Error("failed to init oci client", "error", err, "registry", registry)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
 	soi := &storageTypes.StorageObjectInput{
 		FilePath: aws.String(fmt.Sprintf("%s/%s", name, fileName)),
 		Method:   storageTypes.Get,
-		Version:  &version,
+		Version:  &opendepotv1alpha1.Version{},
 	}
 
-	getObjectFromStorageSystem(w, r, storage, soi, checksum)
+	getObjectFromStorageSystem(w, r, ociStorage, soi, checksum)
 }
 
 // getObjectFromStorage validates the object's sha256 checksum and when valid copies from the storage system src to the
 // download stream dst provided by http.ResponseWriter
 func getObjectFromStorageSystem(w http.ResponseWriter, r *http.Request, storage storage.Storage, soi *storageTypes.StorageObjectInput, checksum string) {
-	if err := storage.GetObjectChecksum(r.Context(), soi); err != nil {
-		logger.Error("failed to get checksum from storage system", "error", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	if soi.ObjectChecksum != nil && *soi.ObjectChecksum != checksum {
-		logger.Error("checksum mismatch from storage system", "want", checksum, "received", *soi.ObjectChecksum)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+	if downloadCache != nil {
+		serveCachedObject(w, r, *soi.FilePath, checksum, func() (*string, error) {
+			return storageObjectChecksum(r.Context(), storage, soi)
+		}, func() (io.Reader, error) {
+			return openStorageObject(r.Context(), storage, soi, checksum)
+		})
 		return
 	}
 
-	reader, err := storage.GetObject(r.Context(), soi)
+	reader, err := openStorageObject(r.Context(), storage, soi, checksum)
 	if err != nil {
 		logger.Error("failed to get module from storage system", "error", err)
 		http.Error(w, "failed to get module", http.StatusInternalServerError)
 		return
 	}
 
-	if strings.HasSuffix(*soi.FilePath, ".zip") {
-		w.Header().Set("Content-Type", "application/zip")
-	} else {
-		w.Header().Set("Content-Type", "application/x-tar")
+	writeStorageObject(w, soi, reader)
+}
+
+// openStorageObject validates the sha256 checksum of the object at soi.FilePath and when valid returns an io.Reader to
+// stream it from the storage system.
+func openStorageObject(ctx context.Context, storage storage.Storage, soi *storageTypes.StorageObjectInput, checksum string) (io.Reader, error) {
+	if err := storage.GetObjectChecksum(ctx, soi); err != nil {
+		return nil, fmt.Errorf("failed to get checksum from storage system: %w", err)
+	}
+
+	if soi.ObjectChecksum != nil && *soi.ObjectChecksum != checksum {
+		return nil, fmt.Errorf("checksum mismatch from storage system: want %s, received %s", checksum, *soi.ObjectChecksum)
+	}
+
+	return storage.GetObject(ctx, soi)
+}
+
+// storageObjectChecksum returns the checksum the storage system reports for the object at soi.FilePath without reading
+// the object. It returns nil when the storage system reports no checksum or cannot authenticate the one it reports.
+func storageObjectChecksum(ctx context.Context, storage storage.Storage, soi *storageTypes.StorageObjectInput) (*string, error) {
+	if err := storage.GetObjectChecksum(ctx, soi); err != nil {
+		return nil, fmt.Errorf("failed to get checksum from storage system: %w", err)
+	}
+
+	if !soi.FileExists {
+		return nil, fmt.Errorf("the object '%s' does not exist in the storage system", *soi.FilePath)
+	}
+
+	if soi.EncryptionKeyStale {
+		return nil, nil
 	}
 
+	return soi.ObjectChecksum, nil
+}
+
+// writeStorageObject copies the object read from reader to the download stream provided by http.ResponseWriter.
+func writeStorageObject(w http.ResponseWriter, soi *storageTypes.StorageObjectInput, reader io.Reader) {
+	setArchiveContentType(w, *soi.FilePath)
 	if _, err := io.Copy(w, reader); err != nil {
 		http.Error(w, fmt.Sprintf("failed to stream file: %v", err), http.StatusInternalServerError)
 		return
 	}
 }
 
+// setArchiveContentType sets the content type of the download of the archive fileName.
+func setArchiveContentType(w http.ResponseWriter, fileName string) {
+	if strings.HasSuffix(fileName, ".zip") {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "application/x-tar")
+	}
+}
+
 // generateKubeClient creates a new kubernetes client from either a kubeconfig as a byte slice
 // or from a bearerToken. When using a bearerToken this function will use the in-cluster config
 // to generate the necessary rest.Config settings for TLS connections.
@@ -678,6 +885,14 @@ func getModuleVersions(w http.ResponseWriter, r *http.Request) {
 		Name(name).
 		DoRaw(r.Context())
 	if err != nil {
+		if k8sApiErrors.IsNotFound(err) {
+			if servePullThroughModuleVersions(w, r, namespace, name) {
+				return
+			}
+
+			http.Error(w, "module not found", http.StatusNotFound)
+			return
+		}
 		logger.Error("unable to get modules", "error", err, "namespace", namespace, "name", name, "responseBody", string(result))
 	}
 
@@ -688,6 +903,11 @@ func getModuleVersions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Pulled-through modules only declare the versions requested so far, so list every upstream version.
+	if isPullThroughManaged(&module) && servePullThroughModuleVersions(w, r, namespace, name) {
+		return
+	}
+
 	response := ModuleVersionsResponse{
 		Modules: []ModuleVersions{
 			{
@@ -711,7 +931,7 @@ func getProviderVersions(w http.ResponseWriter, r *http.Request) {
 	namespace := chi.URLParam(r, "namespace")
 	providerType := chi.URLParam(r, "type")
 
-	_, err = clientset.RESTClient().
+	providerResult, err := clientset.RESTClient().
 		Get().
 		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
 		Namespace(namespace).
@@ -720,6 +940,10 @@ func getProviderVersions(w http.ResponseWriter, r *http.Request) {
 		DoRaw(r.Context())
 	if err != nil {
 		if k8sApiErrors.IsNotFound(err) {
+			if servePullThroughProviderVersions(w, r, namespace, providerType) {
+				return
+			}
+
 			http.Error(w, "provider not found", http.StatusNotFound)
 			return
 		}
@@ -729,6 +953,18 @@ func getProviderVersions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var provider opendepotv1alpha1.Provider
+	if err = json.Unmarshal(providerResult, &provider); err != nil {
+		logger.Error("unable to unmarshal provider", "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Pulled-through providers only declare the versions requested so far, so list every upstream version.
+	if isPullThroughManaged(&provider) && servePullThroughProviderVersions(w, r, namespace, providerType) {
+		return
+	}
+
 	result, err := clientset.RESTClient().
 		Get().
 		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
@@ -793,13 +1029,26 @@ func getProviderPackageMetadata(w http.ResponseWriter, r *http.Request) {
 	osName := chi.URLParam(r, "os")
 	arch := chi.URLParam(r, "arch")
 
-	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, "provider package metadata", r)
+	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, osName, arch, "provider package metadata", r)
 	if err != nil {
 		logger.Error("unable to locate provider version", "error", err, "namespace", namespace, "type", providerType, "version", requestedVersion)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	if versionResource == nil || versionResource.Spec.FileName == nil || versionResource.Status.Checksum == nil {
+		pulledVersion, err := pullThroughProviderVersion(r.Context(), namespace, providerType, requestedVersion, osName, arch)
+		if err != nil {
+			logger.Error("unable to pull through provider version", "error", err, "namespace", namespace, "type", providerType, "version", requestedVersion)
+			writePullThroughError(w, err)
+			return
+		}
+
+		if pulledVersion != nil {
+			versionResource = pulledVersion
+		}
+	}
+
 	if versionResource == nil {
 		http.Error(w, "provider package not found", http.StatusNotFound)
 		return
@@ -832,7 +1081,7 @@ func getProviderPackageMetadata(w http.ResponseWriter, r *http.Request) {
 		OS:                  osName,
 		Arch:                arch,
 		Filename:            *versionResource.Spec.FileName,
-		DownloadURL:         fmt.Sprintf("%s/opendepot/providers/v1/download/%s/%s/%s", baseURL, namespace, providerType, versionString),
+		DownloadURL:         fmt.Sprintf("%s/opendepot/providers/v1/download/%s/%s/%s?os=%s&arch=%s", baseURL, namespace, providerType, versionString, url.QueryEscape(osName), url.QueryEscape(arch)),
 		SHASumsURL:          fmt.Sprintf("%s/opendepot/providers/v1/%s/%s/%s/SHA256SUMS/%s/%s", baseURL, namespace, providerType, versionString, osName, arch),
 		SHASumsSignatureURL: fmt.Sprintf("%s/opendepot/providers/v1/%s/%s/%s/SHA256SUMS.sig/%s/%s", baseURL, namespace, providerType, versionString, osName, arch),
 		SHASum:              checksumHex,
@@ -857,7 +1106,7 @@ func serveProviderPackageDownload(w http.ResponseWriter, r *http.Request) {
 	providerType := chi.URLParam(r, "type")
 	requestedVersion := chi.URLParam(r, "version")
 
-	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, "provider package download", r)
+	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, r.URL.Query().Get("os"), r.URL.Query().Get("arch"), "provider package download", r)
 	if err != nil {
 		logger.Error("unable to locate provider version for download", "error", err, "namespace", namespace, "type", providerType, "version", requestedVersion)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -869,7 +1118,7 @@ func serveProviderPackageDownload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	downloadPath, err := buildDownloadPathFromVersion(versionResource)
+	downloadPath, err := buildDownloadPathFromVersion(r.Context(), versionResource)
 	if err != nil {
 		logger.Error("unable to build download path for provider package", "error", err, "version", versionResource.Name)
 		http.Error(w, "provider package download backend not implemented", http.StatusNotImplemented)
@@ -881,8 +1130,7 @@ func serveProviderPackageDownload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	checksumQuery := url.QueryEscape(*versionResource.Status.Checksum)
-	http.Redirect(w, r, fmt.Sprintf("/opendepot/modules/v1/download/%s?fileChecksum=%s", downloadPath, checksumQuery), http.StatusFound)
+	http.Redirect(w, r, downloadURL(versionResource, downloadPath), http.StatusFound)
 }
 
 func getProviderPackageSHA256SUMS(w http.ResponseWriter, r *http.Request) {
@@ -902,7 +1150,7 @@ func getProviderPackageSHA256SUMS(w http.ResponseWriter, r *http.Request) {
 	providerType := chi.URLParam(r, "type")
 	requestedVersion := chi.URLParam(r, "version")
 
-	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, "provider shasums", r)
+	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, chi.URLParam(r, "os"), chi.URLParam(r, "arch"), "provider shasums", r)
 	if err != nil {
 		logger.Error("unable to locate provider version for shasums", "error", err, "namespace", namespace, "type", providerType, "version", requestedVersion)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -941,7 +1189,7 @@ func getProviderPackageSHA256SUMSSignature(w http.ResponseWriter, r *http.Reques
 	providerType := chi.URLParam(r, "type")
 	requestedVersion := chi.URLParam(r, "version")
 
-	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, "provider shasums signature", r)
+	versionResource, err := getProviderVersionResource(clientset, namespace, providerType, requestedVersion, chi.URLParam(r, "os"), chi.URLParam(r, "arch"), "provider shasums signature", r)
 	if err != nil {
 		logger.Error("unable to locate provider version for shasums signature", "error", err, "namespace", namespace, "type", providerType, "version", requestedVersion)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
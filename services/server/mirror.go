@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+// NetworkMirrorVersionsResponse is the response of the provider network mirror protocol's list available versions endpoint.
+// See: https://opentofu.org/docs/internals/provider-network-mirror-protocol/#list-available-versions
+type NetworkMirrorVersionsResponse struct {
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// NetworkMirrorArchivesResponse is the response of the provider network mirror protocol's list available installation
+// packages endpoint, keyed by '{os}_{arch}'.
+// See: https://opentofu.org/docs/internals/provider-network-mirror-protocol/#list-available-installation-packages
+type NetworkMirrorArchivesResponse struct {
+	Archives map[string]NetworkMirrorArchive `json:"archives"`
+}
+
+// NetworkMirrorArchive is a single platform's package and the lock file hashes it can be verified against.
+type NetworkMirrorArchive struct {
+	URL    string   `json:"url"`
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+// getProviderResource returns the Provider resource named providerType in namespace.
+func getProviderResource(clientset *kubernetes.Clientset, r *http.Request, namespace, providerType string) (*opendepotv1alpha1.Provider, error) {
+	providerResult, err := clientset.RESTClient().
+		Get().
+		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
+		Namespace(namespace).
+		Resource("providers").
+		Name(providerType).
+		DoRaw(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var provider opendepotv1alpha1.Provider
+	if err = json.Unmarshal(providerResult, &provider); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal provider: %w", err)
+	}
+
+	return &provider, nil
+}
+
+// listSyncedProviderVersions returns the Version resources of providerType in namespace that are synced to storage.
+func listSyncedProviderVersions(clientset *kubernetes.Clientset, r *http.Request, namespace, providerType string) ([]opendepotv1alpha1.Version, error) {
+	result, err := clientset.RESTClient().
+		Get().
+		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
+		Namespace(namespace).
+		Resource("versions").
+		DoRaw(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var versionList opendepotv1alpha1.VersionList
+	if err = json.Unmarshal(result, &versionList); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal versions list: %w", err)
+	}
+
+	versions := make([]opendepotv1alpha1.Version, 0)
+	for _, item := range versionList.Items {
+		if item.Spec.ProviderConfigRef == nil || item.Spec.ProviderConfigRef.Name == nil || *item.Spec.ProviderConfigRef.Name != providerType {
+			continue
+		}
+
+		if !item.Status.Synced || item.Status.Checksum == nil || item.Spec.FileName == nil {
+			continue
+		}
+
+		versions = append(versions, item)
+	}
+
+	return versions, nil
+}
+
+// getMirrorProviderVersions returns the synced Version resources of a provider served through the network mirror.
+// The provider is only matched when its registry namespace equals providerNamespace. The hostname of the provider
+// address is not used, so a provider is served for any registry host it is requested for.
+func getMirrorProviderVersions(clientset *kubernetes.Clientset, r *http.Request, namespace, providerNamespace, providerType string) ([]opendepotv1alpha1.Version, error) {
+	provider, err := getProviderResource(clientset, r, namespace, providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(registry.ProviderNamespace(provider.Spec.ProviderConfig), providerNamespace) {
+		return nil, nil
+	}
+
+	return listSyncedProviderVersions(clientset, r, namespace, providerType)
+}
+
+// getMirrorProviderVersionsFromRequest resolves the provider of a network mirror request and writes an error response
+// when it cannot be served. The returned bool is false when a response has already been written.
+func getMirrorProviderVersionsFromRequest(w http.ResponseWriter, r *http.Request) ([]opendepotv1alpha1.Version, bool) {
+	clientset, err := getKubeClientFromRequest(w, r)
+	if err != nil {
+		logger.Error("unable to generate kubeclient", "error", err)
+		return nil, false
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	providerNamespace := chi.URLParam(r, "providerNamespace")
+	providerType := chi.URLParam(r, "type")
+
+	versions, err := getMirrorProviderVersions(clientset, r, namespace, providerNamespace, providerType)
+	if err != nil {
+		if k8sApiErrors.IsNotFound(err) {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return nil, false
+		}
+
+		logger.Error("unable to get mirror provider versions", "error", err, "namespace", namespace, "type", providerType)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if versions == nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return versions, true
+}
+
+func getNetworkMirrorProviderVersions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	versions, ok := getMirrorProviderVersionsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	response := NetworkMirrorVersionsResponse{Versions: map[string]struct{}{}}
+	for _, item := range versions {
+		response.Versions[normalizeVersion(item.Spec.Version)] = struct{}{}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func getNetworkMirrorProviderArchives(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// The router cannot match '{version}.json' for dotted versions, so the '.json' suffix is trimmed here.
+	versionFile := chi.URLParam(r, "versionFile")
+	if !strings.HasSuffix(versionFile, ".json") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	versions, ok := getMirrorProviderVersionsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	namespace := chi.URLParam(r, "namespace")
+	providerType := chi.URLParam(r, "type")
+	requestedVersion := normalizeVersion(strings.TrimSuffix(versionFile, ".json"))
+	baseURL := requestBaseURL(r)
+
+	response := NetworkMirrorArchivesResponse{Archives: map[string]NetworkMirrorArchive{}}
+	for _, item := range versions {
+		if normalizeVersion(item.Spec.Version) != requestedVersion {
+			continue
+		}
+
+		checksumHex, err := decodeSHA256Checksum(*item.Status.Checksum)
+		if err != nil {
+			logger.Error("unable to decode provider checksum", "error", err, "checksum", *item.Status.Checksum)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		archive := NetworkMirrorArchive{
+			URL: fmt.Sprintf("%s/opendepot/providers/v1/download/%s/%s/%s?os=%s&arch=%s", baseURL, namespace, providerType, requestedVersion,
+				url.QueryEscape(item.Spec.OperatingSystem), url.QueryEscape(item.Spec.Architecture)),
+		}
+
+		if item.Status.PackageHash != nil {
+			archive.Hashes = append(archive.Hashes, *item.Status.PackageHash)
+		}
+		archive.Hashes = append(archive.Hashes, "zh:"+checksumHex)
+
+		response.Archives[fmt.Sprintf("%s_%s", item.Spec.OperatingSystem, item.Spec.Architecture)] = archive
+	}
+
+	if len(response.Archives) == 0 {
+		http.Error(w, "provider version not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+	"github.com/tonedefdev/opendepot/pkg/utils"
+)
+
+const (
+	// pullThroughDefaultMaxVersionsPerHour is how many versions are pulled through into a namespace per hour by default.
+	pullThroughDefaultMaxVersionsPerHour = 30
+	// pullThroughDefaultSyncTimeout is how long a request waits for a pulled-through version to sync by default.
+	pullThroughDefaultSyncTimeout = 60 * time.Second
+	// pullThroughLimitWindow is the window the versions pulled through into a namespace are counted over.
+	pullThroughLimitWindow = time.Hour
+	// pullThroughPollInterval is how often a pulled-through Version is checked while waiting for it to sync.
+	pullThroughPollInterval = 2 * time.Second
+	// pullThroughRetryAfterSeconds is sent in the Retry-After header when a pulled-through version is still syncing.
+	pullThroughRetryAfterSeconds = "30"
+)
+
+var (
+	// errPullThroughLimited is returned when the namespace has pulled through its Depot's maximum number of versions
+	// within the last hour.
+	errPullThroughLimited = errors.New("the namespace has pulled through its maximum number of versions for the hour")
+	// errPullThroughTimeout is returned when a pulled-through version has not synced within the Depot's sync timeout.
+	errPullThroughTimeout = errors.New("timed out waiting for the pulled-through version to sync")
+)
+
+// pullThroughLimiter counts the versions pulled through into each namespace within the last hour.
+var pullThroughLimiter = &namespaceLimiter{window: pullThroughLimitWindow, events: map[string][]time.Time{}}
+
+// pullThroughClient is the client used to create pulled-through resources with the server's service account.
+// It is nil unless the server is started with --enable-pull-through.
+var pullThroughClient client.Client
+
+// newPullThroughClient creates a client for the in-cluster API server using the server's service account.
+func newPullThroughClient() (client.Client, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	if err := opendepotv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+// getPullThroughDepot returns the first Depot, by name, in namespace that enables pull-through. Nil is returned
+// when pull-through is disabled on the server or no Depot in the namespace enables it.
+func getPullThroughDepot(ctx context.Context, namespace string) (*opendepotv1alpha1.Depot, error) {
+	if pullThroughClient == nil {
+		return nil, nil
+	}
+
+	var depots opendepotv1alpha1.DepotList
+	if err := pullThroughClient.List(ctx, &depots, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(depots.Items, func(i, j int) bool {
+		return depots.Items[i].Name < depots.Items[j].Name
+	})
+
+	for i := range depots.Items {
+		depot := &depots.Items[i]
+		if depot.Spec.PullThrough == nil {
+			continue
+		}
+
+		if depot.Spec.GlobalConfig == nil {
+			return nil, fmt.Errorf("depot '%s' enables pull-through without a global config", depot.Name)
+		}
+
+		if depot.Spec.GlobalConfig.StorageConfig == nil && depot.Spec.GlobalConfig.StorageProfileName == nil {
+			profileName, err := serviceAccountStorageGetter{}.GetDefaultStorageProfileName(ctx, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			if profileName == "" {
+				return nil, fmt.Errorf("depot '%s' enables pull-through without a global storageConfig or storageProfileName", depot.Name)
+			}
+		}
+
+		return depot, nil
+	}
+
+	return nil, nil
+}
+
+// pullThroughSyncTimeout returns how long a request waits for a version pulled through by depot to sync.
+func pullThroughSyncTimeout(depot *opendepotv1alpha1.Depot) time.Duration {
+	if depot.Spec.PullThrough.SyncTimeoutSeconds != nil && *depot.Spec.PullThrough.SyncTimeoutSeconds > 0 {
+		return time.Duration(*depot.Spec.PullThrough.SyncTimeoutSeconds) * time.Second
+	}
+
+	return pullThroughDefaultSyncTimeout
+}
+
+// pullThroughMaxVersionsPerHour returns how many versions depot allows to be pulled through into its namespace per hour.
+func pullThroughMaxVersionsPerHour(depot *opendepotv1alpha1.Depot) int {
+	if depot.Spec.PullThrough.MaxVersionsPerHour != nil && *depot.Spec.PullThrough.MaxVersionsPerHour > 0 {
+		return *depot.Spec.PullThrough.MaxVersionsPerHour
+	}
+
+	return pullThroughDefaultMaxVersionsPerHour
+}
+
+// namespaceLimiter bounds how many events each namespace records within a sliding window.
+type namespaceLimiter struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+// allow records an event for namespace at now and reports whether it is within limit. An event beyond the limit is
+// not recorded.
+func (l *namespaceLimiter) allow(namespace string, limit int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events := slices.DeleteFunc(l.events[namespace], func(event time.Time) bool {
+		return !event.After(now.Add(-l.window))
+	})
+
+	if len(events) >= limit {
+		l.events[namespace] = events
+		return false
+	}
+
+	l.events[namespace] = append(events, now)
+	return true
+}
+
+// isPullThroughManaged reports whether object was created by the server in pull-through mode.
+func isPullThroughManaged(object metav1.Object) bool {
+	return object.GetLabels()[opendepotv1alpha1.OpenDepotPullThroughLabel] == "true"
+}
+
+// writePullThroughError writes the response for a pull-through request that failed.
+func writePullThroughError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPullThroughLimited) {
+		w.Header().Set("Retry-After", pullThroughRetryAfterSeconds)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	if errors.Is(err, errPullThroughTimeout) {
+		w.Header().Set("Retry-After", pullThroughRetryAfterSeconds)
+		http.Error(w, "version is being pulled through from upstream, retry shortly", http.StatusServiceUnavailable)
+		return
+	}
+
+	http.Error(w, "unable to pull through from upstream", http.StatusBadGateway)
+}
+
+// waitForPullThroughVersion waits until the Version resource identified by key has synced.
+func waitForPullThroughVersion(ctx context.Context, depot *opendepotv1alpha1.Depot, key client.ObjectKey) (*opendepotv1alpha1.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, pullThroughSyncTimeout(depot))
+	defer cancel()
+
+	ticker := time.NewTicker(pullThroughPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var versionResource opendepotv1alpha1.Version
+		err := pullThroughClient.Get(ctx, key, &versionResource)
+		if err != nil && !k8sApiErrors.IsNotFound(err) && ctx.Err() == nil {
+			return nil, err
+		}
+
+		if err == nil && versionResource.Status.Synced && versionResource.Status.Checksum != nil && versionResource.Spec.FileName != nil {
+			return &versionResource, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errPullThroughTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolvePullThroughProvider returns the first allowlisted upstream namespace that publishes providerType, along
+// with the versions available upstream.
+func resolvePullThroughProvider(ctx context.Context, depot *opendepotv1alpha1.Depot, providerType string) (string, *registry.ProviderVersions, error) {
+	registryClient, err := registry.GetProviderRegistryClient(ctx, pullThroughClient, depot.Namespace, opendepotv1alpha1.ProviderConfig{
+		Registry:           depot.Spec.PullThrough.ProviderRegistry,
+		RegistrySecretName: depot.Spec.PullThrough.ProviderRegistrySecretName,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	lastErr := fmt.Errorf("no provider namespaces are allowlisted")
+	for _, upstreamNamespace := range depot.Spec.PullThrough.ProviderNamespaces {
+		versions, err := registryClient.ListProviderVersions(ctx, upstreamNamespace, providerType)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return upstreamNamespace, versions, nil
+	}
+
+	return "", nil, fmt.Errorf("provider '%s' was not found in an allowlisted namespace: %w", providerType, lastErr)
+}
+
+// servePullThroughProviderVersions writes the upstream versions of providerType when pull-through is enabled for
+// namespace. It reports whether a response was written.
+func servePullThroughProviderVersions(w http.ResponseWriter, r *http.Request, namespace, providerType string) bool {
+	depot, err := getPullThroughDepot(r.Context(), namespace)
+	if err != nil {
+		logger.Error("unable to get pull-through depot", "error", err, "namespace", namespace)
+		return false
+	}
+
+	if depot == nil {
+		return false
+	}
+
+	_, upstreamVersions, err := resolvePullThroughProvider(r.Context(), depot, providerType)
+	if err != nil {
+		logger.Info("provider is not available for pull-through", "error", err, "namespace", namespace, "type", providerType)
+		return false
+	}
+
+	providerVersions := make([]ProviderVersionDetails, 0, len(upstreamVersions.Versions))
+	for _, upstreamVersion := range upstreamVersions.Versions {
+		platforms := make([]ProviderPlatform, 0, len(upstreamVersion.Platforms))
+		for _, platform := range upstreamVersion.Platforms {
+			platforms = append(platforms, ProviderPlatform{OS: platform.OS, Arch: platform.Arch})
+		}
+
+		providerVersions = append(providerVersions, ProviderVersionDetails{
+			Version:   normalizeVersion(upstreamVersion.Version),
+			Protocols: upstreamVersion.Protocols,
+			Platforms: platforms,
+		})
+	}
+
+	json.NewEncoder(w).Encode(ProviderVersionsResponse{Versions: providerVersions})
+	return true
+}
+
+// pullThroughProviderVersion declares a provider version for a single platform on a pull-through Provider and waits
+// for its Version to sync. Nil is returned without an error when pull-through is not enabled for the namespace, the
+// version is not published upstream for the platform, or the Provider was declared outside of pull-through.
+func pullThroughProviderVersion(ctx context.Context, namespace, providerType, requestedVersion, osName, arch string) (*opendepotv1alpha1.Version, error) {
+	depot, err := getPullThroughDepot(ctx, namespace)
+	if err != nil || depot == nil {
+		return nil, err
+	}
+
+	upstreamNamespace, upstreamVersions, err := resolvePullThroughProvider(ctx, depot, providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	providerVersion := opendepotv1alpha1.ProviderVersion{
+		Architecture:    arch,
+		OperatingSystem: osName,
+		Version:         normalizeVersion(requestedVersion),
+	}
+
+	if !upstreamPublishesPlatform(upstreamVersions, providerVersion) {
+		return nil, nil
+	}
+
+	provider := opendepotv1alpha1.Provider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      providerType,
+			Namespace: namespace,
+			Labels: map[string]string{
+				opendepotv1alpha1.OpenDepotPullThroughLabel: "true",
+			},
+		},
+		Spec: opendepotv1alpha1.ProviderSpec{
+			ProviderConfig: opendepotv1alpha1.ProviderConfig{
+				Name:               &providerType,
+				Namespace:          &upstreamNamespace,
+				Registry:           depot.Spec.PullThrough.ProviderRegistry,
+				RegistrySecretName: depot.Spec.PullThrough.ProviderRegistrySecretName,
+				StorageConfig:      depot.Spec.GlobalConfig.StorageConfig,
+				StorageProfileName: depot.Spec.GlobalConfig.StorageProfileName,
+			},
+			Versions: []opendepotv1alpha1.ProviderVersion{providerVersion},
+		},
+	}
+
+	managed, counted := true, false
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var currentProvider opendepotv1alpha1.Provider
+		if err := pullThroughClient.Get(ctx, client.ObjectKeyFromObject(&provider), &currentProvider); err != nil {
+			if !k8sApiErrors.IsNotFound(err) {
+				return err
+			}
+
+			if err := countPullThroughVersion(depot, &counted); err != nil {
+				return err
+			}
+			return pullThroughClient.Create(ctx, &provider)
+		}
+
+		if !isPullThroughManaged(&currentProvider) {
+			managed = false
+			return nil
+		}
+
+		if slices.Contains(currentProvider.Spec.Versions, providerVersion) {
+			return nil
+		}
+
+		if err := countPullThroughVersion(depot, &counted); err != nil {
+			return err
+		}
+
+		currentProvider.Spec.Versions = append(currentProvider.Spec.Versions, providerVersion)
+		return pullThroughClient.Update(ctx, &currentProvider)
+	}); err != nil {
+		return nil, err
+	}
+
+	if !managed {
+		return nil, nil
+	}
+
+	logger.Info("pulling through provider version", "namespace", namespace, "type", providerType, "upstreamNamespace", upstreamNamespace, "version", providerVersion.Version, "os", osName, "arch", arch)
+	return waitForPullThroughVersion(ctx, depot, client.ObjectKey{
+		Name:      utils.ProviderVersionResourceName(providerType, providerVersion.Version, osName, arch),
+		Namespace: namespace,
+	})
+}
+
+// countPullThroughVersion counts a version about to be pulled through into the namespace of depot against its hourly
+// maximum, unless counted reports it was already counted by an earlier attempt to declare it.
+func countPullThroughVersion(depot *opendepotv1alpha1.Depot, counted *bool) error {
+	if *counted {
+		return nil
+	}
+
+	if !pullThroughLimiter.allow(depot.Namespace, pullThroughMaxVersionsPerHour(depot), time.Now()) {
+		return errPullThroughLimited
+	}
+
+	*counted = true
+	return nil
+}
+
+// upstreamPublishesPlatform reports whether the upstream versions include providerVersion for its platform.
+func upstreamPublishesPlatform(upstreamVersions *registry.ProviderVersions, providerVersion opendepotv1alpha1.ProviderVersion) bool {
+	for _, upstreamVersion := range upstreamVersions.Versions {
+		if normalizeVersion(upstreamVersion.Version) != providerVersion.Version {
+			continue
+		}
+
+		for _, platform := range upstreamVersion.Platforms {
+			if platform.OS == providerVersion.OperatingSystem && platform.Arch == providerVersion.Architecture {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// resolvePullThroughModule returns the first allowlisted GitHub owner with a repository named name, along with the
+// versions of its published releases.
+func resolvePullThroughModule(ctx context.Context, depot *opendepotv1alpha1.Depot, name string) (string, []string, error) {
+	githubClientConfig := depot.Spec.GlobalConfig.GithubClientConfig
+	useAuthenticatedClient := githubClientConfig != nil && githubClientConfig.UseAuthenticatedClient
+
+	lastErr := fmt.Errorf("no module owners are allowlisted")
+	for _, owner := range depot.Spec.PullThrough.ModuleOwners {
+		githubConfig, err := opendepotGithub.GetGithubClientConfig(ctx, pullThroughClient, depot.Namespace, owner, githubClientConfig)
+		if err != nil {
+			return "", nil, err
+		}
+
+		githubClient, err := opendepotGithub.CreateGithubClient(ctx, useAuthenticatedClient, githubConfig)
+		if err != nil {
+			return "", nil, err
+		}
+
+		versions, err := opendepotGithub.ListReleaseVersions(ctx, githubClient, owner, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return owner, versions, nil
+	}
+
+	return "", nil, fmt.Errorf("module '%s' was not found for an allowlisted owner: %w", name, lastErr)
+}
+
+// servePullThroughModuleVersions writes the upstream release versions of a module when pull-through is enabled for
+// namespace. It reports whether a response was written.
+func servePullThroughModuleVersions(w http.ResponseWriter, r *http.Request, namespace, name string) bool {
+	depot, err := getPullThroughDepot(r.Context(), namespace)
+	if err != nil {
+		logger.Error("unable to get pull-through depot", "error", err, "namespace", namespace)
+		return false
+	}
+
+	if depot == nil {
+		return false
+	}
+
+	_, upstreamVersions, err := resolvePullThroughModule(r.Context(), depot, name)
+	if err != nil {
+		logger.Info("module is not available for pull-through", "error", err, "namespace", namespace, "name", name)
+		return false
+	}
+
+	moduleVersions := make([]opendepotv1alpha1.ModuleVersion, 0, len(upstreamVersions))
+	for _, upstreamVersion := range upstreamVersions {
+		moduleVersions = append(moduleVersions, opendepotv1alpha1.ModuleVersion{Version: upstreamVersion})
+	}
+
+	json.NewEncoder(w).Encode(ModuleVersionsResponse{
+		Modules: []ModuleVersions{
+			{
+				Versions: moduleVersions,
+			},
+		},
+	})
+	return true
+}
+
+// pullThroughModuleVersion declares a module version on a pull-through Module and waits for its Version to sync.
+// Nil is returned without an error when pull-through is not enabled for the namespace, the version has no upstream
+// release, or the Module was declared outside of pull-through.
+func pullThroughModuleVersion(ctx context.Context, namespace, name, system, requestedVersion string) (*opendepotv1alpha1.Version, error) {
+	depot, err := getPullThroughDepot(ctx, namespace)
+	if err != nil || depot == nil {
+		return nil, err
+	}
+
+	owner, upstreamVersions, err := resolvePullThroughModule(ctx, depot, name)
+	if err != nil {
+		return nil, err
+	}
+
+	moduleVersion := opendepotv1alpha1.ModuleVersion{Version: normalizeVersion(requestedVersion)}
+	if !slices.Contains(upstreamVersions, moduleVersion.Version) {
+		return nil, nil
+	}
+
+	fileFormat := "zip"
+	moduleConfig := opendepotv1alpha1.ModuleConfig{
+		FileFormat:         &fileFormat,
+		GithubClientConfig: depot.Spec.GlobalConfig.GithubClientConfig,
+		Name:               &name,
+		Provider:           system,
+		RepoOwner:          owner,
+		StorageConfig:      depot.Spec.GlobalConfig.StorageConfig,
+		StorageProfileName: depot.Spec.GlobalConfig.StorageProfileName,
+	}
+
+	if globalModuleConfig := depot.Spec.GlobalConfig.ModuleConfig; globalModuleConfig != nil {
+		if globalModuleConfig.FileFormat != nil {
+			moduleConfig.FileFormat = globalModuleConfig.FileFormat
+		}
+		moduleConfig.Immutable = globalModuleConfig.Immutable
+		moduleConfig.Dependencies = globalModuleConfig.Dependencies
+		moduleConfig.ExcludePatterns = globalModuleConfig.ExcludePatterns
+		moduleConfig.RetagPolicy = globalModuleConfig.RetagPolicy
+	}
+
+	repoURL := opendepotGithub.GetRepositoryURL(moduleConfig.GithubClientConfig, owner, name)
+	moduleConfig.RepoUrl = &repoURL
+
+	module := opendepotv1alpha1.Module{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				opendepotv1alpha1.OpenDepotPullThroughLabel: "true",
+			},
+		},
+		Spec: opendepotv1alpha1.ModuleSpec{
+			ModuleConfig: moduleConfig,
+			Versions:     []opendepotv1alpha1.ModuleVersion{moduleVersion},
+		},
+	}
+
+	managed, counted := true, false
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var currentModule opendepotv1alpha1.Module
+		if err := pullThroughClient.Get(ctx, client.ObjectKeyFromObject(&module), &currentModule); err != nil {
+			if !k8sApiErrors.IsNotFound(err) {
+				return err
+			}
+
+			if err := countPullThroughVersion(depot, &counted); err != nil {
+				return err
+			}
+			return pullThroughClient.Create(ctx, &module)
+		}
+
+		if !isPullThroughManaged(&currentModule) {
+			managed = false
+			return nil
+		}
+
+		for _, v := range currentModule.Spec.Versions {
+			if normalizeVersion(v.Version) == moduleVersion.Version {
+				return nil
+			}
+		}
+
+		if err := countPullThroughVersion(depot, &counted); err != nil {
+			return err
+		}
+
+		currentModule.Spec.Versions = append(currentModule.Spec.Versions, moduleVersion)
+		return pullThroughClient.Update(ctx, &currentModule)
+	}); err != nil {
+		return nil, err
+	}
+
+	if !managed {
+		return nil, nil
+	}
+
+	logger.Info("pulling through module version", "namespace", namespace, "name", name, "owner", owner, "version", moduleVersion.Version)
+	return waitForPullThroughVersion(ctx, depot, client.ObjectKey{
+		Name:      fmt.Sprintf("%s-%s", name, moduleVersion.Version),
+		Namespace: namespace,
+	})
+}
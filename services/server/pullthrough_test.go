@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+)
+
+// usePullThroughClient replaces pullThroughClient and pullThroughLimiter for the duration of a test with a fake
+// client holding objs and an empty limiter.
+func usePullThroughClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := opendepotv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	previousClient, previousLimiter := pullThroughClient, pullThroughLimiter
+	pullThroughClient = fakeClient
+	pullThroughLimiter = &namespaceLimiter{window: pullThroughLimitWindow, events: map[string][]time.Time{}}
+	t.Cleanup(func() {
+		pullThroughClient, pullThroughLimiter = previousClient, previousLimiter
+	})
+
+	return fakeClient
+}
+
+// newUpstreamServer returns a server that acts as both the upstream provider registry and the GitHub API. It
+// publishes the 'aws' provider in the 'community' namespace and the 'terraform-aws-vpc' module of 'defdev'.
+func newUpstreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"providers.v1": "/v1/providers/"})
+	})
+	mux.HandleFunc("/v1/providers/community/aws/versions", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"versions": []map[string]any{
+				{"version": "5.0.0", "platforms": []map[string]string{{"os": "linux", "arch": "amd64"}}},
+			},
+		})
+	})
+	mux.HandleFunc("/api/v3/repos/defdev/terraform-aws-vpc/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"tag_name": "v1.0.0"},
+			{"tag_name": "v1.1.0"},
+			{"tag_name": "v2.0.0-rc.1", "prerelease": true},
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newPullThroughDepot returns a Depot in namespace that allowlists owners and providerNamespaces on server.
+func newPullThroughDepot(server *httptest.Server, namespace string, owners, providerNamespaces []string) *opendepotv1alpha1.Depot {
+	baseURL := server.URL + "/api/v3/"
+	directory := "/tmp/modules"
+	return &opendepotv1alpha1.Depot{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-through", Namespace: namespace},
+		Spec: opendepotv1alpha1.DepotSpec{
+			GlobalConfig: &opendepotv1alpha1.GlobalConfig{
+				GithubClientConfig: &opendepotv1alpha1.GithubClientConfig{BaseURL: &baseURL},
+				StorageConfig: &opendepotv1alpha1.StorageConfig{
+					FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory},
+				},
+			},
+			PullThrough: &opendepotv1alpha1.DepotPullThroughConfig{
+				ModuleOwners:       owners,
+				ProviderNamespaces: providerNamespaces,
+				ProviderRegistry:   &server.URL,
+			},
+		},
+	}
+}
+
+func TestResolvePullThroughProvider(t *testing.T) {
+	server := newUpstreamServer(t)
+	usePullThroughClient(t)
+
+	tests := []struct {
+		name              string
+		namespaces        []string
+		expectedNamespace string
+		expectErr         bool
+	}{
+		{name: "first namespace publishes the provider", namespaces: []string{"community", "hashicorp"}, expectedNamespace: "community"},
+		{name: "later namespace publishes the provider", namespaces: []string{"hashicorp", "community"}, expectedNamespace: "community"},
+		{name: "no namespace publishes the provider", namespaces: []string{"hashicorp"}, expectErr: true},
+		{name: "no namespaces are allowlisted", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depot := newPullThroughDepot(server, "default", nil, tt.namespaces)
+			namespace, versions, err := resolvePullThroughProvider(context.Background(), depot, "aws")
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, resolved namespace '%s'", namespace)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if namespace != tt.expectedNamespace || len(versions.Versions) != 1 {
+				t.Fatalf("resolved namespace '%s' with %d versions, want '%s' with 1", namespace, len(versions.Versions), tt.expectedNamespace)
+			}
+		})
+	}
+}
+
+func TestResolvePullThroughModule(t *testing.T) {
+	server := newUpstreamServer(t)
+	usePullThroughClient(t)
+
+	tests := []struct {
+		name             string
+		owners           []string
+		expectedOwner    string
+		expectedVersions []string
+		expectErr        bool
+	}{
+		{name: "first owner has the repository", owners: []string{"defdev", "hashicorp"}, expectedOwner: "defdev", expectedVersions: []string{"1.0.0", "1.1.0"}},
+		{name: "later owner has the repository", owners: []string{"hashicorp", "defdev"}, expectedOwner: "defdev", expectedVersions: []string{"1.0.0", "1.1.0"}},
+		{name: "no owner has the repository", owners: []string{"hashicorp"}, expectErr: true},
+		{name: "no owners are allowlisted", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depot := newPullThroughDepot(server, "default", tt.owners, nil)
+			owner, versions, err := resolvePullThroughModule(context.Background(), depot, "terraform-aws-vpc")
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, resolved owner '%s'", owner)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if owner != tt.expectedOwner || !slices.Equal(versions, tt.expectedVersions) {
+				t.Fatalf("resolved owner '%s' with versions %v, want '%s' with %v", owner, versions, tt.expectedOwner, tt.expectedVersions)
+			}
+		})
+	}
+}
+
+func TestPullThroughModuleVersion(t *testing.T) {
+	server := newUpstreamServer(t)
+	ctx := context.Background()
+
+	syncedVersion := func(name string) *opendepotv1alpha1.Version {
+		checksum, fileName := "checksum", "archive.zip"
+		return &opendepotv1alpha1.Version{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       opendepotv1alpha1.VersionSpec{FileName: &fileName},
+			Status:     opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+		}
+	}
+
+	pullThroughModule := func(labels map[string]string, versions ...string) *opendepotv1alpha1.Module {
+		module := &opendepotv1alpha1.Module{
+			ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-vpc", Namespace: "default", Labels: labels},
+		}
+		for _, version := range versions {
+			module.Spec.Versions = append(module.Spec.Versions, opendepotv1alpha1.ModuleVersion{Version: version})
+		}
+		return module
+	}
+	managedLabels := map[string]string{opendepotv1alpha1.OpenDepotPullThroughLabel: "true"}
+
+	tests := []struct {
+		name             string
+		objs             []client.Object
+		version          string
+		expectVersion    bool
+		expectedVersions []string
+	}{
+		{
+			name:             "creates the module and waits for its version",
+			objs:             []client.Object{syncedVersion("terraform-aws-vpc-1.0.0")},
+			version:          "1.0.0",
+			expectVersion:    true,
+			expectedVersions: []string{"1.0.0"},
+		},
+		{
+			name:             "adds the version to a pull-through module",
+			objs:             []client.Object{pullThroughModule(managedLabels, "1.0.0"), syncedVersion("terraform-aws-vpc-1.1.0")},
+			version:          "v1.1.0",
+			expectVersion:    true,
+			expectedVersions: []string{"1.0.0", "1.1.0"},
+		},
+		{
+			name:             "leaves a declared module unchanged",
+			objs:             []client.Object{pullThroughModule(nil, "1.0.0")},
+			version:          "1.1.0",
+			expectedVersions: []string{"1.0.0"},
+		},
+		{
+			name:    "ignores versions without an upstream release",
+			version: "2.0.0-rc.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depot := newPullThroughDepot(server, "default", []string{"defdev"}, nil)
+			fakeClient := usePullThroughClient(t, append(tt.objs, depot)...)
+
+			version, err := pullThroughModuleVersion(ctx, "default", "terraform-aws-vpc", "aws", tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (version != nil) != tt.expectVersion {
+				t.Fatalf("pulled through version %v, expected a version: %t", version, tt.expectVersion)
+			}
+
+			module := &opendepotv1alpha1.Module{}
+			err = fakeClient.Get(ctx, client.ObjectKey{Name: "terraform-aws-vpc", Namespace: "default"}, module)
+			if tt.expectedVersions == nil {
+				if err == nil {
+					t.Fatalf("expected no module, found versions %v", module.Spec.Versions)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var versions []string
+			for _, moduleVersion := range module.Spec.Versions {
+				versions = append(versions, moduleVersion.Version)
+			}
+
+			if !slices.Equal(versions, tt.expectedVersions) {
+				t.Fatalf("module has versions %v, want %v", versions, tt.expectedVersions)
+			}
+		})
+	}
+}
+
+func TestPullThroughModuleVersionTimeout(t *testing.T) {
+	server := newUpstreamServer(t)
+	depot := newPullThroughDepot(server, "default", []string{"defdev"}, nil)
+	syncTimeout := 1
+	depot.Spec.PullThrough.SyncTimeoutSeconds = &syncTimeout
+	usePullThroughClient(t, depot)
+
+	_, err := pullThroughModuleVersion(context.Background(), "default", "terraform-aws-vpc", "aws", "1.0.0")
+	if !errors.Is(err, errPullThroughTimeout) {
+		t.Fatalf("expected errPullThroughTimeout, got %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	writePullThroughError(recorder, err)
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("Retry-After") == "" {
+		t.Fatalf("timeout answered with %d and Retry-After '%s'", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestPullThroughProviderVersionLimit(t *testing.T) {
+	server := newUpstreamServer(t)
+	depot := newPullThroughDepot(server, "default", nil, []string{"community"})
+	maxVersions := 1
+	depot.Spec.PullThrough.MaxVersionsPerHour = &maxVersions
+
+	checksum, fileName := "checksum", "archive.zip"
+	usePullThroughClient(t, depot, &opendepotv1alpha1.Version{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws-5-0-0-linux-amd64", Namespace: "default"},
+		Spec:       opendepotv1alpha1.VersionSpec{FileName: &fileName},
+		Status:     opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+	})
+	pullThroughLimiter.events["default"] = []time.Time{time.Now()}
+
+	_, err := pullThroughProviderVersion(context.Background(), "default", "aws", "5.0.0", "linux", "amd64")
+	if !errors.Is(err, errPullThroughLimited) {
+		t.Fatalf("expected errPullThroughLimited, got %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	writePullThroughError(recorder, err)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("limited pull-through answered with %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+
+	// Once the window has passed the version is pulled through.
+	pullThroughLimiter.events["default"] = []time.Time{time.Now().Add(-pullThroughLimitWindow)}
+	version, err := pullThroughProviderVersion(context.Background(), "default", "aws", "5.0.0", "linux", "amd64")
+	if err != nil || version == nil {
+		t.Fatalf("expected the version to be pulled through, got %v, %v", version, err)
+	}
+}
+
+func TestNamespaceLimiter(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		events   []time.Time
+		limit    int
+		expected bool
+	}{
+		{name: "no events", limit: 1, expected: true},
+		{name: "below the limit", events: []time.Time{now.Add(-time.Minute)}, limit: 2, expected: true},
+		{name: "at the limit", events: []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute)}, limit: 2, expected: false},
+		{name: "events outside the window are not counted", events: []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)}, limit: 1, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &namespaceLimiter{window: time.Hour, events: map[string][]time.Time{"default": tt.events}}
+			if allowed := limiter.allow("default", tt.limit, now); allowed != tt.expected {
+				t.Fatalf("allow returned %t, want %t", allowed, tt.expected)
+			}
+
+			// Other namespaces are counted separately.
+			if !limiter.allow("other", tt.limit, now) {
+				t.Fatal("expected another namespace to be allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	storageTypes "github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+// replicaFailureCooldown is how long a storage replica that failed a download is tried only after the other replicas.
+const replicaFailureCooldown = 30 * time.Second
+
+// replicaFailures holds the time each storage replica last failed a download, keyed by the replica's storage config.
+var replicaFailures sync.Map
+
+// serveModuleFromReplicas streams the archive of a Version whose storage config has replicas or encryption from the
+// first healthy replica in the read order of the storage config. Replicas the Version's status reports as not synced are skipped,
+// and replicas that recently failed a download are tried last.
+func serveModuleFromReplicas(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	fileName := chi.URLParam(r, "fileName")
+	checksum := r.URL.Query().Get("fileChecksum")
+	namespace := r.URL.Query().Get("namespace")
+	versionName := r.URL.Query().Get("version")
+
+	if namespace == "" || versionName == "" {
+		http.Error(w, "the namespace and version query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	versionResource, err := getDownloadVersion(r, namespace, versionName)
+	if err != nil {
+		logger.Error("failed to get version", "error", err, "namespace", namespace, "version", versionName)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
+	var configName *string
+	if versionResource.Spec.ModuleConfigRef != nil {
+		configName = versionResource.Spec.ModuleConfigRef.Name
+	} else if versionResource.Spec.ProviderConfigRef != nil {
+		configName = versionResource.Spec.ProviderConfigRef.Name
+	}
+
+	if configName == nil || *configName != name || versionResource.Spec.FileName == nil || *versionResource.Spec.FileName != fileName {
+		logger.Error("file does not match version", "name", name, "fileName", fileName, "namespace", namespace, "version", versionName)
+		http.Error(w, "module not found", http.StatusNotFound)
+		return
+	}
+
+	storageConfig, secretNamespace, err := getVersionStorageConfig(r.Context(), versionResource)
+	if err != nil {
+		logger.Error("failed to get storage config of version", "error", err, "namespace", namespace, "version", versionName)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
+	replicas, err := storage.ReadOrder(storageConfig)
+	if err != nil {
+		logger.Error("invalid storage replicas", "error", err, "namespace", namespace, "version", versionName)
+		http.Error(w, "failed to get module", http.StatusInternalServerError)
+		return
+	}
+
+	if downloadCache != nil {
+		serveCachedObject(w, r, fileName, checksum, func() (*string, error) {
+			return firstReplicaObjectChecksum(r, secretNamespace, versionResource, replicas, name, fileName, checksum)
+		}, func() (io.Reader, error) {
+			_, reader, err := openFirstReplicaObject(r, secretNamespace, versionResource, replicas, name, fileName, checksum)
+			return reader, err
+		})
+		return
+	}
+
+	soi, reader, err := openFirstReplicaObject(r, secretNamespace, versionResource, replicas, name, fileName, checksum)
+	if err != nil {
+		logger.Error("module unavailable from every storage replica", "namespace", namespace, "version", versionName)
+		http.Error(w, "module unavailable from every storage replica", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeStorageObject(w, soi, reader)
+}
+
+// openFirstReplicaObject returns an io.Reader to stream the archive of versionResource from the first healthy replica
+// in replicas that serves it.
+func openFirstReplicaObject(r *http.Request, secretNamespace string, versionResource *opendepotv1alpha1.Version, replicas []storage.StorageReplica, name, fileName, checksum string) (*storageTypes.StorageObjectInput, io.Reader, error) {
+	for _, replica := range healthyReplicasFirst(versionResource, replicas) {
+		key := replicaKey(replica)
+		soi, reader, err := openReplicaObject(r, secretNamespace, versionResource, replica, name, fileName, checksum)
+		if err != nil {
+			logger.Warn("failed to read from storage replica", "error", err, "replica", replica.Name, "namespace", versionResource.Namespace, "version", versionResource.Name)
+			replicaFailures.Store(key, time.Now())
+			continue
+		}
+
+		replicaFailures.Delete(key)
+		return soi, reader, nil
+	}
+
+	return nil, nil, fmt.Errorf("module unavailable from every storage replica")
+}
+
+// firstReplicaObjectChecksum returns the checksum of the archive of versionResource reported by the first healthy
+// replica in replicas that stores it with checksum, or nil when that replica reports no checksum for it.
+func firstReplicaObjectChecksum(r *http.Request, secretNamespace string, versionResource *opendepotv1alpha1.Version, replicas []storage.StorageReplica, name, fileName, checksum string) (*string, error) {
+	for _, replica := range healthyReplicasFirst(versionResource, replicas) {
+		replicaStorage, soi, err := newReplicaObjectInput(r, secretNamespace, versionResource, replica, name, fileName)
+		if err == nil {
+			var objectChecksum *string
+			objectChecksum, err = storageObjectChecksum(r.Context(), replicaStorage, soi)
+			if err == nil && objectChecksum != nil && *objectChecksum != checksum {
+				err = fmt.Errorf("checksum mismatch from storage system: want %s, received %s", checksum, *objectChecksum)
+			}
+
+			if err == nil {
+				replicaFailures.Delete(replicaKey(replica))
+				return objectChecksum, nil
+			}
+		}
+
+		logger.Warn("failed to read checksum from storage replica", "error", err, "replica", replica.Name, "namespace", versionResource.Namespace, "version", versionResource.Name)
+		replicaFailures.Store(replicaKey(replica), time.Now())
+	}
+
+	return nil, fmt.Errorf("module unavailable from every storage replica")
+}
+
+// newReplicaObjectInput connects to replica and returns the storage object input of the archive of versionResource
+// in it.
+func newReplicaObjectInput(r *http.Request, secretNamespace string, versionResource *opendepotv1alpha1.Version, replica storage.StorageReplica, name, fileName string) (storage.Storage, *storageTypes.StorageObjectInput, error) {
+	filePath, err := storage.ObjectPath(replica.StorageConfig, name, fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replicaStorage, err := storage.NewStorage(r.Context(), serviceAccountStorageGetter{}, secretNamespace, replica.StorageConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return replicaStorage, &storageTypes.StorageObjectInput{
+		FilePath:      &filePath,
+		Method:        storageTypes.Get,
+		StorageConfig: replica.StorageConfig,
+		Version:       versionResource,
+	}, nil
+}
+
+// openReplicaObject connects to replica and returns an io.Reader to stream the archive of versionResource from it once
+// its checksum is verified.
+func openReplicaObject(r *http.Request, secretNamespace string, versionResource *opendepotv1alpha1.Version, replica storage.StorageReplica, name, fileName, checksum string) (*storageTypes.StorageObjectInput, io.Reader, error) {
+	replicaStorage, soi, err := newReplicaObjectInput(r, secretNamespace, versionResource, replica, name, fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := openStorageObject(r.Context(), replicaStorage, soi, checksum)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return soi, reader, nil
+}
+
+// healthyReplicasFirst drops the replicas that the status of versionResource reports as not synced and moves the
+// replicas that failed a download within replicaFailureCooldown after the others, keeping the read order otherwise.
+func healthyReplicasFirst(versionResource *opendepotv1alpha1.Version, replicas []storage.StorageReplica) []storage.StorageReplica {
+	var healthy, failed []storage.StorageReplica
+	for _, replica := range replicas {
+		index := slices.IndexFunc(versionResource.Status.Replicas, func(status opendepotv1alpha1.StorageReplicaStatus) bool {
+			return status.Name == replica.Name
+		})
+		if index >= 0 && !versionResource.Status.Replicas[index].Synced {
+			continue
+		}
+
+		if failedAt, ok := replicaFailures.Load(replicaKey(replica)); ok && time.Since(failedAt.(time.Time)) < replicaFailureCooldown {
+			failed = append(failed, replica)
+			continue
+		}
+
+		healthy = append(healthy, replica)
+	}
+
+	return append(healthy, failed...)
+}
+
+// replicaKey identifies the backend of replica across Versions.
+func replicaKey(replica storage.StorageReplica) string {
+	key, _ := json.Marshal(replica.StorageConfig)
+	return string(key)
+}
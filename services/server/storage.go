@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+)
+
+// downloadURL returns the server URL an archive of versionResource is downloaded from. Downloads from every backend
+// but the filesystem carry the namespace and name of the Version so the server can connect with the Version's
+// storage config and credentials.
+func downloadURL(versionResource *opendepotv1alpha1.Version, downloadPath string) string {
+	query := url.Values{}
+	query.Set("fileChecksum", *versionResource.Status.Checksum)
+
+	if !strings.HasPrefix(downloadPath, "fileSystem/") {
+		query.Set("namespace", versionResource.Namespace)
+		query.Set("version", versionResource.Name)
+	}
+
+	return fmt.Sprintf("/opendepot/modules/v1/download/%s?%s", downloadPath, query.Encode())
+}
+
+// getDownloadStorageConfig returns the storage config of a Version and the namespace the Secrets it names are read
+// from, read with the server's service account since archive downloads are not authenticated.
+func getDownloadStorageConfig(r *http.Request, namespace, versionName string) (*opendepotv1alpha1.StorageConfig, string, error) {
+	versionResource, err := getDownloadVersion(r, namespace, versionName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return getVersionStorageConfig(r.Context(), versionResource)
+}
+
+// getDownloadVersion returns a Version read with the server's service account.
+func getDownloadVersion(r *http.Request, namespace, versionName string) (*opendepotv1alpha1.Version, error) {
+	clientset, err := generateKubeClient(nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := clientset.RESTClient().
+		Get().
+		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
+		Namespace(namespace).
+		Resource("versions").
+		Name(versionName).
+		DoRaw(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var versionResource opendepotv1alpha1.Version
+	if err := json.Unmarshal(result, &versionResource); err != nil {
+		return nil, err
+	}
+
+	return &versionResource, nil
+}
+
+// serviceAccountStorageGetter reads the resources storage configs refer to with the server's service account, since
+// archive downloads are not authenticated.
+type serviceAccountStorageGetter struct{}
+
+// GetSecretData returns the data of the Secret name in namespace.
+func (serviceAccountStorageGetter) GetSecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	clientset, err := generateKubeClient(nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.Data, nil
+}
+
+// GetDefaultStorageProfileName returns the StorageProfile named by the 'opendepot.defdev.io/storage-profile'
+// annotation of namespace, or an empty string when it has none.
+func (serviceAccountStorageGetter) GetDefaultStorageProfileName(ctx context.Context, namespace string) (string, error) {
+	clientset, err := generateKubeClient(nil, nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	namespaceResource, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return namespaceResource.Annotations[opendepotv1alpha1.OpenDepotStorageProfileAnnotation], nil
+}
+
+// GetStorageProfile returns the StorageProfile named name.
+func (serviceAccountStorageGetter) GetStorageProfile(ctx context.Context, name string) (*opendepotv1alpha1.StorageProfile, error) {
+	clientset, err := generateKubeClient(nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := clientset.RESTClient().
+		Get().
+		AbsPath("/apis/opendepot.defdev.io/v1alpha1").
+		Resource("storageprofiles").
+		Name(name).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile opendepotv1alpha1.StorageProfile
+	if err := json.Unmarshal(result, &profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// getVersionStorageConfig resolves the storage config of a Version and the namespace the Secrets it names are read
+// from, with the server's service account.
+func getVersionStorageConfig(ctx context.Context, versionResource *opendepotv1alpha1.Version) (*opendepotv1alpha1.StorageConfig, string, error) {
+	return storage.VersionStorageConfig(ctx, serviceAccountStorageGetter{}, versionResource)
+}
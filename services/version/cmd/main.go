@@ -228,6 +228,7 @@ func main() {
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
 		Log:             logger,
+		Recorder:        mgr.GetEventRecorder("opendepot-versions-controller"),
 		ScanningEnabled: scanningEnabled,
 		ScanModules:     scanModules,
 		TrivyCacheDir:   trivyCacheDir,
@@ -238,6 +239,22 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Version")
 		os.Exit(1)
 	}
+	if err := (&controller.StorageMigrationReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Log:    logger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StorageMigration")
+		os.Exit(1)
+	}
+	if err := (&controller.StorageAuditReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Log:    logger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StorageAudit")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
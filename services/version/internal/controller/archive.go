@@ -0,0 +1,383 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// moduleIgnoreFileName is the file at the root of a module repository that lists the files left out of its archive.
+const moduleIgnoreFileName = ".opendepotignore"
+
+// normalizedArchiveModTime is the modification time of every entry in a normalized archive. It is the earliest
+// time a zip archive can represent.
+var normalizedArchiveModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveEntry is a regular file or symlink read from a module archive.
+type archiveEntry struct {
+	name     string
+	mode     fs.FileMode
+	linkname string
+	data     []byte
+}
+
+// normalizeModuleArchive repackages a zip or tar.gz module archive so the same files always produce the same
+// bytes. The top-level directory GitHub wraps archives in is stripped, files matching the patterns of the
+// repository's '.opendepotignore' followed by excludePatterns are dropped, entries are sorted by name, and
+// modification times, owners and permissions are fixed. The archive keeps its original format.
+func normalizeModuleArchive(archive []byte, excludePatterns []string) ([]byte, error) {
+	isZip := bytes.HasPrefix(archive, []byte("PK\x03\x04"))
+
+	entries, err := readArchiveEntries(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	entries = stripTopLevelDir(entries)
+
+	var patterns []string
+	for _, entry := range entries {
+		if entry.name == moduleIgnoreFileName && entry.mode.IsRegular() {
+			patterns = strings.Split(string(entry.data), "\n")
+			break
+		}
+	}
+	matcher := newExcludeMatcher(append(patterns, excludePatterns...))
+
+	kept := make([]archiveEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.name == moduleIgnoreFileName || matcher.excludes(entry.name) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	sort.Slice(kept, func(i, j int) bool { return kept[i].name < kept[j].name })
+
+	if isZip {
+		return writeNormalizedZip(kept)
+	}
+
+	return writeNormalizedTarGz(kept)
+}
+
+// readArchiveEntries returns the regular files and symlinks in a zip or tar.gz archive. Entries whose cleaned
+// path is absolute or leaves the archive are skipped, and the last of any duplicate entries is kept.
+func readArchiveEntries(archive []byte) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	index := map[string]int{}
+	add := func(entry archiveEntry) {
+		name := path.Clean(strings.TrimPrefix(entry.name, "./"))
+		if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			return
+		}
+		entry.name = name
+
+		if i, exists := index[name]; exists {
+			entries[i] = entry
+			return
+		}
+		index[name] = len(entries)
+		entries = append(entries, entry)
+	}
+
+	if bytes.HasPrefix(archive, []byte("PK\x03\x04")) {
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read zip archive: %w", err)
+		}
+
+		for _, file := range reader.File {
+			mode := file.Mode()
+			if !mode.IsRegular() && mode&fs.ModeSymlink == 0 {
+				continue
+			}
+
+			content, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("unable to read '%s' from zip archive: %w", file.Name, err)
+			}
+
+			data, err := io.ReadAll(content)
+			content.Close()
+			if err != nil {
+				return nil, fmt.Errorf("unable to read '%s' from zip archive: %w", file.Name, err)
+			}
+
+			// Zip archives store the target of a symlink as its content.
+			if mode&fs.ModeSymlink != 0 {
+				add(archiveEntry{name: file.Name, mode: fs.ModeSymlink | 0o777, linkname: string(data)})
+				continue
+			}
+
+			add(archiveEntry{name: file.Name, mode: mode, data: data})
+		}
+
+		return entries, nil
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gzip archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read tar archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			data, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read '%s' from tar archive: %w", header.Name, err)
+			}
+
+			add(archiveEntry{name: header.Name, mode: fs.FileMode(header.Mode).Perm(), data: data})
+		case tar.TypeSymlink:
+			add(archiveEntry{name: header.Name, mode: fs.ModeSymlink | 0o777, linkname: header.Linkname})
+		}
+	}
+
+	return entries, nil
+}
+
+// stripTopLevelDir removes the directory GitHub archives wrap their files in, when every entry shares it.
+func stripTopLevelDir(entries []archiveEntry) []archiveEntry {
+	var topLevelDir string
+	for _, entry := range entries {
+		dir, _, ok := strings.Cut(entry.name, "/")
+		if !ok || (topLevelDir != "" && dir != topLevelDir) {
+			return entries
+		}
+		topLevelDir = dir
+	}
+
+	for i := range entries {
+		entries[i].name = strings.TrimPrefix(entries[i].name, topLevelDir+"/")
+	}
+
+	return entries
+}
+
+// normalizedMode returns the permissions of an entry in a normalized archive. Only the executable bit of a
+// regular file is kept.
+func normalizedMode(mode fs.FileMode) fs.FileMode {
+	if mode&fs.ModeSymlink != 0 {
+		return fs.ModeSymlink | 0o777
+	}
+
+	if mode&0o111 != 0 {
+		return 0o755
+	}
+
+	return 0o644
+}
+
+func writeNormalizedZip(entries []archiveEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:     entry.name,
+			Method:   zip.Deflate,
+			Modified: normalizedArchiveModTime,
+		}
+		header.SetMode(normalizedMode(entry.mode))
+
+		file, err := writer.CreateHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("unable to write '%s' to zip archive: %w", entry.name, err)
+		}
+
+		data := entry.data
+		if entry.mode&fs.ModeSymlink != 0 {
+			data = []byte(entry.linkname)
+		}
+
+		if _, err := file.Write(data); err != nil {
+			return nil, fmt.Errorf("unable to write '%s' to zip archive: %w", entry.name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write zip archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeNormalizedTarGz(entries []archiveEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:    entry.name,
+			Mode:    int64(normalizedMode(entry.mode).Perm()),
+			ModTime: normalizedArchiveModTime,
+		}
+
+		if entry.mode&fs.ModeSymlink != 0 {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.linkname
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.data))
+		}
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("unable to write '%s' to tar archive: %w", entry.name, err)
+		}
+
+		if _, err := tarWriter.Write(entry.data); err != nil {
+			return nil, fmt.Errorf("unable to write '%s' to tar archive: %w", entry.name, err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write tar archive: %w", err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("unable to write gzip archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// moduleArchiveChecksum returns the base64 encoded SHA256 checksum of a module archive, as recorded in
+// Version.status.checksum.
+func moduleArchiveChecksum(archive []byte) string {
+	sum := sha256.Sum256(archive)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// excludePattern is a single '.gitignore' style pattern.
+type excludePattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// excludeMatcher matches archive paths against '.gitignore' style patterns. The last pattern that matches a path
+// or one of its parent directories decides whether it is excluded, and a pattern prefixed with '!' re-includes it.
+type excludeMatcher struct {
+	patterns []excludePattern
+}
+
+// newExcludeMatcher parses patterns in '.gitignore' syntax. Blank lines and lines starting with '#' are ignored.
+// A pattern ending in '/' only matches directories. A pattern containing any other '/' is anchored to the root of
+// the archive; otherwise it matches at any depth. '*', '?' and '[...]' match within a path segment and '**'
+// matches any number of segments.
+func newExcludeMatcher(lines []string) *excludeMatcher {
+	matcher := &excludeMatcher{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern excludePattern
+		if negated, ok := strings.CutPrefix(line, "!"); ok {
+			pattern.negate = true
+			line = negated
+		}
+
+		if trimmed, ok := strings.CutSuffix(line, "/"); ok {
+			pattern.dirOnly = true
+			line = trimmed
+		}
+
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+
+		line = strings.TrimPrefix(line, "/")
+		if line == "" || line == "**/" {
+			continue
+		}
+
+		pattern.segments = strings.Split(line, "/")
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+
+	return matcher
+}
+
+// excludes reports whether the file at name is excluded.
+func (m *excludeMatcher) excludes(name string) bool {
+	segments := strings.Split(name, "/")
+
+	var excluded bool
+	for _, pattern := range m.patterns {
+		for i := 1; i <= len(segments); i++ {
+			if pattern.dirOnly && i == len(segments) {
+				continue
+			}
+
+			if matchPatternSegments(pattern.segments, segments[:i]) {
+				excluded = !pattern.negate
+				break
+			}
+		}
+	}
+
+	return excluded
+}
+
+// matchPatternSegments reports whether the path segments match the pattern segments, where '**' matches zero or
+// more path segments.
+func matchPatternSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		return matchPatternSegments(pattern[1:], segments) ||
+			(len(segments) > 0 && matchPatternSegments(pattern, segments[1:]))
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], segments[0])
+	return err == nil && matched && matchPatternSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,738 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+const (
+	// defaultDependencyOperatingSystem is the OS registered providers are mirrored for when none is configured.
+	defaultDependencyOperatingSystem = "linux"
+	// defaultDependencyArchitecture is the architecture registered providers are mirrored for when none is configured.
+	defaultDependencyArchitecture = "amd64"
+)
+
+var (
+	configFileSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "terraform"},
+			{Type: "module", LabelNames: []string{"name"}},
+		},
+	}
+	terraformBlockSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "required_providers"},
+		},
+	}
+	moduleBlockSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "source"},
+			{Name: "version"},
+		},
+	}
+
+	// skippedModuleDirs hold example and test configurations, whose dependencies are not needed to use the module.
+	skippedModuleDirs = []string{"example", "examples", "test", "tests"}
+)
+
+// readModuleDependencies parses the configuration files in a normalized zip or tar.gz module archive and returns
+// the providers in its 'required_providers' blocks and the modules it calls. Files in example, test and hidden
+// directories are skipped.
+func readModuleDependencies(archive []byte) (*opendepotv1alpha1.ModuleDependencies, error) {
+	files, err := readModuleConfigFiles(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	collector := &dependencyCollector{
+		providers: map[string]*opendepotv1alpha1.ProviderDependency{},
+		modules:   map[string]*opendepotv1alpha1.ModuleDependency{},
+	}
+
+	parser := hclparse.NewParser()
+	for _, name := range names {
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(name, ".json") {
+			file, diags = parser.ParseJSON(files[name], name)
+		} else {
+			file, diags = parser.ParseHCL(files[name], name)
+		}
+
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		if err := collector.collectConfigFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return collector.dependencies(), nil
+}
+
+// readModuleConfigFiles returns the configuration files in a zip or tar.gz module archive, keyed by their path.
+func readModuleConfigFiles(archive []byte) (map[string][]byte, error) {
+	entries, err := readArchiveEntries(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if entry.mode.IsRegular() && isModuleConfigFile(entry.name) {
+			files[entry.name] = entry.data
+		}
+	}
+
+	return skipUnusedModuleDirs(files), nil
+}
+
+// skipUnusedModuleDirs removes the files in example, test and hidden directories.
+func skipUnusedModuleDirs(files map[string][]byte) map[string][]byte {
+	for name := range files {
+		dirs := strings.Split(path.Dir(name), "/")
+		if slices.ContainsFunc(dirs, func(dir string) bool {
+			return slices.Contains(skippedModuleDirs, dir) || (strings.HasPrefix(dir, ".") && dir != ".")
+		}) {
+			delete(files, name)
+		}
+	}
+
+	return files
+}
+
+// isModuleConfigFile reports whether name is an OpenTofu or Terraform configuration file in native or JSON syntax.
+func isModuleConfigFile(name string) bool {
+	for _, suffix := range []string{".tf", ".tofu", ".tf.json", ".tofu.json"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dependencyCollector collects the provider and module dependencies of the configuration files of a module.
+type dependencyCollector struct {
+	providers map[string]*opendepotv1alpha1.ProviderDependency
+	modules   map[string]*opendepotv1alpha1.ModuleDependency
+}
+
+func (c *dependencyCollector) collectConfigFile(file *hcl.File) error {
+	content, _, diags := file.Body.PartialContent(configFileSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "terraform":
+			if err := c.collectTerraformBlock(block); err != nil {
+				return err
+			}
+		case "module":
+			if err := c.collectModuleBlock(block); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *dependencyCollector) collectTerraformBlock(block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(terraformBlockSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, requiredProviders := range content.Blocks {
+		attributes, diags := requiredProviders.Body.JustAttributes()
+		if diags.HasErrors() {
+			return diags
+		}
+
+		for _, attribute := range attributes {
+			c.collectRequiredProvider(attribute)
+		}
+	}
+
+	return nil
+}
+
+// collectRequiredProvider collects a single 'required_providers' entry in either its object form or its legacy form,
+// which sets the version constraint directly, e.g. 'aws = "~> 5.0"'. Entries that cannot be decoded are skipped.
+func (c *dependencyCollector) collectRequiredProvider(attribute *hcl.Attribute) {
+	source := attribute.Name
+	var constraint string
+
+	pairs, diags := hcl.ExprMap(attribute.Expr)
+	if diags.HasErrors() {
+		if diags := gohcl.DecodeExpression(attribute.Expr, nil, &constraint); diags.HasErrors() {
+			return
+		}
+	}
+
+	for _, pair := range pairs {
+		var key string
+		if diags := gohcl.DecodeExpression(pair.Key, nil, &key); diags.HasErrors() {
+			continue
+		}
+
+		var target *string
+		switch key {
+		case "source":
+			target = &source
+		case "version":
+			target = &constraint
+		default:
+			continue
+		}
+
+		if diags := gohcl.DecodeExpression(pair.Value, nil, target); diags.HasErrors() {
+			return
+		}
+	}
+
+	providerSource, err := registry.ParseProviderSource(source)
+	if err != nil {
+		return
+	}
+
+	address := providerSource.Namespace + "/" + providerSource.Name
+	if !registry.IsPublicHost(providerSource.Host) {
+		address = providerSource.Host + "/" + address
+	}
+
+	dependency, ok := c.providers[address]
+	if !ok {
+		dependency = &opendepotv1alpha1.ProviderDependency{Source: address}
+		c.providers[address] = dependency
+	}
+
+	dependency.VersionConstraints = combineConstraints(dependency.VersionConstraints, constraint)
+}
+
+// collectModuleBlock collects a module call. Local module calls are part of the archive and are skipped. Calls
+// that cannot be mirrored from GitHub are recorded without a repository owner and name.
+func (c *dependencyCollector) collectModuleBlock(block *hcl.Block) error {
+	content, _, diags := block.Body.PartialContent(moduleBlockSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	sourceAttribute, ok := content.Attributes["source"]
+	if !ok {
+		return nil
+	}
+
+	var source string
+	if diags := gohcl.DecodeExpression(sourceAttribute.Expr, nil, &source); diags.HasErrors() {
+		return nil
+	}
+
+	var constraint string
+	if versionAttribute, ok := content.Attributes["version"]; ok {
+		if diags := gohcl.DecodeExpression(versionAttribute.Expr, nil, &constraint); diags.HasErrors() {
+			return nil
+		}
+	}
+
+	moduleSource, err := registry.ParseModuleSource(source)
+	if err == nil && moduleSource == nil {
+		return nil
+	}
+
+	dependency := &opendepotv1alpha1.ModuleDependency{Source: strings.TrimSpace(source)}
+	if moduleSource != nil {
+		dependency.RepoOwner = moduleSource.Owner
+		dependency.Name = moduleSource.Repo
+
+		if moduleSource.Ref != "" {
+			// A Git source is pinned by its ref. Refs that are not versions, such as branches and commits,
+			// cannot be served from a Module.
+			refVersion, err := version.NewVersion(moduleSource.Ref)
+			if err != nil {
+				dependency.RepoOwner = ""
+				dependency.Name = ""
+			} else {
+				constraint = refVersion.String()
+			}
+		}
+	}
+
+	if existing, ok := c.modules[dependency.Source]; ok {
+		existing.VersionConstraints = combineConstraints(existing.VersionConstraints, constraint)
+		return nil
+	}
+
+	dependency.VersionConstraints = strings.TrimSpace(constraint)
+	c.modules[dependency.Source] = dependency
+
+	return nil
+}
+
+// dependencies returns the collected dependencies sorted by source, or nil when there are none.
+func (c *dependencyCollector) dependencies() *opendepotv1alpha1.ModuleDependencies {
+	if len(c.providers) == 0 && len(c.modules) == 0 {
+		return nil
+	}
+
+	dependencies := &opendepotv1alpha1.ModuleDependencies{}
+	for _, provider := range c.providers {
+		dependencies.Providers = append(dependencies.Providers, *provider)
+	}
+
+	for _, module := range c.modules {
+		dependencies.Modules = append(dependencies.Modules, *module)
+	}
+
+	sort.Slice(dependencies.Providers, func(i, j int) bool {
+		return dependencies.Providers[i].Source < dependencies.Providers[j].Source
+	})
+
+	sort.Slice(dependencies.Modules, func(i, j int) bool {
+		return dependencies.Modules[i].Source < dependencies.Modules[j].Source
+	})
+
+	return dependencies
+}
+
+// combineConstraints appends constraint to constraints unless it is empty or already present.
+func combineConstraints(constraints, constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return constraints
+	}
+
+	if constraints == "" {
+		return constraint
+	}
+
+	if slices.Contains(strings.Split(constraints, ", "), constraint) {
+		return constraints
+	}
+
+	return constraints + ", " + constraint
+}
+
+// newestMatchingVersion returns the newest of versions that satisfies constraints. Pre-releases only match
+// constraints that name a pre-release, and an empty constraint matches every other version.
+func newestMatchingVersion(versions []string, constraints string) (string, bool) {
+	var versionConstraints version.Constraints
+	if strings.TrimSpace(constraints) != "" {
+		parsed, err := version.NewConstraint(constraints)
+		if err != nil {
+			return "", false
+		}
+		versionConstraints = parsed
+	}
+
+	var newest *version.Version
+	var newestRaw string
+	for _, raw := range versions {
+		candidate, err := version.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+
+		if versionConstraints == nil && candidate.Prerelease() != "" {
+			continue
+		}
+
+		if versionConstraints != nil && !versionConstraints.Check(candidate) {
+			continue
+		}
+
+		if newest == nil || candidate.GreaterThan(newest) {
+			newest = candidate
+			newestRaw = raw
+		}
+	}
+
+	return newestRaw, newest != nil
+}
+
+// isDependencyManaged reports whether object was created by the Version controller to serve a module dependency.
+func isDependencyManaged(object metav1.Object) bool {
+	return object.GetLabels()[opendepotv1alpha1.OpenDepotDependencyLabel] == "true"
+}
+
+// registerModuleDependencies creates or extends a Provider for each provider dependency and a Module for each
+// module dependency of a module version, and records the resource that serves each dependency. Providers and
+// Modules declared by a Depot or by hand are never changed; they serve a dependency when they mirror the same
+// upstream provider or repository. Dependencies that cannot be resolved upstream are logged and skipped.
+func (r *VersionReconciler) registerModuleDependencies(ctx context.Context, moduleVersion *opendepotv1alpha1.Version, dependencies *opendepotv1alpha1.ModuleDependencies) error {
+	if dependencies == nil {
+		return nil
+	}
+
+	for i := range dependencies.Providers {
+		if err := r.registerProviderDependency(ctx, moduleVersion, &dependencies.Providers[i]); err != nil {
+			return fmt.Errorf("unable to register provider '%s': %w", dependencies.Providers[i].Source, err)
+		}
+	}
+
+	for i := range dependencies.Modules {
+		if err := r.registerModuleDependency(ctx, moduleVersion, &dependencies.Modules[i]); err != nil {
+			return fmt.Errorf("unable to register module '%s': %w", dependencies.Modules[i].Source, err)
+		}
+	}
+
+	return nil
+}
+
+// registerProviderDependency ensures a Provider serves a version of dependency that satisfies its constraints.
+func (r *VersionReconciler) registerProviderDependency(ctx context.Context, moduleVersion *opendepotv1alpha1.Version, dependency *opendepotv1alpha1.ProviderDependency) error {
+	source, err := registry.ParseProviderSource(dependency.Source)
+	if err != nil {
+		return err
+	}
+
+	providerConfig := opendepotv1alpha1.ProviderConfig{
+		Name:               &source.Name,
+		Namespace:          &source.Namespace,
+		StorageConfig:      moduleVersion.Spec.ModuleConfigRef.StorageConfig,
+		StorageProfileName: moduleVersion.Spec.ModuleConfigRef.StorageProfileName,
+	}
+
+	if !registry.IsPublicHost(source.Host) {
+		providerConfig.Registry = &source.Host
+	}
+
+	provider := &opendepotv1alpha1.Provider{}
+	err = r.Get(ctx, client.ObjectKey{Name: source.Name, Namespace: moduleVersion.Namespace}, provider)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		if !providerServesSource(provider, source) {
+			r.Log.Info("skipping provider dependency: Provider with the same name mirrors a different provider",
+				"version", moduleVersion.Name, "source", dependency.Source, "provider", provider.Name)
+			return nil
+		}
+
+		if !isDependencyManaged(provider) || providerDeclaresMatchingVersion(provider, dependency.VersionConstraints) {
+			dependency.ProviderRef = &provider.Name
+			return nil
+		}
+	}
+
+	registryClient, err := registry.GetProviderRegistryClient(ctx, r.Client, moduleVersion.Namespace, providerConfig)
+	if err != nil {
+		return err
+	}
+
+	upstreamVersions, err := registryClient.ListProviderVersions(ctx, source.Namespace, source.Name)
+	if err != nil {
+		r.Log.Info("skipping provider dependency: unable to list upstream versions",
+			"version", moduleVersion.Name, "source", dependency.Source, "error", err.Error())
+		return nil
+	}
+
+	published := make([]string, 0, len(upstreamVersions.Versions))
+	for _, upstreamVersion := range upstreamVersions.Versions {
+		published = append(published, upstreamVersion.Version)
+	}
+
+	matched, ok := newestMatchingVersion(published, dependency.VersionConstraints)
+	if !ok {
+		r.Log.Info("skipping provider dependency: no upstream version satisfies its constraints",
+			"version", moduleVersion.Name, "source", dependency.Source, "constraints", dependency.VersionConstraints)
+		return nil
+	}
+
+	providerVersions := dependencyProviderVersions(moduleVersion.Spec.ModuleConfigRef.Dependencies, upstreamVersions, matched)
+	if len(providerVersions) == 0 {
+		r.Log.Info("skipping provider dependency: upstream version is not published for the configured platforms",
+			"version", moduleVersion.Name, "source", dependency.Source, "providerVersion", matched)
+		return nil
+	}
+
+	newProvider := &opendepotv1alpha1.Provider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      source.Name,
+			Namespace: moduleVersion.Namespace,
+			Labels: map[string]string{
+				opendepotv1alpha1.OpenDepotDependencyLabel: "true",
+			},
+		},
+		Spec: opendepotv1alpha1.ProviderSpec{
+			ProviderConfig: providerConfig,
+			Versions:       providerVersions,
+		},
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentProvider := &opendepotv1alpha1.Provider{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(newProvider), currentProvider); err != nil {
+			if !k8serr.IsNotFound(err) {
+				return err
+			}
+			return r.Create(ctx, newProvider)
+		}
+
+		if !isDependencyManaged(currentProvider) {
+			return nil
+		}
+
+		var added bool
+		for _, providerVersion := range providerVersions {
+			if !slices.Contains(currentProvider.Spec.Versions, providerVersion) {
+				currentProvider.Spec.Versions = append(currentProvider.Spec.Versions, providerVersion)
+				added = true
+			}
+		}
+
+		if !added {
+			return nil
+		}
+
+		return r.Update(ctx, currentProvider)
+	}); err != nil {
+		return err
+	}
+
+	r.Log.Info("registered provider dependency", "version", moduleVersion.Name, "source", dependency.Source, "providerVersion", matched)
+	dependency.ProviderRef = &newProvider.Name
+	return nil
+}
+
+// providerServesSource reports whether provider mirrors the upstream provider at source.
+func providerServesSource(provider *opendepotv1alpha1.Provider, source registry.ProviderSource) bool {
+	namespace := registry.DefaultProviderNamespace
+	if provider.Spec.ProviderConfig.Namespace != nil {
+		namespace = *provider.Spec.ProviderConfig.Namespace
+	}
+
+	host := registry.DefaultHost
+	if provider.Spec.ProviderConfig.Registry != nil && strings.TrimSpace(*provider.Spec.ProviderConfig.Registry) != "" {
+		host = *provider.Spec.ProviderConfig.Registry
+	}
+
+	sameHost := strings.EqualFold(host, source.Host) || (registry.IsPublicHost(host) && registry.IsPublicHost(source.Host))
+	return sameHost && strings.EqualFold(namespace, source.Namespace)
+}
+
+// providerDeclaresMatchingVersion reports whether provider already declares a version that satisfies constraints.
+func providerDeclaresMatchingVersion(provider *opendepotv1alpha1.Provider, constraints string) bool {
+	declared := make([]string, 0, len(provider.Spec.Versions))
+	for _, providerVersion := range provider.Spec.Versions {
+		declared = append(declared, providerVersion.Version)
+	}
+
+	_, ok := newestMatchingVersion(declared, constraints)
+	return ok
+}
+
+// dependencyProviderVersions returns a ProviderVersion for each configured platform that providerVersion is
+// published upstream for.
+func dependencyProviderVersions(config *opendepotv1alpha1.ModuleDependencyConfig, upstreamVersions *registry.ProviderVersions, providerVersion string) []opendepotv1alpha1.ProviderVersion {
+	operatingSystems := []string{defaultDependencyOperatingSystem}
+	architectures := []string{defaultDependencyArchitecture}
+	if config != nil && len(config.OperatingSystems) > 0 {
+		operatingSystems = config.OperatingSystems
+	}
+
+	if config != nil && len(config.Architectures) > 0 {
+		architectures = config.Architectures
+	}
+
+	var platforms []registry.ProviderPlatform
+	for _, upstreamVersion := range upstreamVersions.Versions {
+		if upstreamVersion.Version == providerVersion {
+			platforms = upstreamVersion.Platforms
+			break
+		}
+	}
+
+	var providerVersions []opendepotv1alpha1.ProviderVersion
+	for _, operatingSystem := range operatingSystems {
+		for _, architecture := range architectures {
+			if !slices.Contains(platforms, registry.ProviderPlatform{OS: operatingSystem, Arch: architecture}) {
+				continue
+			}
+
+			providerVersions = append(providerVersions, opendepotv1alpha1.ProviderVersion{
+				Architecture:    architecture,
+				OperatingSystem: operatingSystem,
+				Version:         providerVersion,
+			})
+		}
+	}
+
+	return providerVersions
+}
+
+// registerModuleDependency ensures a Module serves a version of dependency that satisfies its constraints. Modules
+// created this way inherit the module version's config, so their own dependencies are registered in turn.
+func (r *VersionReconciler) registerModuleDependency(ctx context.Context, moduleVersion *opendepotv1alpha1.Version, dependency *opendepotv1alpha1.ModuleDependency) error {
+	if dependency.RepoOwner == "" || dependency.Name == "" {
+		r.Log.Info("skipping module dependency: it cannot be mirrored from GitHub", "version", moduleVersion.Name, "source", dependency.Source)
+		return nil
+	}
+
+	module := &opendepotv1alpha1.Module{}
+	err := r.Get(ctx, client.ObjectKey{Name: dependency.Name, Namespace: moduleVersion.Namespace}, module)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		if !strings.EqualFold(module.Spec.ModuleConfig.RepoOwner, dependency.RepoOwner) {
+			r.Log.Info("skipping module dependency: Module with the same name mirrors a different repository",
+				"version", moduleVersion.Name, "source", dependency.Source, "module", module.Name)
+			return nil
+		}
+
+		if !isDependencyManaged(module) || moduleDeclaresMatchingVersion(module, dependency.VersionConstraints) {
+			dependency.ModuleRef = &module.Name
+			return nil
+		}
+	}
+
+	parentConfig := moduleVersion.Spec.ModuleConfigRef
+	useAuthenticatedClient := parentConfig.GithubClientConfig != nil && parentConfig.GithubClientConfig.UseAuthenticatedClient
+
+	githubClientConfig, err := opendepotGithub.GetGithubClientConfig(ctx, r.Client, moduleVersion.Namespace, dependency.RepoOwner, parentConfig.GithubClientConfig)
+	if err != nil {
+		return err
+	}
+
+	githubClient, err := opendepotGithub.CreateGithubClient(ctx, useAuthenticatedClient, githubClientConfig)
+	if err != nil {
+		return err
+	}
+
+	releases, err := opendepotGithub.ListReleaseVersions(ctx, githubClient, dependency.RepoOwner, dependency.Name)
+	if err != nil {
+		r.Log.Info("skipping module dependency: unable to list upstream releases",
+			"version", moduleVersion.Name, "source", dependency.Source, "error", err.Error())
+		return nil
+	}
+
+	matched, ok := newestMatchingVersion(releases, dependency.VersionConstraints)
+	if !ok {
+		r.Log.Info("skipping module dependency: no upstream release satisfies its constraints",
+			"version", moduleVersion.Name, "source", dependency.Source, "constraints", dependency.VersionConstraints)
+		return nil
+	}
+
+	// The dependency's provider is taken from its repository name, which was validated when it was parsed.
+	moduleSource, err := registry.ParseModuleSource("github.com/" + dependency.RepoOwner + "/" + dependency.Name)
+	if err != nil {
+		return err
+	}
+
+	moduleConfig := opendepotv1alpha1.ModuleConfig{
+		Dependencies:       parentConfig.Dependencies,
+		ExcludePatterns:    parentConfig.ExcludePatterns,
+		FileFormat:         parentConfig.FileFormat,
+		GithubClientConfig: parentConfig.GithubClientConfig,
+		Immutable:          parentConfig.Immutable,
+		Name:               &dependency.Name,
+		Provider:           moduleSource.Provider,
+		RepoOwner:          dependency.RepoOwner,
+		RetagPolicy:        parentConfig.RetagPolicy,
+		StorageConfig:      parentConfig.StorageConfig,
+		StorageProfileName: parentConfig.StorageProfileName,
+	}
+
+	repoURL := opendepotGithub.GetRepositoryURL(moduleConfig.GithubClientConfig, dependency.RepoOwner, dependency.Name)
+	moduleConfig.RepoUrl = &repoURL
+
+	newModule := &opendepotv1alpha1.Module{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dependency.Name,
+			Namespace: moduleVersion.Namespace,
+			Labels: map[string]string{
+				opendepotv1alpha1.OpenDepotDependencyLabel: "true",
+			},
+		},
+		Spec: opendepotv1alpha1.ModuleSpec{
+			ModuleConfig: moduleConfig,
+			Versions:     []opendepotv1alpha1.ModuleVersion{{Version: matched}},
+		},
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentModule := &opendepotv1alpha1.Module{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(newModule), currentModule); err != nil {
+			if !k8serr.IsNotFound(err) {
+				return err
+			}
+			return r.Create(ctx, newModule)
+		}
+
+		if !isDependencyManaged(currentModule) {
+			return nil
+		}
+
+		for _, moduleVersion := range currentModule.Spec.Versions {
+			if strings.TrimPrefix(moduleVersion.Version, "v") == matched {
+				return nil
+			}
+		}
+
+		currentModule.Spec.Versions = append(currentModule.Spec.Versions, opendepotv1alpha1.ModuleVersion{Version: matched})
+		return r.Update(ctx, currentModule)
+	}); err != nil {
+		return err
+	}
+
+	r.Log.Info("registered module dependency", "version", moduleVersion.Name, "source", dependency.Source, "moduleVersion", matched)
+	dependency.ModuleRef = &newModule.Name
+	return nil
+}
+
+// moduleDeclaresMatchingVersion reports whether module already declares a version that satisfies constraints.
+func moduleDeclaresMatchingVersion(module *opendepotv1alpha1.Module, constraints string) bool {
+	declared := make([]string, 0, len(module.Spec.Versions))
+	for _, moduleVersion := range module.Spec.Versions {
+		declared = append(declared, moduleVersion.Version)
+	}
+
+	_, ok := newestMatchingVersion(declared, constraints)
+	return ok
+}
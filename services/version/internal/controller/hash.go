@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// hashProviderArchive returns the 'h1:' hash of the files in the provider zip at archivePath. This is the
+// hash scheme recorded for each platform of a provider in '.terraform.lock.hcl'.
+func hashProviderArchive(archivePath string) (string, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read zip archive: %w", err)
+	}
+	defer reader.Close()
+
+	return hashZipFiles(&reader.Reader)
+}
+
+// hashModuleArchive returns the 'h1:' hash of the files in a zip or tar.gz module archive. File names are
+// hashed as they are stored in the archive, including any top-level directory.
+func hashModuleArchive(archive []byte) (string, error) {
+	if bytes.HasPrefix(archive, []byte("PK\x03\x04")) {
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return "", fmt.Errorf("unable to read zip archive: %w", err)
+		}
+
+		return hashZipFiles(reader)
+	}
+
+	return hashTarGzBytes(archive)
+}
+
+// hashZipFiles returns the 'h1:' hash of the regular files in a zip archive. Directory entries are skipped so
+// the hash matches the one computed over the unpacked archive.
+func hashZipFiles(reader *zip.Reader) (string, error) {
+	entries := make(map[string]*zip.File, len(reader.File))
+	files := make([]string, 0, len(reader.File))
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		if _, exists := entries[file.Name]; !exists {
+			files = append(files, file.Name)
+		}
+		entries[file.Name] = file
+	}
+
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return entries[name].Open()
+	})
+}
+
+// hashTarGzBytes returns the 'h1:' hash of the regular files in an in-memory tar.gz archive.
+func hashTarGzBytes(archive []byte) (string, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return "", fmt.Errorf("unable to read gzip archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	contents := map[string][]byte{}
+	files := []string{}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("unable to read tar archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return "", fmt.Errorf("unable to read '%s' from tar archive: %w", header.Name, err)
+		}
+
+		if _, exists := contents[header.Name]; !exists {
+			files = append(files, header.Name)
+		}
+		contents[header.Name] = data
+	}
+
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		data, ok := contents[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
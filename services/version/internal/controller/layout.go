@@ -0,0 +1,319 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+// contentAddressedFilePrefix prefixes the file names of archives stored in the content-addressed layout.
+const contentAddressedFilePrefix = "sha256-"
+
+// storageLayout returns the layout of a storage config. It defaults to 'UUID'.
+func storageLayout(storageConfig *opendepotv1alpha1.StorageConfig) (string, error) {
+	if storageConfig.Layout == nil || *storageConfig.Layout == "" {
+		return opendepotv1alpha1.OpenDepotStorageLayoutUUID, nil
+	}
+
+	switch *storageConfig.Layout {
+	case opendepotv1alpha1.OpenDepotStorageLayoutUUID,
+		opendepotv1alpha1.OpenDepotStorageLayoutContentAddressed:
+		return *storageConfig.Layout, nil
+	}
+
+	return "", fmt.Errorf("invalid storage layout '%s': must be one of 'UUID' or 'ContentAddressed'", *storageConfig.Layout)
+}
+
+// contentAddressedFilePrefixFor returns the prefix of the content-addressed file names of an archive with checksum:
+// the hex encoded SHA256 checksum of the archive, ie: 'sha256-9f86d08188-'.
+func contentAddressedFilePrefixFor(checksum string) (string, error) {
+	sum, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil {
+		return "", fmt.Errorf("unable to decode archive checksum: %w", err)
+	}
+
+	return fmt.Sprintf("%s%s-", contentAddressedFilePrefix, hex.EncodeToString(sum)), nil
+}
+
+// contentAddressedFileName returns a new file name of an archive in the content-addressed layout: the prefix of its
+// checksum followed by a UUID7 and the extension of fileName, ie: 'sha256-9f86d08188-0192f3a4.tar.gz'. The UUID keeps
+// the file name from being derived from the checksum, which registry clients are shown.
+func contentAddressedFileName(fileName, checksum string) (*string, error) {
+	prefix, err := contentAddressedFilePrefixFor(checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s%s%s", prefix, id, archiveExtension(fileName))
+	return &name, nil
+}
+
+// archiveExtension returns the extension of the archive fileName, ie: '.tar.gz' or '.zip'.
+func archiveExtension(fileName string) string {
+	if strings.HasSuffix(fileName, ".tar.gz") {
+		return ".tar.gz"
+	}
+
+	return path.Ext(fileName)
+}
+
+// applyStorageLayout names the archive of version after checksum when its storage config uses the content-addressed
+// layout. When another Version of the same module or provider stores an identical archive in the same backend, its
+// file name is used, so both share one stored object.
+func (r *VersionReconciler) applyStorageLayout(ctx context.Context, version *opendepotv1alpha1.Version, checksum *string) error {
+	if version.Spec.FileName == nil || checksum == nil {
+		return nil
+	}
+
+	storageConfig, _, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		// The file path of the Version cannot be resolved either, which is reported when it is computed.
+		return nil
+	}
+
+	layout, err := storageLayout(storageConfig)
+	if err != nil {
+		return err
+	}
+
+	if layout != opendepotv1alpha1.OpenDepotStorageLayoutContentAddressed {
+		return nil
+	}
+
+	prefix, err := contentAddressedFilePrefixFor(*checksum)
+	if err != nil {
+		return err
+	}
+
+	ext := archiveExtension(*version.Spec.FileName)
+	if strings.HasPrefix(*version.Spec.FileName, prefix) && strings.HasSuffix(*version.Spec.FileName, ext) {
+		return nil
+	}
+
+	fileName, err := r.sharedContentAddressedFileName(ctx, version, storageConfig, prefix, ext)
+	if err != nil {
+		return err
+	}
+
+	if fileName == nil {
+		fileName, err = contentAddressedFileName(*version.Spec.FileName, *checksum)
+		if err != nil {
+			return err
+		}
+	}
+
+	version.Spec.FileName = fileName
+	return nil
+}
+
+// sharedContentAddressedFileName returns the content-addressed file name another Version of the same module or
+// provider stores an archive with the file name prefix and extension ext under, in the same backend as storageConfig.
+// It returns nil when no other Version does.
+func (r *VersionReconciler) sharedContentAddressedFileName(ctx context.Context, version *opendepotv1alpha1.Version, storageConfig *opendepotv1alpha1.StorageConfig, prefix, ext string) (*string, error) {
+	name, err := getVersionName(version)
+	if err != nil {
+		return nil, nil
+	}
+
+	allVersions := &opendepotv1alpha1.VersionList{}
+	if err := r.List(ctx, allVersions); err != nil {
+		return nil, err
+	}
+
+	backend := auditBackendKey(storageConfig)
+	for i := range allVersions.Items {
+		other := &allVersions.Items[i]
+		if (other.Namespace == version.Namespace && other.Name == version.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if other.Spec.FileName == nil || !strings.HasPrefix(*other.Spec.FileName, prefix) || !strings.HasSuffix(*other.Spec.FileName, ext) {
+			continue
+		}
+
+		otherName, err := getVersionName(other)
+		if err != nil || *otherName != *name {
+			continue
+		}
+
+		otherConfig, _, err := r.getVersionStorageConfig(ctx, other)
+		if err != nil || auditBackendKey(otherConfig) != backend {
+			continue
+		}
+
+		return other.Spec.FileName, nil
+	}
+
+	return nil, nil
+}
+
+// archiveReferenced reports whether a Version other than version, that is not being deleted, stores an object at
+// filePath in the backend of storageConfig. Only the archives of the content-addressed layout are shared, so objects
+// with other file names are never referenced. An archive is kept when the storage config of a Version that may
+// reference it cannot be resolved.
+func (r *VersionReconciler) archiveReferenced(ctx context.Context, version *opendepotv1alpha1.Version, storageConfig *opendepotv1alpha1.StorageConfig, filePath string) (bool, error) {
+	fileName := path.Base(filePath)
+	if !strings.HasPrefix(fileName, contentAddressedFilePrefix) {
+		return false, nil
+	}
+
+	allVersions := &opendepotv1alpha1.VersionList{}
+	if err := r.List(ctx, allVersions); err != nil {
+		return false, err
+	}
+
+	backend := auditBackendKey(storageConfig)
+	for i := range allVersions.Items {
+		other := &allVersions.Items[i]
+		if (other.Namespace == version.Namespace && other.Name == version.Name) || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		var fileNames []string
+		if other.Spec.FileName != nil {
+			fileNames = append(fileNames, *other.Spec.FileName)
+		}
+		if other.Status.Quarantined != nil && other.Status.Quarantined.FileName != nil {
+			fileNames = append(fileNames, *other.Status.Quarantined.FileName)
+		}
+
+		if !slices.Contains(fileNames, fileName) {
+			continue
+		}
+
+		otherConfig, _, err := r.getVersionStorageConfig(ctx, other)
+		if err != nil {
+			return true, nil
+		}
+
+		name, err := getVersionName(other)
+		if err != nil {
+			return true, nil
+		}
+
+		replicas, err := storage.StorageReplicas(otherConfig)
+		if err != nil {
+			return true, nil
+		}
+
+		for _, replica := range replicas {
+			if auditBackendKey(replica.StorageConfig) != backend {
+				continue
+			}
+
+			otherPath, err := storage.ObjectPath(replica.StorageConfig, *name, fileName)
+			if err != nil || otherPath == filePath {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// releaseArchive deletes the archive fileName of version from every replica of its storage config that no other
+// Version references. It is called once a Version's archive has been stored under a new file name. Failures are
+// logged and the archive is left in place, since the Version already points at the new file name.
+func (r *VersionReconciler) releaseArchive(ctx context.Context, version *opendepotv1alpha1.Version, fileName string) {
+	released := version.DeepCopy()
+	released.Spec.FileName = &fileName
+
+	storageConfig, secretNamespace, err := r.getVersionStorageConfig(ctx, released)
+	if err != nil {
+		r.Log.Info("unable to release the previous archive", "version", version.Name, "fileName", fileName, "error", err.Error())
+		return
+	}
+
+	replicas, err := storage.StorageReplicas(storageConfig)
+	if err != nil {
+		r.Log.Info("unable to release the previous archive", "version", version.Name, "fileName", fileName, "error", err.Error())
+		return
+	}
+
+	for _, replica := range replicas {
+		filePath, err := versionFilePath(released, replica.StorageConfig)
+		if err == nil {
+			var referenced bool
+			referenced, err = r.archiveReferenced(ctx, released, replica.StorageConfig, *filePath)
+			if err == nil && referenced {
+				r.Log.V(5).Info("previous archive is referenced by another version; keeping it", "version", version.Name, "replica", replica.Name, "filePath", *filePath)
+				continue
+			}
+
+			if err == nil {
+				var storageInterface storage.Storage
+				storageInterface, err = r.newStorage(ctx, secretNamespace, replica.StorageConfig)
+				if err == nil {
+					err = RunStorageFactory(ctx, storageInterface, &types.StorageObjectInput{
+						Method:        types.Delete,
+						FilePath:      filePath,
+						Version:       released,
+						StorageConfig: replica.StorageConfig,
+					})
+				}
+			}
+		}
+
+		if err != nil {
+			r.Log.Info("unable to release the previous archive", "version", version.Name, "replica", replica.Name, "fileName", fileName, "error", err.Error())
+		}
+	}
+}
+
+// contentAddressedArchiveStored reports whether the content-addressed archive soi would upload is already stored with
+// checksum, ie: by another Version whose archive is identical. When it is, soi records the stored object.
+func (r *VersionReconciler) contentAddressedArchiveStored(ctx context.Context, soi *types.StorageObjectInput, checksum *string) bool {
+	if checksum == nil || soi.Version.Spec.FileName == nil || !strings.HasPrefix(*soi.Version.Spec.FileName, contentAddressedFilePrefix) {
+		return false
+	}
+
+	probe := &types.StorageObjectInput{
+		Method:   types.Get,
+		FilePath: soi.FilePath,
+		Version:  soi.Version,
+	}
+
+	// Backends that report a missing object as an error are uploaded to as usual.
+	if err := r.InitStorageFactory(ctx, probe); err != nil {
+		return false
+	}
+
+	if !probe.FileExists || probe.EncryptionKeyStale || probe.ObjectChecksum == nil || *probe.ObjectChecksum != *checksum {
+		return false
+	}
+
+	soi.FileExists = true
+	soi.ObjectChecksum = probe.ObjectChecksum
+	return true
+}
@@ -22,6 +22,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -34,7 +35,10 @@ import (
 	"github.com/google/go-github/v81/github"
 	"github.com/google/uuid"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,6 +48,7 @@ import (
 
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/registry"
 	"github.com/tonedefdev/opendepot/pkg/storage"
 	"github.com/tonedefdev/opendepot/pkg/storage/types"
 )
@@ -67,6 +72,8 @@ type VersionReconciler struct {
 	// invocation loads the full vulnerability DB (~2 GiB) so running more than
 	// one at a time risks OOMKill even with a generous container memory limit.
 	scanSem chan struct{}
+	// Recorder emits events, such as when the tag of a module version moves. Events are not emitted when nil.
+	Recorder events.EventRecorder
 	// downloadSem limits the number of concurrent provider archive downloads.
 	// Each download streams a ~700 MB zip to disk; allowing all four workers to
 	// download simultaneously risks exhausting memory and disk I/O.
@@ -76,11 +83,14 @@ type VersionReconciler struct {
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions/finalizers,verbs=update
-// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=modules,verbs=get
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=modules,verbs=get;create;update
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=modules/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=providers,verbs=get
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=providers,verbs=get;create;update
 // +kubebuilder:rbac:groups=opendepot.defdev.io,resources=providers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=storageprofiles,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	version := &opendepotv1alpha1.Version{}
@@ -112,6 +122,14 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return r.reconcileDeletion(ctx, version)
 	}
 
+	// The file name the archive of a synced Version is stored under. It is released once the archive is stored
+	// under another content-addressed file name.
+	var storedFileName *string
+	if version.Status.Checksum != nil {
+		storedFileName = version.Spec.FileName
+	}
+	persistedFileName := version.Spec.FileName
+
 	var prepareResult ctrl.Result
 	var prepareErr error
 
@@ -149,12 +167,15 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	var fileBytes []byte
 	var archiveChecksum *string
+	var packageHash *string
 	var providerTmpPath string
+	var moduleDependencies *opendepotv1alpha1.ModuleDependencies
+	var moduleSource *opendepotv1alpha1.ModuleVersionSource
 
 	switch version.Spec.Type {
 	case opendepotv1alpha1.OpenDepotModule:
 		r.Log.V(5).Info("fetching module archive", "version", version.Name, "versionStr", version.Spec.Version)
-		moduleBytes, checksum, err := r.fetchModuleArchive(ctx, version)
+		moduleBytes, moduleTag, err := r.fetchModuleArchive(ctx, version)
 		if err != nil {
 			version.Status.SyncStatus = fmt.Sprintf("Failed to retrieve module archive: %v", err)
 			_ = r.Status().Update(ctx, version)
@@ -162,8 +183,129 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 
 		r.Log.V(5).Info("module archive fetched", "version", version.Name, "bytes", len(moduleBytes))
+
+		// GitHub regenerates archives with different bytes over time, so the archive is repackaged to keep its
+		// checksum stable for as long as the files it serves are unchanged.
+		moduleBytes, err = normalizeModuleArchive(moduleBytes, version.Spec.ModuleConfigRef.ExcludePatterns)
+		if err != nil {
+			version.Status.SyncStatus = fmt.Sprintf("Failed to normalize module archive: %v", err)
+			_ = r.Status().Update(ctx, version)
+			return ctrl.Result{}, err
+		}
+
+		checksum := moduleArchiveChecksum(moduleBytes)
 		fileBytes = moduleBytes
-		archiveChecksum = checksum
+		archiveChecksum = &checksum
+
+		// The checksum of a version stored before archives were normalized is of GitHub's archive. When the stored
+		// archive normalizes to the same files, dropping the checksum uploads the normalized archive in its place
+		// instead of tripping the immutability check. Otherwise the content changed since it was stored, and the
+		// checksum is kept so the immutability check and the retag policy apply to it.
+		if version.Status.Checksum != nil && !version.Status.Normalized {
+			storedChecksum, err := r.normalizedStoredArchiveChecksum(ctx, version)
+			if err != nil {
+				version.Status.SyncStatus = fmt.Sprintf("Failed to normalize the stored module archive: %v", err)
+				_ = r.Status().Update(ctx, version)
+				return ctrl.Result{}, err
+			}
+
+			if storedChecksum == nil || *storedChecksum == checksum {
+				r.Log.Info("replacing module archive stored before archives were normalized", "version", version.Name)
+				version.Status.Checksum = nil
+			} else {
+				r.Log.Info("module archive stored before archives were normalized differs from the fetched archive", "version", version.Name)
+			}
+		}
+
+		fetchedAt := time.Now().UTC().Format(time.RFC3339)
+		if moduleTag != nil {
+			moduleSource = &opendepotv1alpha1.ModuleVersionSource{
+				Tag:          moduleTag.Name,
+				CommitSHA:    moduleTag.CommitSHA,
+				TagObjectSHA: moduleTag.ObjectSHA,
+				FetchedAt:    fetchedAt,
+			}
+		}
+
+		servedSource := version.Status.Source
+		switch {
+		case moduleTag == nil || servedSource == nil || version.Status.Checksum == nil:
+		case servedSource.CommitSHA == moduleTag.CommitSHA:
+			// The archive being served is unchanged, so the time it was fetched is kept.
+			moduleSource = servedSource
+			if err := r.deleteQuarantinedArchive(ctx, version); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			if meta.IsStatusConditionTrue(version.Status.Conditions, opendepotv1alpha1.OpenDepotConditionTagMoved) {
+				r.setTagMovedCondition(version, metav1.ConditionFalse, tagRestoredReason,
+					fmt.Sprintf("tag '%s' points to the served commit %s again", moduleTag.Name, moduleTag.CommitSHA))
+			}
+		default:
+			retagPolicy, err := moduleRetagPolicy(version.Spec.ModuleConfigRef)
+			if err != nil {
+				version.Status.SyncStatus = err.Error()
+				_ = r.Status().Update(ctx, version)
+				// This is a permanent configuration error that requeuing cannot resolve.
+				return ctrl.Result{}, nil
+			}
+
+			switch retagPolicy {
+			case opendepotv1alpha1.OpenDepotRetagPolicyRefuse:
+				r.setTagMovedCondition(version, metav1.ConditionTrue, tagRefusedReason,
+					tagMovedMessage(version, moduleTag, "the new content is refused and the stored archive is still served"))
+				version.Status.SyncStatus = fmt.Sprintf("Refusing the content of moved tag '%s'", moduleTag.Name)
+				if err := r.Status().Update(ctx, version); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{}, nil
+			case opendepotv1alpha1.OpenDepotRetagPolicyQuarantine:
+				if err := r.quarantineModuleArchive(ctx, version, moduleTag, moduleBytes, checksum, fetchedAt); err != nil {
+					version.Status.SyncStatus = fmt.Sprintf("Failed to quarantine the content of moved tag '%s': %v", moduleTag.Name, err)
+					_ = r.Status().Update(ctx, version)
+					return ctrl.Result{}, err
+				}
+
+				r.setTagMovedCondition(version, metav1.ConditionTrue, tagQuarantinedReason,
+					tagMovedMessage(version, moduleTag, fmt.Sprintf("the new content is quarantined as '%s' and the stored archive is still served", *version.Status.Quarantined.FileName)))
+				version.Status.SyncStatus = fmt.Sprintf("Quarantined the content of moved tag '%s'", moduleTag.Name)
+				if err := r.Status().Update(ctx, version); err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{}, nil
+			case opendepotv1alpha1.OpenDepotRetagPolicyAccept:
+				if err := r.deleteQuarantinedArchive(ctx, version); err != nil {
+					return ctrl.Result{}, err
+				}
+
+				version.Status.DigestHistory = appendDigestHistory(version.Status.DigestHistory, opendepotv1alpha1.ModuleVersionDigest{
+					CommitSHA:  servedSource.CommitSHA,
+					Checksum:   *version.Status.Checksum,
+					FetchedAt:  servedSource.FetchedAt,
+					RecordedAt: fetchedAt,
+				})
+				r.setTagMovedCondition(version, metav1.ConditionFalse, tagAcceptedReason,
+					tagMovedMessage(version, moduleTag, "the new content replaced the stored archive"))
+
+				// Dropping the checksum uploads the new content in place of the stored archive, and the policy
+				// takes precedence over the immutability check.
+				version.Status.Checksum = nil
+			}
+		}
+
+		hash, err := hashModuleArchive(moduleBytes)
+		if err != nil {
+			version.Status.SyncStatus = fmt.Sprintf("Failed to compute module archive hash: %v", err)
+			_ = r.Status().Update(ctx, version)
+			return ctrl.Result{}, err
+		}
+		packageHash = &hash
+
+		// Dependencies are informational, so a module whose configuration cannot be parsed is still synced.
+		moduleDependencies, err = readModuleDependencies(moduleBytes)
+		if err != nil {
+			r.Log.Info("unable to read module dependencies", "version", version.Name, "error", err.Error())
+		}
 
 		if version.Spec.ModuleConfigRef.Immutable != nil &&
 			*version.Spec.ModuleConfigRef.Immutable &&
@@ -177,14 +319,24 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			_ = r.Status().Update(ctx, version)
 			return ctrl.Result{}, statusMsg
 		}
+
+		if err := r.applyStorageLayout(ctx, version, archiveChecksum); err != nil {
+			version.Status.SyncStatus = err.Error()
+			_ = r.Status().Update(ctx, version)
+			// This is a permanent configuration error that requeuing cannot resolve.
+			return ctrl.Result{}, nil
+		}
 	case opendepotv1alpha1.OpenDepotProvider:
 		r.Log.V(5).Info("checking provider fast-path", "version", version.Name, "synced", version.Status.Synced, "checksumSet", version.Status.Checksum != nil)
 		// Fast path: if the Version has already been synced and the artifact exists in
 		// storage with a matching checksum, there is nothing to download or upload.
 		// Skipping the download is critical — /tmp is tmpfs (RAM-backed) in Linux
 		// containers, so downloading 700MB per worker on every reconcile exhausts memory.
-		if version.Status.Checksum != nil && version.Status.Synced && version.Spec.FileName != nil {
-			existingFilePath, pathErr := getVersionFilePath(version)
+		// Versions synced before package hashes were recorded, or whose storage replicas are not all
+		// synced, are downloaded once more.
+		if version.Status.Checksum != nil && version.Status.PackageHash != nil && version.Status.Synced && version.Spec.FileName != nil &&
+			r.storageReplicasSynced(ctx, version) {
+			existingFilePath, pathErr := r.getVersionFilePath(ctx, version)
 			if pathErr == nil {
 				earlySoi := &types.StorageObjectInput{
 					Method:   types.Get,
@@ -194,7 +346,8 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				if checkErr := r.InitStorageFactory(ctx, earlySoi); checkErr == nil &&
 					earlySoi.FileExists &&
 					earlySoi.ObjectChecksum != nil &&
-					*earlySoi.ObjectChecksum == *version.Status.Checksum {
+					*earlySoi.ObjectChecksum == *version.Status.Checksum &&
+					!earlySoi.EncryptionKeyStale {
 					r.Log.V(5).Info("provider fast-path hit: artifact exists with matching checksum; skipping download", "version", version.Name)
 					return reconcile.Result{}, nil
 				}
@@ -216,8 +369,15 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		<-r.downloadSem
 		r.Log.V(5).Info("download semaphore released", "version", version.Name)
 
+		if errors.Is(err, errSignatureVerification) {
+			version.Status.Synced = false
+			version.Status.SyncStatus = fmt.Sprintf("Refusing to ingest provider archive: %v", err)
+			_ = r.Status().Update(ctx, version)
+			return ctrl.Result{}, err
+		}
+
 		if err != nil {
-			version.Status.SyncStatus = fmt.Sprintf("Failed to retrieve provider archive from HashiCorp releases API: %v", err)
+			version.Status.SyncStatus = fmt.Sprintf("Failed to retrieve provider archive from upstream registry: %v", err)
 			_ = r.Status().Update(ctx, version)
 			return ctrl.Result{}, err
 		}
@@ -225,6 +385,14 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		r.Log.V(5).Info("provider archive fetched", "version", version.Name, "tmpPath", tmpPath)
 		defer cleanupArchive()
 
+		hash, err := hashProviderArchive(tmpPath)
+		if err != nil {
+			version.Status.SyncStatus = fmt.Sprintf("Failed to compute provider archive hash: %v", err)
+			_ = r.Status().Update(ctx, version)
+			return ctrl.Result{}, err
+		}
+		packageHash = &hash
+
 		if version.Spec.FileName == nil {
 			uuidFileName, err := generateProviderFileName(*fileName)
 			if err != nil {
@@ -236,11 +404,18 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			version.Spec.FileName = uuidFileName
 		}
 
+		if err := r.applyStorageLayout(ctx, version, checksum); err != nil {
+			version.Status.SyncStatus = err.Error()
+			_ = r.Status().Update(ctx, version)
+			// This is a permanent configuration error that requeuing cannot resolve.
+			return ctrl.Result{}, nil
+		}
+
 		archiveChecksum = checksum
 		providerTmpPath = tmpPath
 	}
 
-	filePath, err := getVersionFilePath(version)
+	filePath, err := r.getVersionFilePath(ctx, version)
 	if err != nil {
 		// FileName is nil — the spec update that persists it from a previous
 		// reconcile has not propagated yet (e.g. the update was lost to a
@@ -282,6 +457,16 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		soi.FileReader = providerFile
 	}
 
+	// A content-addressed archive may already be stored by another Version, which releases it once no Version
+	// references it. The file name is persisted before the stored archive is relied on, so a Version released
+	// from now on sees this Version's reference and keeps the archive.
+	if strings.HasPrefix(*version.Spec.FileName, contentAddressedFilePrefix) &&
+		(persistedFileName == nil || *persistedFileName != *version.Spec.FileName) {
+		if err = r.persistVersionSpec(ctx, version); err != nil {
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, err
+		}
+	}
+
 	if version.Status.Checksum != nil {
 		r.Log.V(5).Info("status checksum set; performing storage get to verify artifact", "version", version.Name)
 		soi.Method = types.Get
@@ -298,15 +483,20 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 		}
 
-		r.Log.V(5).Info("no status checksum; uploading artifact", "version", version.Name)
-		soi.Method = types.Put
-		if err = r.InitStorageFactory(ctx, soi); err != nil {
-			return ctrl.Result{}, err
+		if r.contentAddressedArchiveStored(ctx, soi, archiveChecksum) {
+			r.Log.V(5).Info("content-addressed archive already stored by another version; skipping upload", "version", version.Name)
+		} else {
+			r.Log.V(5).Info("no status checksum; uploading artifact", "version", version.Name)
+			soi.Method = types.Put
+			if err = r.InitStorageFactory(ctx, soi); err != nil {
+				return ctrl.Result{}, err
+			}
+			r.Log.V(5).Info("initial storage put complete", "version", version.Name)
 		}
-		r.Log.V(5).Info("initial storage put complete", "version", version.Name)
 	}
 
-	if !soi.FileExists || (soi.ObjectChecksum != nil && version.Status.Checksum != nil && *soi.ObjectChecksum != *version.Status.Checksum) {
+	// Archives encrypted with a key other than the primary key of the keyring are stored again to rotate their key.
+	if !soi.FileExists || soi.EncryptionKeyStale || (soi.ObjectChecksum != nil && version.Status.Checksum != nil && *soi.ObjectChecksum != *version.Status.Checksum) {
 		if !hasArtifact {
 			version.Status.Synced = false
 			version.Status.SyncStatus = "Artifact missing in storage and no bytes available to reconcile"
@@ -321,7 +511,7 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			}
 		}
 
-		r.Log.V(5).Info("artifact missing or checksum mismatch; re-uploading", "version", version.Name, "fileExists", soi.FileExists)
+		r.Log.V(5).Info("artifact missing, checksum mismatch or stale encryption key; re-uploading", "version", version.Name, "fileExists", soi.FileExists)
 		soi.Method = types.Put
 		if err = r.InitStorageFactory(ctx, soi); err != nil {
 			return ctrl.Result{}, err
@@ -329,24 +519,28 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		r.Log.V(5).Info("re-upload storage put complete", "version", version.Name)
 	}
 
-	if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		currentVersion := &opendepotv1alpha1.Version{}
-		if err := r.Get(ctx, req.NamespacedName, currentVersion); err != nil {
-			return err
-		}
+	// Copy the archive to the other replicas of the storage config. A replica that fails to sync is recorded
+	// in the status and retried later without failing the Version, which is served from the healthy replicas.
+	replicaChecksum := archiveChecksum
+	if replicaChecksum == nil {
+		replicaChecksum = version.Status.Checksum
+	}
 
-		currentVersion.Spec.FileName = version.Spec.FileName
-		currentVersion.Spec.ModuleConfigRef = version.Spec.ModuleConfigRef
-		currentVersion.Spec.ProviderConfigRef = version.Spec.ProviderConfigRef
+	replicaStatuses, err := r.syncStorageReplicas(ctx, version, soi.FileBytes, soi.FileReader, replicaChecksum)
+	if err != nil {
+		version.Status.SyncStatus = fmt.Sprintf("Failed to sync storage replicas: %v", err)
+		_ = r.Status().Update(ctx, version)
+		return ctrl.Result{}, err
+	}
 
-		if err := r.Update(ctx, currentVersion); err != nil {
-			return err
-		}
-		return nil
-	}); err != nil {
+	if err = r.persistVersionSpec(ctx, version); err != nil {
 		return ctrl.Result{RequeueAfter: 30 * time.Second}, err
 	}
 
+	if storedFileName != nil && *storedFileName != *version.Spec.FileName && strings.HasPrefix(*version.Spec.FileName, contentAddressedFilePrefix) {
+		r.releaseArchive(ctx, version, *storedFileName)
+	}
+
 	// Run Trivy security scan for provider artifacts when scanning is enabled.
 	// The binary scan result is returned here and written in the final status
 	// update below so that all required fields (checksum, synced, syncStatus)
@@ -386,6 +580,18 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	// Register the providers and modules the module depends on so its dependency closure is served.
+	if version.Spec.Type == opendepotv1alpha1.OpenDepotModule &&
+		version.Spec.ModuleConfigRef.Dependencies != nil &&
+		version.Spec.ModuleConfigRef.Dependencies.Register {
+		if err = r.registerModuleDependencies(ctx, version, moduleDependencies); err != nil {
+			version.Status.Synced = false
+			version.Status.SyncStatus = fmt.Sprintf("Failed to register module dependencies: %v", err)
+			_ = r.Status().Update(ctx, version)
+			return ctrl.Result{}, err
+		}
+	}
+
 	if err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		currentVersion := &opendepotv1alpha1.Version{}
 		if err := r.Get(ctx, req.NamespacedName, currentVersion); err != nil {
@@ -397,6 +603,10 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			currentVersion.Status.Checksum = archiveChecksum
 		}
 
+		if packageHash != nil {
+			currentVersion.Status.PackageHash = packageHash
+		}
+
 		currentVersion.Status.SyncStatus = "Successfully synced version"
 		if binaryScan != nil {
 			currentVersion.Status.BinaryScan = binaryScan
@@ -406,6 +616,27 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			currentVersion.Status.SourceScan = moduleScan
 		}
 
+		if version.Status.SignatureVerification != nil {
+			currentVersion.Status.SignatureVerification = version.Status.SignatureVerification
+		}
+
+		if moduleDependencies != nil {
+			currentVersion.Status.Dependencies = moduleDependencies
+		}
+
+		if version.Spec.Type == opendepotv1alpha1.OpenDepotModule {
+			currentVersion.Status.Normalized = true
+			currentVersion.Status.DigestHistory = version.Status.DigestHistory
+			currentVersion.Status.Quarantined = version.Status.Quarantined
+			currentVersion.Status.Conditions = version.Status.Conditions
+		}
+
+		if moduleSource != nil {
+			currentVersion.Status.Source = moduleSource
+		}
+
+		currentVersion.Status.Replicas = replicaStatuses
+
 		if err := r.Status().Update(ctx, currentVersion, &client.SubResourceUpdateOptions{
 			UpdateOptions: client.UpdateOptions{FieldManager: opendepotControllerName},
 		}); err != nil {
@@ -417,9 +648,31 @@ func (r *VersionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	for _, replicaStatus := range replicaStatuses {
+		if !replicaStatus.Synced {
+			return reconcile.Result{RequeueAfter: replicaRetryInterval}, nil
+		}
+	}
+
 	return reconcile.Result{}, nil
 }
 
+// persistVersionSpec writes the file name and config references of version to the latest revision of the Version.
+func (r *VersionReconciler) persistVersionSpec(ctx context.Context, version *opendepotv1alpha1.Version) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentVersion := &opendepotv1alpha1.Version{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(version), currentVersion); err != nil {
+			return err
+		}
+
+		currentVersion.Spec.FileName = version.Spec.FileName
+		currentVersion.Spec.ModuleConfigRef = version.Spec.ModuleConfigRef
+		currentVersion.Spec.ProviderConfigRef = version.Spec.ProviderConfigRef
+
+		return r.Update(ctx, currentVersion)
+	})
+}
+
 // reconcileDeletion removes the stored artifact and finalizer when a Version is being deleted.
 func (r *VersionReconciler) reconcileDeletion(ctx context.Context, version *opendepotv1alpha1.Version) (ctrl.Result, error) {
 	r.Log.V(5).Info("reconciling deletion", "version", version.Name)
@@ -428,7 +681,7 @@ func (r *VersionReconciler) reconcileDeletion(ctx context.Context, version *open
 		return ctrl.Result{}, nil
 	}
 
-	filePath, err := getVersionFilePath(version)
+	filePath, err := r.getVersionFilePath(ctx, version)
 	if err != nil {
 		// If the file path cannot be resolved (e.g. FileName was never persisted
 		// because the Version was deleted before its first successful sync), there
@@ -444,14 +697,33 @@ func (r *VersionReconciler) reconcileDeletion(ctx context.Context, version *open
 		return ctrl.Result{}, nil
 	}
 
-	soi := &types.StorageObjectInput{
-		Method:   types.Delete,
-		FilePath: filePath,
-		Version:  version,
+	storageConfig, _, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// An archive of the content-addressed layout is only deleted with the last Version that references it.
+	referenced, err := r.archiveReferenced(ctx, version, storageConfig, *filePath)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if referenced {
+		r.Log.V(5).Info("stored artifact is referenced by another version; keeping it", "version", version.Name, "filePath", filePath)
+	} else {
+		soi := &types.StorageObjectInput{
+			Method:   types.Delete,
+			FilePath: filePath,
+			Version:  version,
+		}
+
+		r.Log.V(5).Info("deleting stored artifact", "version", version.Name, "filePath", filePath)
+		if err := r.InitStorageFactory(ctx, soi); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	r.Log.V(5).Info("deleting stored artifact", "version", version.Name, "filePath", filePath)
-	if err := r.InitStorageFactory(ctx, soi); err != nil {
+	if err := r.deleteStorageReplicas(ctx, version); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -532,7 +804,10 @@ func (r *VersionReconciler) prepareModuleVersion(ctx context.Context, req ctrl.R
 	}
 
 	version.Spec.ModuleConfigRef = &module.Spec.ModuleConfig
-	version.Spec.FileName = moduleRef.FileName
+	// An archive stored in the content-addressed layout keeps its file name until the Module controller records it.
+	if version.Spec.FileName == nil || !strings.HasPrefix(*version.Spec.FileName, contentAddressedFilePrefix) {
+		version.Spec.FileName = moduleRef.FileName
+	}
 
 	if version.Spec.ModuleConfigRef.Name == nil {
 		version.Spec.ModuleConfigRef.Name = &module.ObjectMeta.Name
@@ -558,9 +833,9 @@ func (r *VersionReconciler) prepareProviderVersion(version *opendepotv1alpha1.Ve
 	return ctrl.Result{}, nil
 }
 
-// fetchModuleArchive downloads module source from GitHub and returns bytes with a checksum.
-func (r *VersionReconciler) fetchModuleArchive(ctx context.Context, version *opendepotv1alpha1.Version) ([]byte, *string, error) {
-	var githubClientConfig *opendepotGithub.GithubClientConfig
+// fetchModuleArchive downloads module source from GitHub. When the version's tag resolves, the archive of the commit
+// it points to is downloaded and the tag is returned, so the archive always matches the recorded commit.
+func (r *VersionReconciler) fetchModuleArchive(ctx context.Context, version *opendepotv1alpha1.Version) ([]byte, *opendepotGithub.ModuleTag, error) {
 	var githubClient *github.Client
 
 	useAuthClient := false
@@ -568,12 +843,9 @@ func (r *VersionReconciler) fetchModuleArchive(ctx context.Context, version *ope
 		useAuthClient = version.Spec.ModuleConfigRef.GithubClientConfig.UseAuthenticatedClient
 	}
 
-	var err error
-	if useAuthClient {
-		githubClientConfig, err = opendepotGithub.GetGithubApplicationSecret(ctx, r.Client, version.Namespace)
-		if err != nil {
-			return nil, nil, err
-		}
+	githubClientConfig, err := opendepotGithub.GetGithubClientConfig(ctx, r.Client, version.Namespace, version.Spec.ModuleConfigRef.RepoOwner, version.Spec.ModuleConfigRef.GithubClientConfig)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	githubClient, err = opendepotGithub.CreateGithubClient(ctx, useAuthClient, githubClientConfig)
@@ -588,7 +860,72 @@ func (r *VersionReconciler) fetchModuleArchive(ctx context.Context, version *ope
 		fileFormat = github.Tarball
 	}
 
-	return opendepotGithub.GetModuleArchiveFromRef(ctx, r.Log, githubClient, version, fileFormat)
+	moduleTag, err := opendepotGithub.ResolveModuleTag(ctx, githubClient, version.Spec.ModuleConfigRef.RepoOwner, *version.Spec.ModuleConfigRef.Name, version.Spec.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if moduleTag == nil {
+		moduleBytes, _, err := opendepotGithub.GetModuleArchiveFromRef(ctx, r.Log, githubClient, githubClientConfig, version, fileFormat)
+		return moduleBytes, nil, err
+	}
+
+	moduleBytes, err := opendepotGithub.GetModuleArchiveFromCommit(ctx, githubClient, githubClientConfig, version, fileFormat, moduleTag.CommitSHA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return moduleBytes, moduleTag, nil
+}
+
+// normalizedStoredArchiveChecksum reads the module archive stored for version and returns the checksum of its
+// normalized form, or nil when no archive is stored.
+func (r *VersionReconciler) normalizedStoredArchiveChecksum(ctx context.Context, version *opendepotv1alpha1.Version) (*string, error) {
+	storageConfig, secretNamespace, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err := versionFilePath(version, storageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	storageInterface, err := r.newStorage(ctx, secretNamespace, storageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	soi := &types.StorageObjectInput{Method: types.Get, FilePath: filePath, Version: version, StorageConfig: storageConfig}
+	if err := RunStorageFactory(ctx, storageInterface, soi); err != nil {
+		return nil, err
+	}
+
+	if !soi.FileExists {
+		return nil, nil
+	}
+
+	reader, err := storageInterface.GetObject(ctx, soi)
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	stored, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the stored archive: %w", err)
+	}
+
+	normalized, err := normalizeModuleArchive(stored, version.Spec.ModuleConfigRef.ExcludePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := moduleArchiveChecksum(normalized)
+	return &checksum, nil
 }
 
 // generateModuleFileName returns a randomly generated UUID7 filename for a module archive.
@@ -618,10 +955,11 @@ func generateProviderFileName(originalFileName string) (*string, error) {
 	return &name, nil
 }
 
-// fetchProviderArchive resolves a provider binary download from the OpenTofu registry
+// fetchProviderArchive resolves a provider binary download from the upstream provider registry
 // and streams the artifact to a temporary file on disk to avoid buffering the
 // full provider zip (~700 MB) in the Go heap. The caller must invoke the returned
-// cleanup function (typically via defer) to remove the temp file.
+// cleanup function (typically via defer) to remove the temp file. The archive is only
+// returned once its upstream SHA256SUMS signature has been verified against a trusted key.
 func (r *VersionReconciler) fetchProviderArchive(ctx context.Context, version *opendepotv1alpha1.Version) (archivePath string, cleanup func(), checksum *string, fileName *string, err error) {
 	r.Log.V(5).Info("looking up provider download URL", "version", version.Name, "versionStr", version.Spec.Version, "os", version.Spec.OperatingSystem, "arch", version.Spec.Architecture)
 	if version.Spec.ProviderConfigRef == nil || version.Spec.ProviderConfigRef.Name == nil {
@@ -638,21 +976,19 @@ func (r *VersionReconciler) fetchProviderArchive(ctx context.Context, version *o
 		return "", func() {}, nil, nil, fmt.Errorf("provider version is empty")
 	}
 
-	providerNamespace := "hashicorp"
-	if version.Spec.ProviderConfigRef.Namespace != nil {
-		if ns := strings.TrimSpace(*version.Spec.ProviderConfigRef.Namespace); ns != "" {
-			providerNamespace = ns
-		}
+	registryClient, err := registry.GetProviderRegistryClient(ctx, r.Client, version.Namespace, *version.Spec.ProviderConfigRef)
+	if err != nil {
+		return "", func() {}, nil, nil, err
 	}
 
-	download, err := lookupProviderDownload(ctx, providerNamespace, providerName, providerVersion,
+	download, err := lookupProviderDownload(ctx, registryClient, registry.ProviderNamespace(*version.Spec.ProviderConfigRef), providerName, providerVersion,
 		version.Spec.OperatingSystem, version.Spec.Architecture)
 	if err != nil {
 		return "", func() {}, nil, nil, err
 	}
 	r.Log.V(5).Info("provider download URL resolved; streaming archive", "version", version.Name, "url", download.DownloadURL, "filename", download.Filename)
 
-	tmpPath, checksumHex, cleanupFn, err := httpStreamToFile(ctx, download.DownloadURL)
+	tmpPath, checksumHex, cleanupFn, err := httpStreamToFile(ctx, registryClient, download.DownloadURL)
 	if err != nil {
 		return "", func() {}, nil, nil, err
 	}
@@ -667,10 +1003,6 @@ func (r *VersionReconciler) fetchProviderArchive(ctx context.Context, version *o
 		r.Log.V(5).Info("provider archive checksum verified", "version", version.Name, "sha256", checksumHex)
 	}
 
-	// Re-encode the hex SHA-256 as base64 for storage (matches the existing format).
-	checksumBytes, _ := hex.DecodeString(checksumHex)
-	checksumB64 := base64.StdEncoding.EncodeToString(checksumBytes)
-
 	fn := download.Filename
 	if fn == "" {
 		fn = path.Base(download.DownloadURL)
@@ -681,6 +1013,20 @@ func (r *VersionReconciler) fetchProviderArchive(ctx context.Context, version *o
 		return "", func() {}, nil, nil, fmt.Errorf("unable to determine filename from provider download URL '%s'", download.DownloadURL)
 	}
 
+	// Verify the upstream SHA256SUMS signature before the archive is ingested. The result is recorded on the
+	// Version status by the caller whether or not verification succeeds.
+	verification, err := r.verifyProviderShasums(ctx, registryClient, version, download, fn, checksumHex)
+	version.Status.SignatureVerification = verification
+	if err != nil {
+		cleanupFn()
+		return "", func() {}, nil, nil, err
+	}
+	r.Log.V(5).Info("provider SHA256SUMS signature verified", "version", version.Name, "keySource", verification.KeySource, "signedBy", *verification.SignedByKeyID)
+
+	// Re-encode the hex SHA-256 as base64 for storage (matches the existing format).
+	checksumBytes, _ := hex.DecodeString(checksumHex)
+	checksumB64 := base64.StdEncoding.EncodeToString(checksumBytes)
+
 	return tmpPath, cleanupFn, &checksumB64, &fn, nil
 }
 
@@ -726,27 +1072,18 @@ func httpGetBytes(ctx context.Context, requestURL string) ([]byte, error) {
 	return fileBytes, nil
 }
 
-// httpStreamToFile streams an HTTP GET response body to a temporary file on disk
-// while computing its SHA-256 checksum via an io.TeeReader. It returns the temp
-// file path, the hex-encoded checksum, a cleanup function that removes the file,
-// and any error. This avoids buffering large provider binaries (~700 MB) in the
+// httpStreamToFile streams a file referenced by a registry response to a temporary file on disk
+// while computing its SHA-256 checksum via an io.TeeReader. The file is downloaded through
+// registryClient, so its bearer token is sent when the file is served by the registry host.
+// It returns the temp file path, the hex-encoded checksum, a cleanup function that removes
+// the file, and any error. This avoids buffering large provider binaries (~700 MB) in the
 // Go heap, which is critical when multiple reconcilers run concurrently.
-func httpStreamToFile(ctx context.Context, requestURL string) (filePath string, checksumHex string, cleanup func(), err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+func httpStreamToFile(ctx context.Context, registryClient *registry.Client, requestURL string) (filePath string, checksumHex string, cleanup func(), err error) {
+	body, err := registryClient.OpenFile(ctx, requestURL)
 	if err != nil {
 		return "", "", func() {}, err
 	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", "", func() {}, fmt.Errorf("request failed for '%s': %w", requestURL, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", "", func() {}, fmt.Errorf("request to '%s' failed with status %d", requestURL, resp.StatusCode)
-	}
+	defer body.Close()
 
 	f, err := os.CreateTemp("", "opendepot-provider-*.zip")
 	if err != nil {
@@ -759,7 +1096,7 @@ func httpStreamToFile(ctx context.Context, requestURL string) (filePath string,
 	}
 
 	h := sha256.New()
-	if _, err = io.Copy(f, io.TeeReader(resp.Body, h)); err != nil {
+	if _, err = io.Copy(f, io.TeeReader(body, h)); err != nil {
 		cleanupFn()
 		return "", "", func() {}, fmt.Errorf("failed to stream provider archive from '%s': %w", requestURL, err)
 	}
@@ -811,58 +1148,18 @@ func RunStorageFactory(ctx context.Context, storageInterface storage.Storage, so
 
 // InitStorageFactory prepares and initializes storage using the version's storage config.
 func (r *VersionReconciler) InitStorageFactory(ctx context.Context, soi *types.StorageObjectInput) error {
-	storageConfig, err := getVersionStorageConfig(soi.Version)
+	storageConfig, secretNamespace, err := r.getVersionStorageConfig(ctx, soi.Version)
 	if err != nil {
 		return err
 	}
 
-	var storageInterface storage.Storage
-	if storageConfig.FileSystem != nil {
-		storageInterface = &storage.FileSystem{}
-		return RunStorageFactory(ctx, storageInterface, soi)
-	}
-
-	if storageConfig.S3 != nil {
-		amazonS3Storage := &storage.AmazonS3Storage{}
-		if err := amazonS3Storage.NewClient(ctx, storageConfig.S3.Region); err != nil {
-			return err
-		}
-		storageInterface = amazonS3Storage
-		return RunStorageFactory(ctx, storageInterface, soi)
-	}
-
-	if storageConfig.AzureStorage != nil {
-		azureBlobStorage := &storage.AzureBlobStorage{}
-		if err := azureBlobStorage.NewClients(storageConfig.AzureStorage.SubscriptionID, storageConfig.AzureStorage.AccountUrl); err != nil {
-			return err
-		}
-		storageInterface = azureBlobStorage
-		return RunStorageFactory(ctx, storageInterface, soi)
-	}
-
-	if storageConfig.GCS != nil {
-		gcsStorage := &storage.GoogleCloudStorage{}
-		if err := gcsStorage.NewClient(ctx); err != nil {
-			return err
-		}
-		storageInterface = gcsStorage
-		return RunStorageFactory(ctx, storageInterface, soi)
-	}
-
-	return fmt.Errorf("at least one StorageConfig backend must be configured")
-}
-
-// getVersionStorageConfig resolves storage configuration from module or provider config references.
-func getVersionStorageConfig(version *opendepotv1alpha1.Version) (*opendepotv1alpha1.StorageConfig, error) {
-	if version.Spec.ModuleConfigRef != nil && version.Spec.ModuleConfigRef.StorageConfig != nil {
-		return version.Spec.ModuleConfigRef.StorageConfig, nil
-	}
-
-	if version.Spec.ProviderConfigRef != nil && version.Spec.ProviderConfigRef.StorageConfig != nil {
-		return version.Spec.ProviderConfigRef.StorageConfig, nil
+	storageInterface, err := r.newStorage(ctx, secretNamespace, storageConfig)
+	if err != nil {
+		return err
 	}
 
-	return nil, fmt.Errorf("storage config is not configured on moduleConfigRef or providerConfigRef")
+	soi.StorageConfig = storageConfig
+	return RunStorageFactory(ctx, storageInterface, soi)
 }
 
 // getVersionName resolves the logical resource name used as the storage prefix for a Version.
@@ -879,12 +1176,17 @@ func getVersionName(version *opendepotv1alpha1.Version) (*string, error) {
 }
 
 // getVersionFilePath computes the object key for module/provider artifacts.
-func getVersionFilePath(version *opendepotv1alpha1.Version) (*string, error) {
-	storageConfig, err := getVersionStorageConfig(version)
+func (r *VersionReconciler) getVersionFilePath(ctx context.Context, version *opendepotv1alpha1.Version) (*string, error) {
+	storageConfig, _, err := r.getVersionStorageConfig(ctx, version)
 	if err != nil {
 		return nil, err
 	}
 
+	return versionFilePath(version, storageConfig)
+}
+
+// versionFilePath computes the object key of the Version's artifact in the backend of storageConfig.
+func versionFilePath(version *opendepotv1alpha1.Version, storageConfig *opendepotv1alpha1.StorageConfig) (*string, error) {
 	name, err := getVersionName(version)
 	if err != nil {
 		return nil, err
@@ -894,24 +1196,10 @@ func getVersionFilePath(version *opendepotv1alpha1.Version) (*string, error) {
 		return nil, fmt.Errorf("fileName is nil for version '%s'", version.Name)
 	}
 
-	if storageConfig.S3 != nil && storageConfig.S3.Key != nil {
-		sanitized, err := storage.RemoveTrailingSlash(storageConfig.S3.Key)
-		if err != nil {
-			return nil, err
-		}
-		filePath := fmt.Sprintf("%s/%s/%s", *sanitized, *name, *version.Spec.FileName)
-		return &filePath, nil
-	}
-
-	if storageConfig.FileSystem != nil && storageConfig.FileSystem.DirectoryPath != nil {
-		sanitized, err := storage.RemoveTrailingSlash(storageConfig.FileSystem.DirectoryPath)
-		if err != nil {
-			return nil, err
-		}
-		filePath := fmt.Sprintf("%s/%s/%s", *sanitized, *name, *version.Spec.FileName)
-		return &filePath, nil
+	filePath, err := storage.ObjectPath(storageConfig, *name, *version.Spec.FileName)
+	if err != nil {
+		return nil, err
 	}
 
-	filePath := fmt.Sprintf("%s/%s", *name, *version.Spec.FileName)
 	return &filePath, nil
 }
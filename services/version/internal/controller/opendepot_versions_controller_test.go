@@ -17,18 +17,100 @@ limitations under the License.
 package controller
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/go-logr/logr"
+	"github.com/google/go-github/v81/github"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/mod/sumdb/dirhash"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	storagetypes "github.com/tonedefdev/opendepot/pkg/storage/types"
 )
 
+// newFakeVersionReconciler returns a VersionReconciler whose fake client is seeded with objs and updates the status
+// of Versions, StorageMigrations and StorageAudits through their status subresource.
+func newFakeVersionReconciler(objs ...client.Object) *VersionReconciler {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(opendepotv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&opendepotv1alpha1.Version{}, &opendepotv1alpha1.StorageMigration{}, &opendepotv1alpha1.StorageAudit{}).
+		WithObjects(objs...).
+		Build()
+
+	return &VersionReconciler{Client: fakeClient, Scheme: scheme, Log: logr.Discard()}
+}
+
+// newS3StandIn returns a TLS server that stands in for an S3 compatible endpoint. It stores the objects it is sent in
+// objects and their checksums in checksums, and records the requests signed with the access key 'opendepot'.
+func newS3StandIn(objects map[string][]byte, checksums map[string]string, requests *[]string) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=opendepot/") {
+			http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+			return
+		}
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+
+		switch r.Method {
+		case http.MethodPut:
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			objects[r.URL.Path] = body
+			checksums[r.URL.Path] = r.Header.Get("X-Amz-Checksum-Sha256")
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+				return
+			}
+			w.Header().Set("X-Amz-Checksum-Sha256", checksums[r.URL.Path])
+			_, _ = w.Write(body)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	DeferCleanup(server.Close)
+	return server
+}
+
 var _ = Describe("Version Controller", func() {
 	ctx := context.Background()
 
@@ -138,4 +220,2030 @@ var _ = Describe("Version Controller", func() {
 			Expect(err.Error()).To(ContainSubstring("moduleConfigRef is required"))
 		})
 	})
+
+	Context("parseRepositoryURL", func() {
+		It("should parse the owner and repository from github.com and GitHub Enterprise Server URLs", func() {
+			owner, repo, err := parseRepositoryURL("https://github.com/hashicorp/terraform-provider-aws")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal("hashicorp"))
+			Expect(repo).To(Equal("terraform-provider-aws"))
+
+			owner, repo, err = parseRepositoryURL("https://github.example.com/platform/terraform-provider-internal/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal("platform"))
+			Expect(repo).To(Equal("terraform-provider-internal"))
+		})
+
+		It("should return an error when the URL has no repository", func() {
+			_, _, err := parseRepositoryURL("https://github.com/hashicorp")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("lookupProviderDownload", func() {
+		var registryURL string
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"providers.v1": "/opendepot/providers/v1/"})
+			})
+			mux.HandleFunc("/opendepot/providers/v1/integrations/github/6.2.0/download/linux/amd64", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer registry-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"download_url": "https://downloads.example.com/terraform-provider-github_6.2.0_linux_amd64.zip",
+					"filename":     "terraform-provider-github_6.2.0_linux_amd64.zip",
+					"shasum":       "abc123",
+				})
+			})
+			server := httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+
+			registryURL = server.URL
+		})
+
+		It("should resolve the provider API through service discovery", func() {
+			download, err := lookupProviderDownload(ctx, registry.NewClient(registryURL, "registry-token"), "integrations", "github", "v6.2.0", "linux", "amd64")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(download.Filename).To(Equal("terraform-provider-github_6.2.0_linux_amd64.zip"))
+			Expect(download.Shasum).To(Equal("abc123"))
+		})
+
+		It("should return an error when the registry rejects the credentials", func() {
+			_, err := lookupProviderDownload(ctx, registry.NewClient(registryURL, ""), "integrations", "github", "6.2.0", "linux", "amd64")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("status 401"))
+		})
+	})
+
+	Context("httpStreamToFile", func() {
+		archive := []byte("provider package")
+
+		var (
+			registryURL            string
+			otherHostURL           string
+			otherHostAuthorization []string
+		)
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/downloads/terraform-provider-internal_1.0.0_linux_amd64.zip", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer registry-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write(archive)
+			})
+			server := httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+			registryURL = server.URL
+
+			otherHostAuthorization = nil
+			otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				otherHostAuthorization = append(otherHostAuthorization, r.Header.Get("Authorization"))
+				_, _ = w.Write(archive)
+			}))
+			DeferCleanup(otherHost.Close)
+			otherHostURL = otherHost.URL
+		})
+
+		It("should send the registry credentials when the package is served by the registry host", func() {
+			filePath, checksumHex, cleanup, err := httpStreamToFile(ctx, registry.NewClient(registryURL, "registry-token"), "/downloads/terraform-provider-internal_1.0.0_linux_amd64.zip")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cleanup)
+
+			downloaded, err := os.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(downloaded).To(Equal(archive))
+			Expect(checksumHex).To(Equal(fmt.Sprintf("%x", sha256.Sum256(archive))))
+
+			_, _, _, err = httpStreamToFile(ctx, registry.NewClient(registryURL, ""), registryURL+"/downloads/terraform-provider-internal_1.0.0_linux_amd64.zip")
+			Expect(err).To(MatchError(ContainSubstring("status 401")))
+		})
+
+		It("should not send the registry credentials to another host", func() {
+			_, _, cleanup, err := httpStreamToFile(ctx, registry.NewClient(registryURL, "registry-token"), otherHostURL+"/terraform-provider-internal_1.0.0_linux_amd64.zip")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cleanup)
+			Expect(otherHostAuthorization).To(Equal([]string{""}))
+		})
+	})
+
+	Context("verifyProviderShasums", func() {
+		const (
+			fileName    = "terraform-provider-github_6.2.0_linux_amd64.zip"
+			checksumHex = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+		)
+
+		var (
+			registryURL string
+			signer      *openpgp.Entity
+			reconciler  *VersionReconciler
+			version     *opendepotv1alpha1.Version
+		)
+
+		newArmoredPublicKey := func(entity *openpgp.Entity) string {
+			var buf bytes.Buffer
+			writer, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entity.Serialize(writer)).To(Succeed())
+			Expect(writer.Close()).To(Succeed())
+			return buf.String()
+		}
+
+		BeforeEach(func() {
+			var err error
+			signer, err = openpgp.NewEntity("OpenDepot Test", "", "test@opendepot.defdev.io", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			shasums := fmt.Sprintf("%s  %s\n", checksumHex, fileName)
+			var signature bytes.Buffer
+			Expect(openpgp.DetachSign(&signature, signer, bytes.NewReader([]byte(shasums)), nil)).To(Succeed())
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(shasums))
+			})
+			mux.HandleFunc("/SHA256SUMS.sig", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(signature.Bytes())
+			})
+			server := httptest.NewServer(mux)
+			DeferCleanup(server.Close)
+
+			registryURL = server.URL
+			reconciler = &VersionReconciler{Client: k8sClient, Log: logr.Discard()}
+			version = &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "github-6-2-0-linux-amd64", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					ProviderConfigRef: &opendepotv1alpha1.ProviderConfig{},
+				},
+			}
+		})
+
+		newDownload := func(keys ...*openpgp.Entity) *registry.ProviderDownload {
+			download := &registry.ProviderDownload{
+				Filename:            fileName,
+				ShasumsURL:          "/SHA256SUMS",
+				ShasumsSignatureURL: registryURL + "/SHA256SUMS.sig",
+			}
+
+			for _, key := range keys {
+				download.SigningKeys.GPGPublicKeys = append(download.SigningKeys.GPGPublicKeys, registry.GPGPublicKey{
+					KeyID:      fmt.Sprintf("%016X", key.PrimaryKey.KeyId),
+					ASCIIArmor: newArmoredPublicKey(key),
+				})
+			}
+
+			return download
+		}
+
+		It("should verify SHA256SUMS against the signing keys in the registry response", func() {
+			verification, err := reconciler.verifyProviderShasums(ctx, registry.NewClient(registryURL, ""), version, newDownload(signer), fileName, checksumHex)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verification.Verified).To(BeTrue())
+			Expect(verification.KeySource).To(Equal(signingKeySourceRegistry))
+			Expect(verification.TrustedKeyIDs).To(ConsistOf(fmt.Sprintf("%016X", signer.PrimaryKey.KeyId)))
+			Expect(verification.SignedByKeyID).NotTo(BeNil())
+			Expect(*verification.SignedByKeyID).To(Equal(fmt.Sprintf("%016X", signer.PrimaryKey.KeyId)))
+		})
+
+		It("should refuse SHA256SUMS signed by an untrusted key", func() {
+			other, err := openpgp.NewEntity("Someone Else", "", "other@example.com", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			verification, err := reconciler.verifyProviderShasums(ctx, registry.NewClient(registryURL, ""), version, newDownload(other), fileName, checksumHex)
+			Expect(err).To(MatchError(errSignatureVerification))
+			Expect(verification.Verified).To(BeFalse())
+			Expect(verification.Message).NotTo(BeNil())
+			Expect(*verification.Message).To(ContainSubstring("not signed by a trusted key"))
+		})
+
+		It("should refuse an archive whose checksum is not listed in SHA256SUMS", func() {
+			verification, err := reconciler.verifyProviderShasums(ctx, registry.NewClient(registryURL, ""), version, newDownload(signer), fileName, "0000")
+			Expect(err).To(MatchError(errSignatureVerification))
+			Expect(verification.Verified).To(BeFalse())
+			Expect(*verification.SignedByKeyID).To(Equal(fmt.Sprintf("%016X", signer.PrimaryKey.KeyId)))
+		})
+
+		It("should refuse a registry response without signing keys", func() {
+			_, err := reconciler.verifyProviderShasums(ctx, registry.NewClient(registryURL, ""), version, newDownload(), fileName, checksumHex)
+			Expect(err).To(MatchError(errSignatureVerification))
+			Expect(err.Error()).To(ContainSubstring("no public keys found"))
+		})
+	})
+
+	Context("package hashes", func() {
+		files := map[string]string{
+			"terraform-aws-example/main.tf":      "resource \"null_resource\" \"this\" {}\n",
+			"terraform-aws-example/variables.tf": "variable \"name\" {}\n",
+		}
+
+		newZip := func() []byte {
+			var buf bytes.Buffer
+			writer := zip.NewWriter(&buf)
+			_, err := writer.Create("terraform-aws-example/")
+			Expect(err).NotTo(HaveOccurred())
+			for name, content := range files {
+				file, err := writer.Create(name)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(writer.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		newTarGz := func() []byte {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			tarWriter := tar.NewWriter(gzipWriter)
+			Expect(tarWriter.WriteHeader(&tar.Header{Name: "terraform-aws-example/", Typeflag: tar.TypeDir, Mode: 0o755})).To(Succeed())
+			for name, content := range files {
+				Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})).To(Succeed())
+				_, err := tarWriter.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(tarWriter.Close()).To(Succeed())
+			Expect(gzipWriter.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		It("should compute the same h1 hash for zip and tar.gz module archives with the same files", func() {
+			zipHash, err := hashModuleArchive(newZip())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zipHash).To(HavePrefix("h1:"))
+
+			tarGzHash, err := hashModuleArchive(newTarGz())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tarGzHash).To(Equal(zipHash))
+		})
+
+		It("should compute the h1 hash of a provider archive as the hash of its unpacked files", func() {
+			archivePath := filepath.Join(GinkgoT().TempDir(), "terraform-provider-example_1.0.0_linux_amd64.zip")
+			Expect(os.WriteFile(archivePath, newZip(), 0o600)).To(Succeed())
+
+			unpackedDir := GinkgoT().TempDir()
+			for name, content := range files {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(unpackedDir, name)), 0o755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(unpackedDir, name), []byte(content), 0o644)).To(Succeed())
+			}
+
+			expected, err := dirhash.HashDir(unpackedDir, "", dirhash.Hash1)
+			Expect(err).NotTo(HaveOccurred())
+
+			providerHash, err := hashProviderArchive(archivePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providerHash).To(Equal(expected))
+		})
+
+		It("should return an error for an archive that is neither zip nor tar.gz", func() {
+			_, err := hashModuleArchive([]byte("not an archive"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("module dependencies", func() {
+		files := map[string]string{
+			"terraform-aws-example/versions.tf": `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 5.0"
+    }
+    random = "~> 3.6"
+    internal = {
+      source = "registry.example.com/acme/internal"
+    }
+  }
+}
+`,
+			"terraform-aws-example/main.tf": `module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "~> 5.1"
+}
+
+module "labels" {
+  source = "git::https://github.com/acme/terraform-null-labels.git?ref=v1.2.0"
+}
+
+module "branch" {
+  source = "github.com/acme/terraform-null-branch?ref=main"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+`,
+			"terraform-aws-example/modules/local/versions.tf": `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "< 6.0"
+    }
+  }
+}
+`,
+			"terraform-aws-example/examples/complete/main.tf": `terraform {
+  required_providers {
+    tls = {
+      source = "hashicorp/tls"
+    }
+  }
+}
+`,
+		}
+
+		newZip := func(files map[string]string) []byte {
+			var buf bytes.Buffer
+			writer := zip.NewWriter(&buf)
+			for name, content := range files {
+				file, err := writer.Create(name)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(writer.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		newTarGz := func(files map[string]string) []byte {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			tarWriter := tar.NewWriter(gzipWriter)
+			for name, content := range files {
+				Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})).To(Succeed())
+				_, err := tarWriter.Write([]byte(content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(tarWriter.Close()).To(Succeed())
+			Expect(gzipWriter.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		It("should record the providers and modules required by a module archive", func() {
+			for _, archive := range [][]byte{newZip(files), newTarGz(files)} {
+				dependencies, err := readModuleDependencies(archive)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dependencies.Providers).To(Equal([]opendepotv1alpha1.ProviderDependency{
+					{Source: "hashicorp/aws", VersionConstraints: "< 6.0, >= 5.0"},
+					{Source: "hashicorp/random", VersionConstraints: "~> 3.6"},
+					{Source: "registry.example.com/acme/internal"},
+				}))
+
+				Expect(dependencies.Modules).To(Equal([]opendepotv1alpha1.ModuleDependency{
+					{Source: "git::https://github.com/acme/terraform-null-labels.git?ref=v1.2.0", VersionConstraints: "1.2.0", RepoOwner: "acme", Name: "terraform-null-labels"},
+					{Source: "github.com/acme/terraform-null-branch?ref=main"},
+					{Source: "terraform-aws-modules/vpc/aws", VersionConstraints: "~> 5.1", RepoOwner: "terraform-aws-modules", Name: "terraform-aws-vpc"},
+				}))
+			}
+		})
+
+		It("should return no dependencies for a module without configuration files", func() {
+			dependencies, err := readModuleDependencies(newTarGz(map[string]string{"terraform-aws-example/README.md": "# example\n"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependencies).To(BeNil())
+		})
+
+		It("should select the newest version that satisfies the constraints", func() {
+			versions := []string{"5.0.0", "5.2.1", "5.10.0", "6.0.0", "6.1.0-beta1"}
+
+			matched, ok := newestMatchingVersion(versions, "~> 5.0")
+			Expect(ok).To(BeTrue())
+			Expect(matched).To(Equal("5.10.0"))
+
+			matched, ok = newestMatchingVersion(versions, "")
+			Expect(ok).To(BeTrue())
+			Expect(matched).To(Equal("6.0.0"))
+
+			_, ok = newestMatchingVersion(versions, ">= 7.0")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("module archive normalization", func() {
+		type testEntry struct {
+			name    string
+			content string
+			mode    int64
+		}
+
+		newTarGz := func(entries []testEntry, modTime time.Time) []byte {
+			var buf bytes.Buffer
+			gzipWriter := gzip.NewWriter(&buf)
+			gzipWriter.ModTime = modTime
+			tarWriter := tar.NewWriter(gzipWriter)
+			for _, entry := range entries {
+				Expect(tarWriter.WriteHeader(&tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: entry.mode, Size: int64(len(entry.content)), ModTime: modTime, Uname: "github"})).To(Succeed())
+				_, err := tarWriter.Write([]byte(entry.content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(tarWriter.Close()).To(Succeed())
+			Expect(gzipWriter.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		newZip := func(entries []testEntry, modTime time.Time) []byte {
+			var buf bytes.Buffer
+			writer := zip.NewWriter(&buf)
+			for _, entry := range entries {
+				header := &zip.FileHeader{Name: entry.name, Method: zip.Store, Modified: modTime}
+				header.SetMode(os.FileMode(entry.mode))
+				file, err := writer.CreateHeader(header)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.Write([]byte(entry.content))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(writer.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		entryNames := func(archive []byte) []string {
+			entries, err := readArchiveEntries(archive)
+			Expect(err).NotTo(HaveOccurred())
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.name)
+			}
+			return names
+		}
+
+		files := []testEntry{
+			{name: "main.tf", content: "resource \"null_resource\" \"this\" {}\n", mode: 0o644},
+			{name: "scripts/setup.sh", content: "#!/bin/sh\n", mode: 0o775},
+			{name: ".github/workflows/ci.yml", content: "on: push\n", mode: 0o644},
+			{name: "tests/main.tftest.hcl", content: "run \"plan\" {}\n", mode: 0o644},
+			{name: "docs/README.md", content: "# docs\n", mode: 0o644},
+			{name: "docs/usage.md", content: "# usage\n", mode: 0o644},
+			{name: ".opendepotignore", content: "# CI and tests\n.github/\ntests/\ndocs/*.md\n", mode: 0o644},
+		}
+
+		withTopLevelDir := func(dir string, entries []testEntry) []testEntry {
+			wrapped := make([]testEntry, 0, len(entries))
+			for _, entry := range entries {
+				wrapped = append(wrapped, testEntry{name: dir + "/" + entry.name, content: entry.content, mode: entry.mode})
+			}
+			return wrapped
+		}
+
+		It("should produce the same archive regardless of top-level directory, entry order and timestamps", func() {
+			reversed := slices.Clone(files)
+			slices.Reverse(reversed)
+
+			first, err := normalizeModuleArchive(newTarGz(withTopLevelDir("acme-terraform-aws-example-1a2b3c4", files), time.Now()), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := normalizeModuleArchive(newTarGz(withTopLevelDir("acme-terraform-aws-example-5d6e7f8", reversed), time.Now().Add(-time.Hour)), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(second).To(Equal(first))
+			Expect(moduleArchiveChecksum(second)).To(Equal(moduleArchiveChecksum(first)))
+
+			firstZip, err := normalizeModuleArchive(newZip(withTopLevelDir("acme-terraform-aws-example-1a2b3c4", files), time.Now()), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(firstZip).To(HavePrefix("PK\x03\x04"))
+
+			secondZip, err := normalizeModuleArchive(newZip(reversed, time.Now().Add(-time.Hour)), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secondZip).To(Equal(firstZip))
+		})
+
+		It("should exclude files matched by .opendepotignore and the module config patterns", func() {
+			archive, err := normalizeModuleArchive(newTarGz(withTopLevelDir("acme-terraform-aws-example-1a2b3c4", files), time.Now()), []string{"scripts/", "!docs/usage.md"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entryNames(archive)).To(Equal([]string{"docs/usage.md", "main.tf"}))
+		})
+
+		It("should keep only the executable bit of file permissions", func() {
+			archive, err := normalizeModuleArchive(newTarGz(files, time.Now()), []string{"!.github/"})
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := readArchiveEntries(archive)
+			Expect(err).NotTo(HaveOccurred())
+			modes := map[string]os.FileMode{}
+			for _, entry := range entries {
+				modes[entry.name] = entry.mode
+			}
+			Expect(modes).To(HaveKeyWithValue("scripts/setup.sh", os.FileMode(0o755)))
+			Expect(modes).To(HaveKeyWithValue("main.tf", os.FileMode(0o644)))
+			Expect(modes).To(HaveKey(".github/workflows/ci.yml"))
+		})
+
+		It("should match .gitignore style patterns", func() {
+			matcher := newExcludeMatcher([]string{"*.md", "!README.md", "/build", "examples/**/*.tfvars", "vendor/"})
+			Expect(matcher.excludes("CHANGELOG.md")).To(BeTrue())
+			Expect(matcher.excludes("modules/vpc/notes.md")).To(BeTrue())
+			Expect(matcher.excludes("README.md")).To(BeFalse())
+			Expect(matcher.excludes("build/output.txt")).To(BeTrue())
+			Expect(matcher.excludes("modules/build/main.tf")).To(BeFalse())
+			Expect(matcher.excludes("examples/complete/terraform.tfvars")).To(BeTrue())
+			Expect(matcher.excludes("examples/terraform.tfvars")).To(BeTrue())
+			Expect(matcher.excludes("vendor/lib/main.tf")).To(BeTrue())
+			Expect(matcher.excludes("vendor")).To(BeFalse())
+			Expect(matcher.excludes("main.tf")).To(BeFalse())
+		})
+
+		It("should normalize the archive stored before archives were normalized to compare it with the fetched archive", func() {
+			reconciler := newFakeVersionReconciler()
+			dir := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			fileName := "0192f3a4.tar.gz"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName: &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+						Name:          &name,
+						StorageConfig: &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &dir}},
+					},
+					Type:    opendepotv1alpha1.OpenDepotModule,
+					Version: "1.0.0",
+				},
+			}
+
+			storedChecksum, err := reconciler.normalizedStoredArchiveChecksum(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storedChecksum).To(BeNil())
+
+			stored := newTarGz(withTopLevelDir("acme-terraform-aws-example-1a2b3c4", files), time.Now().Add(-time.Hour))
+			filePath, err := reconciler.getVersionFilePath(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{
+				FileBytes: stored,
+				FilePath:  filePath,
+				Method:    storagetypes.Put,
+				Version:   version,
+			})).To(Succeed())
+
+			unchanged, err := normalizeModuleArchive(newTarGz(withTopLevelDir("acme-terraform-aws-example-5d6e7f8", files), time.Now()), nil)
+			Expect(err).NotTo(HaveOccurred())
+			storedChecksum, err = reconciler.normalizedStoredArchiveChecksum(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*storedChecksum).NotTo(Equal(moduleArchiveChecksum(stored)))
+			Expect(*storedChecksum).To(Equal(moduleArchiveChecksum(unchanged)))
+
+			changedFiles := slices.Clone(files)
+			changedFiles[0].content = "resource \"null_resource\" \"that\" {}\n"
+			changed, err := normalizeModuleArchive(newTarGz(changedFiles, time.Now()), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*storedChecksum).NotTo(Equal(moduleArchiveChecksum(changed)))
+		})
+	})
+
+	Context("module version tags", func() {
+		newGithubClient := func(handler http.HandlerFunc) *github.Client {
+			server := httptest.NewServer(handler)
+			DeferCleanup(server.Close)
+
+			githubClient := github.NewClient(nil)
+			baseURL, err := url.Parse(server.URL + "/")
+			Expect(err).NotTo(HaveOccurred())
+			githubClient.BaseURL = baseURL
+			return githubClient
+		}
+
+		It("should resolve lightweight and annotated tags to the commit they point to", func() {
+			githubClient := newGithubClient(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/acme/terraform-aws-example/git/ref/tags/v1.0.0":
+					_, _ = w.Write([]byte(`{"ref":"refs/tags/v1.0.0","object":{"type":"commit","sha":"1111111111111111111111111111111111111111"}}`))
+				case "/repos/acme/terraform-aws-example/git/ref/tags/2.0.0":
+					_, _ = w.Write([]byte(`{"ref":"refs/tags/2.0.0","object":{"type":"tag","sha":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}}`))
+				case "/repos/acme/terraform-aws-example/git/tags/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa":
+					_, _ = w.Write([]byte(`{"sha":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa","object":{"type":"commit","sha":"2222222222222222222222222222222222222222"}}`))
+				default:
+					http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+				}
+			})
+
+			lightweight, err := opendepotGithub.ResolveModuleTag(ctx, githubClient, "acme", "terraform-aws-example", "1.0.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lightweight).To(Equal(&opendepotGithub.ModuleTag{Name: "v1.0.0", CommitSHA: "1111111111111111111111111111111111111111"}))
+
+			annotated, err := opendepotGithub.ResolveModuleTag(ctx, githubClient, "acme", "terraform-aws-example", "2.0.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(annotated).To(Equal(&opendepotGithub.ModuleTag{
+				Name:      "2.0.0",
+				CommitSHA: "2222222222222222222222222222222222222222",
+				ObjectSHA: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			}))
+
+			missing, err := opendepotGithub.ResolveModuleTag(ctx, githubClient, "acme", "terraform-aws-example", "3.0.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(BeNil())
+		})
+
+		It("should default the retag policy from the module's immutability", func() {
+			immutable := true
+			policy, err := moduleRetagPolicy(&opendepotv1alpha1.ModuleConfig{Immutable: &immutable})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(opendepotv1alpha1.OpenDepotRetagPolicyRefuse))
+
+			policy, err = moduleRetagPolicy(&opendepotv1alpha1.ModuleConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(opendepotv1alpha1.OpenDepotRetagPolicyAccept))
+
+			quarantine := opendepotv1alpha1.OpenDepotRetagPolicyQuarantine
+			policy, err = moduleRetagPolicy(&opendepotv1alpha1.ModuleConfig{Immutable: &immutable, RetagPolicy: &quarantine})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(opendepotv1alpha1.OpenDepotRetagPolicyQuarantine))
+
+			invalid := "Ignore"
+			_, err = moduleRetagPolicy(&opendepotv1alpha1.ModuleConfig{RetagPolicy: &invalid})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should emit an event only when the TagMoved condition changes", func() {
+			recorder := events.NewFakeRecorder(10)
+			reconciler := &VersionReconciler{Recorder: recorder}
+			version := &opendepotv1alpha1.Version{}
+
+			reconciler.setTagMovedCondition(version, metav1.ConditionTrue, tagRefusedReason, "tag 'v1.0.0' moved")
+			reconciler.setTagMovedCondition(version, metav1.ConditionTrue, tagRefusedReason, "tag 'v1.0.0' moved")
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(Equal("Warning TagMoved tag 'v1.0.0' moved"))
+
+			reconciler.setTagMovedCondition(version, metav1.ConditionFalse, tagRestoredReason, "tag 'v1.0.0' restored")
+			Expect(<-recorder.Events).To(Equal("Normal TagMoved tag 'v1.0.0' restored"))
+			Expect(version.Status.Conditions).To(HaveLen(1))
+			Expect(version.Status.Conditions[0].Reason).To(Equal(tagRestoredReason))
+		})
+
+		It("should keep the most recent replaced archives in the digest history", func() {
+			var history []opendepotv1alpha1.ModuleVersionDigest
+			for i := range maxDigestHistory + 2 {
+				history = appendDigestHistory(history, opendepotv1alpha1.ModuleVersionDigest{CommitSHA: fmt.Sprintf("%040d", i)})
+			}
+
+			Expect(history).To(HaveLen(maxDigestHistory))
+			Expect(history[0].CommitSHA).To(Equal(fmt.Sprintf("%040d", 2)))
+			Expect(quarantineFileName("0192f3a4.tar.gz", "2222222222222222222222222222222222222222")).To(Equal("quarantine-222222222222-0192f3a4.tar.gz"))
+		})
+	})
+
+	Context("S3 compatible storage", func() {
+		It("should store archives with the endpoint, CA bundle and credentials of the S3 config", func() {
+			objects := map[string][]byte{}
+			checksums := map[string]string{}
+			var requests []string
+			server := newS3StandIn(objects, checksums, &requests)
+
+			caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			reconciler := newFakeVersionReconciler(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "minio-ca", Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotS3SecretDataFieldCABundle: caBundle},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "minio-credentials", Namespace: "default"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID: []byte("opendepot"),
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldSecretKey:   []byte("opendepot-secret"),
+					},
+				},
+			)
+
+			name := "terraform-aws-example"
+			caBundleSecretName := "minio-ca"
+			credentialsSecretName := "minio-credentials"
+			fileName := "0192f3a4.tar.gz"
+			key := "modules/"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName: &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+						Name: &name,
+						StorageConfig: &opendepotv1alpha1.StorageConfig{
+							S3: &opendepotv1alpha1.AmazonS3Config{
+								Bucket:                "opendepot",
+								Key:                   &key,
+								Region:                "us-east-1",
+								Endpoint:              &server.URL,
+								UsePathStyle:          true,
+								CABundleSecretName:    &caBundleSecretName,
+								CredentialsSecretName: &credentialsSecretName,
+							},
+						},
+					},
+				},
+			}
+
+			filePath, err := reconciler.getVersionFilePath(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*filePath).To(Equal("modules/terraform-aws-example/0192f3a4.tar.gz"))
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{
+				ArchiveChecksum: &checksum,
+				FileBytes:       archive,
+				FilePath:        filePath,
+				Method:          storagetypes.Put,
+				Version:         version,
+			})).To(Succeed())
+			Expect(objects).To(HaveKeyWithValue("/opendepot/modules/terraform-aws-example/0192f3a4.tar.gz", archive))
+
+			soi := &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Get, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, soi)).To(Succeed())
+			Expect(soi.FileExists).To(BeTrue())
+			Expect(*soi.ObjectChecksum).To(Equal(checksum))
+
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Delete, Version: version})).To(Succeed())
+			Expect(objects).To(BeEmpty())
+			Expect(requests).To(HaveLen(3))
+		})
+
+		It("should store provider packages with the S3 config of a StorageProfile", func() {
+			objects := map[string][]byte{}
+			checksums := map[string]string{}
+			var requests []string
+			server := newS3StandIn(objects, checksums, &requests)
+
+			caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			caBundleSecretName := "minio-ca"
+			credentialsSecretName := "minio-credentials"
+			reconciler := newFakeVersionReconciler(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: caBundleSecretName, Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotS3SecretDataFieldCABundle: caBundle},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: credentialsSecretName, Namespace: "default"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID: []byte("opendepot"),
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldSecretKey:   []byte("opendepot-secret"),
+					},
+				},
+				&opendepotv1alpha1.StorageProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "minio"},
+					Spec: opendepotv1alpha1.StorageProfileSpec{
+						StorageConfig: opendepotv1alpha1.StorageConfig{
+							S3: &opendepotv1alpha1.AmazonS3Config{
+								Bucket:                "opendepot",
+								Region:                "us-east-1",
+								Endpoint:              &server.URL,
+								UsePathStyle:          true,
+								CABundleSecretName:    &caBundleSecretName,
+								CredentialsSecretName: &credentialsSecretName,
+							},
+						},
+					},
+				},
+			)
+
+			name := "aws"
+			fileName := "terraform-provider-aws_6.0.0_linux_amd64.zip"
+			profileName := "minio"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-6.0.0-linux-amd64", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName:          &fileName,
+					ProviderConfigRef: &opendepotv1alpha1.ProviderConfig{Name: &name, StorageProfileName: &profileName},
+					Type:              opendepotv1alpha1.OpenDepotProvider,
+					Version:           "6.0.0",
+				},
+			}
+
+			filePath, err := reconciler.getVersionFilePath(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+
+			archive := []byte("provider package")
+			checksum := moduleArchiveChecksum(archive)
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{
+				ArchiveChecksum: &checksum,
+				FileBytes:       archive,
+				FilePath:        filePath,
+				Method:          storagetypes.Put,
+				Version:         version,
+			})).To(Succeed())
+			Expect(objects).To(HaveKeyWithValue("/opendepot/aws/terraform-provider-aws_6.0.0_linux_amd64.zip", archive))
+			Expect(version.Spec.ModuleConfigRef).To(BeNil())
+
+			soi := &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Get, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, soi)).To(Succeed())
+			Expect(soi.FileExists).To(BeTrue())
+			Expect(*soi.ObjectChecksum).To(Equal(checksum))
+		})
+
+		It("should return an error when the credentials secret is incomplete", func() {
+			reconciler := newFakeVersionReconciler(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "minio-credentials", Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID: []byte("opendepot")},
+				},
+			)
+
+			credentialsSecretName := "minio-credentials"
+			_, err := storage.GetAmazonS3ClientOptions(ctx, storageResourceGetter{reconciler.Client}, "default", &opendepotv1alpha1.AmazonS3Config{
+				Bucket:                "opendepot",
+				Region:                "us-east-1",
+				CredentialsSecretName: &credentialsSecretName,
+			})
+			Expect(err).To(MatchError(ContainSubstring("must have 'accessKeyID' and 'secretAccessKey' fields")))
+		})
+	})
+
+	Context("OCI registry storage", func() {
+		newRegistryStandIn := func(blobs, manifests map[string][]byte, tags map[string]string) *httptest.Server {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if username, password, ok := r.BasicAuth(); !ok || username != "robot$opendepot" || password != "harbor-secret" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				if r.URL.Path == "/v2/" {
+					return
+				}
+
+				if repository, ref, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/"); ok {
+					key := repository + "@" + ref
+					if digest, tagged := tags[repository+":"+ref]; tagged {
+						key = repository + "@" + digest
+					}
+
+					switch r.Method {
+					case http.MethodPut:
+						body, err := io.ReadAll(r.Body)
+						Expect(err).NotTo(HaveOccurred())
+						digest := fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+						manifests[repository+"@"+digest] = body
+						if !strings.HasPrefix(ref, "sha256:") {
+							tags[repository+":"+ref] = digest
+						}
+						w.Header().Set("Docker-Content-Digest", digest)
+						w.WriteHeader(http.StatusCreated)
+					case http.MethodGet, http.MethodHead:
+						body, found := manifests[key]
+						if !found {
+							w.WriteHeader(http.StatusNotFound)
+							return
+						}
+						w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+						w.Header().Set("Docker-Content-Digest", strings.TrimPrefix(key, repository+"@"))
+						w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+						if r.Method == http.MethodGet {
+							_, _ = w.Write(body)
+						}
+					case http.MethodDelete:
+						delete(manifests, key)
+						for tag, digest := range tags {
+							if repository+"@"+digest == key {
+								delete(tags, tag)
+							}
+						}
+						w.WriteHeader(http.StatusAccepted)
+					}
+					return
+				}
+
+				if repository, _, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/blobs/uploads/"); ok {
+					switch r.Method {
+					case http.MethodPost:
+						w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/upload", repository))
+						w.WriteHeader(http.StatusAccepted)
+					case http.MethodPut:
+						body, err := io.ReadAll(r.Body)
+						Expect(err).NotTo(HaveOccurred())
+						blobs[r.URL.Query().Get("digest")] = body
+						w.WriteHeader(http.StatusCreated)
+					}
+					return
+				}
+
+				if _, digest, ok := strings.Cut(r.URL.Path, "/blobs/"); ok {
+					body, found := blobs[digest]
+					if !found {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+					w.Header().Set("Docker-Content-Digest", digest)
+					if r.Method == http.MethodGet {
+						_, _ = w.Write(body)
+					}
+					return
+				}
+
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			DeferCleanup(server.Close)
+			return server
+		}
+
+		It("should store provider packages as annotated OCI artifacts tagged with the file name", func() {
+			blobs := map[string][]byte{}
+			manifests := map[string][]byte{}
+			tags := map[string]string{}
+			server := newRegistryStandIn(blobs, manifests, tags)
+			registryHost := strings.TrimPrefix(server.URL, "http://")
+
+			reconciler := newFakeVersionReconciler(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "harbor-credentials", Namespace: "default"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotOCISecretDataFieldUsername: []byte("robot$opendepot"),
+						opendepotv1alpha1.OpenDepotOCISecretDataFieldPassword: []byte("harbor-secret"),
+					},
+				},
+			)
+
+			name := "aws"
+			fileName := "terraform-provider-aws_5.31.0_linux_amd64.zip"
+			credentialsSecretName := "harbor-credentials"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-5.31.0-linux-amd64", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					Architecture:    "amd64",
+					FileName:        &fileName,
+					OperatingSystem: "linux",
+					Type:            opendepotv1alpha1.OpenDepotProvider,
+					Version:         "5.31.0",
+					ProviderConfigRef: &opendepotv1alpha1.ProviderConfig{
+						Name: &name,
+						StorageConfig: &opendepotv1alpha1.StorageConfig{
+							OCI: &opendepotv1alpha1.OCIStorageConfig{
+								Registry:              registryHost,
+								Repository:            "opendepot/providers",
+								PlainHTTP:             true,
+								CredentialsSecretName: &credentialsSecretName,
+							},
+						},
+					},
+				},
+			}
+
+			filePath, err := reconciler.getVersionFilePath(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+
+			archive := []byte("provider package")
+			checksum := moduleArchiveChecksum(archive)
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{
+				ArchiveChecksum: &checksum,
+				FileBytes:       archive,
+				FilePath:        filePath,
+				Method:          storagetypes.Put,
+				Version:         version,
+			})).To(Succeed())
+
+			digest, tagged := tags["opendepot/providers/aws:"+fileName]
+			Expect(tagged).To(BeTrue())
+			var manifest struct {
+				ArtifactType string            `json:"artifactType"`
+				Annotations  map[string]string `json:"annotations"`
+				Layers       []struct {
+					MediaType string `json:"mediaType"`
+				} `json:"layers"`
+			}
+			Expect(json.Unmarshal(manifests["opendepot/providers/aws@"+digest], &manifest)).To(Succeed())
+			Expect(manifest.ArtifactType).To(Equal(storage.OCIArtifactTypeProvider))
+			Expect(manifest.Layers).To(HaveLen(1))
+			Expect(manifest.Layers[0].MediaType).To(Equal(storage.OCIMediaTypeProviderZip))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(storage.OCIAnnotationName, "aws"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue("org.opencontainers.image.version", "5.31.0"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(storage.OCIAnnotationOS, "linux"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(storage.OCIAnnotationArch, "amd64"))
+			Expect(manifest.Annotations).To(HaveKeyWithValue(storage.OCIAnnotationChecksum, checksum))
+
+			soi := &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Get, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, soi)).To(Succeed())
+			Expect(soi.FileExists).To(BeTrue())
+			Expect(*soi.ObjectChecksum).To(Equal(checksum))
+
+			options, err := storage.GetOCIClientOptions(ctx, storageResourceGetter{reconciler.Client}, "default", version.Spec.ProviderConfigRef.StorageConfig.OCI)
+			Expect(err).NotTo(HaveOccurred())
+			ociStorage := &storage.OCIStorage{}
+			Expect(ociStorage.NewClient(options)).To(Succeed())
+			reader, err := ociStorage.GetObject(ctx, soi)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(io.ReadAll(reader)).To(Equal(archive))
+
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Delete, Version: version})).To(Succeed())
+			Expect(tags).To(BeEmpty())
+
+			missing := &storagetypes.StorageObjectInput{FilePath: filePath, Method: storagetypes.Get, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, missing)).To(Succeed())
+			Expect(missing.FileExists).To(BeFalse())
+		})
+
+		It("should derive valid OCI tags from file names", func() {
+			Expect(storage.OCITag("0192f3a4.tar.gz")).To(Equal("0192f3a4.tar.gz"))
+			Expect(storage.OCITag("quarantine-222222222222-module+build.zip")).To(Equal("quarantine-222222222222-module_build.zip"))
+			Expect(storage.OCITag(".hidden")).To(Equal("_hidden"))
+			Expect(storage.OCITag(strings.Repeat("a", 200))).To(HaveLen(128))
+		})
+	})
+
+	Context("storage credentials", func() {
+		var reconciler *VersionReconciler
+
+		BeforeEach(func() {
+			reconciler = newFakeVersionReconciler(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "aws-role", Namespace: "team-a"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldRoleARN:    []byte("arn:aws:iam::111111111111:role/opendepot"),
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldExternalID: []byte("team-a"),
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "azure-sas", Namespace: "team-a"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotAzureSecretDataFieldSASToken: []byte("sv=2024-11-04&sig=abc")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "gcs-key", Namespace: "team-b"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotGCSSecretDataFieldAccountJSON: []byte(`{"type":"service_account"}`)},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "team-b"},
+				},
+			)
+		})
+
+		It("should assume the role in the S3 credentials secret with the default credential chain", func() {
+			secretName := "aws-role"
+			options, err := storage.GetAmazonS3ClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-a", &opendepotv1alpha1.AmazonS3Config{
+				Bucket:                "opendepot",
+				Region:                "us-east-1",
+				CredentialsSecretName: &secretName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(options.AccessKeyID).To(BeEmpty())
+			Expect(options.RoleARN).To(Equal("arn:aws:iam::111111111111:role/opendepot"))
+			Expect(options.ExternalID).To(Equal("team-a"))
+		})
+
+		It("should read Azure and GCS credentials from the Version's namespace", func() {
+			secretName := "azure-sas"
+			azureOptions, err := storage.GetAzureBlobClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-a", &opendepotv1alpha1.AzureStorageConfig{CredentialsSecretName: &secretName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(azureOptions.SASToken).To(Equal("sv=2024-11-04&sig=abc"))
+
+			_, err = storage.GetAzureBlobClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-b", &opendepotv1alpha1.AzureStorageConfig{CredentialsSecretName: &secretName})
+			Expect(err).To(HaveOccurred())
+
+			azureOptions, err = storage.GetAzureBlobClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-a", &opendepotv1alpha1.AzureStorageConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(azureOptions).To(BeNil())
+
+			secretName = "gcs-key"
+			gcsOptions, err := storage.GetGoogleCloudStorageClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-b", &opendepotv1alpha1.GoogleCloudStorageConfig{CredentialsSecretName: &secretName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(gcsOptions.ServiceAccountJSON)).To(Equal(`{"type":"service_account"}`))
+
+			secretName = "empty"
+			_, err = storage.GetGoogleCloudStorageClientOptions(ctx, storageResourceGetter{reconciler.Client}, "team-b", &opendepotv1alpha1.GoogleCloudStorageConfig{CredentialsSecretName: &secretName})
+			Expect(err).To(MatchError(ContainSubstring("has no 'serviceAccountJSON' field")))
+		})
+	})
+
+	Context("storage profiles", func() {
+		var reconciler *VersionReconciler
+
+		BeforeEach(func() {
+			key := "modules/"
+			secretNamespace := "opendepot-system"
+			reconciler = newFakeVersionReconciler(
+				&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "team-a",
+						Annotations: map[string]string{opendepotv1alpha1.OpenDepotStorageProfileAnnotation: "shared"},
+					},
+				},
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+				&opendepotv1alpha1.StorageProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+					Spec: opendepotv1alpha1.StorageProfileSpec{
+						StorageConfig: opendepotv1alpha1.StorageConfig{
+							S3: &opendepotv1alpha1.AmazonS3Config{Bucket: "opendepot-shared", Key: &key, Region: "us-east-1"},
+						},
+						SecretNamespace: &secretNamespace,
+					},
+				},
+				&opendepotv1alpha1.StorageProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "archive"},
+					Spec: opendepotv1alpha1.StorageProfileSpec{
+						StorageConfig: opendepotv1alpha1.StorageConfig{
+							GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{Bucket: "opendepot-archive"},
+						},
+					},
+				},
+			)
+		})
+
+		It("should resolve the profile named by the config reference", func() {
+			name := "terraform-aws-example"
+			fileName := "0192f3a4.tar.gz"
+			profileName := "archive"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: "team-a"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName:        &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageProfileName: &profileName},
+					Type:            opendepotv1alpha1.OpenDepotModule,
+					Version:         "1.0.0",
+				},
+			}
+
+			storageConfig, secretNamespace, err := reconciler.getVersionStorageConfig(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storageConfig.GCS.Bucket).To(Equal("opendepot-archive"))
+			Expect(secretNamespace).To(Equal("team-a"))
+
+			version.Spec.ModuleConfigRef.StorageConfig = &opendepotv1alpha1.StorageConfig{
+				GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{Bucket: "opendepot-inline"},
+			}
+			storageConfig, _, err = reconciler.getVersionStorageConfig(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storageConfig.GCS.Bucket).To(Equal("opendepot-inline"))
+
+			version.Spec.ModuleConfigRef.StorageConfig = nil
+			profileName = "missing"
+			_, _, err = reconciler.getVersionStorageConfig(ctx, version)
+			Expect(err).To(MatchError(ContainSubstring("failed to get storage profile 'missing'")))
+		})
+
+		It("should fall back to the default profile of the Version's namespace", func() {
+			name := "aws"
+			fileName := "terraform-provider-aws_6.0.0_linux_amd64.zip"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-6.0.0-linux-amd64", Namespace: "team-a"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName:          &fileName,
+					ProviderConfigRef: &opendepotv1alpha1.ProviderConfig{Name: &name},
+					Type:              opendepotv1alpha1.OpenDepotProvider,
+					Version:           "6.0.0",
+				},
+			}
+
+			storageConfig, secretNamespace, err := reconciler.getVersionStorageConfig(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storageConfig.S3.Bucket).To(Equal("opendepot-shared"))
+			Expect(secretNamespace).To(Equal("opendepot-system"))
+
+			filePath, err := reconciler.getVersionFilePath(ctx, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*filePath).To(Equal("modules/aws/terraform-provider-aws_6.0.0_linux_amd64.zip"))
+
+			version.Namespace = "team-b"
+			_, _, err = reconciler.getVersionStorageConfig(ctx, version)
+			Expect(err).To(MatchError(ContainSubstring("namespace 'team-b' has no default storage profile")))
+		})
+	})
+
+	Context("storage replicas", func() {
+		var reconciler *VersionReconciler
+
+		BeforeEach(func() {
+			reconciler = newFakeVersionReconciler()
+		})
+
+		It("should copy archives to every replica and report replicas that fail to sync", func() {
+			primaryDir := GinkgoT().TempDir()
+			backupDir := GinkgoT().TempDir()
+			credentialsSecretName := "missing-credentials"
+			name := "terraform-aws-example"
+			fileName := "0192f3a4.tar.gz"
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName: &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+						Name: &name,
+						StorageConfig: &opendepotv1alpha1.StorageConfig{
+							FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &primaryDir},
+							Replicas: []opendepotv1alpha1.StorageReplicaConfig{
+								{Name: "backup", FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &backupDir}},
+								{Name: "offsite", S3: &opendepotv1alpha1.AmazonS3Config{Bucket: "opendepot-offsite", Region: "us-east-1", CredentialsSecretName: &credentialsSecretName}},
+							},
+							ReadOrder: []string{"backup"},
+						},
+					},
+					Type:    opendepotv1alpha1.OpenDepotModule,
+					Version: "1.0.0",
+				},
+			}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			statuses, err := reconciler.syncStorageReplicas(ctx, version, archive, nil, &checksum)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveLen(3))
+
+			Expect(statuses[0].Name).To(Equal(opendepotv1alpha1.OpenDepotStoragePrimaryReplica))
+			Expect(statuses[0].Synced).To(BeTrue())
+			Expect(statuses[1].Name).To(Equal("backup"))
+			Expect(statuses[1].Synced).To(BeTrue())
+			Expect(*statuses[1].Checksum).To(Equal(checksum))
+			Expect(statuses[2].Name).To(Equal("offsite"))
+			Expect(statuses[2].Synced).To(BeFalse())
+			Expect(statuses[2].SyncStatus).To(ContainSubstring("missing-credentials"))
+
+			for _, dir := range []string{primaryDir, backupDir} {
+				stored, err := os.ReadFile(filepath.Join(dir, name, fileName))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored).To(Equal(archive))
+			}
+
+			version.Status.Checksum = &checksum
+			version.Status.Replicas = statuses
+			Expect(reconciler.storageReplicasSynced(ctx, version)).To(BeFalse())
+
+			version.Spec.ModuleConfigRef.StorageConfig.Replicas = version.Spec.ModuleConfigRef.StorageConfig.Replicas[:1]
+			version.Status.Replicas = statuses[:2]
+			Expect(reconciler.storageReplicasSynced(ctx, version)).To(BeTrue())
+
+			Expect(reconciler.deleteStorageReplicas(ctx, version)).To(Succeed())
+			_, err = os.Stat(filepath.Join(backupDir, name, fileName))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			_, err = os.Stat(filepath.Join(primaryDir, name, fileName))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should validate replicas and order them for reads", func() {
+			dir := "/var/opendepot"
+			storageConfig := &opendepotv1alpha1.StorageConfig{
+				FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &dir},
+				Replicas: []opendepotv1alpha1.StorageReplicaConfig{
+					{Name: "backup", FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &dir}},
+					{Name: "offsite", GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{Bucket: "opendepot-offsite"}},
+				},
+				ReadOrder: []string{"offsite"},
+			}
+
+			replicas, err := storage.ReadOrder(storageConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicas).To(HaveLen(3))
+			Expect(replicas[0].Name).To(Equal("offsite"))
+			Expect(replicas[0].StorageConfig.GCS.Bucket).To(Equal("opendepot-offsite"))
+			Expect(replicas[1].Name).To(Equal(opendepotv1alpha1.OpenDepotStoragePrimaryReplica))
+			Expect(replicas[1].StorageConfig.Replicas).To(BeNil())
+			Expect(replicas[2].Name).To(Equal("backup"))
+
+			storageConfig.ReadOrder = []string{"unknown"}
+			_, err = storage.ReadOrder(storageConfig)
+			Expect(err).To(MatchError(ContainSubstring("unknown storage replica 'unknown'")))
+
+			storageConfig.ReadOrder = nil
+			storageConfig.Replicas[1].Name = "backup"
+			_, err = storage.StorageReplicas(storageConfig)
+			Expect(err).To(MatchError(ContainSubstring("'backup' is used more than once")))
+
+			storageConfig.Replicas[1].Name = "offsite"
+			storageConfig.Replicas[1].FileSystem = &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &dir}
+			_, err = storage.StorageReplicas(storageConfig)
+			Expect(err).To(MatchError(ContainSubstring("must configure exactly one backend")))
+		})
+	})
+
+	Context("storage migrations", func() {
+		It("should copy archives to the target and point the config references at it", func() {
+			sourceDir := GinkgoT().TempDir()
+			targetDir := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			sourceConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &sourceDir}}
+			targetConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &targetDir}}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			corruptChecksum := moduleArchiveChecksum([]byte("other archive"))
+			Expect(os.MkdirAll(filepath.Join(sourceDir, name), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(sourceDir, name, "stored.tar.gz"), archive, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(sourceDir, name, "corrupt.tar.gz"), archive, 0644)).To(Succeed())
+
+			owner := []metav1.OwnerReference{{
+				APIVersion: opendepotv1alpha1.GroupVersion.String(),
+				Kind:       opendepotv1alpha1.OpenDepotModule,
+				Name:       name,
+				UID:        "module-uid",
+				Controller: func() *bool { controller := true; return &controller }(),
+			}}
+			newVersion := func(versionName, fileName string, checksum *string) *opendepotv1alpha1.Version {
+				return &opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: versionName, Namespace: "default", OwnerReferences: owner},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: sourceConfig.DeepCopy()},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         versionName,
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: checksum, Synced: checksum != nil},
+				}
+			}
+
+			migration := &opendepotv1alpha1.StorageMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "to-target", Namespace: "default", Generation: 1},
+				Spec:       opendepotv1alpha1.StorageMigrationSpec{TargetStorageConfig: targetConfig},
+			}
+
+			fakeClient := newFakeVersionReconciler(
+				migration,
+				newVersion("stored", "stored.tar.gz", &checksum),
+				newVersion("corrupt", "corrupt.tar.gz", &corruptChecksum),
+				newVersion("unsynced", "unsynced.tar.gz", nil),
+				&opendepotv1alpha1.Module{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec: opendepotv1alpha1.ModuleSpec{
+						ModuleConfig: opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: sourceConfig.DeepCopy()},
+					},
+				},
+				&opendepotv1alpha1.Depot{
+					ObjectMeta: metav1.ObjectMeta{Name: "modules", Namespace: "default"},
+					Spec: opendepotv1alpha1.DepotSpec{
+						GlobalConfig:  &opendepotv1alpha1.GlobalConfig{StorageConfig: sourceConfig.DeepCopy()},
+						ModuleConfigs: []opendepotv1alpha1.ModuleConfig{{Name: &name}},
+					},
+				},
+			).Client
+
+			reconciler := &StorageMigrationReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "to-target", Namespace: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "to-target", Namespace: "default"}, migration)).To(Succeed())
+			Expect(migration.Status.Phase).To(Equal(opendepotv1alpha1.OpenDepotStorageMigrationPhaseFailed))
+			Expect(migration.Status.TotalVersions).To(Equal(3))
+			Expect(migration.Status.MigratedVersions).To(Equal(2))
+			Expect(migration.Status.FailedVersions).To(HaveLen(1))
+			Expect(migration.Status.FailedVersions[0].Name).To(Equal("corrupt"))
+			Expect(migration.Status.FailedVersions[0].Message).To(ContainSubstring("does not match the Version's checksum"))
+
+			stored, err := os.ReadFile(filepath.Join(targetDir, name, "stored.tar.gz"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(Equal(archive))
+			_, err = os.Stat(filepath.Join(targetDir, name, "corrupt.tar.gz"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			for versionName, wantTarget := range map[string]bool{"stored": true, "unsynced": true, "corrupt": false} {
+				version := &opendepotv1alpha1.Version{}
+				Expect(fakeClient.Get(ctx, types.NamespacedName{Name: versionName, Namespace: "default"}, version)).To(Succeed())
+				Expect(*version.Spec.ModuleConfigRef.StorageConfig.FileSystem.DirectoryPath == targetDir).To(Equal(wantTarget), versionName)
+			}
+
+			module := &opendepotv1alpha1.Module{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, module)).To(Succeed())
+			Expect(*module.Spec.ModuleConfig.StorageConfig.FileSystem.DirectoryPath).To(Equal(sourceDir))
+
+			depot := &opendepotv1alpha1.Depot{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "modules", Namespace: "default"}, depot)).To(Succeed())
+			Expect(depot.Spec.ModuleConfigs[0].StorageConfig).To(BeNil())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "to-target", Namespace: "default"}, migration)).To(Succeed())
+			migration.Spec.DeleteSource = true
+			migration.Generation = 2
+			Expect(fakeClient.Update(ctx, migration)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(sourceDir, name, "corrupt.tar.gz"), []byte("other archive"), 0644)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "to-target", Namespace: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "to-target", Namespace: "default"}, migration)).To(Succeed())
+			Expect(migration.Status.Phase).To(Equal(opendepotv1alpha1.OpenDepotStorageMigrationPhaseSucceeded))
+			Expect(migration.Status.MigratedVersions).To(Equal(3))
+			Expect(migration.Status.FailedVersions).To(BeEmpty())
+
+			_, err = os.Stat(filepath.Join(sourceDir, name, "corrupt.tar.gz"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			_, err = os.Stat(filepath.Join(sourceDir, name, "stored.tar.gz"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, module)).To(Succeed())
+			Expect(*module.Spec.ModuleConfig.StorageConfig.FileSystem.DirectoryPath).To(Equal(targetDir))
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "modules", Namespace: "default"}, depot)).To(Succeed())
+			Expect(*depot.Spec.ModuleConfigs[0].StorageConfig.FileSystem.DirectoryPath).To(Equal(targetDir))
+			Expect(*depot.Spec.GlobalConfig.StorageConfig.FileSystem.DirectoryPath).To(Equal(sourceDir))
+		})
+
+		It("should require exactly one target", func() {
+			profileName := "shared"
+			migration := &opendepotv1alpha1.StorageMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "both-targets", Namespace: "default"},
+				Spec: opendepotv1alpha1.StorageMigrationSpec{
+					TargetStorageConfig:      &opendepotv1alpha1.StorageConfig{GCS: &opendepotv1alpha1.GoogleCloudStorageConfig{Bucket: "opendepot"}},
+					TargetStorageProfileName: &profileName,
+				},
+			}
+
+			fakeClient := newFakeVersionReconciler(migration).Client
+			reconciler := &StorageMigrationReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "both-targets", Namespace: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "both-targets", Namespace: "default"}, migration)).To(Succeed())
+			Expect(migration.Status.Phase).To(Equal(opendepotv1alpha1.OpenDepotStorageMigrationPhaseFailed))
+			Expect(migration.Status.Message).To(ContainSubstring("exactly one of targetStorageConfig and targetStorageProfileName"))
+		})
+
+		It("should keep the archive when the target only changes the credentials of the source", func() {
+			objects := map[string][]byte{}
+			checksums := map[string]string{}
+			var requests []string
+			server := newS3StandIn(objects, checksums, &requests)
+
+			name := "terraform-aws-example"
+			key := "modules/"
+			caBundleSecretName := "minio-ca"
+			sourceCredentials := "minio-credentials"
+			targetCredentials := "minio-rotated-credentials"
+			s3Config := func(credentialsSecretName string) *opendepotv1alpha1.StorageConfig {
+				return &opendepotv1alpha1.StorageConfig{
+					S3: &opendepotv1alpha1.AmazonS3Config{
+						Bucket:                "opendepot",
+						Key:                   &key,
+						Region:                "us-east-1",
+						Endpoint:              &server.URL,
+						UsePathStyle:          true,
+						CABundleSecretName:    &caBundleSecretName,
+						CredentialsSecretName: &credentialsSecretName,
+					},
+				}
+			}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			objectPath := "/opendepot/modules/terraform-aws-example/stored.tar.gz"
+			objects[objectPath] = archive
+			checksums[objectPath] = checksum
+
+			fileName := "stored.tar.gz"
+			migration := &opendepotv1alpha1.StorageMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "rotate-credentials", Namespace: "default", Generation: 1},
+				Spec: opendepotv1alpha1.StorageMigrationSpec{
+					TargetStorageConfig: s3Config(targetCredentials),
+					DeleteSource:        true,
+				},
+			}
+
+			caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			credentials := func(secretName string) *corev1.Secret {
+				return &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: "default"},
+					Data: map[string][]byte{
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldAccessKeyID: []byte("opendepot"),
+						opendepotv1alpha1.OpenDepotS3SecretDataFieldSecretKey:   []byte(secretName),
+					},
+				}
+			}
+			fakeClient := newFakeVersionReconciler(
+				migration,
+				credentials(sourceCredentials),
+				credentials(targetCredentials),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: caBundleSecretName, Namespace: "default"},
+					Data:       map[string][]byte{opendepotv1alpha1.OpenDepotS3SecretDataFieldCABundle: caBundle},
+				},
+				&opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: "stored", Namespace: "default"},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: s3Config(sourceCredentials)},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         "stored",
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+				},
+			).Client
+
+			reconciler := &StorageMigrationReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "rotate-credentials", Namespace: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "rotate-credentials", Namespace: "default"}, migration)).To(Succeed())
+			Expect(migration.Status.Phase).To(Equal(opendepotv1alpha1.OpenDepotStorageMigrationPhaseSucceeded))
+			Expect(migration.Status.MigratedVersions).To(Equal(1))
+
+			version := &opendepotv1alpha1.Version{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "stored", Namespace: "default"}, version)).To(Succeed())
+			Expect(*version.Spec.ModuleConfigRef.StorageConfig.S3.CredentialsSecretName).To(Equal(targetCredentials))
+
+			Expect(objects).To(HaveKeyWithValue(objectPath, archive))
+			Expect(requests).NotTo(ContainElement(HavePrefix(http.MethodDelete)))
+		})
+
+		It("should not delete a source archive stored at the location of the target", func() {
+			directory := GinkgoT().TempDir()
+			keyring := "opendepot-keyring"
+			name := "terraform-aws-example"
+			fileName := "stored.tar.gz"
+			version := &opendepotv1alpha1.Version{
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName:        &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name},
+					Type:            opendepotv1alpha1.OpenDepotModule,
+				},
+			}
+
+			sourceConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory}}
+			targetConfig := sourceConfig.DeepCopy()
+			targetConfig.Encryption = &opendepotv1alpha1.StorageEncryptionConfig{KeyringSecretName: keyring, PrimaryKeyID: "primary"}
+			Expect(sameArchiveLocation(version, sourceConfig, targetConfig)).To(BeTrue())
+
+			otherDirectory := GinkgoT().TempDir()
+			otherConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &otherDirectory}}
+			Expect(sameArchiveLocation(version, sourceConfig, otherConfig)).To(BeFalse())
+
+			filePath := filepath.Join(directory, name, fileName)
+			Expect(os.MkdirAll(filepath.Dir(filePath), 0755)).To(Succeed())
+			Expect(os.WriteFile(filePath, []byte("module archive"), 0644)).To(Succeed())
+
+			deleteSourceArchives(ctx, newFakeVersionReconciler(), version, sourceConfig, "default", targetConfig)
+			_, err := os.Stat(filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			deleteSourceArchives(ctx, newFakeVersionReconciler(), version, sourceConfig, "default", otherConfig)
+			_, err = os.Stat(filePath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Context("storage audits", func() {
+		It("should report damaged archives and delete orphaned objects after the grace period", func() {
+			directory := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			storageConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory}}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			moduleDir := filepath.Join(directory, name)
+			Expect(os.MkdirAll(moduleDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "stored.tar.gz"), archive, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "corrupt.tar.gz"), []byte("other archive"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "old-orphan.tar.gz"), archive, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "new-orphan.tar.gz"), archive, 0644)).To(Succeed())
+			twoDaysAgo := time.Now().Add(-48 * time.Hour)
+			Expect(os.Chtimes(filepath.Join(moduleDir, "old-orphan.tar.gz"), twoDaysAgo, twoDaysAgo)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(moduleDir, "stored.tar.gz"), twoDaysAgo, twoDaysAgo)).To(Succeed())
+
+			newVersion := func(versionName, fileName string) *opendepotv1alpha1.Version {
+				return &opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: versionName, Namespace: "default"},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: storageConfig.DeepCopy()},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         versionName,
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+				}
+			}
+
+			audit := &opendepotv1alpha1.StorageAudit{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", Generation: 1},
+				Spec:       opendepotv1alpha1.StorageAuditSpec{DeleteOrphans: true, DryRun: true},
+			}
+
+			fakeClient := newFakeVersionReconciler(
+				audit,
+				newVersion("stored", "stored.tar.gz"),
+				newVersion("corrupt", "corrupt.tar.gz"),
+				newVersion("missing", "missing.tar.gz"),
+			).Client
+
+			reconciler := &StorageAuditReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "default"}}
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(24 * time.Hour))
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			Expect(audit.Status.CheckedVersions).To(Equal(3))
+			Expect(audit.Status.FailedVersions).To(Equal(2))
+			Expect(audit.Status.Findings).To(HaveLen(2))
+			problems := map[string]string{}
+			for _, finding := range audit.Status.Findings {
+				Expect(finding.Replica).To(Equal(opendepotv1alpha1.OpenDepotStoragePrimaryReplica))
+				problems[finding.Version] = finding.Problem
+			}
+			Expect(problems).To(Equal(map[string]string{
+				"corrupt": opendepotv1alpha1.OpenDepotStorageAuditProblemChecksumMismatch,
+				"missing": opendepotv1alpha1.OpenDepotStorageAuditProblemMissing,
+			}))
+			Expect(audit.Status.OrphanedObjects).To(Equal(2))
+			Expect(audit.Status.DeletedObjects).To(Equal(0))
+			_, err = os.Stat(filepath.Join(moduleDir, "old-orphan.tar.gz"))
+			Expect(err).NotTo(HaveOccurred())
+
+			version := &opendepotv1alpha1.Version{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "missing", Namespace: "default"}, version)).To(Succeed())
+			Expect(version.Status.Conditions).To(BeEmpty())
+
+			result, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 23*time.Hour))
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			audit.Spec.DryRun = false
+			audit.Spec.Repair = true
+			audit.Generation = 2
+			Expect(fakeClient.Update(ctx, audit)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			Expect(audit.Status.OrphanedObjects).To(Equal(2))
+			Expect(audit.Status.DeletedObjects).To(Equal(1))
+			_, err = os.Stat(filepath.Join(moduleDir, "old-orphan.tar.gz"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			for _, fileName := range []string{"new-orphan.tar.gz", "stored.tar.gz", "corrupt.tar.gz"} {
+				_, err = os.Stat(filepath.Join(moduleDir, fileName))
+				Expect(err).NotTo(HaveOccurred(), fileName)
+			}
+
+			for versionName, wantSynced := range map[string]bool{"stored": true, "corrupt": false, "missing": false} {
+				version := &opendepotv1alpha1.Version{}
+				Expect(fakeClient.Get(ctx, types.NamespacedName{Name: versionName, Namespace: "default"}, version)).To(Succeed())
+				Expect(version.Status.Synced).To(Equal(wantSynced), versionName)
+
+				condition := meta.FindStatusCondition(version.Status.Conditions, opendepotv1alpha1.OpenDepotConditionStorageIntegrity)
+				if wantSynced {
+					Expect(condition).To(BeNil())
+					continue
+				}
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			}
+		})
+
+		It("should share a backend between storage configs that differ only in their encryption", func() {
+			directory := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			plainConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory}}
+			encryptedConfig := plainConfig.DeepCopy()
+			encryptedConfig.Encryption = &opendepotv1alpha1.StorageEncryptionConfig{KeyringSecretName: "opendepot-keyring", PrimaryKeyID: "2026-10"}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			moduleDir := filepath.Join(directory, name)
+			Expect(os.MkdirAll(moduleDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "plain.tar.gz"), archive, 0644)).To(Succeed())
+
+			key := bytes.Repeat([]byte{7}, 32)
+			encrypted, err := storage.NewEncryptedStorage(&storage.FileSystem{}, map[string][]byte{"2026-10": key}, "2026-10")
+			Expect(err).NotTo(HaveOccurred())
+			encryptedPath := filepath.Join(moduleDir, "encrypted.tar.gz")
+			Expect(encrypted.PutObject(ctx, &storagetypes.StorageObjectInput{FileBytes: archive, FilePath: &encryptedPath})).To(Succeed())
+
+			twoDaysAgo := time.Now().Add(-48 * time.Hour)
+			for _, fileName := range []string{"plain.tar.gz", "encrypted.tar.gz"} {
+				Expect(os.Chtimes(filepath.Join(moduleDir, fileName), twoDaysAgo, twoDaysAgo)).To(Succeed())
+			}
+
+			newVersion := func(versionName, fileName string, storageConfig *opendepotv1alpha1.StorageConfig) *opendepotv1alpha1.Version {
+				return &opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: versionName, Namespace: "default"},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: storageConfig},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         versionName,
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+				}
+			}
+
+			audit := &opendepotv1alpha1.StorageAudit{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", Generation: 1},
+				Spec:       opendepotv1alpha1.StorageAuditSpec{DeleteOrphans: true},
+			}
+
+			fakeClient := newFakeVersionReconciler(
+				audit,
+				newVersion("plain", "plain.tar.gz", plainConfig),
+				newVersion("encrypted", "encrypted.tar.gz", encryptedConfig),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "opendepot-keyring", Namespace: "default"},
+					Data:       map[string][]byte{"2026-10": key},
+				},
+			).Client
+
+			reconciler := &StorageAuditReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "default"}}
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			Expect(audit.Status.CheckedVersions).To(Equal(2))
+			Expect(audit.Status.FailedVersions).To(Equal(0))
+			Expect(audit.Status.OrphanedObjects).To(Equal(0))
+			Expect(audit.Status.DeletedObjects).To(Equal(0))
+			for _, fileName := range []string{"plain.tar.gz", "encrypted.tar.gz"} {
+				_, err = os.Stat(filepath.Join(moduleDir, fileName))
+				Expect(err).NotTo(HaveOccurred(), fileName)
+			}
+
+			stored, err := os.ReadFile(encryptedPath)
+			Expect(err).NotTo(HaveOccurred())
+			stored[len(stored)-1] ^= 0xff
+			Expect(os.WriteFile(encryptedPath, stored, 0644)).To(Succeed())
+
+			audit.Generation = 2
+			Expect(fakeClient.Update(ctx, audit)).To(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			Expect(audit.Status.FailedVersions).To(Equal(1))
+			Expect(audit.Status.Findings).To(HaveLen(1))
+			Expect(audit.Status.Findings[0].Version).To(Equal("encrypted"))
+			Expect(audit.Status.Findings[0].Problem).To(Equal(opendepotv1alpha1.OpenDepotStorageAuditProblemChecksumMismatch))
+			Expect(audit.Status.Findings[0].Message).To(ContainSubstring("failed authentication"))
+		})
+
+		It("should not delete orphaned objects when a Version cannot be resolved", func() {
+			directory := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			storageConfig := &opendepotv1alpha1.StorageConfig{FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory}}
+
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			moduleDir := filepath.Join(directory, name)
+			Expect(os.MkdirAll(moduleDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "stored.tar.gz"), archive, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(moduleDir, "orphan.tar.gz"), archive, 0644)).To(Succeed())
+			twoDaysAgo := time.Now().Add(-48 * time.Hour)
+			Expect(os.Chtimes(filepath.Join(moduleDir, "orphan.tar.gz"), twoDaysAgo, twoDaysAgo)).To(Succeed())
+
+			fileName := "stored.tar.gz"
+			unresolvedFileName := "unresolved.tar.gz"
+			missingProfile := "missing"
+			fakeClient := newFakeVersionReconciler(
+				&opendepotv1alpha1.StorageAudit{
+					ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", Generation: 1},
+					Spec:       opendepotv1alpha1.StorageAuditSpec{DeleteOrphans: true},
+				},
+				&opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: "stored", Namespace: "default"},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: storageConfig},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         "stored",
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: &checksum, Synced: true},
+				},
+				&opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: "unresolved", Namespace: "other"},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName:        &unresolvedFileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageProfileName: &missingProfile},
+						Type:            opendepotv1alpha1.OpenDepotModule,
+						Version:         "unresolved",
+					},
+				},
+			).Client
+
+			reconciler := &StorageAuditReconciler{Client: fakeClient, Scheme: fakeClient.Scheme(), Log: logr.Discard()}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "nightly", Namespace: "default"}}
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			audit := &opendepotv1alpha1.StorageAudit{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, audit)).To(Succeed())
+			Expect(audit.Status.FailedVersions).To(Equal(0))
+			Expect(audit.Status.OrphanedObjects).To(Equal(1))
+			Expect(audit.Status.DeletedObjects).To(Equal(0))
+			Expect(audit.Status.Message).To(ContainSubstring("other/unresolved"))
+			_, err = os.Stat(filepath.Join(moduleDir, "orphan.tar.gz"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("storage encryption", func() {
+		It("should store archives again when their key is rotated", func() {
+			directory := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			fileName := "rotated.tar.gz"
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			oldKey := bytes.Repeat([]byte{1}, 32)
+			newKey := []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)))
+
+			storageConfig := &opendepotv1alpha1.StorageConfig{
+				FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory},
+				Encryption: &opendepotv1alpha1.StorageEncryptionConfig{KeyringSecretName: "opendepot-keyring", PrimaryKeyID: "new"},
+			}
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "rotated", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName:        &fileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name, StorageConfig: storageConfig},
+					Type:            opendepotv1alpha1.OpenDepotModule,
+					Version:         "rotated",
+				},
+			}
+
+			filePath := filepath.Join(directory, name, fileName)
+			Expect(os.MkdirAll(filepath.Dir(filePath), 0755)).To(Succeed())
+			oldStorage, err := storage.NewEncryptedStorage(&storage.FileSystem{}, map[string][]byte{"old": oldKey}, "old")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(oldStorage.PutObject(ctx, &storagetypes.StorageObjectInput{FileBytes: archive, FilePath: &filePath})).To(Succeed())
+
+			reconciler := newFakeVersionReconciler(
+				version,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "opendepot-keyring", Namespace: "default"},
+					Data:       map[string][]byte{"old": oldKey, "new": newKey},
+				},
+			)
+
+			soi := &storagetypes.StorageObjectInput{Method: storagetypes.Get, FilePath: &filePath, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, soi)).To(Succeed())
+			Expect(*soi.ObjectChecksum).To(Equal(checksum))
+			Expect(soi.EncryptionKeyStale).To(BeTrue())
+
+			replicas, err := storage.StorageReplicas(storageConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicas[0].StorageConfig.Encryption).NotTo(BeNil())
+			stored, err := reconciler.syncStorageReplica(ctx, version, replicas[0], "default", archive, nil, &checksum)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*stored).To(Equal(checksum))
+
+			soi = &storagetypes.StorageObjectInput{Method: storagetypes.Get, FilePath: &filePath, Version: version}
+			Expect(reconciler.InitStorageFactory(ctx, soi)).To(Succeed())
+			Expect(*soi.ObjectChecksum).To(Equal(checksum))
+			Expect(soi.EncryptionKeyStale).To(BeFalse())
+
+			_, err = oldStorage.GetObject(ctx, soi)
+			Expect(err).To(MatchError(ContainSubstring("'new', which is not in the keyring")))
+		})
+	})
+
+	Context("content-addressed storage", func() {
+		It("should name archives after their checksum when the layout is content-addressed", func() {
+			reconciler := newFakeVersionReconciler()
+
+			checksum := moduleArchiveChecksum([]byte("module archive"))
+			fileName, err := contentAddressedFileName("0192f3a4.tar.gz", checksum)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*fileName).To(MatchRegexp(`^sha256-[0-9a-f]{64}-[0-9a-f-]{36}\.tar\.gz$`))
+
+			providerFileName, err := contentAddressedFileName("0192f3a4.zip", checksum)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*providerFileName).To(MatchRegexp(`^sha256-[0-9a-f]{64}-[0-9a-f-]{36}\.zip$`))
+			Expect(*providerFileName).NotTo(HavePrefix(strings.TrimSuffix(*fileName, ".tar.gz")))
+
+			directory := "/var/opendepot"
+			name := "terraform-aws-example"
+			uuidFileName := "0192f3a4.tar.gz"
+			layout := opendepotv1alpha1.OpenDepotStorageLayoutUUID
+			version := &opendepotv1alpha1.Version{
+				ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: "default"},
+				Spec: opendepotv1alpha1.VersionSpec{
+					FileName: &uuidFileName,
+					ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+						Name: &name,
+						StorageConfig: &opendepotv1alpha1.StorageConfig{
+							FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory},
+							Layout:     &layout,
+						},
+					},
+					Type:    opendepotv1alpha1.OpenDepotModule,
+					Version: "1.0.0",
+				},
+			}
+
+			Expect(reconciler.applyStorageLayout(ctx, version, &checksum)).To(Succeed())
+			Expect(*version.Spec.FileName).To(Equal(uuidFileName))
+
+			layout = opendepotv1alpha1.OpenDepotStorageLayoutContentAddressed
+			Expect(reconciler.applyStorageLayout(ctx, version, &checksum)).To(Succeed())
+			Expect(*version.Spec.FileName).To(MatchRegexp(`^sha256-[0-9a-f]{64}-[0-9a-f-]{36}\.tar\.gz$`))
+			Expect(*version.Spec.FileName).NotTo(Equal(*fileName))
+			contentAddressed := *version.Spec.FileName
+
+			Expect(reconciler.applyStorageLayout(ctx, version, &checksum)).To(Succeed())
+			Expect(*version.Spec.FileName).To(Equal(contentAddressed))
+
+			Expect(reconciler.Create(ctx, version)).To(Succeed())
+			newVersion := func(namespace, directory string) *opendepotv1alpha1.Version {
+				other := version.DeepCopy()
+				other.ObjectMeta = metav1.ObjectMeta{Name: version.Name, Namespace: namespace}
+				other.Spec.FileName = &uuidFileName
+				other.Spec.ModuleConfigRef.StorageConfig.FileSystem.DirectoryPath = &directory
+				return other
+			}
+
+			shared := newVersion("platform", directory)
+			Expect(reconciler.applyStorageLayout(ctx, shared, &checksum)).To(Succeed())
+			Expect(*shared.Spec.FileName).To(Equal(contentAddressed))
+
+			otherBackend := newVersion("platform", "/var/other")
+			Expect(reconciler.applyStorageLayout(ctx, otherBackend, &checksum)).To(Succeed())
+			Expect(*otherBackend.Spec.FileName).NotTo(Equal(contentAddressed))
+
+			otherArchive := newVersion("platform", directory)
+			otherChecksum := moduleArchiveChecksum([]byte("other archive"))
+			Expect(reconciler.applyStorageLayout(ctx, otherArchive, &otherChecksum)).To(Succeed())
+			Expect(*otherArchive.Spec.FileName).NotTo(Equal(contentAddressed))
+
+			layout = "Random"
+			Expect(reconciler.applyStorageLayout(ctx, version, &checksum)).To(MatchError(ContainSubstring("invalid storage layout 'Random'")))
+		})
+
+		It("should only delete a shared archive with the last Version that references it", func() {
+			directory := GinkgoT().TempDir()
+			name := "terraform-aws-example"
+			archive := []byte("module archive")
+			checksum := moduleArchiveChecksum(archive)
+			fileName, err := contentAddressedFileName("0192f3a4.tar.gz", checksum)
+			Expect(err).NotTo(HaveOccurred())
+			layout := opendepotv1alpha1.OpenDepotStorageLayoutContentAddressed
+
+			newVersion := func(namespace string) *opendepotv1alpha1.Version {
+				return &opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:       "terraform-aws-example-1.0.0",
+						Namespace:  namespace,
+						Finalizers: []string{opendepotv1alpha1.OpenDepotFinalizer},
+					},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName: fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+							Name: &name,
+							StorageConfig: &opendepotv1alpha1.StorageConfig{
+								FileSystem: &opendepotv1alpha1.FileSystemConfig{DirectoryPath: &directory},
+								Layout:     &layout,
+							},
+						},
+						Type:    opendepotv1alpha1.OpenDepotModule,
+						Version: "1.0.0",
+					},
+					Status: opendepotv1alpha1.VersionStatus{Checksum: &checksum},
+				}
+			}
+
+			team := newVersion("team")
+			platform := newVersion("platform")
+			reconciler := newFakeVersionReconciler(team, platform)
+
+			filePath := filepath.Join(directory, name, *fileName)
+			Expect(reconciler.InitStorageFactory(ctx, &storagetypes.StorageObjectInput{Method: storagetypes.Put, FileBytes: archive, FilePath: &filePath, Version: team})).To(Succeed())
+
+			soi := &storagetypes.StorageObjectInput{FilePath: &filePath, Version: platform}
+			Expect(reconciler.contentAddressedArchiveStored(ctx, soi, &checksum)).To(BeTrue())
+			Expect(soi.FileExists).To(BeTrue())
+			otherChecksum := moduleArchiveChecksum([]byte("other archive"))
+			Expect(reconciler.contentAddressedArchiveStored(ctx, &storagetypes.StorageObjectInput{FilePath: &filePath, Version: platform}, &otherChecksum)).To(BeFalse())
+
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: team.Name, Namespace: team.Namespace}, team)).To(Succeed())
+			_, err = reconciler.reconcileDeletion(ctx, team)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(team.Finalizers).To(BeEmpty())
+			_, err = os.Stat(filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Delete(ctx, team)).To(Succeed())
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: platform.Name, Namespace: platform.Namespace}, platform)).To(Succeed())
+			_, err = reconciler.reconcileDeletion(ctx, platform)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = os.Stat(filePath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("should only keep a shared archive for Versions storing it in the same physical location", func() {
+			name := "terraform-aws-example"
+			fileName, err := contentAddressedFileName("0192f3a4.tar.gz", moduleArchiveChecksum([]byte("module archive")))
+			Expect(err).NotTo(HaveOccurred())
+			layout := opendepotv1alpha1.OpenDepotStorageLayoutContentAddressed
+
+			newVersion := func(namespace, endpoint string) *opendepotv1alpha1.Version {
+				return &opendepotv1alpha1.Version{
+					ObjectMeta: metav1.ObjectMeta{Name: "terraform-aws-example-1.0.0", Namespace: namespace},
+					Spec: opendepotv1alpha1.VersionSpec{
+						FileName: fileName,
+						ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{
+							Name: &name,
+							StorageConfig: &opendepotv1alpha1.StorageConfig{
+								S3:     &opendepotv1alpha1.AmazonS3Config{Bucket: "opendepot", Region: "us-east-1", Endpoint: &endpoint},
+								Layout: &layout,
+							},
+						},
+						Type:    opendepotv1alpha1.OpenDepotModule,
+						Version: "1.0.0",
+					},
+				}
+			}
+
+			team := newVersion("team", "https://minio-a.example.com")
+			filePath, err := versionFilePath(team, team.Spec.ModuleConfigRef.StorageConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler := newFakeVersionReconciler(team, newVersion("platform", "https://minio-b.example.com"))
+			referenced, err := reconciler.archiveReferenced(ctx, team, team.Spec.ModuleConfigRef.StorageConfig, *filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(referenced).To(BeFalse())
+
+			reconciler = newFakeVersionReconciler(team, newVersion("platform", "https://minio-a.example.com/"))
+			referenced, err = reconciler.archiveReferenced(ctx, team, team.Spec.ModuleConfigRef.StorageConfig, *filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(referenced).To(BeTrue())
+		})
+	})
 })
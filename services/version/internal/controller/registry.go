@@ -20,13 +20,14 @@ import (
 	"context"
 	"fmt"
 	"strings"
-)
 
-const (
-	openTofuDocsAPI     = "https://api.opentofu.org"
-	openTofuRegistryAPI = "https://registry.opentofu.org"
+	"github.com/tonedefdev/opendepot/pkg/registry"
 )
 
+// openTofuDocsAPI is the OpenTofu registry docs API. It is only queried for providers mirrored from the
+// OpenTofu registry, as it is not part of the provider registry protocol.
+const openTofuDocsAPI = "https://api.opentofu.org"
+
 // openTofuProviderResponse is the subset of the OpenTofu registry docs API provider response used here.
 type openTofuProviderResponse struct {
 	Link string `json:"link"`
@@ -53,35 +54,18 @@ func lookupProviderRepo(ctx context.Context, namespace, name string) (string, er
 	return strings.TrimSpace(resp.Link), nil
 }
 
-// openTofuRegistryDownload holds the fields from an OpenTofu registry provider download response.
-// See: https://opentofu.org/docs/internals/provider-registry-protocol/#find-a-provider-package
-type openTofuRegistryDownload struct {
-	DownloadURL string `json:"download_url"`
-	Filename    string `json:"filename"`
-	Shasum      string `json:"shasum"`
-}
-
-// lookupProviderDownload queries the OpenTofu registry for a specific provider version's download metadata.
-func lookupProviderDownload(ctx context.Context, namespace, name, version, os, arch string) (*openTofuRegistryDownload, error) {
-	endpoint := fmt.Sprintf("%s/v1/providers/%s/%s/%s/download/%s/%s",
-		openTofuRegistryAPI,
-		strings.ToLower(strings.TrimSpace(namespace)),
-		strings.ToLower(strings.TrimSpace(name)),
-		strings.TrimPrefix(strings.TrimSpace(version), "v"),
-		strings.ToLower(strings.TrimSpace(os)),
-		strings.ToLower(strings.TrimSpace(arch)),
-	)
-
-	var resp openTofuRegistryDownload
-	if err := httpGetJSON(ctx, endpoint, &resp); err != nil {
-		return nil, fmt.Errorf("opentofu registry download lookup for %s/%s@%s (%s/%s) failed: %w",
+// lookupProviderDownload queries the upstream registry for a specific provider version's download metadata.
+func lookupProviderDownload(ctx context.Context, registryClient *registry.Client, namespace, name, version, os, arch string) (*registry.ProviderDownload, error) {
+	download, err := registryClient.GetProviderDownload(ctx, namespace, name, version, os, arch)
+	if err != nil {
+		return nil, fmt.Errorf("registry download lookup for %s/%s@%s (%s/%s) failed: %w",
 			namespace, name, version, os, arch, err)
 	}
 
-	if strings.TrimSpace(resp.DownloadURL) == "" {
-		return nil, fmt.Errorf("opentofu registry returned empty download_url for %s/%s@%s (%s/%s)",
-			namespace, name, version, os, arch)
+	if strings.TrimSpace(download.DownloadURL) == "" {
+		return nil, fmt.Errorf("registry '%s' returned empty download_url for %s/%s@%s (%s/%s)",
+			registryClient.BaseURL, namespace, name, version, os, arch)
 	}
 
-	return &resp, nil
+	return download, nil
 }
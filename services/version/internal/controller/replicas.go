@@ -0,0 +1,198 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+// replicaRetryInterval is how long the Version controller waits before retrying replicas that failed to sync.
+const replicaRetryInterval = 5 * time.Minute
+
+// syncStorageReplicas copies the archive of version to every replica of its storage config that is missing it or
+// holds other content, and returns the state of each replica including the primary. Nil is returned when the storage
+// config has no replicas. A replica that cannot be synced is reported in its status instead of failing the Version,
+// so downloads keep being served from the healthy replicas.
+func (r *VersionReconciler) syncStorageReplicas(ctx context.Context, version *opendepotv1alpha1.Version, fileBytes []byte, fileReader io.ReadSeeker, checksum *string) ([]opendepotv1alpha1.StorageReplicaStatus, error) {
+	storageConfig, secretNamespace, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(storageConfig.Replicas) == 0 {
+		return nil, nil
+	}
+
+	replicas, err := storage.StorageReplicas(storageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]opendepotv1alpha1.StorageReplicaStatus, 0, len(replicas))
+	for _, replica := range replicas {
+		status := opendepotv1alpha1.StorageReplicaStatus{Name: replica.Name}
+		replicaChecksum, err := r.syncStorageReplica(ctx, version, replica, secretNamespace, fileBytes, fileReader, checksum)
+		if err != nil {
+			r.Log.Info("unable to sync storage replica", "version", version.Name, "replica", replica.Name, "error", err.Error())
+			status.SyncStatus = fmt.Sprintf("Failed to sync replica: %v", err)
+		} else {
+			status.Checksum = replicaChecksum
+			status.Synced = true
+			status.SyncStatus = "Successfully synced replica"
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// syncStorageReplica uploads the archive to replica unless it already holds an archive with checksum encrypted with
+// the primary key of the keyring, and returns the checksum of the archive stored in the replica.
+func (r *VersionReconciler) syncStorageReplica(ctx context.Context, version *opendepotv1alpha1.Version, replica storage.StorageReplica, secretNamespace string, fileBytes []byte, fileReader io.ReadSeeker, checksum *string) (*string, error) {
+	filePath, err := versionFilePath(version, replica.StorageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	storageInterface, err := r.newStorage(ctx, secretNamespace, replica.StorageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	soi := &types.StorageObjectInput{
+		Method:        types.Get,
+		FilePath:      filePath,
+		Version:       version,
+		StorageConfig: replica.StorageConfig,
+	}
+
+	if err := RunStorageFactory(ctx, storageInterface, soi); err != nil {
+		return nil, err
+	}
+
+	if soi.FileExists && !soi.EncryptionKeyStale && soi.ObjectChecksum != nil && (checksum == nil || *soi.ObjectChecksum == *checksum) {
+		return soi.ObjectChecksum, nil
+	}
+
+	if len(fileBytes) == 0 && fileReader == nil {
+		return nil, fmt.Errorf("the archive is missing from the replica and no bytes are available to copy")
+	}
+
+	if fileReader != nil {
+		if _, err := fileReader.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek provider archive: %w", err)
+		}
+	}
+
+	soi = &types.StorageObjectInput{
+		Method:        types.Put,
+		FileBytes:     fileBytes,
+		FileReader:    fileReader,
+		FilePath:      filePath,
+		Version:       version,
+		StorageConfig: replica.StorageConfig,
+	}
+
+	if err := RunStorageFactory(ctx, storageInterface, soi); err != nil {
+		return nil, err
+	}
+
+	return checksum, nil
+}
+
+// storageReplicasSynced reports whether every replica of the Version's storage config holds the archive recorded in
+// its status. Versions whose storage config has no replicas are always synced.
+func (r *VersionReconciler) storageReplicasSynced(ctx context.Context, version *opendepotv1alpha1.Version) bool {
+	storageConfig, _, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil || len(storageConfig.Replicas) == 0 {
+		return err == nil
+	}
+
+	replicas, err := storage.StorageReplicas(storageConfig)
+	if err != nil {
+		return false
+	}
+
+	for _, replica := range replicas {
+		if !slices.ContainsFunc(version.Status.Replicas, func(status opendepotv1alpha1.StorageReplicaStatus) bool {
+			return status.Name == replica.Name && status.Synced && status.Checksum != nil &&
+				version.Status.Checksum != nil && *status.Checksum == *version.Status.Checksum
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deleteStorageReplicas deletes the archive of version from every replica of its storage config but the primary.
+func (r *VersionReconciler) deleteStorageReplicas(ctx context.Context, version *opendepotv1alpha1.Version) error {
+	storageConfig, secretNamespace, err := r.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	if len(storageConfig.Replicas) == 0 {
+		return nil
+	}
+
+	replicas, err := storage.StorageReplicas(storageConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, replica := range replicas[1:] {
+		filePath, err := versionFilePath(version, replica.StorageConfig)
+		if err != nil {
+			return err
+		}
+
+		referenced, err := r.archiveReferenced(ctx, version, replica.StorageConfig, *filePath)
+		if err != nil {
+			return err
+		}
+
+		if referenced {
+			continue
+		}
+
+		storageInterface, err := r.newStorage(ctx, secretNamespace, replica.StorageConfig)
+		if err != nil {
+			return err
+		}
+
+		if err := RunStorageFactory(ctx, storageInterface, &types.StorageObjectInput{
+			Method:        types.Delete,
+			FilePath:      filePath,
+			Version:       version,
+			StorageConfig: replica.StorageConfig,
+		}); err != nil {
+			return fmt.Errorf("unable to delete the archive from storage replica '%s': %w", replica.Name, err)
+		}
+	}
+
+	return nil
+}
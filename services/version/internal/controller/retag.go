@@ -0,0 +1,194 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+const (
+	// maxDigestHistory is the number of replaced module archives kept in Version.status.digestHistory.
+	maxDigestHistory = 10
+
+	// tagMovedReason is the reason of the events emitted when the tag of a module version moves.
+	tagMovedReason = "TagMoved"
+	// tagRestoredReason is the reason of the TagMoved condition once the tag points back to the served commit.
+	tagRestoredReason = "Restored"
+	// tagAcceptedReason is the reason of the TagMoved condition once the content of a moved tag is served.
+	tagAcceptedReason = "Accepted"
+	// tagRefusedReason is the reason of the TagMoved condition while the content of a moved tag is refused.
+	tagRefusedReason = "Refused"
+	// tagQuarantinedReason is the reason of the TagMoved condition while the content of a moved tag is quarantined.
+	tagQuarantinedReason = "Quarantined"
+)
+
+// moduleRetagPolicy returns the retag policy of a module config. It defaults to 'Refuse' for immutable modules
+// and to 'Accept' otherwise.
+func moduleRetagPolicy(moduleConfig *opendepotv1alpha1.ModuleConfig) (string, error) {
+	if moduleConfig.RetagPolicy == nil || *moduleConfig.RetagPolicy == "" {
+		if moduleConfig.Immutable != nil && *moduleConfig.Immutable {
+			return opendepotv1alpha1.OpenDepotRetagPolicyRefuse, nil
+		}
+		return opendepotv1alpha1.OpenDepotRetagPolicyAccept, nil
+	}
+
+	switch *moduleConfig.RetagPolicy {
+	case opendepotv1alpha1.OpenDepotRetagPolicyAccept,
+		opendepotv1alpha1.OpenDepotRetagPolicyQuarantine,
+		opendepotv1alpha1.OpenDepotRetagPolicyRefuse:
+		return *moduleConfig.RetagPolicy, nil
+	}
+
+	return "", fmt.Errorf("invalid retagPolicy '%s': must be one of 'Refuse', 'Quarantine' or 'Accept'", *moduleConfig.RetagPolicy)
+}
+
+// setTagMovedCondition sets the TagMoved condition of a Version and emits an event when the condition changes.
+func (r *VersionReconciler) setTagMovedCondition(version *opendepotv1alpha1.Version, status metav1.ConditionStatus, reason, message string) {
+	existing := meta.FindStatusCondition(version.Status.Conditions, opendepotv1alpha1.OpenDepotConditionTagMoved)
+	changed := existing == nil || existing.Status != status || existing.Reason != reason || existing.Message != message
+
+	meta.SetStatusCondition(&version.Status.Conditions, metav1.Condition{
+		Type:               opendepotv1alpha1.OpenDepotConditionTagMoved,
+		Status:             status,
+		ObservedGeneration: version.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	if !changed || r.Recorder == nil {
+		return
+	}
+
+	eventType := corev1.EventTypeWarning
+	if status == metav1.ConditionFalse {
+		eventType = corev1.EventTypeNormal
+	}
+
+	r.Recorder.Eventf(version, nil, eventType, tagMovedReason, reason, "%s", message)
+}
+
+// tagMovedMessage describes a tag that moved from the served commit to another commit.
+func tagMovedMessage(version *opendepotv1alpha1.Version, moduleTag *opendepotGithub.ModuleTag, action string) string {
+	return fmt.Sprintf("tag '%s' moved from commit %s to %s: %s", moduleTag.Name, version.Status.Source.CommitSHA, moduleTag.CommitSHA, action)
+}
+
+// quarantineFileName returns the file name the content of a moved tag is quarantined under.
+func quarantineFileName(fileName, commitSHA string) string {
+	if len(commitSHA) > 12 {
+		commitSHA = commitSHA[:12]
+	}
+
+	return fmt.Sprintf("quarantine-%s-%s", commitSHA, fileName)
+}
+
+// quarantineModuleArchive stores the content of a moved tag under a quarantine file name next to the served archive
+// and records it in Version.status.quarantined. Content that was quarantined for an earlier commit is removed.
+func (r *VersionReconciler) quarantineModuleArchive(ctx context.Context, version *opendepotv1alpha1.Version, moduleTag *opendepotGithub.ModuleTag, moduleBytes []byte, checksum, fetchedAt string) error {
+	if version.Status.Quarantined != nil && version.Status.Quarantined.CommitSHA == moduleTag.CommitSHA {
+		return nil
+	}
+
+	if err := r.deleteQuarantinedArchive(ctx, version); err != nil {
+		return err
+	}
+
+	fileName := quarantineFileName(*version.Spec.FileName, moduleTag.CommitSHA)
+	quarantined := version.DeepCopy()
+	quarantined.Spec.FileName = &fileName
+
+	filePath, err := r.getVersionFilePath(ctx, quarantined)
+	if err != nil {
+		return err
+	}
+
+	if err := r.InitStorageFactory(ctx, &types.StorageObjectInput{
+		Method:    types.Put,
+		FileBytes: moduleBytes,
+		FilePath:  filePath,
+		Version:   quarantined,
+	}); err != nil {
+		return fmt.Errorf("unable to store quarantined module archive: %w", err)
+	}
+
+	version.Status.Quarantined = &opendepotv1alpha1.ModuleVersionDigest{
+		CommitSHA:  moduleTag.CommitSHA,
+		Checksum:   checksum,
+		FetchedAt:  fetchedAt,
+		RecordedAt: fetchedAt,
+		FileName:   &fileName,
+	}
+
+	return nil
+}
+
+// deleteQuarantinedArchive removes the quarantined content recorded in Version.status.quarantined, if any.
+func (r *VersionReconciler) deleteQuarantinedArchive(ctx context.Context, version *opendepotv1alpha1.Version) error {
+	if version.Status.Quarantined == nil || version.Status.Quarantined.FileName == nil {
+		version.Status.Quarantined = nil
+		return nil
+	}
+
+	quarantined := version.DeepCopy()
+	quarantined.Spec.FileName = version.Status.Quarantined.FileName
+
+	storageConfig, _, err := r.getVersionStorageConfig(ctx, quarantined)
+	if err != nil {
+		return err
+	}
+
+	filePath, err := versionFilePath(quarantined, storageConfig)
+	if err != nil {
+		return err
+	}
+
+	referenced, err := r.archiveReferenced(ctx, quarantined, storageConfig, *filePath)
+	if err != nil {
+		return err
+	}
+
+	if !referenced {
+		if err := r.InitStorageFactory(ctx, &types.StorageObjectInput{
+			Method:   types.Delete,
+			FilePath: filePath,
+			Version:  quarantined,
+		}); err != nil {
+			return fmt.Errorf("unable to delete quarantined module archive: %w", err)
+		}
+	}
+
+	version.Status.Quarantined = nil
+	return nil
+}
+
+// appendDigestHistory records a replaced module archive, keeping at most maxDigestHistory entries.
+func appendDigestHistory(history []opendepotv1alpha1.ModuleVersionDigest, digest opendepotv1alpha1.ModuleVersionDigest) []opendepotv1alpha1.ModuleVersionDigest {
+	history = append(history, digest)
+	if len(history) > maxDigestHistory {
+		history = history[len(history)-maxDigestHistory:]
+	}
+
+	return history
+}
@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -37,6 +38,7 @@ import (
 	"github.com/google/go-github/v81/github"
 	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
 	opendepotGithub "github.com/tonedefdev/opendepot/pkg/github"
+	"github.com/tonedefdev/opendepot/pkg/registry"
 )
 
 // trivyVulnerability is the subset of Trivy's per-vulnerability JSON output used here.
@@ -136,8 +138,9 @@ func parseTrivyReport(data []byte, filter func(trivyResult) bool) ([]opendepotv1
 
 // resolveProviderSourceRepository returns the VCS source URL for a provider.
 // If ProviderConfig.SourceRepository is set it is used directly (explicit override).
-// Otherwise the OpenTofu registry docs API (api.opentofu.org) is queried for the provider's
-// repository link. If that lookup fails, a heuristic URL is derived from the namespace and name.
+// Otherwise, for providers mirrored from the OpenTofu registry, the OpenTofu registry docs API
+// (api.opentofu.org) is queried for the provider's repository link. If that lookup fails or the
+// provider comes from another registry, a heuristic URL is derived from the namespace and name.
 func resolveProviderSourceRepository(ctx context.Context, namespace, providerName string, cfg *opendepotv1alpha1.ProviderConfig) string {
 	if cfg != nil && cfg.SourceRepository != nil && strings.TrimSpace(*cfg.SourceRepository) != "" {
 		return strings.TrimSpace(*cfg.SourceRepository)
@@ -147,9 +150,16 @@ func resolveProviderSourceRepository(ctx context.Context, namespace, providerNam
 		namespace = "hashicorp"
 	}
 
-	repoURL, err := lookupProviderRepo(ctx, namespace, providerName)
-	if err == nil && repoURL != "" {
-		return repoURL
+	var registryHost string
+	if cfg != nil && cfg.Registry != nil {
+		registryHost = *cfg.Registry
+	}
+
+	if registry.NewClient(registryHost, "").IsDefaultHost() {
+		repoURL, err := lookupProviderRepo(ctx, namespace, providerName)
+		if err == nil && repoURL != "" {
+			return repoURL
+		}
 	}
 
 	// Fall back to heuristic — scan degrades gracefully rather than blocking sync.
@@ -200,14 +210,29 @@ func extractBinaryFromZip(archivePath string) ([]byte, error) {
 
 // downloadGoMod fetches go.mod for a given provider version from its GitHub source repository
 // using the provided GitHub client (authenticated or unauthenticated). The repoURL must be a
-// https://github.com/owner/repo URL; version should be bare (no leading v).
+// https://<host>/owner/repo URL, where host is github.com or a GitHub Enterprise Server instance;
+// version should be bare (no leading v).
 func downloadGoMod(ctx context.Context, repoURL, version string, githubClient *github.Client) ([]byte, error) {
-	trimmed := strings.TrimSuffix(strings.TrimPrefix(repoURL, "https://github.com/"), "/")
+	owner, repo, err := parseRepositoryURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	return opendepotGithub.GetProviderGoMod(ctx, githubClient, owner, repo, version)
+}
+
+// parseRepositoryURL returns the owner and repository name from a https://<host>/owner/repo URL.
+func parseRepositoryURL(repoURL string) (owner, repo string, err error) {
+	parsedURL, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot parse owner/repo from URL %q: %w", repoURL, err)
+	}
+
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(parsedURL.Path, "/"), "/")
 	parts := strings.SplitN(trimmed, "/", 2)
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return nil, fmt.Errorf("cannot parse owner/repo from URL %q", repoURL)
+		return "", "", fmt.Errorf("cannot parse owner/repo from URL %q", repoURL)
 	}
-	return opendepotGithub.GetProviderGoMod(ctx, githubClient, parts[0], parts[1], version)
+	return parts[0], parts[1], nil
 }
 
 // scanProviderBinary runs `trivy rootfs` against the provider executable extracted
@@ -387,19 +412,20 @@ func (r *VersionReconciler) runProviderScan(
 		return binaryScan, nil
 	}
 
-	useAuthClient := version.Spec.ProviderConfigRef != nil &&
-		version.Spec.ProviderConfigRef.GithubClientConfig != nil &&
-		version.Spec.ProviderConfigRef.GithubClientConfig.UseAuthenticatedClient
+	var specGithubCfg *opendepotv1alpha1.GithubClientConfig
+	if version.Spec.ProviderConfigRef != nil {
+		specGithubCfg = version.Spec.ProviderConfigRef.GithubClientConfig
+	}
 
-	var githubCfg *opendepotGithub.GithubClientConfig
-	if useAuthClient {
-		var cfgErr error
-		githubCfg, cfgErr = opendepotGithub.GetGithubApplicationSecret(ctx, r.Client, version.Namespace)
-		if cfgErr != nil {
-			r.Log.Error(cfgErr, "Failed to load GitHub App secret — falling back to unauthenticated source scan",
-				"provider", providerName)
-			useAuthClient = false
-		}
+	useAuthClient := specGithubCfg != nil && specGithubCfg.UseAuthenticatedClient
+
+	repoOwner, _, _ := parseRepositoryURL(repoURL)
+	githubCfg, cfgErr := opendepotGithub.GetGithubClientConfig(ctx, r.Client, version.Namespace, repoOwner, specGithubCfg)
+	if cfgErr != nil {
+		r.Log.Error(cfgErr, "Failed to load GitHub credentials secret — falling back to unauthenticated source scan",
+			"provider", providerName)
+		githubCfg = opendepotGithub.NewGithubClientConfig(specGithubCfg)
+		useAuthClient = false
 	}
 
 	ghClient, ghErr := opendepotGithub.CreateGithubClient(ctx, useAuthClient, githubCfg)
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/registry"
+)
+
+const (
+	// signingKeySourceRegistry marks keys taken from the upstream registry's download response.
+	signingKeySourceRegistry = "Registry"
+	// signingKeySourceSecret marks keys pinned in the Secret named by the provider config's signingKeysSecretName.
+	signingKeySourceSecret = "Secret"
+
+	armoredPublicKeyHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	armoredSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+)
+
+// errSignatureVerification wraps every failure to verify a provider package's SHA256SUMS signature,
+// so the reconciler can refuse ingest instead of treating it as a download error.
+var errSignatureVerification = errors.New("provider signature verification failed")
+
+// verifyProviderShasums downloads the upstream SHA256SUMS of a provider package and its detached signature,
+// verifies the signature against the trusted signing keys, and checks that SHA256SUMS lists checksumHex for
+// fileName. The returned verification is populated whether or not an error is returned.
+func (r *VersionReconciler) verifyProviderShasums(ctx context.Context, registryClient *registry.Client, version *opendepotv1alpha1.Version, download *registry.ProviderDownload, fileName, checksumHex string) (*opendepotv1alpha1.ProviderSignatureVerification, error) {
+	verification := &opendepotv1alpha1.ProviderSignatureVerification{
+		VerifiedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	fail := func(err error) (*opendepotv1alpha1.ProviderSignatureVerification, error) {
+		message := err.Error()
+		verification.Message = &message
+		return verification, fmt.Errorf("%w: %w", errSignatureVerification, err)
+	}
+
+	keyring, keySource, err := r.getProviderSigningKeys(ctx, version, download)
+	verification.KeySource = keySource
+	if err != nil {
+		return fail(err)
+	}
+	verification.TrustedKeyIDs = signingKeyIDs(keyring)
+
+	if strings.TrimSpace(download.ShasumsURL) == "" || strings.TrimSpace(download.ShasumsSignatureURL) == "" {
+		return fail(fmt.Errorf("registry '%s' did not return shasums_url and shasums_signature_url for '%s'", registryClient.BaseURL, fileName))
+	}
+
+	shasums, err := registryClient.GetFile(ctx, download.ShasumsURL)
+	if err != nil {
+		return fail(fmt.Errorf("unable to download SHA256SUMS: %w", err))
+	}
+
+	signature, err := registryClient.GetFile(ctx, download.ShasumsSignatureURL)
+	if err != nil {
+		return fail(fmt.Errorf("unable to download SHA256SUMS signature: %w", err))
+	}
+
+	signer, err := checkDetachedSignature(keyring, shasums, signature)
+	if err != nil {
+		return fail(fmt.Errorf("SHA256SUMS is not signed by a trusted key: %w", err))
+	}
+
+	signedBy := fmt.Sprintf("%016X", signer.PrimaryKey.KeyId)
+	verification.SignedByKeyID = &signedBy
+
+	if !shasumsContains(shasums, fileName, checksumHex) {
+		return fail(fmt.Errorf("SHA256SUMS does not list checksum %s for '%s'", checksumHex, fileName))
+	}
+
+	verification.Verified = true
+	return verification, nil
+}
+
+// getProviderSigningKeys returns the keys trusted to sign a provider package's SHA256SUMS and where they came from.
+// Keys pinned in the provider config's signing keys Secret take precedence over the keys in the registry response.
+func (r *VersionReconciler) getProviderSigningKeys(ctx context.Context, version *opendepotv1alpha1.Version, download *registry.ProviderDownload) (openpgp.EntityList, string, error) {
+	providerConfig := version.Spec.ProviderConfigRef
+	if providerConfig.SigningKeysSecretName != nil && strings.TrimSpace(*providerConfig.SigningKeysSecretName) != "" {
+		object := client.ObjectKey{
+			Name:      strings.TrimSpace(*providerConfig.SigningKeysSecretName),
+			Namespace: version.Namespace,
+		}
+
+		secret := corev1.Secret{}
+		if err := r.Get(ctx, object, &secret); err != nil {
+			return nil, signingKeySourceSecret, fmt.Errorf("failed to get signing keys secret '%s': %w", object.Name, err)
+		}
+
+		keyring, err := readArmoredKeyRing(secret.Data[opendepotv1alpha1.OpenDepotSigningKeysSecretDataField])
+		if err != nil {
+			return nil, signingKeySourceSecret, fmt.Errorf("invalid '%s' field in signing keys secret '%s': %w", opendepotv1alpha1.OpenDepotSigningKeysSecretDataField, object.Name, err)
+		}
+
+		return keyring, signingKeySourceSecret, nil
+	}
+
+	var armored bytes.Buffer
+	for _, key := range download.SigningKeys.GPGPublicKeys {
+		armored.WriteString(key.ASCIIArmor)
+		armored.WriteString("\n")
+	}
+
+	keyring, err := readArmoredKeyRing(armored.Bytes())
+	if err != nil {
+		return nil, signingKeySourceRegistry, fmt.Errorf("invalid signing keys in registry response: %w", err)
+	}
+
+	return keyring, signingKeySourceRegistry, nil
+}
+
+// readArmoredKeyRing parses every ASCII armored public key block in data into a single keyring.
+func readArmoredKeyRing(data []byte) (openpgp.EntityList, error) {
+	blocks := strings.Split(string(data), armoredPublicKeyHeader)
+
+	var keyring openpgp.EntityList
+	for _, block := range blocks[1:] {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPublicKeyHeader + block))
+		if err != nil {
+			return nil, err
+		}
+
+		keyring = append(keyring, entities...)
+	}
+
+	if len(keyring) == 0 {
+		return nil, fmt.Errorf("no public keys found")
+	}
+
+	return keyring, nil
+}
+
+// signingKeyIDs returns the primary key IDs of keyring as 16 character uppercase hex strings.
+func signingKeyIDs(keyring openpgp.EntityList) []string {
+	keyIDs := make([]string, 0, len(keyring))
+	for _, entity := range keyring {
+		keyIDs = append(keyIDs, fmt.Sprintf("%016X", entity.PrimaryKey.KeyId))
+	}
+
+	return keyIDs
+}
+
+// checkDetachedSignature verifies a binary or ASCII armored detached signature of signed against keyring.
+func checkDetachedSignature(keyring openpgp.EntityList, signed, signature []byte) (*openpgp.Entity, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(armoredSignatureHeader)) {
+		return openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+	}
+
+	return openpgp.CheckDetachedSignature(keyring, bytes.NewReader(signed), bytes.NewReader(signature), nil)
+}
+
+// shasumsContains reports whether a SHA256SUMS file lists checksumHex for fileName.
+func shasumsContains(shasums []byte, fileName, checksumHex string) bool {
+	for _, line := range strings.Split(string(shasums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == fileName && strings.EqualFold(fields[0], checksumHex) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+)
+
+// storageResourceGetter reads the resources storage configs refer to with the reconciler's client.
+type storageResourceGetter struct {
+	client.Reader
+}
+
+// GetSecretData returns the data of the Secret name in namespace.
+func (g storageResourceGetter) GetSecretData(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := g.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		return nil, err
+	}
+
+	return secret.Data, nil
+}
+
+// GetDefaultStorageProfileName returns the StorageProfile named by the 'opendepot.defdev.io/storage-profile'
+// annotation of namespace.
+func (g storageResourceGetter) GetDefaultStorageProfileName(ctx context.Context, namespace string) (string, error) {
+	namespaceResource := &corev1.Namespace{}
+	if err := g.Get(ctx, client.ObjectKey{Name: namespace}, namespaceResource); err != nil {
+		return "", err
+	}
+
+	return namespaceResource.Annotations[opendepotv1alpha1.OpenDepotStorageProfileAnnotation], nil
+}
+
+// GetStorageProfile returns the StorageProfile named name.
+func (g storageResourceGetter) GetStorageProfile(ctx context.Context, name string) (*opendepotv1alpha1.StorageProfile, error) {
+	profile := &opendepotv1alpha1.StorageProfile{}
+	if err := g.Get(ctx, client.ObjectKey{Name: name}, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// getVersionStorageConfig resolves the storage config of a Version and the namespace the Secrets it names are read
+// from.
+func (r *VersionReconciler) getVersionStorageConfig(ctx context.Context, version *opendepotv1alpha1.Version) (*opendepotv1alpha1.StorageConfig, string, error) {
+	return storage.VersionStorageConfig(ctx, storageResourceGetter{r.Client}, version)
+}
+
+// newStorage initializes a client for the backend configured on storageConfig, reading the Secrets it names from
+// secretNamespace. The client encrypts archives when storageConfig configures encryption.
+func (r *VersionReconciler) newStorage(ctx context.Context, secretNamespace string, storageConfig *opendepotv1alpha1.StorageConfig) (storage.Storage, error) {
+	return storage.NewStorage(ctx, storageResourceGetter{r.Client}, secretNamespace, storageConfig)
+}
@@ -0,0 +1,628 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+const (
+	// defaultStorageAuditIntervalMinutes is the interval between audits when a StorageAudit does not set one.
+	defaultStorageAuditIntervalMinutes = 1440
+	// defaultOrphanGracePeriodMinutes is how long an orphaned object must go unmodified before it is deleted when a
+	// StorageAudit does not set a grace period.
+	defaultOrphanGracePeriodMinutes = 1440
+	// storageAuditMaxReported is the number of findings and orphans listed in the status of a StorageAudit.
+	storageAuditMaxReported = 100
+
+	storageIntegrityVerifiedReason = "ArchiveVerified"
+	storageIntegrityRepairedReason = "ArchiveRepaired"
+)
+
+// StorageAuditReconciler reconciles a StorageAudit object.
+type StorageAuditReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// auditBackend is a storage backend used by the Versions seen during an audit.
+type auditBackend struct {
+	storageConfig   *opendepotv1alpha1.StorageConfig
+	secretNamespace string
+	// The names of the modules and providers whose prefix is listed for orphans.
+	names map[string]bool
+	// The stored paths of the objects referenced by Versions, in the form ListObjects returns them.
+	referenced map[string]bool
+}
+
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=storageaudits,verbs=get;list;watch
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=storageaudits/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions,verbs=get;list
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions/status,verbs=get;update
+
+// Reconcile audits the archives of the Versions selected by a StorageAudit every interval, and again whenever its spec
+// changes. Each archive is checked in every replica of the Version's storage config against the Version's checksum,
+// and the prefix of each module and provider is listed for objects that no Version references.
+func (r *StorageAuditReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	audit := &opendepotv1alpha1.StorageAudit{}
+	if err := r.Get(ctx, req.NamespacedName, audit); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	interval := time.Duration(minutesOrDefault(audit.Spec.IntervalMinutes, defaultStorageAuditIntervalMinutes)) * time.Minute
+	if audit.Status.ObservedGeneration == audit.Generation && audit.Status.LastAuditAt != "" {
+		lastAuditAt, err := time.Parse(time.RFC3339, audit.Status.LastAuditAt)
+		if err == nil && time.Since(lastAuditAt) < interval {
+			return ctrl.Result{RequeueAfter: interval - time.Since(lastAuditAt)}, nil
+		}
+	}
+
+	status, err := r.runStorageAudit(ctx, audit)
+	if err != nil {
+		audit.Status.Message = fmt.Sprintf("Failed to audit storage: %v", err)
+		_ = r.updateStorageAuditStatus(ctx, audit)
+		return ctrl.Result{}, err
+	}
+
+	status.ObservedGeneration = audit.Generation
+	status.LastAuditAt = time.Now().UTC().Format(time.RFC3339)
+	audit.Status = *status
+	if err := r.updateStorageAuditStatus(ctx, audit); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// runStorageAudit audits the Versions selected by audit and returns the result. Objects are only considered orphaned
+// when no Version the controller can list references them, so Versions in every namespace are read.
+func (r *StorageAuditReconciler) runStorageAudit(ctx context.Context, audit *opendepotv1alpha1.StorageAudit) (*opendepotv1alpha1.StorageAuditStatus, error) {
+	versions := &VersionReconciler{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
+	status := &opendepotv1alpha1.StorageAuditStatus{}
+
+	allVersions := &opendepotv1alpha1.VersionList{}
+	if err := r.List(ctx, allVersions); err != nil {
+		return nil, err
+	}
+
+	backends := map[string]*auditBackend{}
+	var unresolved []string
+	for i := range allVersions.Items {
+		version := &allVersions.Items[i]
+		if version.Spec.FileName == nil {
+			continue
+		}
+
+		if err := referenceVersionObjects(ctx, versions, version, backends); err != nil {
+			unresolved = append(unresolved, fmt.Sprintf("%s/%s", version.Namespace, version.Name))
+			r.Log.Info("unable to resolve the stored objects of version", "storageAudit", audit.Name, "version", version.Name, "error", err.Error())
+		}
+	}
+
+	selected, err := listSelectedVersions(ctx, r.Client, audit.Namespace, audit.Spec.VersionSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range selected {
+		version := &selected[i]
+		if version.Spec.FileName == nil || version.Status.Checksum == nil {
+			continue
+		}
+
+		storageConfig, secretNamespace, err := versions.getVersionStorageConfig(ctx, version)
+		if err != nil {
+			continue
+		}
+
+		name, err := getVersionName(version)
+		if err != nil {
+			continue
+		}
+
+		replicas, err := storage.StorageReplicas(storageConfig)
+		if err != nil {
+			continue
+		}
+
+		for _, replica := range replicas {
+			backend := backends[auditBackendKey(replica.StorageConfig)]
+			if backend != nil {
+				if backend.secretNamespace == "" {
+					backend.secretNamespace = secretNamespace
+				}
+				backend.names[*name] = true
+			}
+		}
+
+		status.CheckedVersions++
+		findings := auditVersion(ctx, versions, version, replicas, secretNamespace, audit.Spec.Repair && !audit.Spec.DryRun)
+		if slices.ContainsFunc(findings, func(finding opendepotv1alpha1.StorageAuditFinding) bool { return !finding.Repaired }) {
+			status.FailedVersions++
+		}
+
+		for _, finding := range findings {
+			if len(status.Findings) < storageAuditMaxReported {
+				status.Findings = append(status.Findings, finding)
+			}
+		}
+
+		if !audit.Spec.DryRun {
+			if err := r.flagVersion(ctx, version, findings, audit.Spec.Repair); err != nil {
+				return nil, fmt.Errorf("failed to update the status of version '%s': %w", version.Name, err)
+			}
+		}
+	}
+
+	deleteOrphans := audit.Spec.DeleteOrphans && !audit.Spec.DryRun && len(unresolved) == 0
+	gracePeriod := time.Duration(minutesOrDefault(audit.Spec.OrphanGracePeriodMinutes, defaultOrphanGracePeriodMinutes)) * time.Minute
+
+	keys := make([]string, 0, len(backends))
+	for key := range backends {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		backend := backends[key]
+		if len(backend.names) == 0 {
+			continue
+		}
+
+		orphans, deleted, err := auditBackendOrphans(ctx, versions, backend, deleteOrphans, gracePeriod)
+		if err != nil {
+			r.Log.Info("unable to list objects for orphans", "storageAudit", audit.Name, "backend", storageBackendName(backend.storageConfig), "error", err.Error())
+		}
+
+		status.OrphanedObjects += len(orphans)
+		status.DeletedObjects += deleted
+		for _, orphan := range orphans {
+			if len(status.Orphans) < storageAuditMaxReported {
+				status.Orphans = append(status.Orphans, orphan)
+			}
+		}
+	}
+
+	status.Message = fmt.Sprintf("Audited %d Versions: %d failed, %d orphaned objects, %d deleted",
+		status.CheckedVersions, status.FailedVersions, status.OrphanedObjects, status.DeletedObjects)
+	if audit.Spec.DeleteOrphans && len(unresolved) > 0 {
+		status.Message = fmt.Sprintf("%s. Orphaned objects were not deleted because the storage config of '%s' could not be resolved",
+			status.Message, strings.Join(unresolved, "', '"))
+	}
+
+	return status, nil
+}
+
+// referenceVersionObjects records the objects version stores in every replica of its storage config as referenced.
+func referenceVersionObjects(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, backends map[string]*auditBackend) error {
+	storageConfig, _, err := versions.getVersionStorageConfig(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	name, err := getVersionName(version)
+	if err != nil {
+		return err
+	}
+
+	replicas, err := storage.StorageReplicas(storageConfig)
+	if err != nil {
+		return err
+	}
+
+	fileNames := []string{*version.Spec.FileName}
+	if version.Status.Quarantined != nil && version.Status.Quarantined.FileName != nil {
+		fileNames = append(fileNames, *version.Status.Quarantined.FileName)
+	}
+
+	for _, replica := range replicas {
+		key := auditBackendKey(replica.StorageConfig)
+		backend, ok := backends[key]
+		if !ok {
+			backend = &auditBackend{storageConfig: replica.StorageConfig, names: map[string]bool{}, referenced: map[string]bool{}}
+			backends[key] = backend
+		}
+
+		for _, fileName := range fileNames {
+			objectPath, err := storage.ObjectPath(replica.StorageConfig, *name, fileName)
+			if err != nil {
+				return err
+			}
+			backend.referenced[storage.StoredObjectPath(replica.StorageConfig, objectPath)] = true
+		}
+	}
+
+	return nil
+}
+
+// auditVersion checks the archive of version in each of its replicas and returns the problems found. When repair is
+// true, an archive that is missing or corrupt in a replica is copied from another replica that holds it.
+func auditVersion(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, replicas []storage.StorageReplica, secretNamespace string, repair bool) []opendepotv1alpha1.StorageAuditFinding {
+	checksum := *version.Status.Checksum
+	var findings []opendepotv1alpha1.StorageAuditFinding
+	var healthy []storage.StorageReplica
+	for _, replica := range replicas {
+		problem, message := checkReplicaArchive(ctx, versions, version, replica, secretNamespace, checksum)
+		if problem == "" {
+			healthy = append(healthy, replica)
+			continue
+		}
+
+		findings = append(findings, opendepotv1alpha1.StorageAuditFinding{
+			Version: version.Name,
+			Replica: replica.Name,
+			Problem: problem,
+			Message: message,
+		})
+	}
+
+	if !repair || len(healthy) == 0 {
+		return findings
+	}
+
+	for i := range findings {
+		index := slices.IndexFunc(replicas, func(replica storage.StorageReplica) bool { return replica.Name == findings[i].Replica })
+		for _, source := range healthy {
+			if err := copyReplicaArchive(ctx, versions, version, source, replicas[index], secretNamespace, checksum); err != nil {
+				versions.Log.Info("unable to repair archive from storage replica", "version", version.Name, "replica", findings[i].Replica, "source", source.Name, "error", err.Error())
+				continue
+			}
+
+			findings[i].Repaired = true
+			findings[i].Message = fmt.Sprintf("%s. Copied the archive from replica '%s'", findings[i].Message, source.Name)
+			break
+		}
+	}
+
+	return findings
+}
+
+// checkReplicaArchive returns the problem with the archive of version in replica, or an empty problem when the replica
+// holds the archive with checksum. Backends that fail to read a missing object are told apart by listing its prefix.
+func checkReplicaArchive(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, replica storage.StorageReplica, secretNamespace string, checksum string) (string, string) {
+	filePath, err := versionFilePath(version, replica.StorageConfig)
+	if err != nil {
+		return opendepotv1alpha1.OpenDepotStorageAuditProblemError, err.Error()
+	}
+
+	storageInterface, err := versions.newStorage(ctx, secretNamespace, replica.StorageConfig)
+	if err != nil {
+		return opendepotv1alpha1.OpenDepotStorageAuditProblemError, err.Error()
+	}
+
+	soi := &types.StorageObjectInput{Method: types.Get, FilePath: filePath, Version: version, StorageConfig: replica.StorageConfig}
+	if err := RunStorageFactory(ctx, storageInterface, soi); err != nil {
+		prefix := (*filePath)[:strings.LastIndex(*filePath, "/")+1]
+		objects, listErr := storageInterface.ListObjects(ctx, &types.StorageObjectInput{
+			FilePath:      &prefix,
+			Version:       version,
+			StorageConfig: replica.StorageConfig,
+		})
+		storedPath := storage.StoredObjectPath(replica.StorageConfig, *filePath)
+		if listErr == nil && !slices.ContainsFunc(objects, func(object types.StorageObject) bool { return object.Path == storedPath }) {
+			return opendepotv1alpha1.OpenDepotStorageAuditProblemMissing, fmt.Sprintf("The archive '%s' is missing", *filePath)
+		}
+		return opendepotv1alpha1.OpenDepotStorageAuditProblemError, err.Error()
+	}
+
+	if !soi.FileExists {
+		return opendepotv1alpha1.OpenDepotStorageAuditProblemMissing, fmt.Sprintf("The archive '%s' is missing", *filePath)
+	}
+
+	if soi.ObjectChecksum == nil || *soi.ObjectChecksum != checksum {
+		stored := "none"
+		if soi.ObjectChecksum != nil {
+			stored = *soi.ObjectChecksum
+		}
+		return opendepotv1alpha1.OpenDepotStorageAuditProblemChecksumMismatch,
+			fmt.Sprintf("The archive '%s' has checksum '%s' instead of '%s'", *filePath, stored, checksum)
+	}
+
+	// The checksum of an encrypted archive is read from its header, so the archive is read in full to authenticate it.
+	if replica.StorageConfig.Encryption != nil {
+		reader, err := storageInterface.GetObject(ctx, soi)
+		if err != nil {
+			return opendepotv1alpha1.OpenDepotStorageAuditProblemError, err.Error()
+		}
+
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return opendepotv1alpha1.OpenDepotStorageAuditProblemChecksumMismatch,
+				fmt.Sprintf("The archive '%s' could not be read: %v", *filePath, err)
+		}
+	}
+
+	return "", ""
+}
+
+// copyReplicaArchive copies the archive of version from the replica source to the replica target and verifies the
+// checksum of the copy.
+func copyReplicaArchive(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, source storage.StorageReplica, target storage.StorageReplica, secretNamespace string, checksum string) error {
+	archive, err := downloadReplicaArchive(ctx, versions, version, source, secretNamespace, checksum)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	targetPath, err := versionFilePath(version, target.StorageConfig)
+	if err != nil {
+		return err
+	}
+
+	targetStorage, err := versions.newStorage(ctx, secretNamespace, target.StorageConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := RunStorageFactory(ctx, targetStorage, &types.StorageObjectInput{
+		ArchiveChecksum: &checksum,
+		Method:          types.Put,
+		FileReader:      archive,
+		FilePath:        targetPath,
+		Version:         version,
+		StorageConfig:   target.StorageConfig,
+	}); err != nil {
+		return err
+	}
+
+	soi := &types.StorageObjectInput{Method: types.Get, FilePath: targetPath, Version: version, StorageConfig: target.StorageConfig}
+	if err := RunStorageFactory(ctx, targetStorage, soi); err != nil {
+		return err
+	}
+
+	if !soi.FileExists || soi.ObjectChecksum == nil || *soi.ObjectChecksum != checksum {
+		return fmt.Errorf("the copied archive does not match the Version's checksum '%s'", checksum)
+	}
+
+	return nil
+}
+
+// flagVersion records the result of the audit of version in its 'StorageIntegrity' condition. When repair is true,
+// a primary archive that could not be repaired marks the Version as not synced, and a replica archive that could not
+// be repaired marks the replica as not synced, so the Version controller stores the archive again.
+func (r *StorageAuditReconciler) flagVersion(ctx context.Context, version *opendepotv1alpha1.Version, findings []opendepotv1alpha1.StorageAuditFinding, repair bool) error {
+	var unrepaired []opendepotv1alpha1.StorageAuditFinding
+	for _, finding := range findings {
+		if !finding.Repaired {
+			unrepaired = append(unrepaired, finding)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    opendepotv1alpha1.OpenDepotConditionStorageIntegrity,
+		Status:  metav1.ConditionTrue,
+		Reason:  storageIntegrityVerifiedReason,
+		Message: fmt.Sprintf("The archive matches checksum '%s' in every storage replica", *version.Status.Checksum),
+	}
+
+	switch {
+	case len(unrepaired) > 0:
+		messages := make([]string, 0, len(unrepaired))
+		for _, finding := range unrepaired {
+			messages = append(messages, fmt.Sprintf("replica '%s': %s", finding.Replica, finding.Message))
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = unrepaired[0].Problem
+		condition.Message = strings.Join(messages, "; ")
+	case len(findings) > 0:
+		condition.Reason = storageIntegrityRepairedReason
+		condition.Message = fmt.Sprintf("The archive was copied to %d storage replicas from a healthy replica", len(findings))
+	case meta.FindStatusCondition(version.Status.Conditions, opendepotv1alpha1.OpenDepotConditionStorageIntegrity) == nil:
+		// Versions that have never failed an audit are not flagged, to avoid writing every Version on every audit.
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentVersion := &opendepotv1alpha1.Version{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(version), currentVersion); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		changed := meta.SetStatusCondition(&currentVersion.Status.Conditions, condition)
+		if repair {
+			for _, finding := range unrepaired {
+				if finding.Problem == opendepotv1alpha1.OpenDepotStorageAuditProblemError {
+					continue
+				}
+
+				syncStatus := fmt.Sprintf("Storage audit found a problem, syncing again: %s", finding.Message)
+				if finding.Replica == opendepotv1alpha1.OpenDepotStoragePrimaryReplica {
+					currentVersion.Status.Synced = false
+					currentVersion.Status.SyncStatus = syncStatus
+					changed = true
+					continue
+				}
+
+				index := slices.IndexFunc(currentVersion.Status.Replicas, func(status opendepotv1alpha1.StorageReplicaStatus) bool {
+					return status.Name == finding.Replica
+				})
+				if index >= 0 {
+					currentVersion.Status.Replicas[index].Synced = false
+					currentVersion.Status.Replicas[index].SyncStatus = syncStatus
+					changed = true
+				}
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+		return r.Status().Update(ctx, currentVersion)
+	})
+}
+
+// auditBackendOrphans lists the prefix of every module and provider name of backend and returns the objects no
+// Version references. When deleteOrphans is true, orphans that have gone unmodified for gracePeriod are deleted.
+// Objects whose modification time the backend does not record are never deleted.
+func auditBackendOrphans(ctx context.Context, versions *VersionReconciler, backend *auditBackend, deleteOrphans bool, gracePeriod time.Duration) ([]opendepotv1alpha1.StorageAuditOrphan, int, error) {
+	storageInterface, err := versions.newStorage(ctx, backend.secretNamespace, backend.storageConfig)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	names := make([]string, 0, len(backend.names))
+	for name := range backend.names {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var orphans []opendepotv1alpha1.StorageAuditOrphan
+	deleted := 0
+	for _, name := range names {
+		prefix, err := storage.ObjectPath(backend.storageConfig, name, "")
+		if err != nil {
+			return orphans, deleted, err
+		}
+
+		listVersion := &opendepotv1alpha1.Version{
+			Spec: opendepotv1alpha1.VersionSpec{
+				ModuleConfigRef: &opendepotv1alpha1.ModuleConfig{Name: &name},
+			},
+		}
+
+		objects, err := storageInterface.ListObjects(ctx, &types.StorageObjectInput{FilePath: &prefix, Version: listVersion, StorageConfig: backend.storageConfig})
+		if err != nil {
+			return orphans, deleted, err
+		}
+
+		for _, object := range objects {
+			if backend.referenced[object.Path] {
+				continue
+			}
+
+			orphan := opendepotv1alpha1.StorageAuditOrphan{
+				Backend: storageBackendName(backend.storageConfig),
+				Path:    object.Path,
+			}
+			if !object.LastModified.IsZero() {
+				orphan.LastModified = object.LastModified.UTC().Format(time.RFC3339)
+			}
+
+			if deleteOrphans && !object.LastModified.IsZero() && time.Since(object.LastModified) >= gracePeriod {
+				objectPath := object.Path
+				if err := storageInterface.DeleteObject(ctx, &types.StorageObjectInput{
+					Method:        types.Delete,
+					FilePath:      &objectPath,
+					Version:       listVersion,
+					StorageConfig: backend.storageConfig,
+				}); err != nil {
+					versions.Log.Info("unable to delete orphaned object", "backend", orphan.Backend, "path", object.Path, "error", err.Error())
+				} else {
+					orphan.Deleted = true
+					deleted++
+				}
+			}
+
+			orphans = append(orphans, orphan)
+		}
+	}
+
+	return orphans, deleted, nil
+}
+
+// auditBackendKey identifies the physical location a replica's storage config stores archives in: its bucket,
+// storage account, registry repository or directory, and its key prefix. Storage configs that differ only in their
+// credentials, encryption or layout store archives in the same place, so they share a backend and the archives one
+// of them stores are not reported as orphans of the other.
+func auditBackendKey(storageConfig *opendepotv1alpha1.StorageConfig) string {
+	key := storageBackendName(storageConfig)
+	switch {
+	case storageConfig.S3 != nil:
+		if storageConfig.S3.Endpoint != nil {
+			key = fmt.Sprintf("%s@%s", key, strings.TrimRight(*storageConfig.S3.Endpoint, "/"))
+		}
+		if storageConfig.S3.Key != nil {
+			key = fmt.Sprintf("%s/%s", key, strings.Trim(*storageConfig.S3.Key, "/"))
+		}
+	case storageConfig.FileSystem != nil && storageConfig.FileSystem.DirectoryPath != nil:
+		key = fmt.Sprintf("%s%s", key, filepath.Clean(*storageConfig.FileSystem.DirectoryPath))
+	}
+
+	return key
+}
+
+// storageBackendName describes the backend of a replica's storage config, ie: 's3://opendepot-modules'.
+func storageBackendName(storageConfig *opendepotv1alpha1.StorageConfig) string {
+	switch {
+	case storageConfig.S3 != nil:
+		return fmt.Sprintf("s3://%s", storageConfig.S3.Bucket)
+	case storageConfig.AzureStorage != nil:
+		return fmt.Sprintf("azure://%s", storageConfig.AzureStorage.AccountName)
+	case storageConfig.GCS != nil:
+		return fmt.Sprintf("gs://%s", storageConfig.GCS.Bucket)
+	case storageConfig.OCI != nil:
+		return fmt.Sprintf("oci://%s/%s", storageConfig.OCI.Registry, storageConfig.OCI.Repository)
+	case storageConfig.FileSystem != nil:
+		return "file://"
+	}
+
+	return ""
+}
+
+// minutesOrDefault returns the minutes set by value, or defaultMinutes when value is nil or not positive.
+func minutesOrDefault(value *int, defaultMinutes int) int {
+	if value == nil || *value <= 0 {
+		return defaultMinutes
+	}
+
+	return *value
+}
+
+// updateStorageAuditStatus writes the status of audit to the latest revision of the StorageAudit.
+func (r *StorageAuditReconciler) updateStorageAuditStatus(ctx context.Context, audit *opendepotv1alpha1.StorageAudit) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentAudit := &opendepotv1alpha1.StorageAudit{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(audit), currentAudit); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		currentAudit.Status = audit.Status
+		return r.Status().Update(ctx, currentAudit)
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StorageAuditReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&opendepotv1alpha1.StorageAudit{}).
+		Named("opendepot-storage-audit-controller").
+		Complete(r)
+}
@@ -0,0 +1,630 @@
+/*
+Copyright 2026 Tony Owens.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	opendepotv1alpha1 "github.com/tonedefdev/opendepot/api/v1alpha1"
+	"github.com/tonedefdev/opendepot/pkg/storage"
+	"github.com/tonedefdev/opendepot/pkg/storage/types"
+)
+
+// storageMigrationBatchSize is the number of Versions a StorageMigration migrates per reconcile, so its progress is
+// recorded in its status while it runs and an interrupted migration resumes where it stopped.
+const storageMigrationBatchSize = 5
+
+// StorageMigrationReconciler reconciles a StorageMigration object.
+type StorageMigrationReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=storagemigrations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=storagemigrations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=depots,verbs=get;list;update
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=modules,verbs=get;update
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=providers,verbs=get;update
+// +kubebuilder:rbac:groups=opendepot.defdev.io,resources=versions,verbs=get;list;update
+
+// Reconcile copies the archives of the Versions selected by a StorageMigration to its target storage, verifies their
+// checksums in the target and points the config references of each Version at the target. Once every selected
+// Version of a Module or Provider is migrated, the config of the Module or Provider and of the Depots that manage it
+// is pointed at the target too. Versions whose config references already point at the target are counted as
+// migrated, so a migration that was interrupted resumes with the Versions it has not migrated yet.
+func (r *StorageMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	migration := &opendepotv1alpha1.StorageMigration{}
+	if err := r.Get(ctx, req.NamespacedName, migration); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if migration.Status.ObservedGeneration != migration.Generation {
+		migration.Status = opendepotv1alpha1.StorageMigrationStatus{
+			ObservedGeneration: migration.Generation,
+			Phase:              opendepotv1alpha1.OpenDepotStorageMigrationPhasePending,
+		}
+	}
+
+	if migration.Status.Phase == opendepotv1alpha1.OpenDepotStorageMigrationPhaseSucceeded ||
+		migration.Status.Phase == opendepotv1alpha1.OpenDepotStorageMigrationPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	targetConfig, targetSecretNamespace, err := r.getMigrationTarget(ctx, migration)
+	if err != nil {
+		return ctrl.Result{}, r.completeStorageMigration(ctx, migration, opendepotv1alpha1.OpenDepotStorageMigrationPhaseFailed, err.Error())
+	}
+
+	versions, err := listSelectedVersions(ctx, r.Client, migration.Namespace, migration.Spec.VersionSelector)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if migration.Status.StartedAt == "" {
+		migration.Status.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	migration.Status.Phase = opendepotv1alpha1.OpenDepotStorageMigrationPhaseRunning
+	migration.Status.TotalVersions = len(versions)
+
+	migrated := map[string]bool{}
+	processed := 0
+	remaining := false
+	for i := range versions {
+		version := &versions[i]
+		if slices.ContainsFunc(migration.Status.FailedVersions, func(failure opendepotv1alpha1.StorageMigrationFailure) bool {
+			return failure.Name == version.Name
+		}) {
+			continue
+		}
+
+		if referencesMigrationTarget(migration, version) {
+			migrated[version.Name] = true
+			continue
+		}
+
+		if processed == storageMigrationBatchSize {
+			remaining = true
+			break
+		}
+		processed++
+
+		if err := r.migrateVersion(ctx, migration, version, targetConfig, targetSecretNamespace); err != nil {
+			r.Log.Info("unable to migrate version", "storageMigration", migration.Name, "version", version.Name, "error", err.Error())
+			migration.Status.FailedVersions = append(migration.Status.FailedVersions, opendepotv1alpha1.StorageMigrationFailure{
+				Name:    version.Name,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		migrated[version.Name] = true
+	}
+
+	if err := r.pointOwnersAtMigrationTarget(ctx, migration, versions, migrated); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to rewrite storage config references: %w", err)
+	}
+
+	migration.Status.MigratedVersions = len(migrated)
+	if remaining {
+		migration.Status.Message = fmt.Sprintf("Migrated %d of %d Versions", len(migrated), len(versions))
+		if err := r.updateStorageMigrationStatus(ctx, migration); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+
+	if len(migration.Status.FailedVersions) > 0 {
+		message := fmt.Sprintf("Migrated %d of %d Versions, %d failed", len(migrated), len(versions), len(migration.Status.FailedVersions))
+		return ctrl.Result{}, r.completeStorageMigration(ctx, migration, opendepotv1alpha1.OpenDepotStorageMigrationPhaseFailed, message)
+	}
+
+	message := fmt.Sprintf("Migrated %d of %d Versions", len(migrated), len(versions))
+	return ctrl.Result{}, r.completeStorageMigration(ctx, migration, opendepotv1alpha1.OpenDepotStorageMigrationPhaseSucceeded, message)
+}
+
+// getMigrationTarget resolves the target storage config of a StorageMigration and the namespace the Secrets it
+// names are read from.
+func (r *StorageMigrationReconciler) getMigrationTarget(ctx context.Context, migration *opendepotv1alpha1.StorageMigration) (*opendepotv1alpha1.StorageConfig, string, error) {
+	hasConfig := migration.Spec.TargetStorageConfig != nil
+	hasProfile := migration.Spec.TargetStorageProfileName != nil && *migration.Spec.TargetStorageProfileName != ""
+	if hasConfig == hasProfile {
+		return nil, "", fmt.Errorf("exactly one of targetStorageConfig and targetStorageProfileName must be set")
+	}
+
+	if hasConfig {
+		return migration.Spec.TargetStorageConfig, migration.Namespace, nil
+	}
+
+	profile := &opendepotv1alpha1.StorageProfile{}
+	if err := r.Get(ctx, client.ObjectKey{Name: *migration.Spec.TargetStorageProfileName}, profile); err != nil {
+		return nil, "", fmt.Errorf("failed to get storage profile '%s': %w", *migration.Spec.TargetStorageProfileName, err)
+	}
+
+	secretNamespace := migration.Namespace
+	if profile.Spec.SecretNamespace != nil && *profile.Spec.SecretNamespace != "" {
+		secretNamespace = *profile.Spec.SecretNamespace
+	}
+
+	return &profile.Spec.StorageConfig, secretNamespace, nil
+}
+
+// listSelectedVersions returns the Versions in namespace matched by selector, leaving out Versions that are being
+// deleted. Every Version in namespace is matched when selector is nil.
+func listSelectedVersions(ctx context.Context, c client.Client, namespace string, selector *metav1.LabelSelector) ([]opendepotv1alpha1.Version, error) {
+	options := []client.ListOption{client.InNamespace(namespace)}
+	if selector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version selector: %w", err)
+		}
+		options = append(options, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+
+	versionList := &opendepotv1alpha1.VersionList{}
+	if err := c.List(ctx, versionList, options...); err != nil {
+		return nil, err
+	}
+
+	versions := slices.DeleteFunc(versionList.Items, func(version opendepotv1alpha1.Version) bool {
+		return version.DeletionTimestamp != nil
+	})
+	slices.SortFunc(versions, func(a, b opendepotv1alpha1.Version) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return versions, nil
+}
+
+// migrateVersion copies the archive of version to the target storage unless it is already there, and then points the
+// config reference of version at the target. Versions without a checksum have never stored an archive, so only their config
+// references are rewritten and the Version controller stores their archive in the target once they sync. The source
+// archives are only deleted when the archive was copied, so a target that already holds the archive, ie: the source
+// with other credentials, keeps it.
+func (r *StorageMigrationReconciler) migrateVersion(ctx context.Context, migration *opendepotv1alpha1.StorageMigration, version *opendepotv1alpha1.Version, targetConfig *opendepotv1alpha1.StorageConfig, targetSecretNamespace string) error {
+	versions := &VersionReconciler{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
+
+	var sourceConfig *opendepotv1alpha1.StorageConfig
+	var sourceSecretNamespace string
+	copied := false
+	if version.Status.Checksum != nil {
+		var err error
+		sourceConfig, sourceSecretNamespace, err = versions.getVersionStorageConfig(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		if !equality.Semantic.DeepEqual(sourceConfig, targetConfig) {
+			copied, err = copyVersionArchive(ctx, versions, version, sourceConfig, sourceSecretNamespace, targetConfig, targetSecretNamespace)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.pointVersionAtMigrationTarget(ctx, migration, version); err != nil {
+		return fmt.Errorf("failed to rewrite storage config references: %w", err)
+	}
+
+	if copied && migration.Spec.DeleteSource {
+		deleteSourceArchives(ctx, versions, version, sourceConfig, sourceSecretNamespace, targetConfig)
+	}
+
+	return nil
+}
+
+// copyVersionArchive copies the archive of version from the first replica of sourceConfig in read order that holds it
+// with the Version's checksum to the primary backend of targetConfig, and verifies the checksum of the copy. The copy
+// is skipped when the target already holds the archive, and copyVersionArchive reports whether the archive was stored
+// in the target.
+func copyVersionArchive(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, sourceConfig *opendepotv1alpha1.StorageConfig, sourceSecretNamespace string, targetConfig *opendepotv1alpha1.StorageConfig, targetSecretNamespace string) (bool, error) {
+	checksum := *version.Status.Checksum
+	targetPath, err := versionFilePath(version, targetConfig)
+	if err != nil {
+		return false, err
+	}
+
+	targetStorage, err := versions.newStorage(ctx, targetSecretNamespace, targetConfig)
+	if err != nil {
+		return false, err
+	}
+
+	soi := &types.StorageObjectInput{Method: types.Get, FilePath: targetPath, Version: version, StorageConfig: targetConfig}
+	if err := RunStorageFactory(ctx, targetStorage, soi); err != nil {
+		return false, fmt.Errorf("failed to check the target storage: %w", err)
+	}
+
+	if soi.FileExists && !soi.EncryptionKeyStale && soi.ObjectChecksum != nil && *soi.ObjectChecksum == checksum {
+		return false, nil
+	}
+
+	sourceReplicas, err := storage.ReadOrder(sourceConfig)
+	if err != nil {
+		return false, err
+	}
+
+	var archive *os.File
+	for _, replica := range sourceReplicas {
+		archive, err = downloadReplicaArchive(ctx, versions, version, replica, sourceSecretNamespace, checksum)
+		if err == nil {
+			break
+		}
+		versions.Log.Info("unable to read archive from storage replica", "version", version.Name, "replica", replica.Name, "error", err.Error())
+	}
+
+	if archive == nil {
+		return false, fmt.Errorf("the archive could not be read from the source storage: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := RunStorageFactory(ctx, targetStorage, &types.StorageObjectInput{
+		Method:        types.Put,
+		FileReader:    archive,
+		FilePath:      targetPath,
+		Version:       version,
+		StorageConfig: targetConfig,
+	}); err != nil {
+		return false, fmt.Errorf("failed to store the archive in the target storage: %w", err)
+	}
+
+	soi = &types.StorageObjectInput{Method: types.Get, FilePath: targetPath, Version: version, StorageConfig: targetConfig}
+	if err := RunStorageFactory(ctx, targetStorage, soi); err != nil {
+		return true, fmt.Errorf("failed to verify the archive in the target storage: %w", err)
+	}
+
+	if !soi.FileExists || soi.ObjectChecksum == nil || *soi.ObjectChecksum != checksum {
+		return true, fmt.Errorf("the archive in the target storage does not match the Version's checksum '%s'", checksum)
+	}
+
+	return true, nil
+}
+
+// downloadReplicaArchive verifies that replica holds the archive of version with checksum and downloads it to a
+// temporary file, which the caller closes and removes.
+func downloadReplicaArchive(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, replica storage.StorageReplica, secretNamespace string, checksum string) (*os.File, error) {
+	filePath, err := versionFilePath(version, replica.StorageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	storageInterface, err := versions.newStorage(ctx, secretNamespace, replica.StorageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	soi := &types.StorageObjectInput{Method: types.Get, FilePath: filePath, Version: version, StorageConfig: replica.StorageConfig}
+	if err := RunStorageFactory(ctx, storageInterface, soi); err != nil {
+		return nil, err
+	}
+
+	if !soi.FileExists {
+		return nil, fmt.Errorf("the archive was not found")
+	}
+
+	if soi.ObjectChecksum == nil || *soi.ObjectChecksum != checksum {
+		return nil, fmt.Errorf("the archive does not match the Version's checksum '%s'", checksum)
+	}
+
+	reader, err := storageInterface.GetObject(ctx, &types.StorageObjectInput{
+		Method:        types.Get,
+		FilePath:      filePath,
+		Version:       version,
+		StorageConfig: replica.StorageConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	archive, err := os.CreateTemp("", "opendepot-migration-*")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(archive, reader); err != nil {
+		archive.Close()
+		os.Remove(archive.Name())
+		return nil, fmt.Errorf("failed to download the archive: %w", err)
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		archive.Close()
+		os.Remove(archive.Name())
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// deleteSourceArchives deletes the archive of version from every replica of sourceConfig that does not store it at the
+// same physical location as a replica of targetConfig, ie: the same bucket and object path under other credentials.
+// Failures are logged and the archive is left in place, since the Version already points at the target.
+func deleteSourceArchives(ctx context.Context, versions *VersionReconciler, version *opendepotv1alpha1.Version, sourceConfig *opendepotv1alpha1.StorageConfig, secretNamespace string, targetConfig *opendepotv1alpha1.StorageConfig) {
+	sourceReplicas, err := storage.StorageReplicas(sourceConfig)
+	if err != nil {
+		versions.Log.Info("unable to delete archive from the source storage", "version", version.Name, "error", err.Error())
+		return
+	}
+
+	targetReplicas, err := storage.StorageReplicas(targetConfig)
+	if err != nil {
+		versions.Log.Info("unable to delete archive from the source storage", "version", version.Name, "error", err.Error())
+		return
+	}
+
+	for _, replica := range sourceReplicas {
+		filePath, err := versionFilePath(version, replica.StorageConfig)
+		if err == nil && slices.ContainsFunc(targetReplicas, func(target storage.StorageReplica) bool {
+			return sameArchiveLocation(version, target.StorageConfig, replica.StorageConfig)
+		}) {
+			continue
+		}
+
+		if err == nil {
+			var referenced bool
+			referenced, err = versions.archiveReferenced(ctx, version, replica.StorageConfig, *filePath)
+			if err == nil && referenced {
+				continue
+			}
+		}
+
+		if err == nil {
+			var storageInterface storage.Storage
+			storageInterface, err = versions.newStorage(ctx, secretNamespace, replica.StorageConfig)
+			if err == nil {
+				err = RunStorageFactory(ctx, storageInterface, &types.StorageObjectInput{
+					Method:        types.Delete,
+					FilePath:      filePath,
+					Version:       version,
+					StorageConfig: replica.StorageConfig,
+				})
+			}
+		}
+
+		if err != nil {
+			versions.Log.Info("unable to delete archive from the source storage", "version", version.Name, "replica", replica.Name, "error", err.Error())
+		}
+	}
+}
+
+// sameArchiveLocation reports whether the storage configs a and b store the archive of version at the same physical
+// location: the same backend and object path, regardless of their credentials or encryption.
+func sameArchiveLocation(version *opendepotv1alpha1.Version, a, b *opendepotv1alpha1.StorageConfig) bool {
+	if auditBackendKey(a) != auditBackendKey(b) {
+		return false
+	}
+
+	pathA, err := versionFilePath(version, a)
+	if err != nil {
+		return false
+	}
+
+	pathB, err := versionFilePath(version, b)
+	if err != nil {
+		return false
+	}
+
+	return *pathA == *pathB
+}
+
+// pointVersionAtMigrationTarget sets the target of migration on the config references of version.
+func (r *StorageMigrationReconciler) pointVersionAtMigrationTarget(ctx context.Context, migration *opendepotv1alpha1.StorageMigration, version *opendepotv1alpha1.Version) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentVersion := &opendepotv1alpha1.Version{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(version), currentVersion); err != nil {
+			return err
+		}
+
+		if currentVersion.Spec.ModuleConfigRef != nil {
+			setMigrationTarget(migration, &currentVersion.Spec.ModuleConfigRef.StorageConfig, &currentVersion.Spec.ModuleConfigRef.StorageProfileName)
+		}
+		if currentVersion.Spec.ProviderConfigRef != nil {
+			setMigrationTarget(migration, &currentVersion.Spec.ProviderConfigRef.StorageConfig, &currentVersion.Spec.ProviderConfigRef.StorageProfileName)
+		}
+
+		return r.Update(ctx, currentVersion)
+	})
+}
+
+// pointOwnersAtMigrationTarget sets the target of migration on the config of every Module and Provider whose selected
+// Versions are all migrated, and on the entries of the Depots that manage them, so their next sync does not restore
+// the previous storage config. Modules and Providers with a Version that failed or is still to be migrated keep
+// their config, since new Versions would otherwise be stored in the target while the migration is incomplete.
+func (r *StorageMigrationReconciler) pointOwnersAtMigrationTarget(ctx context.Context, migration *opendepotv1alpha1.StorageMigration, versions []opendepotv1alpha1.Version, migrated map[string]bool) error {
+	owners := map[metav1.OwnerReference]bool{}
+	for i := range versions {
+		owner := metav1.GetControllerOf(&versions[i])
+		if owner == nil || (owner.Kind != opendepotv1alpha1.OpenDepotModule && owner.Kind != opendepotv1alpha1.OpenDepotProvider) {
+			continue
+		}
+
+		key := metav1.OwnerReference{Kind: owner.Kind, Name: owner.Name}
+		if _, ok := owners[key]; !ok {
+			owners[key] = true
+		}
+		owners[key] = owners[key] && migrated[versions[i].Name]
+	}
+
+	for owner, complete := range owners {
+		if !complete {
+			continue
+		}
+
+		if err := r.pointDepotsAtMigrationTarget(ctx, migration, owner.Name, owner.Kind); err != nil {
+			return err
+		}
+
+		key := client.ObjectKey{Name: owner.Name, Namespace: migration.Namespace}
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			if owner.Kind == opendepotv1alpha1.OpenDepotModule {
+				module := &opendepotv1alpha1.Module{}
+				if err := r.Get(ctx, key, module); err != nil {
+					return client.IgnoreNotFound(err)
+				}
+
+				if !setMigrationTarget(migration, &module.Spec.ModuleConfig.StorageConfig, &module.Spec.ModuleConfig.StorageProfileName) {
+					return nil
+				}
+				return r.Update(ctx, module)
+			}
+
+			provider := &opendepotv1alpha1.Provider{}
+			if err := r.Get(ctx, key, provider); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+
+			if !setMigrationTarget(migration, &provider.Spec.ProviderConfig.StorageConfig, &provider.Spec.ProviderConfig.StorageProfileName) {
+				return nil
+			}
+			return r.Update(ctx, provider)
+		}); err != nil {
+			return fmt.Errorf("failed to update %s '%s': %w", strings.ToLower(owner.Kind), owner.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// pointDepotsAtMigrationTarget sets the target of migration on the entries of the Depots in the migration's namespace
+// that manage the Module or Provider called name.
+func (r *StorageMigrationReconciler) pointDepotsAtMigrationTarget(ctx context.Context, migration *opendepotv1alpha1.StorageMigration, name string, kind string) error {
+	depotList := &opendepotv1alpha1.DepotList{}
+	if err := r.List(ctx, depotList, client.InNamespace(migration.Namespace)); err != nil {
+		return err
+	}
+
+	for _, depot := range depotList.Items {
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			currentDepot := &opendepotv1alpha1.Depot{}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(&depot), currentDepot); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+
+			changed := false
+			if kind == opendepotv1alpha1.OpenDepotModule {
+				for i := range currentDepot.Spec.ModuleConfigs {
+					moduleConfig := &currentDepot.Spec.ModuleConfigs[i]
+					if moduleConfig.Name != nil && *moduleConfig.Name == name &&
+						setMigrationTarget(migration, &moduleConfig.StorageConfig, &moduleConfig.StorageProfileName) {
+						changed = true
+					}
+				}
+			} else {
+				for i := range currentDepot.Spec.ProviderConfigs {
+					providerConfig := &currentDepot.Spec.ProviderConfigs[i]
+					if providerConfig.Name != nil && *providerConfig.Name == name &&
+						setMigrationTarget(migration, &providerConfig.StorageConfig, &providerConfig.StorageProfileName) {
+						changed = true
+					}
+				}
+			}
+
+			if !changed {
+				return nil
+			}
+			return r.Update(ctx, currentDepot)
+		}); err != nil {
+			return fmt.Errorf("failed to update depot '%s': %w", depot.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// setMigrationTarget points the storage fields of a module or provider config at the target of migration and reports
+// whether they changed.
+func setMigrationTarget(migration *opendepotv1alpha1.StorageMigration, storageConfig **opendepotv1alpha1.StorageConfig, storageProfileName **string) bool {
+	previousConfig, previousProfileName := *storageConfig, *storageProfileName
+
+	if migration.Spec.TargetStorageProfileName != nil && *migration.Spec.TargetStorageProfileName != "" {
+		profileName := *migration.Spec.TargetStorageProfileName
+		*storageConfig = nil
+		*storageProfileName = &profileName
+	} else {
+		*storageConfig = migration.Spec.TargetStorageConfig.DeepCopy()
+		*storageProfileName = nil
+	}
+
+	return !equality.Semantic.DeepEqual(previousConfig, *storageConfig) || !equality.Semantic.DeepEqual(previousProfileName, *storageProfileName)
+}
+
+// referencesMigrationTarget reports whether the config reference of version already points at the target of migration.
+func referencesMigrationTarget(migration *opendepotv1alpha1.StorageMigration, version *opendepotv1alpha1.Version) bool {
+	var storageConfig *opendepotv1alpha1.StorageConfig
+	var storageProfileName *string
+	if version.Spec.ModuleConfigRef != nil {
+		storageConfig = version.Spec.ModuleConfigRef.StorageConfig
+		storageProfileName = version.Spec.ModuleConfigRef.StorageProfileName
+	} else if version.Spec.ProviderConfigRef != nil {
+		storageConfig = version.Spec.ProviderConfigRef.StorageConfig
+		storageProfileName = version.Spec.ProviderConfigRef.StorageProfileName
+	}
+
+	if migration.Spec.TargetStorageProfileName != nil && *migration.Spec.TargetStorageProfileName != "" {
+		return storageConfig == nil && storageProfileName != nil && *storageProfileName == *migration.Spec.TargetStorageProfileName
+	}
+
+	return storageConfig != nil && migration.Spec.TargetStorageConfig != nil &&
+		equality.Semantic.DeepEqual(storageConfig, migration.Spec.TargetStorageConfig)
+}
+
+// completeStorageMigration records the final phase of migration.
+func (r *StorageMigrationReconciler) completeStorageMigration(ctx context.Context, migration *opendepotv1alpha1.StorageMigration, phase string, message string) error {
+	migration.Status.Phase = phase
+	migration.Status.Message = message
+	migration.Status.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+	return r.updateStorageMigrationStatus(ctx, migration)
+}
+
+// updateStorageMigrationStatus writes the status of migration to the latest revision of the StorageMigration.
+func (r *StorageMigrationReconciler) updateStorageMigrationStatus(ctx context.Context, migration *opendepotv1alpha1.StorageMigration) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		currentMigration := &opendepotv1alpha1.StorageMigration{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(migration), currentMigration); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		currentMigration.Status = migration.Status
+		return r.Status().Update(ctx, currentMigration)
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StorageMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&opendepotv1alpha1.StorageMigration{}).
+		Named("opendepot-storage-migration-controller").
+		Complete(r)
+}